@@ -4,18 +4,23 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/wangtengda0310/gobee/agent/pkg/llm"
 	"github.com/wangtengda0310/gobee/agent/pkg/llm/anthropic"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/cassette"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/gemini"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/local"
 	"github.com/wangtengda0310/gobee/agent/pkg/llm/openai"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/openairesponses"
 )
 
 func main() {
 	// 命令行参数
-	provider := flag.String("provider", "anthropic", "LLM 提供商: anthropic, openai")
+	provider := flag.String("provider", "anthropic", "LLM 提供商: anthropic, openai, openai-responses, gemini, ollama, llamacpp")
 	prompt := flag.String("prompt", "Say hello in one word", "提示词")
 	stream := flag.Bool("stream", false, "使用流式响应")
 	model := flag.String("model", "", "模型名称 (留空使用默认)")
@@ -24,22 +29,34 @@ func main() {
 	timeout := flag.Duration("timeout", 60*time.Second, "请求超时")
 	image := flag.String("image", "", "图像文件路径 (base64)")
 	imageType := flag.String("image-type", "image/png", "图像 MIME 类型")
+	cassettePath := flag.String("cassette", "", "录制/回放文件路径 (留空直接请求 API)")
+	cassetteMode := flag.String("cassette-mode", string(cassette.ModeReplay), "录制/回放模式: replay, record, auto")
 	flag.Parse()
 
+	// 录制/回放时替换 HTTP 客户端，回放不需要 API Key
+	var rec *cassette.Recorder
+	httpClient := &http.Client{Timeout: *timeout}
+	if *cassettePath != "" {
+		var err error
+		rec, err = cassette.New(*cassettePath, cassette.WithMode(cassette.Mode(*cassetteMode)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "创建录制器失败: %v\n", err)
+			os.Exit(1)
+		}
+		httpClient.Transport = rec
+	}
+
 	// 创建客户端
 	var client llm.ChatCompleter
 	var err error
 
 	switch strings.ToLower(*provider) {
 	case "anthropic":
-		apiKey := os.Getenv("ANTHROPIC_API_KEY")
-		if apiKey == "" {
-			fmt.Fprintln(os.Stderr, "错误: 请设置 ANTHROPIC_API_KEY 环境变量")
-			os.Exit(1)
-		}
+		apiKey := requireAPIKey("ANTHROPIC_API_KEY", rec)
 		opts := []anthropic.Option{
 			anthropic.WithAPIKey(apiKey),
 			anthropic.WithTimeout(*timeout),
+			anthropic.WithHTTPClient(httpClient),
 		}
 		if *model != "" {
 			opts = append(opts, anthropic.WithModel(*model))
@@ -57,14 +74,11 @@ func main() {
 		}
 
 	case "openai":
-		apiKey := os.Getenv("OPENAI_API_KEY")
-		if apiKey == "" {
-			fmt.Fprintln(os.Stderr, "错误: 请设置 OPENAI_API_KEY 环境变量")
-			os.Exit(1)
-		}
+		apiKey := requireAPIKey("OPENAI_API_KEY", rec)
 		opts := []openai.Option{
 			openai.WithAPIKey(apiKey),
 			openai.WithTimeout(*timeout),
+			openai.WithHTTPClient(httpClient),
 		}
 		if *model != "" {
 			opts = append(opts, openai.WithModel(*model))
@@ -81,6 +95,71 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "openai-responses":
+		apiKey := requireAPIKey("OPENAI_API_KEY", rec)
+		opts := []openairesponses.Option{
+			openairesponses.WithAPIKey(apiKey),
+			openairesponses.WithTimeout(*timeout),
+			openairesponses.WithHTTPClient(httpClient),
+		}
+		if *model != "" {
+			opts = append(opts, openairesponses.WithModel(*model))
+		}
+		if *baseURL != "" {
+			opts = append(opts, openairesponses.WithBaseURL(*baseURL))
+		}
+		if *maxTokens > 0 {
+			opts = append(opts, openairesponses.WithMaxTokens(*maxTokens))
+		}
+		client, err = openairesponses.NewClient(opts...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "创建 OpenAI Responses 客户端失败: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "gemini":
+		apiKey := requireAPIKey("GEMINI_API_KEY", rec)
+		opts := []gemini.Option{
+			gemini.WithAPIKey(apiKey),
+			gemini.WithTimeout(*timeout),
+			gemini.WithHTTPClient(httpClient),
+		}
+		if *model != "" {
+			opts = append(opts, gemini.WithModel(*model))
+		}
+		if *baseURL != "" {
+			opts = append(opts, gemini.WithBaseURL(*baseURL))
+		}
+		if *maxTokens > 0 {
+			opts = append(opts, gemini.WithMaxTokens(*maxTokens))
+		}
+		client, err = gemini.NewClient(opts...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "创建 Gemini 客户端失败: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "ollama", "llamacpp":
+		opts := []local.Option{
+			local.WithBackend(local.Backend(strings.ToLower(*provider))),
+			local.WithTimeout(*timeout),
+			local.WithHTTPClient(httpClient),
+		}
+		if *model != "" {
+			opts = append(opts, local.WithModel(*model))
+		}
+		if *baseURL != "" {
+			opts = append(opts, local.WithBaseURL(*baseURL))
+		}
+		if *maxTokens > 0 {
+			opts = append(opts, local.WithMaxTokens(*maxTokens))
+		}
+		client, err = local.NewClient(opts...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "创建本地模型客户端失败: %v\n", err)
+			os.Exit(1)
+		}
+
 	default:
 		fmt.Fprintf(os.Stderr, "不支持的提供商: %s\n", *provider)
 		os.Exit(1)
@@ -121,12 +200,31 @@ func main() {
 		err = completeRequest(ctx, client, req, *provider)
 	}
 
+	if rec != nil {
+		if saveErr := rec.Save(); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "保存录制文件失败: %v\n", saveErr)
+		}
+	}
+
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "请求失败: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// requireAPIKey 读取 API Key，回放模式下不需要真实的 Key
+func requireAPIKey(env string, rec *cassette.Recorder) string {
+	apiKey := os.Getenv(env)
+	if apiKey == "" && rec != nil && rec.Mode() == cassette.ModeReplay {
+		return "replay"
+	}
+	if apiKey == "" {
+		fmt.Fprintf(os.Stderr, "错误: 请设置 %s 环境变量\n", env)
+		os.Exit(1)
+	}
+	return apiKey
+}
+
 func completeRequest(ctx context.Context, client llm.ChatCompleter, req *llm.ChatRequest, provider string) error {
 	fmt.Printf("[%s] 发送: %q\n", provider, llm.TextString(req.Messages[0].Content))
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/tokenizer"
 	"github.com/wangtengda0310/gobee/agent/pkg/memory"
 	"github.com/wangtengda0310/gobee/agent/pkg/prompt"
 	"github.com/wangtengda0310/gobee/agent/pkg/tool"
@@ -21,6 +22,7 @@ type Agent struct {
 	executor *tool.BatchExecutor
 	history  *prompt.History
 	memory   memory.Memory
+	counter  llm.TokenCounter
 	mu       sync.RWMutex
 }
 
@@ -41,11 +43,23 @@ func New(opts ...Option) *Agent {
 	// 创建批量执行器
 	executor := tool.NewBatchExecutor(registry, 4)
 
+	// 选择 token 计数器
+	counter := config.TokenCounter
+	if counter == nil {
+		var model string
+		if info, ok := config.LLM.(llm.ModelInfo); ok {
+			model = info.ModelName()
+		}
+		// 找不到 OpenAI 词表时按字符估算，需要精确计数时用 WithTokenCounter 传入
+		counter, _ = tokenizer.ForModel(model)
+	}
+
 	return &Agent{
 		config:   config,
 		registry: registry,
 		executor: executor,
 		history:  prompt.NewHistory(),
+		counter:  counter,
 	}
 }
 
@@ -153,6 +167,7 @@ func (a *Agent) Run(ctx context.Context, input string) (*Result, error) {
 			Role:      llm.RoleAssistant,
 			Content:   llm.Text(resp.Content),
 			ToolCalls: resp.ToolCalls,
+			Thinking:  resp.Thinking,
 		}
 		state.Messages = append(state.Messages, assistantMsg)
 
@@ -175,7 +190,7 @@ func (a *Agent) Run(ctx context.Context, input string) (*Result, error) {
 					Role:       llm.RoleTool,
 					ToolCallID: tr.ToolCallID,
 					Name:       tr.Name,
-					Content:    llm.Text(a.resultToString(tr)),
+					Content:    a.resultToContent(tr),
 				}
 				state.Messages = append(state.Messages, toolMsg)
 
@@ -222,15 +237,20 @@ func (a *Agent) Run(ctx context.Context, input string) (*Result, error) {
 
 // callLLM 调用 LLM
 func (a *Agent) callLLM(ctx context.Context, messages []*llm.Message) (*llm.ChatResponse, error) {
-	// 触发 LLM 调用钩子
-	if a.config.Hooks != nil && a.config.Hooks.OnLLMCall != nil {
-		a.config.Hooks.OnLLMCall(messages)
+	req, err := a.buildRequest(messages)
+	if err != nil {
+		return nil, err
 	}
+	return a.config.LLM.Complete(ctx, req)
+}
 
+// buildRequest 构建请求，超出上下文窗口时裁剪历史
+func (a *Agent) buildRequest(messages []*llm.Message) (*llm.ChatRequest, error) {
 	req := &llm.ChatRequest{
 		Messages:    messages,
 		MaxTokens:   a.config.MaxTokens,
 		Temperature: a.config.Temperature,
+		Thinking:    a.config.Thinking,
 	}
 
 	// 添加工具定义
@@ -238,7 +258,19 @@ func (a *Agent) callLLM(ctx context.Context, messages []*llm.Message) (*llm.Chat
 		req.Tools = tools
 	}
 
-	return a.config.LLM.Complete(ctx, req)
+	if err := a.fitContext(req); err != nil {
+		return nil, err
+	}
+	if a.config.PromptCaching {
+		req.Messages = withCacheBreakpoints(req.Messages)
+	}
+
+	// 触发 LLM 调用钩子
+	if a.config.Hooks != nil && a.config.Hooks.OnLLMCall != nil {
+		a.config.Hooks.OnLLMCall(req.Messages)
+	}
+
+	return req, nil
 }
 
 // executeTools 执行工具调用
@@ -288,12 +320,16 @@ func (a *Agent) parseArguments(argsJSON string) map[string]any {
 	return args
 }
 
-// resultToString 将结果转换为字符串
-func (a *Agent) resultToString(tr *tool.ToolResult) string {
+// resultToContent 将结果转换为消息内容
+// 工具返回 llm.Content (如截图工具返回的图像) 时直接使用，其他结果转换为字符串
+func (a *Agent) resultToContent(tr *tool.ToolResult) llm.Content {
 	if tr.Error != nil {
-		return fmt.Sprintf("错误: %s", tr.Error.Error())
+		return llm.Text(fmt.Sprintf("错误: %s", tr.Error.Error()))
 	}
-	return fmt.Sprintf("%v", tr.Result)
+	if content, ok := tr.Result.(llm.Content); ok && content != nil {
+		return content
+	}
+	return llm.Text(fmt.Sprintf("%v", tr.Result))
 }
 
 // AddTool 添加工具
@@ -415,6 +451,7 @@ func (a *Agent) RunStream(ctx context.Context, input string) (<-chan *StreamEven
 			// 处理流式响应
 			var contentBuffer string
 			var toolCalls []*llm.ToolCall
+			var thinking []*llm.ThinkingBlock
 			var usage *llm.Usage
 			var stopReason llm.StopReason
 
@@ -424,6 +461,15 @@ func (a *Agent) RunStream(ctx context.Context, input string) (<-chan *StreamEven
 					return
 				}
 
+				// 发送思考内容增量
+				if chunk.Thinking != "" {
+					eventCh <- &StreamEvent{
+						Type:      EventTypeThinking,
+						Thinking:  chunk.Thinking,
+						LoopCount: state.LoopCount,
+					}
+				}
+
 				// 发送内容增量
 				if chunk.Content != "" {
 					contentBuffer += chunk.Content
@@ -450,6 +496,19 @@ func (a *Agent) RunStream(ctx context.Context, input string) (<-chan *StreamEven
 				if chunk.IsDone() && chunk.Response != nil {
 					usage = chunk.Response.Usage
 					stopReason = chunk.Response.StopReason
+					thinking = chunk.Response.Thinking
+
+					// 部分提供商只在最终响应中给出工具调用
+					if len(toolCalls) == 0 && len(chunk.Response.ToolCalls) > 0 {
+						toolCalls = chunk.Response.ToolCalls
+						for _, tc := range toolCalls {
+							eventCh <- &StreamEvent{
+								Type:      EventTypeToolCall,
+								ToolCall:  tc,
+								LoopCount: state.LoopCount,
+							}
+						}
+					}
 				}
 			}
 
@@ -459,6 +518,7 @@ func (a *Agent) RunStream(ctx context.Context, input string) (<-chan *StreamEven
 				ToolCalls:  toolCalls,
 				Usage:      usage,
 				StopReason: stopReason,
+				Thinking:   thinking,
 			}
 			state.Response = resp
 
@@ -472,6 +532,7 @@ func (a *Agent) RunStream(ctx context.Context, input string) (<-chan *StreamEven
 				Role:      llm.RoleAssistant,
 				Content:   llm.Text(contentBuffer),
 				ToolCalls: toolCalls,
+				Thinking:  thinking,
 			}
 			state.Messages = append(state.Messages, assistantMsg)
 
@@ -494,7 +555,7 @@ func (a *Agent) RunStream(ctx context.Context, input string) (<-chan *StreamEven
 						Role:       llm.RoleTool,
 						ToolCallID: tr.ToolCallID,
 						Name:       tr.Name,
-						Content:    llm.Text(a.resultToString(tr)),
+						Content:    a.resultToContent(tr),
 					}
 					state.Messages = append(state.Messages, toolMsg)
 
@@ -556,21 +617,9 @@ func (a *Agent) RunStream(ctx context.Context, input string) (<-chan *StreamEven
 
 // callLLMStream 调用 LLM 流式 API
 func (a *Agent) callLLMStream(ctx context.Context, messages []*llm.Message) (<-chan *llm.StreamChunk, error) {
-	// 触发 LLM 调用钩子
-	if a.config.Hooks != nil && a.config.Hooks.OnLLMCall != nil {
-		a.config.Hooks.OnLLMCall(messages)
-	}
-
-	req := &llm.ChatRequest{
-		Messages:    messages,
-		MaxTokens:   a.config.MaxTokens,
-		Temperature: a.config.Temperature,
+	req, err := a.buildRequest(messages)
+	if err != nil {
+		return nil, err
 	}
-
-	// 添加工具定义
-	if tools := a.registry.GetDefinitions(); len(tools) > 0 {
-		req.Tools = tools
-	}
-
 	return a.config.LLM.Stream(ctx, req)
 }
@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -604,3 +605,180 @@ func TestAgent_MemoryWithContext(t *testing.T) {
 		t.Errorf("expected more messages in second call due to history, got %d vs %d", len(lastMessages), firstMsgCount)
 	}
 }
+
+// charCounter 每个字节算一个 token
+type charCounter struct{}
+
+func (charCounter) CountTokens(text string) int {
+	return len(text)
+}
+
+func TestAgent_ContextTrimming(t *testing.T) {
+	var sent []*llm.Message
+	mem := memory.NewSlidingWindow(20)
+	_ = mem.AddBatch(context.Background(), []*llm.Message{
+		{Role: llm.RoleUser, Content: llm.Text(strings.Repeat("a", 500))},
+		{Role: llm.RoleAssistant, ToolCalls: []*llm.ToolCall{llm.NewToolCall("call_1", "search", "{}")}},
+		{Role: llm.RoleTool, ToolCallID: "call_1", Name: "search", Content: llm.Text(strings.Repeat("b", 500))},
+		{Role: llm.RoleAssistant, Content: llm.Text("old answer")},
+		{Role: llm.RoleUser, Content: llm.Text("q2")},
+		{Role: llm.RoleAssistant, Content: llm.Text("a2")},
+	})
+
+	ag := New(
+		WithLLM(&MockLLM{}),
+		WithSystemPrompt("sys"),
+		WithMaxTokens(100),
+		WithContextWindow(300),
+		WithTokenCounter(charCounter{}),
+		WithHooks(&Hooks{
+			OnLLMCall: func(messages []*llm.Message) {
+				sent = messages
+			},
+		}),
+	)
+	ag.SetMemory(mem)
+
+	if _, err := ag.Run(context.Background(), "q3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 最早的一轮 (含工具调用和结果) 整体丢弃
+	want := []string{"sys", "q2", "a2", "q3"}
+	if len(sent) != len(want) {
+		t.Fatalf("expected %d messages, got %d", len(want), len(sent))
+	}
+	for i, msg := range sent {
+		if llm.TextString(msg.Content) != want[i] {
+			t.Errorf("message %d: expected %q, got %q", i, want[i], llm.TextString(msg.Content))
+		}
+	}
+
+	// memory 中的历史不受影响
+	if mem.Len() != 8 {
+		t.Errorf("expected 8 messages in memory, got %d", mem.Len())
+	}
+}
+
+func TestAgent_ContextLengthExceeded(t *testing.T) {
+	mockLLM := &MockLLM{responses: []*llm.ChatResponse{{Content: "unreachable"}}}
+	ag := New(
+		WithLLM(mockLLM),
+		WithMaxTokens(40),
+		WithContextWindow(50),
+		WithTokenCounter(charCounter{}),
+	)
+
+	_, err := ag.Run(context.Background(), strings.Repeat("x", 100))
+	var llmErr *llm.LLMError
+	if !errors.As(err, &llmErr) || llmErr.Type != llm.ErrorTypeContextLength {
+		t.Fatalf("expected context length error, got %v", err)
+	}
+	if mockLLM.callCount != 0 {
+		t.Error("request should not be sent")
+	}
+}
+
+// recordingLLM 记录每次请求的 MockLLM
+type recordingLLM struct {
+	MockLLM
+	requests []*llm.ChatRequest
+}
+
+func (m *recordingLLM) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	m.requests = append(m.requests, req)
+	return m.MockLLM.Complete(ctx, req)
+}
+
+func TestAgent_PromptCachingAndThinking(t *testing.T) {
+	thinking := []*llm.ThinkingBlock{{Thinking: "需要查询时间", Signature: "sig"}}
+	mockLLM := &recordingLLM{MockLLM: MockLLM{
+		responses: []*llm.ChatResponse{
+			{
+				StopReason: llm.StopReasonToolUse,
+				ToolCalls:  []*llm.ToolCall{llm.NewToolCall("call_1", "get_time", "{}")},
+				Thinking:   thinking,
+			},
+			{Content: "现在是中午", StopReason: llm.StopReasonEndTurn},
+		},
+	}}
+
+	ag := New(
+		WithLLM(mockLLM),
+		WithSystemPrompt("sys"),
+		WithTools(tool.NewFunction("get_time", "获取当前时间",
+			func(ctx context.Context, args map[string]any) (any, error) {
+				return "12:00", nil
+			},
+		)),
+		WithPromptCaching(),
+		WithThinking(2048),
+	)
+
+	if _, err := ag.Run(context.Background(), "几点了"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockLLM.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(mockLLM.requests))
+	}
+
+	for i, req := range mockLLM.requests {
+		if req.Thinking == nil || req.Thinking.BudgetTokens != 2048 {
+			t.Errorf("request %d: unexpected thinking config %+v", i, req.Thinking)
+		}
+
+		// 只有系统消息和最后一条消息带缓存断点
+		last := len(req.Messages) - 1
+		for j, msg := range req.Messages {
+			marked := msg.CacheControl != nil
+			if want := j == 0 || j == last; marked != want {
+				t.Errorf("request %d message %d (%s): cache breakpoint = %v", i, j, msg.Role, marked)
+			}
+		}
+	}
+
+	// 第二次请求中助手消息带回思考内容，上一次的断点没有留在历史中
+	second := mockLLM.requests[1].Messages
+	if len(second) != 4 || second[2].Role != llm.RoleAssistant || second[2].Thinking[0].Signature != "sig" {
+		t.Fatalf("expected thinking in assistant message, got %+v", second)
+	}
+	if second[1].CacheControl != nil {
+		t.Error("expected cache breakpoint not to leak into history")
+	}
+}
+
+func TestAgent_RichToolResult(t *testing.T) {
+	mockLLM := &recordingLLM{MockLLM: MockLLM{
+		responses: []*llm.ChatResponse{
+			{
+				StopReason: llm.StopReasonToolUse,
+				ToolCalls:  []*llm.ToolCall{llm.NewToolCall("call_1", "screenshot", "{}")},
+			},
+			{Content: "页面是空白的", StopReason: llm.StopReasonEndTurn},
+		},
+	}}
+
+	screenshot := llm.NewContentList(llm.Text("当前页面截图"), llm.ImageFromBase64("iVBORw0KGgo=", "image/png"))
+	ag := New(
+		WithLLM(mockLLM),
+		WithTools(tool.NewFunction("screenshot", "截取当前页面",
+			func(ctx context.Context, args map[string]any) (any, error) {
+				return screenshot, nil
+			},
+		)),
+	)
+
+	if _, err := ag.Run(context.Background(), "页面上有什么"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockLLM.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(mockLLM.requests))
+	}
+
+	// 工具返回的多模态内容原样发给模型，而不是被转换为字符串
+	messages := mockLLM.requests[1].Messages
+	toolMsg := messages[len(messages)-1]
+	if toolMsg.Role != llm.RoleTool || toolMsg.Content != llm.Content(screenshot) {
+		t.Errorf("expected rich tool result, got %#v", toolMsg.Content)
+	}
+}
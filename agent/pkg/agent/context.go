@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// contextWindow 返回上下文窗口大小，0 表示未知 (不裁剪)
+func (a *Agent) contextWindow() int {
+	if a.config.ContextWindow > 0 {
+		return a.config.ContextWindow
+	}
+	if info, ok := a.config.LLM.(llm.ModelInfo); ok {
+		return info.ContextWindow()
+	}
+	return 0
+}
+
+// fitContext 在发送前裁剪历史，使输入加上预留的 MaxTokens 不超过上下文窗口
+//
+// 系统消息和当前轮次 (最后一条用户消息及之后的工具调用) 总是保留，
+// 其余对话按轮次从最早的开始丢弃，每轮从用户消息开始，
+// 工具调用和工具结果随所在轮次一起丢弃，不会留下孤立的工具结果。
+// 只剩必须保留的消息时仍然超出，返回 llm.ErrContextLength。
+func (a *Agent) fitContext(req *llm.ChatRequest) error {
+	window := a.contextWindow()
+	if window <= 0 {
+		return nil
+	}
+	budget := window - req.MaxTokens
+	total := llm.CountRequestTokens(a.counter, req)
+	if total <= budget {
+		return nil
+	}
+
+	// 当前轮次从最后一条用户消息开始
+	current := len(req.Messages)
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == llm.RoleUser {
+			current = i
+			break
+		}
+	}
+
+	// 从最早的对话开始丢弃，直到下一条用户消息 (轮次边界) 且总量不超出
+	drop := make([]bool, len(req.Messages))
+	for i := 0; i < current; i++ {
+		msg := req.Messages[i]
+		if msg.Role == llm.RoleSystem {
+			continue
+		}
+		if total <= budget && msg.Role == llm.RoleUser {
+			break
+		}
+		drop[i] = true
+		total -= llm.CountMessageTokens(a.counter, msg)
+	}
+
+	if total > budget {
+		return llm.ErrContextLength(fmt.Sprintf(
+			"请求约 %d token，加上预留输出 %d token 超出上下文窗口 %d", total, req.MaxTokens, window))
+	}
+
+	kept := make([]*llm.Message, 0, len(req.Messages))
+	for i, msg := range req.Messages {
+		if !drop[i] {
+			kept = append(kept, msg)
+		}
+	}
+	req.Messages = kept
+	return nil
+}
+
+// withCacheBreakpoints 在最后一条系统消息和最后一条消息上设置缓存断点
+// 返回新的消息列表，被标记的消息是副本，不会把断点留在历史中
+func withCacheBreakpoints(messages []*llm.Message) []*llm.Message {
+	if len(messages) == 0 {
+		return messages
+	}
+	marked := make([]*llm.Message, len(messages))
+	copy(marked, messages)
+
+	mark := func(i int) {
+		msg := *marked[i]
+		msg.CacheControl = llm.EphemeralCache()
+		marked[i] = &msg
+	}
+	for i := len(marked) - 1; i >= 0; i-- {
+		if marked[i].Role == llm.RoleSystem {
+			mark(i)
+			break
+		}
+	}
+	if marked[len(marked)-1].CacheControl == nil {
+		mark(len(marked) - 1)
+	}
+	return marked
+}
@@ -36,4 +36,17 @@
 //	    log.Fatal(err)
 //	}
 //	fmt.Println(result.Content)
+//
+// 上下文窗口:
+//
+// 每次调用 LLM 前按 llm.TokenCounter 估算请求大小，加上 MaxTokens 超出上下文窗口时
+// 从最早的对话开始按轮次裁剪历史 (系统消息和当前轮次总是保留)，
+// 仍然超出时直接返回 llm.ErrContextLength，不发送请求。
+// 窗口大小取 WithContextWindow 或 LLM 客户端的 llm.ModelInfo.ContextWindow，都没有时不裁剪。
+//
+// 提示缓存和扩展思考:
+//
+// WithPromptCaching 在系统提示和每次请求的最后一条消息上设置缓存断点，
+// 循环中重复发送的系统提示和历史按缓存读取计费。
+// WithThinking 启用扩展思考，思考内容随助手消息保留，流式执行时以 EventTypeThinking 事件发送。
 package agent
@@ -72,6 +72,42 @@ func WithTemperature(temp float64) Option {
 	}
 }
 
+// WithContextWindow 设置上下文窗口大小
+// 请求超出窗口时从最早的对话开始裁剪历史
+func WithContextWindow(tokens int) Option {
+	return func(c *Config) {
+		if tokens > 0 {
+			c.ContextWindow = tokens
+		}
+	}
+}
+
+// WithTokenCounter 设置 token 计数器
+func WithTokenCounter(counter llm.TokenCounter) Option {
+	return func(c *Config) {
+		c.TokenCounter = counter
+	}
+}
+
+// WithPromptCaching 启用提示缓存
+// 在系统提示和每次请求的最后一条消息上设置缓存断点，
+// 工具定义、系统提示和历史对话在循环中按缓存读取计费
+func WithPromptCaching() Option {
+	return func(c *Config) {
+		c.PromptCaching = true
+	}
+}
+
+// WithThinking 启用扩展思考，budgetTokens 为思考可使用的最大 token 数
+// Anthropic 要求至少 1024，且小于 MaxTokens
+func WithThinking(budgetTokens int) Option {
+	return func(c *Config) {
+		if budgetTokens > 0 {
+			c.Thinking = &llm.ThinkingConfig{BudgetTokens: budgetTokens}
+		}
+	}
+}
+
 // WithMemory 设置记忆管理器
 // 注意：这个选项会创建一个使用 memory 的 Agent
 func WithMemory(m memory.Memory) Option {
@@ -114,6 +114,18 @@ type Config struct {
 
 	// Temperature 采样温度
 	Temperature float64
+
+	// ContextWindow 上下文窗口大小 (可选，默认从 LLM 的 llm.ModelInfo 获取，0 表示不裁剪)
+	ContextWindow int
+
+	// TokenCounter token 计数器 (可选，默认按模型选择 tokenizer.ForModel，找不到词表时按字符估算)
+	TokenCounter llm.TokenCounter
+
+	// PromptCaching 是否在系统提示和最后一条消息上设置提示缓存断点
+	PromptCaching bool
+
+	// Thinking 扩展思考配置 (可选)
+	Thinking *llm.ThinkingConfig
 }
 
 // Option Agent 配置选项
@@ -135,6 +147,8 @@ type EventType string
 const (
 	// EventTypeContent 文本内容增量
 	EventTypeContent EventType = "content"
+	// EventTypeThinking 思考内容增量
+	EventTypeThinking EventType = "thinking"
 	// EventTypeToolCall 工具调用
 	EventTypeToolCall EventType = "tool_call"
 	// EventTypeToolResult 工具执行结果
@@ -153,6 +167,9 @@ type StreamEvent struct {
 	// Content 文本内容
 	Content string `json:"content,omitempty"`
 
+	// Thinking 思考内容
+	Thinking string `json:"thinking,omitempty"`
+
 	// ToolCall 工具调用信息
 	ToolCall *llm.ToolCall `json:"tool_call,omitempty"`
 
@@ -142,6 +142,11 @@ func (c *Client) ProviderName() string {
 	return "anthropic"
 }
 
+// ContextWindow 返回模型的上下文窗口大小
+func (c *Client) ContextWindow() int {
+	return llm.ContextWindowFor(c.config.Model)
+}
+
 // Complete 发送非流式请求
 func (c *Client) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
 	// 填充默认值
@@ -186,11 +191,7 @@ func (c *Client) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatR
 
 	// 检查错误
 	if resp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error != nil {
-			return nil, convertError(errResp.Error, resp.StatusCode)
-		}
-		return nil, fmt.Errorf("请求失败: %s (状态码: %d)", string(respBody), resp.StatusCode)
+		return nil, parseError(resp, respBody)
 	}
 
 	// 解析响应
@@ -242,11 +243,7 @@ func (c *Client) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.
 	if resp.StatusCode != http.StatusOK {
 		defer resp.Body.Close()
 		respBody, _ := io.ReadAll(resp.Body)
-		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error != nil {
-			return nil, convertError(errResp.Error, resp.StatusCode)
-		}
-		return nil, fmt.Errorf("请求失败: %s (状态码: %d)", string(respBody), resp.StatusCode)
+		return nil, parseError(resp, respBody)
 	}
 
 	// 创建输出通道
@@ -266,13 +263,14 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 	scanner := bufio.NewScanner(resp.Body)
 
 	var (
-		messageID      string
-		model          string
-		accumulated    string
-		toolCalls      []*llm.ToolCall
-		currentToolIdx = -1
-		inputUsage     int
-		outputUsage    int
+		messageID       string
+		model           string
+		accumulated     string
+		toolCalls       []*llm.ToolCall
+		currentToolIdx  = -1
+		thinking        []*llm.ThinkingBlock
+		currentThinking *llm.ThinkingBlock
+		usage           Usage
 	)
 
 	for scanner.Scan() {
@@ -309,13 +307,19 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 			if event.Message != nil {
 				messageID = event.Message.ID
 				model = event.Message.Model
-				inputUsage = event.Message.Usage.InputTokens
+				usage = event.Message.Usage
 			}
 
 		case EventTypeContentBlockStart:
 			// 内容块开始
 			if event.ContentBlock != nil {
-				if event.ContentBlock.Type == "tool_use" {
+				switch event.ContentBlock.Type {
+				case "thinking":
+					currentThinking = &llm.ThinkingBlock{}
+					thinking = append(thinking, currentThinking)
+				case "redacted_thinking":
+					thinking = append(thinking, &llm.ThinkingBlock{RedactedData: event.ContentBlock.Data})
+				case "tool_use":
 					// 开始新的工具调用
 					toolCalls = append(toolCalls, &llm.ToolCall{
 						ID:   event.ContentBlock.ID,
@@ -341,37 +345,52 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 					if currentToolIdx >= 0 && currentToolIdx < len(toolCalls) {
 						toolCalls[currentToolIdx].Function.Arguments += event.Delta.PartialJSON
 					}
+
+				case "thinking_delta":
+					// 思考内容增量
+					if currentThinking != nil {
+						currentThinking.Thinking += event.Delta.Thinking
+					}
+					ch <- llm.NewThinkingChunk(event.Delta.Thinking)
+
+				case "signature_delta":
+					// 思考内容签名，在思考块结束前发送
+					if currentThinking != nil {
+						currentThinking.Signature += event.Delta.Signature
+					}
 				}
 			}
 
 		case EventTypeContentBlockStop:
 			// 内容块结束
 			currentToolIdx = -1
+			currentThinking = nil
 
 		case EventTypeMessageDelta:
 			// 消息增量，包含 stop_reason 和输出 token
 			if event.Delta != nil {
 				if event.Delta.StopReason != "" {
 					// 构建最终响应
-					finalResp := &llm.ChatResponse{
+					// 官方 API 的 usage 在事件顶层，部分兼容服务放在 delta 中
+					delta := event.Usage
+					if delta == nil {
+						delta = event.Delta.Usage
+					}
+					if delta != nil {
+						usage.OutputTokens = delta.OutputTokens
+					}
+
+					// 构建最终响应
+					ch <- llm.NewDoneChunk(&llm.ChatResponse{
 						ID:         messageID,
 						Model:      model,
 						Content:    accumulated,
 						Role:       llm.RoleAssistant,
 						ToolCalls:  toolCalls,
 						StopReason: convertStopReason(event.Delta.StopReason),
-						Usage: &llm.Usage{
-							InputTokens:  inputUsage,
-							OutputTokens: outputUsage,
-							TotalTokens:  inputUsage + outputUsage,
-						},
-					}
-					if event.Delta.Usage != nil {
-						outputUsage = event.Delta.Usage.OutputTokens
-						finalResp.Usage.OutputTokens = outputUsage
-						finalResp.Usage.TotalTokens = inputUsage + outputUsage
-					}
-					ch <- llm.NewDoneChunk(finalResp)
+						Usage:      convertUsage(&usage),
+						Thinking:   thinking,
+					})
 				}
 			}
 
@@ -396,6 +415,20 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 	}
 }
 
+// parseError 解析错误响应，带上 Retry-After 响应头
+// 响应体不是 Anthropic 错误格式时按状态码确定错误类型
+func parseError(resp *http.Response, body []byte) *llm.LLMError {
+	var llmErr *llm.LLMError
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		llmErr = convertError(errResp.Error, resp.StatusCode)
+	} else {
+		llmErr = llm.ErrorFromStatus(resp.StatusCode, fmt.Sprintf("请求失败: %s (状态码: %d)", string(body), resp.StatusCode)).
+			WithProvider("anthropic")
+	}
+	return llmErr.WithRetryAfter(llm.ParseRetryAfter(resp.Header.Get("Retry-After")))
+}
+
 // convertError 转换错误
 func convertError(err *ErrorDetail, statusCode int) *llm.LLMError {
 	var typ llm.ErrorType
@@ -1,7 +1,17 @@
 package anthropic
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/cassette"
 )
 
 func TestBuildEndpoint(t *testing.T) {
@@ -79,3 +89,431 @@ func TestBuildEndpoint_EdgeCases(t *testing.T) {
 		t.Errorf("empty path with /v1 baseURL should work, got %q", result)
 	}
 }
+
+func TestParseError(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "12")
+
+	err := parseError(resp, []byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+	if err.Type != llm.ErrorTypeRateLimit || err.RetryAfter != 12 {
+		t.Errorf("unexpected error: %+v", err)
+	}
+
+	// 非 JSON 响应体按状态码判断
+	resp = &http.Response{StatusCode: 529, Header: http.Header{}}
+	err = parseError(resp, []byte("<html>overloaded</html>"))
+	if err.Type != llm.ErrorTypeOverloaded || !err.IsRetryable() {
+		t.Errorf("expected retryable overloaded error, got %+v", err)
+	}
+}
+
+func TestConvertRequest_ToolChoice(t *testing.T) {
+	tests := []struct {
+		choice *llm.ToolChoice
+		want   string
+	}{
+		{llm.ToolChoiceAuto(), `{"type":"auto"}`},
+		{llm.ToolChoiceNone(), `{"type":"none"}`},
+		{llm.ToolChoiceRequired(), `{"type":"any"}`},
+		{llm.ToolChoiceFunction("get_weather"), `{"type":"tool","name":"get_weather"}`},
+	}
+
+	for _, tt := range tests {
+		req := convertRequest(&llm.ChatRequest{ToolChoice: tt.choice})
+		got, _ := json.Marshal(req.ToolChoice)
+		if string(got) != tt.want {
+			t.Errorf("tool choice %v: got %s, want %s", tt.choice.Type, got, tt.want)
+		}
+	}
+}
+
+func TestToolArgumentsRoundTrip(t *testing.T) {
+	// 请求中的工具调用参数作为 JSON 对象发送
+	req := convertRequest(&llm.ChatRequest{
+		Messages: []*llm.Message{{
+			Role:      llm.RoleAssistant,
+			ToolCalls: []*llm.ToolCall{llm.NewToolCall("toolu_1", "get_weather", `{"city":"北京"}`)},
+		}},
+	})
+	data, _ := json.Marshal(req.Messages[0])
+	if !strings.Contains(string(data), `"input":{"city":"北京"}`) {
+		t.Errorf("expected input object, got %s", data)
+	}
+
+	// 响应中的工具调用参数转换为 JSON 字符串
+	var resp ChatResponse
+	body := `{"id":"msg_1","content":[{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"北京"}}],"stop_reason":"tool_use"}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := convertResponse(&resp)
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].Function.Arguments != `{"city":"北京"}` {
+		t.Errorf("unexpected tool calls: %+v", result.ToolCalls[0].Function)
+	}
+}
+
+// newCassetteClient 使用 testdata/cassettes 中录制的交互创建客户端
+// 重新录制: LLM_CASSETTE_MODE=record ANTHROPIC_API_KEY=... go test ./pkg/llm/anthropic
+func newCassetteClient(t *testing.T, name string) *Client {
+	t.Helper()
+	rec, err := cassette.New(filepath.Join("testdata", "cassettes", name+".json"))
+	if err != nil {
+		t.Fatalf("加载录制文件失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Save(); err != nil {
+			t.Errorf("保存录制文件失败: %v", err)
+		}
+	})
+
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		apiKey = "test-key"
+	}
+	client, err := NewClient(
+		WithAPIKey(apiKey),
+		WithModel("claude-3-5-haiku-20241022"),
+		WithMaxTokens(256),
+		WithHTTPClient(rec.Client()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client
+}
+
+func cassetteTextRequest() *llm.ChatRequest {
+	return &llm.ChatRequest{
+		System: "用一句话回答",
+		Messages: []*llm.Message{
+			{Role: llm.RoleUser, Content: llm.Text("你好")},
+		},
+	}
+}
+
+func cassetteToolRequest() *llm.ChatRequest {
+	return &llm.ChatRequest{
+		Tools: []*llm.Tool{llm.NewTool("get_weather", "查询城市天气", map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"city": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"city"},
+		})},
+		Messages: []*llm.Message{
+			{Role: llm.RoleUser, Content: llm.Text("北京的天气怎么样？")},
+		},
+	}
+}
+
+// collectStream 读取流直到结束
+func collectStream(t *testing.T, ch <-chan *llm.StreamChunk) (string, *llm.ChatResponse) {
+	t.Helper()
+	var content string
+	var done *llm.ChatResponse
+	for chunk := range ch {
+		if chunk.IsError() {
+			t.Fatalf("unexpected error chunk: %v", chunk.Error)
+		}
+		content += chunk.Content
+		if chunk.IsDone() {
+			done = chunk.Response
+		}
+	}
+	if done == nil {
+		t.Fatal("stream ended without done chunk")
+	}
+	return content, done
+}
+
+func TestCassette_CompleteText(t *testing.T) {
+	client := newCassetteClient(t, "complete_text")
+
+	resp, err := client.Complete(context.Background(), cassetteTextRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "你好！有什么我可以帮你的吗？" || resp.StopReason != llm.StopReasonEndTurn {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.Usage.InputTokens != 18 || resp.Usage.OutputTokens != 16 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestCassette_CompleteToolCall(t *testing.T) {
+	client := newCassetteClient(t, "complete_tool_call")
+
+	resp, err := client.Complete(context.Background(), cassetteToolRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StopReason != llm.StopReasonToolUse || len(resp.ToolCalls) != 1 {
+		t.Fatalf("expected tool call, got %+v", resp)
+	}
+	tc := resp.ToolCalls[0]
+	if tc.ID != "toolu_01Bj" || tc.Function.Name != "get_weather" || tc.Function.Arguments != `{"city":"北京"}` {
+		t.Errorf("unexpected tool call: %+v %+v", tc, tc.Function)
+	}
+	if resp.Content != "我来查询北京的天气。" {
+		t.Errorf("unexpected content: %q", resp.Content)
+	}
+}
+
+func TestCassette_StreamText(t *testing.T) {
+	client := newCassetteClient(t, "stream_text")
+
+	ch, err := client.Stream(context.Background(), cassetteTextRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, done := collectStream(t, ch)
+	if content != "你好！有什么我可以帮你的吗？" || done.Content != content {
+		t.Errorf("unexpected content: %q / %q", content, done.Content)
+	}
+	// message_delta 的 usage 在事件顶层
+	if done.StopReason != llm.StopReasonEndTurn || done.Usage.InputTokens != 18 || done.Usage.OutputTokens != 16 {
+		t.Errorf("unexpected done response: %+v %+v", done, done.Usage)
+	}
+}
+
+func TestCassette_StreamToolCall(t *testing.T) {
+	client := newCassetteClient(t, "stream_tool_call")
+
+	ch, err := client.Stream(context.Background(), cassetteToolRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, done := collectStream(t, ch)
+	if content != "我来查询北京的天气。" {
+		t.Errorf("unexpected content: %q", content)
+	}
+	if done.StopReason != llm.StopReasonToolUse || len(done.ToolCalls) != 1 {
+		t.Fatalf("expected tool call, got %+v", done.ToolCalls)
+	}
+	tc := done.ToolCalls[0]
+	if tc.ID != "toolu_01Bj" || tc.Function.Arguments != `{"city": "北京"}` {
+		t.Errorf("unexpected tool call: %+v %+v", tc, tc.Function)
+	}
+}
+
+func TestCassette_Overloaded(t *testing.T) {
+	client := newCassetteClient(t, "error_overloaded")
+
+	_, err := client.Complete(context.Background(), cassetteTextRequest())
+	llmErr, ok := llm.AsLLMError(err)
+	if !ok {
+		t.Fatalf("expected LLMError, got %v", err)
+	}
+	if llmErr.Type != llm.ErrorTypeOverloaded || llmErr.StatusCode != 529 || !llmErr.IsRetryable() {
+		t.Errorf("unexpected error: %+v", llmErr)
+	}
+}
+
+func TestCassette_RequestMismatch(t *testing.T) {
+	if mode := os.Getenv(cassette.EnvMode); mode != "" && mode != string(cassette.ModeReplay) {
+		t.Skip("只在回放模式下检查")
+	}
+	client := newCassetteClient(t, "complete_text")
+
+	// 请求转换发生变化时回放失败，而不是返回录制的响应
+	req := cassetteTextRequest()
+	req.System = "另一个系统提示"
+	if _, err := client.Complete(context.Background(), req); !errors.Is(err, cassette.ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+}
+
+func TestConvertRequest_CacheAndThinking(t *testing.T) {
+	// 没有缓存断点时系统消息合并为字符串
+	plain := convertRequest(&llm.ChatRequest{
+		System: "top",
+		Messages: []*llm.Message{
+			{Role: llm.RoleSystem, Content: llm.Text("sys")},
+			{Role: llm.RoleUser, Content: llm.Text("hi")},
+		},
+	})
+	if plain.System != "top\n\nsys" || len(plain.Messages) != 1 {
+		t.Errorf("unexpected system: %#v", plain.System)
+	}
+
+	req := &llm.ChatRequest{
+		Temperature: 0.7,
+		Thinking:    &llm.ThinkingConfig{BudgetTokens: 2048},
+		Messages: []*llm.Message{
+			{Role: llm.RoleSystem, Content: llm.Text("long system prompt"), CacheControl: llm.EphemeralCache()},
+			{Role: llm.RoleUser, Content: llm.NewContentList(llm.Text("看图"), llm.ImageFromBase64("aW1n", "image/png")), CacheControl: &llm.CacheControl{TTL: "1h"}},
+			{
+				Role:      llm.RoleAssistant,
+				ToolCalls: []*llm.ToolCall{llm.NewToolCall("toolu_1", "get_time", "{}")},
+				Thinking: []*llm.ThinkingBlock{
+					{Thinking: "先查时间", Signature: "sig-1"},
+					{RedactedData: "encrypted"},
+				},
+			},
+			{Role: llm.RoleTool, ToolCallID: "toolu_1", Content: llm.Text("12:00"), CacheControl: llm.EphemeralCache()},
+		},
+	}
+	anthReq := convertRequest(req)
+
+	if anthReq.Thinking == nil || anthReq.Thinking.Type != "enabled" || anthReq.Thinking.BudgetTokens != 2048 || anthReq.Temperature != 0 {
+		t.Errorf("unexpected thinking config: %+v temperature=%v", anthReq.Thinking, anthReq.Temperature)
+	}
+
+	system, ok := anthReq.System.([]ContentBlock)
+	if !ok || len(system) != 1 || system[0].CacheControl == nil || system[0].CacheControl.Type != "ephemeral" {
+		t.Fatalf("expected cached system block, got %#v", anthReq.System)
+	}
+
+	data, _ := json.Marshal(anthReq.Messages)
+	var messages []struct {
+		Role    string                   `json:"role"`
+		Content []map[string]interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(data, &messages); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+
+	// 断点设置在消息的最后一个内容块上
+	user := messages[0].Content
+	if _, ok := user[0]["cache_control"]; ok {
+		t.Error("expected no cache_control on first block")
+	}
+	if cc, _ := user[1]["cache_control"].(map[string]interface{}); cc["type"] != "ephemeral" || cc["ttl"] != "1h" {
+		t.Errorf("unexpected image block: %v", user[1])
+	}
+	if _, ok := req.Messages[1].Content.ToAnthropic().([]interface{})[1].(map[string]interface{})["cache_control"]; ok {
+		t.Error("original content should not be modified")
+	}
+
+	// 思考块在工具调用之前原样发回
+	assistant := messages[1].Content
+	if len(assistant) != 3 || assistant[0]["type"] != "thinking" || assistant[0]["signature"] != "sig-1" ||
+		assistant[1]["type"] != "redacted_thinking" || assistant[1]["data"] != "encrypted" || assistant[2]["type"] != "tool_use" {
+		t.Errorf("unexpected assistant content: %v", assistant)
+	}
+
+	if result := messages[2].Content[0]; result["type"] != "tool_result" || result["cache_control"] == nil {
+		t.Errorf("unexpected tool result: %v", result)
+	}
+}
+
+func TestConvertResponse_ThinkingAndCacheUsage(t *testing.T) {
+	resp := convertResponse(&ChatResponse{
+		Content: []ContentBlock{
+			{Type: "thinking", Thinking: "想一想", Signature: "sig"},
+			{Type: "text", Text: "答案"},
+		},
+		StopReason: "end_turn",
+		Usage:      Usage{InputTokens: 10, OutputTokens: 20, CacheReadInputTokens: 1000, CacheCreationInputTokens: 200},
+	})
+
+	if resp.Content != "答案" || resp.ThinkingText() != "想一想" || resp.Thinking[0].Signature != "sig" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	u := resp.Usage
+	if u.InputTokens != 1210 || u.CacheReadTokens != 1000 || u.CacheCreationTokens != 200 || u.TotalTokens != 1230 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+}
+
+func TestCassette_StreamThinking(t *testing.T) {
+	client := newCassetteClient(t, "stream_thinking")
+
+	req := cassetteToolRequest()
+	req.MaxTokens = 4096
+	req.Thinking = &llm.ThinkingConfig{BudgetTokens: 1024}
+	req.Messages[0].CacheControl = llm.EphemeralCache()
+
+	ch, err := client.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var thinking string
+	var done *llm.ChatResponse
+	for chunk := range ch {
+		if chunk.IsError() {
+			t.Fatalf("unexpected error chunk: %v", chunk.Error)
+		}
+		if chunk.Type == llm.ChunkTypeThinking {
+			thinking += chunk.Thinking
+		}
+		if chunk.IsDone() {
+			done = chunk.Response
+		}
+	}
+
+	if thinking != "用户想知道北京的天气，我应该调用 get_weather。" || done.ThinkingText() != thinking {
+		t.Errorf("unexpected thinking: %q / %q", thinking, done.ThinkingText())
+	}
+	if len(done.Thinking) != 1 || done.Thinking[0].Signature == "" {
+		t.Errorf("expected signed thinking block, got %+v", done.Thinking)
+	}
+	if len(done.ToolCalls) != 1 || done.ToolCalls[0].Function.Arguments != `{"city":"北京"}` {
+		t.Errorf("unexpected tool calls: %+v", done.ToolCalls)
+	}
+	if u := done.Usage; u.CacheReadTokens != 1400 || u.InputTokens != 1412 || u.OutputTokens != 96 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+}
+
+func TestConvertRequest_DocumentsAndToolResultImages(t *testing.T) {
+	req := &llm.ChatRequest{Messages: []*llm.Message{
+		{Role: llm.RoleUser, Content: llm.NewContentList(
+			llm.Text("总结这两份文件"),
+			llm.DocumentFromBase64("JVBERi0=", "application/pdf"),
+			llm.DocumentFromText("会议纪要", "notes.txt"),
+			llm.AudioFromBase64("UklGRg==", "wav"),
+		)},
+		{Role: llm.RoleUser, Content: llm.DocumentFromURL("https://example.com/a.pdf")},
+		{Role: llm.RoleAssistant, ToolCalls: []*llm.ToolCall{llm.NewToolCall("toolu_1", "screenshot", "{}")}},
+		{Role: llm.RoleTool, ToolCallID: "toolu_1", Content: llm.NewContentList(
+			llm.Text("截图"),
+			llm.ImageFromBase64("iVBORw0KGgo=", "image/png"),
+		)},
+	}}
+
+	data, err := json.Marshal(convertRequest(req))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got struct {
+		Messages []struct {
+			Content []map[string]interface{} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first := got.Messages[0].Content
+	if len(first) != 4 || first[1]["type"] != "document" || first[2]["type"] != "document" || first[2]["title"] != "notes.txt" {
+		t.Fatalf("unexpected document blocks: %v", first)
+	}
+	if source := first[1]["source"].(map[string]interface{}); source["type"] != "base64" || source["media_type"] != "application/pdf" {
+		t.Errorf("unexpected pdf source: %v", source)
+	}
+	// Anthropic 不支持音频，替换为文本说明
+	if first[3]["type"] != "text" || first[3]["text"] != "[音频]" {
+		t.Errorf("expected audio placeholder, got %v", first[3])
+	}
+
+	// 单个文档也使用内容块数组
+	urlDoc := got.Messages[1].Content
+	if len(urlDoc) != 1 || urlDoc[0]["source"].(map[string]interface{})["url"] != "https://example.com/a.pdf" {
+		t.Errorf("unexpected url document: %v", urlDoc)
+	}
+
+	result := got.Messages[3].Content
+	if len(result) != 1 || result[0]["type"] != "tool_result" {
+		t.Fatalf("unexpected tool result: %v", result)
+	}
+	blocks, ok := result[0]["content"].([]interface{})
+	if !ok || len(blocks) != 2 || blocks[1].(map[string]interface{})["type"] != "image" {
+		t.Errorf("expected image in tool result, got %v", result[0]["content"])
+	}
+}
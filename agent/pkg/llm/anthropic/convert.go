@@ -1,6 +1,9 @@
 package anthropic
 
 import (
+	"encoding/json"
+	"strings"
+
 	"github.com/wangtengda0310/gobee/agent/pkg/llm"
 )
 
@@ -12,7 +15,7 @@ func convertRequest(req *llm.ChatRequest) *ChatRequest {
 		Temperature: req.Temperature,
 		TopP:        req.TopP,
 		Stream:      req.Stream,
-		System:      req.System,
+		System:      convertSystem(req),
 	}
 
 	// 如果没有设置 max_tokens，使用默认值 (Anthropic 要求必填)
@@ -20,6 +23,12 @@ func convertRequest(req *llm.ChatRequest) *ChatRequest {
 		anthReq.MaxTokens = DefaultMaxTokens
 	}
 
+	// 扩展思考不支持修改 temperature
+	if req.Thinking != nil && req.Thinking.BudgetTokens > 0 {
+		anthReq.Thinking = &Thinking{Type: "enabled", BudgetTokens: req.Thinking.BudgetTokens}
+		anthReq.Temperature = 0
+	}
+
 	// 转换消息
 	anthReq.Messages = convertMessages(req.Messages)
 
@@ -27,16 +36,80 @@ func convertRequest(req *llm.ChatRequest) *ChatRequest {
 	if len(req.Tools) > 0 {
 		anthReq.Tools = convertTools(req.Tools)
 	}
+	if req.ToolChoice != nil {
+		anthReq.ToolChoice = convertToolChoice(req.ToolChoice)
+	}
 
 	return anthReq
 }
 
+// convertSystem 合并顶层 System 和系统消息
+// 没有缓存断点时使用字符串，否则使用带 cache_control 的文本块数组
+func convertSystem(req *llm.ChatRequest) interface{} {
+	var blocks []ContentBlock
+	cached := false
+	if req.System != "" {
+		blocks = append(blocks, ContentBlock{Type: "text", Text: req.System})
+	}
+	for _, msg := range req.Messages {
+		if msg.Role != llm.RoleSystem {
+			continue
+		}
+		text := llm.TextString(msg.Content)
+		if text == "" {
+			continue
+		}
+		blocks = append(blocks, ContentBlock{
+			Type:         "text",
+			Text:         text,
+			CacheControl: convertCacheControl(msg.CacheControl),
+		})
+		cached = cached || msg.CacheControl != nil
+	}
+
+	if len(blocks) == 0 {
+		return nil
+	}
+	if cached {
+		return blocks
+	}
+	texts := make([]string, len(blocks))
+	for i, block := range blocks {
+		texts[i] = block.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// convertCacheControl 转换缓存断点
+func convertCacheControl(cc *llm.CacheControl) *CacheControl {
+	if cc == nil {
+		return nil
+	}
+	typ := cc.Type
+	if typ == "" {
+		typ = llm.CacheTypeEphemeral
+	}
+	return &CacheControl{Type: typ, TTL: cc.TTL}
+}
+
+// convertToolChoice 转换工具选择策略
+func convertToolChoice(choice *llm.ToolChoice) *ToolChoice {
+	switch choice.Type {
+	case llm.ToolChoiceTypeRequired:
+		return &ToolChoice{Type: "any"}
+	case llm.ToolChoiceTypeTool:
+		return &ToolChoice{Type: "tool", Name: choice.Name}
+	default:
+		return &ToolChoice{Type: string(choice.Type)}
+	}
+}
+
 // convertMessages 转换消息列表
 func convertMessages(messages []*llm.Message) []Message {
 	result := make([]Message, 0, len(messages))
 
 	for _, msg := range messages {
-		// 跳过系统消息 (Anthropic 使用顶层 system 字段)
+		// 跳过系统消息 (Anthropic 使用顶层 system 字段，见 convertSystem)
 		if msg.Role == llm.RoleSystem {
 			continue
 		}
@@ -53,25 +126,35 @@ func convertMessages(messages []*llm.Message) []Message {
 					{Type: "text", Text: text.Text},
 				}
 			} else {
-				anthMsg.Content = msg.Content.ToAnthropic()
+				anthMsg.Content = convertContent(msg.Content)
 			}
 		}
 
-		// 处理工具响应消息
+		// 处理工具响应消息，工具结果可以包含图像和文档
 		if msg.Role == llm.RoleTool && msg.ToolCallID != "" {
-			anthMsg.Content = []ContentBlock{
-				{
-					Type:      "tool_result",
-					ToolUseID: msg.ToolCallID,
-					Content:   llm.TextString(msg.Content),
-				},
+			block := ContentBlock{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   llm.PlainText(msg.Content),
+			}
+			if _, media := llm.SplitMedia(msg.Content); len(media) > 0 {
+				block.Content = convertContent(msg.Content)
 			}
+			anthMsg.Content = []ContentBlock{block}
 		}
 
-		// 处理助手的工具调用
-		if msg.Role == llm.RoleAssistant && len(msg.ToolCalls) > 0 {
+		// 处理助手的思考内容和工具调用，思考内容必须在最前面
+		if msg.Role == llm.RoleAssistant && (len(msg.ToolCalls) > 0 || len(msg.Thinking) > 0) {
 			blocks := make([]ContentBlock, 0)
 
+			for _, tb := range msg.Thinking {
+				if tb.RedactedData != "" {
+					blocks = append(blocks, ContentBlock{Type: "redacted_thinking", Data: tb.RedactedData})
+				} else {
+					blocks = append(blocks, ContentBlock{Type: "thinking", Thinking: tb.Thinking, Signature: tb.Signature})
+				}
+			}
+
 			// 添加文本内容
 			if text := llm.TextString(msg.Content); text != "" {
 				blocks = append(blocks, ContentBlock{
@@ -93,12 +176,64 @@ func convertMessages(messages []*llm.Message) []Message {
 			anthMsg.Content = blocks
 		}
 
+		if msg.CacheControl != nil {
+			anthMsg.Content = withCacheControl(anthMsg.Content, convertCacheControl(msg.CacheControl))
+		}
+
 		result = append(result, anthMsg)
 	}
 
 	return result
 }
 
+// convertContent 转换多模态内容为内容块数组
+// 单个图像或文档的 ToAnthropic 结果是一个内容块，需要包装为数组
+func convertContent(content llm.Content) interface{} {
+	converted := content.ToAnthropic()
+	if block, ok := converted.(map[string]interface{}); ok {
+		return []interface{}{block}
+	}
+	return converted
+}
+
+// withCacheControl 在消息的最后一个内容块上设置缓存断点
+// 不修改原内容，多模态内容 (ToAnthropic 的结果) 复制最后一项后再设置
+func withCacheControl(content interface{}, cc *CacheControl) interface{} {
+	switch v := content.(type) {
+	case []ContentBlock:
+		if len(v) == 0 {
+			return v
+		}
+		blocks := append([]ContentBlock(nil), v...)
+		blocks[len(blocks)-1].CacheControl = cc
+		return blocks
+	case []interface{}:
+		if len(v) == 0 {
+			return v
+		}
+		last, ok := v[len(v)-1].(map[string]interface{})
+		if !ok {
+			return v
+		}
+		parts := append([]interface{}(nil), v...)
+		parts[len(parts)-1] = withCacheControlMap(last, cc)
+		return parts
+	case map[string]interface{}:
+		return []interface{}{withCacheControlMap(v, cc)}
+	}
+	return content
+}
+
+// withCacheControlMap 复制内容块并设置缓存断点
+func withCacheControlMap(block map[string]interface{}, cc *CacheControl) map[string]interface{} {
+	copied := make(map[string]interface{}, len(block)+1)
+	for k, v := range block {
+		copied[k] = v
+	}
+	copied["cache_control"] = cc
+	return copied
+}
+
 // convertTools 转换工具定义列表
 func convertTools(tools []*llm.Tool) []Tool {
 	result := make([]Tool, len(tools))
@@ -142,6 +277,13 @@ func convertResponse(resp *ChatResponse) *llm.ChatResponse {
 					Arguments: interfaceToJSON(block.Input),
 				},
 			})
+		case "thinking":
+			result.Thinking = append(result.Thinking, &llm.ThinkingBlock{
+				Thinking:  block.Thinking,
+				Signature: block.Signature,
+			})
+		case "redacted_thinking":
+			result.Thinking = append(result.Thinking, &llm.ThinkingBlock{RedactedData: block.Data})
 		}
 	}
 
@@ -149,15 +291,24 @@ func convertResponse(resp *ChatResponse) *llm.ChatResponse {
 	result.ToolCalls = toolCalls
 
 	// 转换使用统计
-	result.Usage = &llm.Usage{
-		InputTokens:  resp.Usage.InputTokens,
-		OutputTokens: resp.Usage.OutputTokens,
-		TotalTokens:  resp.Usage.InputTokens + resp.Usage.OutputTokens,
-	}
+	result.Usage = convertUsage(&resp.Usage)
 
 	return result
 }
 
+// convertUsage 转换使用统计
+// Anthropic 的 input_tokens 不含缓存部分，统一格式的 InputTokens 包含缓存读取和写入
+func convertUsage(usage *Usage) *llm.Usage {
+	input := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+	return &llm.Usage{
+		InputTokens:         input,
+		OutputTokens:        usage.OutputTokens,
+		TotalTokens:         input + usage.OutputTokens,
+		CacheReadTokens:     usage.CacheReadInputTokens,
+		CacheCreationTokens: usage.CacheCreationInputTokens,
+	}
+}
+
 // convertStopReason 转换停止原因
 func convertStopReason(reason string) llm.StopReason {
 	switch reason {
@@ -174,17 +325,19 @@ func convertStopReason(reason string) llm.StopReason {
 	}
 }
 
-// jsonRawToInterface 将 JSON 字符串转换为 interface{}
+// jsonRawToInterface 将 JSON 字符串转换为 tool_use 的 input 对象
+// Anthropic 要求 input 是 JSON 对象，空参数或无效 JSON 时使用空对象
 func jsonRawToInterface(s string) interface{} {
-	// 简单处理：直接返回字符串
-	// 实际使用时会通过 json.Unmarshal 解析
-	return s
+	if s == "" || !json.Valid([]byte(s)) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(s)
 }
 
-// interfaceToJSON 将 interface{} 转换为 JSON 字符串
+// interfaceToJSON 将 tool_use 的 input 对象转换为 JSON 字符串
 func interfaceToJSON(v interface{}) string {
 	if v == nil {
-		return ""
+		return "{}"
 	}
 	switch val := v.(type) {
 	case string:
@@ -192,8 +345,10 @@ func interfaceToJSON(v interface{}) string {
 	case []byte:
 		return string(val)
 	default:
-		// 这里应该使用 json.Marshal，但为了避免导入 encoding/json
-		// 实际实现在 client.go 中
-		return ""
+		data, err := json.Marshal(val)
+		if err != nil {
+			return "{}"
+		}
+		return string(data)
 	}
 }
@@ -34,15 +34,36 @@ type ChatRequest struct {
 	Model     string    `json:"model"`
 	Messages  []Message `json:"messages"`
 	MaxTokens int       `json:"max_tokens"`
-	System    string    `json:"system,omitempty"`
+	// System 字符串，或带缓存断点时为 ContentBlock 数组
+	System interface{} `json:"system,omitempty"`
 
 	// 可选参数
-	Temperature   float64  `json:"temperature,omitempty"`
-	TopP          float64  `json:"top_p,omitempty"`
-	TopK          int      `json:"top_k,omitempty"`
-	StopSequences []string `json:"stop_sequences,omitempty"`
-	Stream        bool     `json:"stream,omitempty"`
-	Tools         []Tool   `json:"tools,omitempty"`
+	Temperature   float64     `json:"temperature,omitempty"`
+	TopP          float64     `json:"top_p,omitempty"`
+	TopK          int         `json:"top_k,omitempty"`
+	StopSequences []string    `json:"stop_sequences,omitempty"`
+	Stream        bool        `json:"stream,omitempty"`
+	Tools         []Tool      `json:"tools,omitempty"`
+	ToolChoice    *ToolChoice `json:"tool_choice,omitempty"`
+	Thinking      *Thinking   `json:"thinking,omitempty"`
+}
+
+// Thinking 扩展思考配置
+type Thinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// CacheControl 提示缓存断点
+type CacheControl struct {
+	Type string `json:"type"` // "ephemeral"
+	TTL  string `json:"ttl,omitempty"`
+}
+
+// ToolChoice 工具选择策略
+type ToolChoice struct {
+	Type string `json:"type"` // "auto", "any", "tool", "none"
+	Name string `json:"name,omitempty"`
 }
 
 // Message Anthropic 消息格式
@@ -53,7 +74,7 @@ type Message struct {
 
 // ContentBlock 内容块
 type ContentBlock struct {
-	Type string `json:"type"` // "text", "image", "tool_use", "tool_result"
+	Type string `json:"type"` // "text", "image", "tool_use", "tool_result", "thinking", "redacted_thinking"
 
 	// 文本内容
 	Text string `json:"text,omitempty"`
@@ -70,6 +91,14 @@ type ContentBlock struct {
 	ToolUseID string      `json:"tool_use_id,omitempty"`
 	Content   interface{} `json:"content,omitempty"`
 	IsError   bool        `json:"is_error,omitempty"`
+
+	// 思考内容
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"` // redacted_thinking
+
+	// 缓存断点
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 // ImageSource 图像源
@@ -99,9 +128,12 @@ type ChatResponse struct {
 }
 
 // Usage token 使用统计
+// InputTokens 不包含缓存读取和写入的 token
 type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 }
 
 // StreamEvent 流式事件
@@ -112,13 +144,17 @@ type StreamEvent struct {
 	Message      *ChatResponse `json:"message,omitempty"`
 	ContentBlock *ContentBlock `json:"content_block,omitempty"`
 	Error        *ErrorDetail  `json:"error,omitempty"`
+	// Usage message_delta 事件的累计输出 token
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // StreamDelta 流式增量
 type StreamDelta struct {
-	Type        string `json:"type,omitempty"` // "text_delta", "input_json_delta"
+	Type        string `json:"type,omitempty"` // "text_delta", "input_json_delta", "thinking_delta", "signature_delta"
 	Text        string `json:"text,omitempty"`
 	PartialJSON string `json:"partial_json,omitempty"`
+	Thinking    string `json:"thinking,omitempty"`
+	Signature   string `json:"signature,omitempty"`
 	StopReason  string `json:"stop_reason,omitempty"`
 	Usage       *Usage `json:"usage,omitempty"`
 }
@@ -0,0 +1,481 @@
+// Package cassette 提供录制/回放 HTTP 交互的 http.RoundTripper，
+// 用于在没有网络和 API Key 的情况下测试各提供商的请求转换和流解析。
+//
+// 录制模式下请求照常发往真实 API，请求和响应 (包括 SSE 流) 写入 JSON 文件，
+// API Key、组织 ID 和请求 ID 等敏感信息被替换为 REDACTED；回放模式下按方法、URL 和规范化后的请求体
+// 匹配录制的交互，不会访问网络。
+//
+//	rec, err := cassette.New("testdata/cassettes/stream_text.json")
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	defer rec.Save()
+//
+//	client, _ := anthropic.NewClient(
+//	    anthropic.WithAPIKey(key),
+//	    anthropic.WithHTTPClient(rec.Client()),
+//	)
+//
+// 模式默认取环境变量 LLM_CASSETTE_MODE，未设置时为回放，
+// 因此重新录制只需要:
+//
+//	LLM_CASSETTE_MODE=record ANTHROPIC_API_KEY=... go test ./pkg/llm/anthropic
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode 录制/回放模式
+type Mode string
+
+const (
+	// ModeReplay 只回放，没有匹配的交互时返回 ErrNoMatch
+	ModeReplay Mode = "replay"
+	// ModeRecord 所有请求发往真实 API，重新录制整个文件
+	ModeRecord Mode = "record"
+	// ModeAuto 优先回放，没有匹配的交互时发往真实 API 并追加录制
+	ModeAuto Mode = "auto"
+)
+
+// EnvMode 默认模式的环境变量
+const EnvMode = "LLM_CASSETTE_MODE"
+
+// Redacted 敏感信息的替换值
+const Redacted = "REDACTED"
+
+// ErrNoMatch 回放时没有匹配的交互
+var ErrNoMatch = errors.New("没有匹配的录制交互")
+
+// defaultRedactHeaders 默认脱敏的请求头
+var defaultRedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"X-Goog-Api-Key",
+	"Api-Key",
+	"Cookie",
+}
+
+// defaultRedactResponseHeaders 默认脱敏的响应头 (组织、项目和请求 ID)
+var defaultRedactResponseHeaders = []string{
+	"Openai-Organization",
+	"Openai-Project",
+	"Anthropic-Organization-Id",
+	"X-Request-Id",
+	"Request-Id",
+	"Cf-Ray",
+}
+
+// redactQuery 脱敏的查询参数
+var redactQuery = []string{"key", "api_key", "access_token"}
+
+// skipResponseHeaders 不录制的响应头
+// Content-Length 在回放时按实际响应体重新计算
+var skipResponseHeaders = []string{"Set-Cookie", "Content-Length"}
+
+// Cassette 录制文件内容
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction 一次请求和响应
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request 录制的请求
+type Request struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body JSON 请求体，按键排序
+	Body json.RawMessage `json:"body,omitempty"`
+	// Text 不是 JSON 的请求体
+	Text string `json:"text,omitempty"`
+}
+
+// Response 录制的响应
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body JSON 响应体
+	Body json.RawMessage `json:"body,omitempty"`
+	// Text 不是 JSON 的响应体，SSE 流原样保存
+	Text string `json:"text,omitempty"`
+}
+
+// Config 录制器配置
+type Config struct {
+	Mode                  Mode
+	Transport             http.RoundTripper
+	RedactHeaders         []string
+	RedactResponseHeaders []string
+}
+
+// Option 配置选项函数
+type Option func(*Config)
+
+// WithMode 设置模式，覆盖环境变量
+func WithMode(mode Mode) Option {
+	return func(c *Config) {
+		c.Mode = mode
+	}
+}
+
+// WithTransport 设置录制时使用的底层传输，默认 http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Config) {
+		c.Transport = transport
+	}
+}
+
+// WithRedactHeaders 追加需要脱敏的请求头
+func WithRedactHeaders(names ...string) Option {
+	return func(c *Config) {
+		c.RedactHeaders = append(c.RedactHeaders, names...)
+	}
+}
+
+// WithRedactResponseHeaders 追加需要脱敏的响应头
+func WithRedactResponseHeaders(names ...string) Option {
+	return func(c *Config) {
+		c.RedactResponseHeaders = append(c.RedactResponseHeaders, names...)
+	}
+}
+
+// Recorder 录制/回放 HTTP 交互
+// 实现 http.RoundTripper 接口，可以并发使用
+type Recorder struct {
+	config   *Config
+	path     string
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+	pending  []*recordingBody
+	dirty    bool
+	requests []*Request
+}
+
+// New 创建录制器，回放和自动模式下加载已有的录制文件
+func New(path string, opts ...Option) (*Recorder, error) {
+	config := &Config{
+		Mode:                  Mode(os.Getenv(EnvMode)),
+		Transport:             http.DefaultTransport,
+		RedactHeaders:         append([]string(nil), defaultRedactHeaders...),
+		RedactResponseHeaders: append([]string(nil), defaultRedactResponseHeaders...),
+	}
+	if config.Mode == "" {
+		config.Mode = ModeReplay
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	r := &Recorder{
+		config:   config,
+		path:     path,
+		cassette: &Cassette{},
+	}
+
+	switch config.Mode {
+	case ModeRecord:
+		// 重新录制，不加载旧文件
+	case ModeReplay, ModeAuto:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if config.Mode == ModeAuto && os.IsNotExist(err) {
+				break
+			}
+			return nil, fmt.Errorf("读取录制文件失败: %w", err)
+		}
+		if err := json.Unmarshal(data, r.cassette); err != nil {
+			return nil, fmt.Errorf("解析录制文件失败: %w", err)
+		}
+		// 文件中的请求体带缩进，规范化后才能与实际请求比较
+		for _, it := range r.cassette.Interactions {
+			if len(it.Request.Body) > 0 {
+				it.Request.Body, _ = splitBody(it.Request.Body, true)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("未知模式: %s", config.Mode)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+
+	return r, nil
+}
+
+// Mode 返回当前模式
+func (r *Recorder) Mode() Mode {
+	return r.config.Mode
+}
+
+// Requests 返回收到的请求 (脱敏、规范化后)，按发送顺序排列
+// 测试可以用它检查请求转换的细节
+func (r *Recorder) Requests() []*Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Request(nil), r.requests...)
+}
+
+// Client 返回使用该录制器的 HTTP 客户端
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip 回放或录制一次请求
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取请求体失败: %w", err)
+		}
+	}
+	recorded := r.newRequest(req, body)
+	r.mu.Lock()
+	r.requests = append(r.requests, recorded)
+	r.mu.Unlock()
+
+	if r.config.Mode != ModeRecord {
+		if it := r.match(recorded); it != nil {
+			return it.Response.toHTTP(req), nil
+		}
+		if r.config.Mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoMatch, recorded.Method, recorded.URL)
+		}
+	}
+
+	// 发往真实 API，请求体已被读取，需要重新设置
+	real := req.Clone(req.Context())
+	real.Body = io.NopCloser(bytes.NewReader(body))
+	real.ContentLength = int64(len(body))
+	resp, err := r.config.Transport.RoundTrip(real)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &Interaction{
+		Request: *recorded,
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: r.responseHeaders(resp.Header),
+		},
+	}
+	// 边读边录，流式响应不会被缓冲到结束
+	recording := &recordingBody{body: resp.Body, recorder: r, interaction: it}
+	resp.Body = recording
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, it)
+	r.used = append(r.used, true)
+	r.pending = append(r.pending, recording)
+	r.dirty = true
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Save 写入录制文件，回放模式或没有新交互时不做任何事
+// 尚未读完的响应体按已读取的部分保存
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.config.Mode == ModeReplay || !r.dirty {
+		return nil
+	}
+	for _, b := range r.pending {
+		b.flush()
+	}
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化录制文件失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入录制文件失败: %w", err)
+	}
+	r.dirty = false
+	return nil
+}
+
+// match 返回第一个未使用且匹配的交互，并标记为已使用
+// 相同的请求按录制顺序依次回放 (例如重试)
+func (r *Recorder) match(req *Request) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, it := range r.cassette.Interactions {
+		if r.used[i] {
+			continue
+		}
+		if it.Request.Method == req.Method &&
+			it.Request.URL == req.URL &&
+			bytes.Equal(it.Request.Body, req.Body) &&
+			it.Request.Text == req.Text {
+			r.used[i] = true
+			return it
+		}
+	}
+	return nil
+}
+
+// newRequest 生成脱敏、规范化后的请求
+func (r *Recorder) newRequest(req *http.Request, body []byte) *Request {
+	recorded := &Request{
+		Method:  req.Method,
+		URL:     redactURL(req.URL),
+		Headers: make(map[string]string),
+	}
+	for name := range req.Header {
+		recorded.Headers[name] = req.Header.Get(name)
+	}
+	for _, name := range r.config.RedactHeaders {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := recorded.Headers[name]; ok {
+			recorded.Headers[name] = Redacted
+		}
+	}
+	recorded.Body, recorded.Text = splitBody(body, true)
+	return recorded
+}
+
+// redactURL 脱敏查询参数
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	changed := false
+	for _, name := range redactQuery {
+		if query.Has(name) {
+			query.Set(name, Redacted)
+			changed = true
+		}
+	}
+	if changed {
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
+// splitBody 合法 JSON 放入 RawMessage，其他内容原样作为文本
+// normalize 为 true 时按键排序并去掉空白，使相同语义的请求体相等
+func splitBody(body []byte, normalize bool) (json.RawMessage, string) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, ""
+	}
+	if !json.Valid(body) {
+		return nil, string(body)
+	}
+	if !normalize {
+		return append(json.RawMessage(nil), body...), ""
+	}
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, string(body)
+	}
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return nil, string(body)
+	}
+	return normalized, ""
+}
+
+// responseHeaders 录制的响应头
+func (r *Recorder) responseHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string)
+	for name := range header {
+		headers[name] = header.Get(name)
+	}
+	for _, name := range skipResponseHeaders {
+		delete(headers, name)
+	}
+	for _, name := range r.config.RedactResponseHeaders {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := headers[name]; ok {
+			headers[name] = Redacted
+		}
+	}
+	return headers
+}
+
+// toHTTP 把录制的响应转换为 http.Response
+// JSON 响应体在文件中带缩进，回放时压缩，避免缩进出现在原样透传的字段中
+func (resp *Response) toHTTP(req *http.Request) *http.Response {
+	body := []byte(resp.Text)
+	if len(resp.Body) > 0 {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, resp.Body); err == nil {
+			body = compact.Bytes()
+		} else {
+			body = resp.Body
+		}
+	}
+	header := make(http.Header)
+	for name, value := range resp.Headers {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// recordingBody 读取响应体的同时写入交互
+type recordingBody struct {
+	body        io.ReadCloser
+	recorder    *Recorder
+	interaction *Interaction
+	buf         bytes.Buffer
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 {
+		b.recorder.mu.Lock()
+		b.buf.Write(p[:n])
+		b.recorder.mu.Unlock()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	return b.body.Close()
+}
+
+// flush 用已读取的内容更新交互的响应体，调用方持有 recorder.mu
+func (b *recordingBody) flush() {
+	resp := &b.interaction.Response
+	if strings.HasPrefix(resp.Headers["Content-Type"], "text/event-stream") {
+		resp.Body, resp.Text = nil, b.buf.String()
+		return
+	}
+	resp.Body, resp.Text = splitBody(b.buf.Bytes(), false)
+}
@@ -0,0 +1,192 @@
+package cassette
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const sseBody = "event: message_start\ndata: {\"type\":\"message_start\"}\n\ndata: [DONE]\n\n"
+
+func newUpstream(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Openai-Organization", "org-secret")
+		w.Header().Set("X-Request-Id", "req-secret")
+		if r.URL.Path == "/stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, sseBody)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"call": %d, "echo": %s}`, n, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func doRequest(t *testing.T, client *http.Client, url, body string) string {
+	t.Helper()
+	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer sk-secret")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	server, calls := newUpstream(t)
+	path := filepath.Join(t.TempDir(), "cassettes", "test.json")
+
+	rec, err := New(path, WithMode(ModeRecord))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := rec.Client()
+	first := doRequest(t, client, server.URL+"/json?key=sk-query", `{"b": 1, "a": [1, 2]}`)
+	second := doRequest(t, client, server.URL+"/json?key=sk-query", `{"a":[1,2],"b":1}`)
+	stream := doRequest(t, client, server.URL+"/stream", `{}`)
+	if err := rec.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"sk-secret", "sk-query", "session=secret", "org-secret", "req-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains secret %q", secret)
+		}
+	}
+	if !strings.Contains(string(data), Redacted) {
+		t.Error("expected redacted values in cassette")
+	}
+
+	// 回放不访问网络，键顺序和空白不影响匹配，相同请求按录制顺序返回
+	server.Close()
+	before := atomic.LoadInt32(calls)
+	rec, err = New(path, WithMode(ModeReplay))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client = rec.Client()
+	if got := doRequest(t, client, server.URL+"/json?key=other", `{"a":[1,2], "b":1}`); !jsonEqual(got, first) {
+		t.Errorf("first replay = %s, want %s", got, first)
+	}
+	if got := doRequest(t, client, server.URL+"/json?key=other", `{"b":1,"a":[1,2]}`); !jsonEqual(got, second) {
+		t.Errorf("second replay = %s, want %s", got, second)
+	}
+	if got := doRequest(t, client, server.URL+"/stream", `{}`); got != stream || got != sseBody {
+		t.Errorf("stream replay = %q, want %q", got, sseBody)
+	}
+	if atomic.LoadInt32(calls) != before {
+		t.Error("replay should not reach upstream")
+	}
+	if requests := rec.Requests(); len(requests) != 3 || string(requests[0].Body) != `{"a":[1,2],"b":1}` {
+		t.Errorf("unexpected requests: %+v", requests)
+	}
+
+	// 录制的交互已用完
+	req, _ := http.NewRequest("POST", server.URL+"/stream", strings.NewReader(`{}`))
+	if _, err := client.Do(req); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+}
+
+func TestRecorder_ReplayMismatch(t *testing.T) {
+	server, _ := newUpstream(t)
+	path := filepath.Join(t.TempDir(), "test.json")
+
+	rec, _ := New(path, WithMode(ModeRecord))
+	doRequest(t, rec.Client(), server.URL+"/json", `{"model":"a"}`)
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := New(path, WithMode(ModeReplay))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req, _ := http.NewRequest("POST", server.URL+"/json", strings.NewReader(`{"model":"b"}`))
+	if _, err := rec.Client().Do(req); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), WithMode(ModeReplay)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+}
+
+func TestRecorder_Auto(t *testing.T) {
+	server, calls := newUpstream(t)
+	path := filepath.Join(t.TempDir(), "test.json")
+
+	rec, err := New(path, WithMode(ModeAuto))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doRequest(t, rec.Client(), server.URL+"/json", `{"n":1}`)
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 已录制的请求回放，新请求发往上游并追加
+	rec, _ = New(path, WithMode(ModeAuto))
+	doRequest(t, rec.Client(), server.URL+"/json", `{"n":1}`)
+	doRequest(t, rec.Client(), server.URL+"/json", `{"n":2}`)
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", n)
+	}
+
+	rec, _ = New(path, WithMode(ModeReplay))
+	doRequest(t, rec.Client(), server.URL+"/json", `{"n":1}`)
+	doRequest(t, rec.Client(), server.URL+"/json", `{"n":2}`)
+}
+
+func TestMode_FromEnv(t *testing.T) {
+	t.Setenv(EnvMode, string(ModeRecord))
+	rec, err := New(filepath.Join(t.TempDir(), "test.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Mode() != ModeRecord {
+		t.Errorf("expected record mode, got %s", rec.Mode())
+	}
+
+	t.Setenv(EnvMode, "bogus")
+	if _, err := New(filepath.Join(t.TempDir(), "test.json")); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func jsonEqual(a, b string) bool {
+	x, _ := splitBody([]byte(a), true)
+	y, _ := splitBody([]byte(b), true)
+	return string(x) == string(y)
+}
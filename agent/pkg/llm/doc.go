@@ -1,8 +1,10 @@
 // Package llm 提供统一的大语言模型适配接口。
 //
 // 支持多种 LLM 提供商:
-//   - OpenAI (GPT-4, GPT-4o)
+//   - OpenAI (GPT-4, GPT-4o)，包括 Responses API
 //   - Anthropic (Claude)
+//   - Google Gemini
+//   - 本地模型 (Ollama, llama.cpp server)
 //
 // 核心接口:
 //
@@ -43,6 +45,18 @@
 //	)
 //	req.Messages[0].Content = content
 //
+//	// 文档 (PDF) 和音频
+//	content = llm.NewContentList(
+//	    llm.Text("总结这份报告"),
+//	    llm.PDFFromBytes(pdfData),
+//	    llm.AudioFromBytes(wavData, "wav"),
+//	)
+//
+// 提供商不支持的内容类型 (如 Anthropic 的音频、本地模型的文档) 替换为文本说明，
+// 见 PlainText。工具结果也可以是多模态内容: 工具返回 llm.Content 时，
+// Agent 原样把它作为工具结果发送; OpenAI 的工具消息只接受文本，
+// 其中的图像和文件在工具消息之后作为一条用户消息发送。
+//
 // 流式响应:
 //
 //	stream, err := client.Stream(ctx, req)
@@ -55,4 +69,42 @@
 //	        break
 //	    }
 //	}
+//
+// 结构化输出:
+//
+//	// 根据类型生成 JSON Schema，校验失败时自动把错误发回给模型重试
+//	type Weather struct {
+//	    City  string  `json:"city" description:"城市名称"`
+//	    TempC float64 `json:"temp_c"`
+//	}
+//	weather, err := llm.CompleteStructured[Weather](ctx, client, req)
+//
+// 支持原生结构化输出的客户端 (StructuredOutputSupporter) 使用 ResponseFormat，
+// 其他客户端强制调用一个以 Schema 为参数的工具，此时 req.Tools 会被替换。
+//
+// 提示缓存和扩展思考:
+//
+//	// 系统提示及之前的内容 (工具定义) 被缓存，后续请求按缓存读取计费
+//	req.Messages[0].CacheControl = llm.EphemeralCache()
+//	req.Thinking = &llm.ThinkingConfig{BudgetTokens: 2048}
+//
+//	for chunk := range stream {
+//	    if chunk.Type == llm.ChunkTypeThinking {
+//	        fmt.Print(chunk.Thinking)
+//	    }
+//	}
+//
+// 工具调用轮次中需要把 resp.Thinking 放回助手消息的 Thinking 字段。
+// Usage 的 CacheReadTokens、CacheCreationTokens 包含在 InputTokens 中，
+// ReasoningTokens 包含在 OutputTokens 中。
+//
+// 向量化:
+//
+//	embedder, _ := openai.NewEmbedder(openai.WithAPIKey(key)) // 或 local.NewEmbedder()
+//	embedder = middleware.RetryEmbedder(embedder)
+//	vectors, err := embedder.Embed(ctx, []string{"文本一", "文本二"})
+//	score := llm.CosineSimilarity(vectors[0], vectors[1])
+//
+// 输入超过单次请求上限时自动分批，错误与聊天接口一样使用 LLMError。
+// 测试中可以使用 mock.NewEmbedder 得到确定性的向量。
 package llm
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Embedder 文本向量化接口
+//
+// 用于检索式记忆、语义搜索等场景，所有向量化提供商都需要实现此接口。
+type Embedder interface {
+	// Embed 批量计算文本向量，返回的向量与 texts 一一对应
+	// 超出提供商单次请求上限的输入由实现自动分批
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Dimensions 返回向量维度，未知时 (尚未请求过的自定义模型) 返回 0
+	Dimensions() int
+
+	// ModelName 返回向量模型名称
+	ModelName() string
+}
+
+// EmbedText 计算单条文本的向量
+func EmbedText(ctx context.Context, e Embedder, text string) ([]float32, error) {
+	vectors, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatches 按 batchSize 分批调用 fn，按输入顺序合并结果
+// fn 返回的向量数量与批次大小不一致时返回服务端错误
+func EmbedBatches(ctx context.Context, texts []string, batchSize int, fn func(ctx context.Context, batch []string) ([][]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if batchSize <= 0 {
+		batchSize = len(texts)
+	}
+
+	result := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		vectors, err := fn(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != end-start {
+			return nil, ErrServerError(fmt.Sprintf("返回的向量数量不匹配: 期望 %d，实际 %d", end-start, len(vectors)))
+		}
+		result = append(result, vectors...)
+	}
+	return result, nil
+}
+
+// CosineSimilarity 计算两个向量的余弦相似度，维度不同或存在零向量时返回 0
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// embeddingDimensions 常用向量模型的默认维度
+var embeddingDimensions = map[string]int{
+	// OpenAI
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+
+	// Ollama
+	"nomic-embed-text":       768,
+	"mxbai-embed-large":      1024,
+	"all-minilm":             384,
+	"snowflake-arctic-embed": 1024,
+	"bge-m3":                 1024,
+}
+
+// EmbeddingDimensionsFor 返回向量模型的默认维度，未知模型返回 0
+// 忽略 "provider/" 前缀和 ":tag" 后缀 (如 "nomic-embed-text:latest")
+func EmbeddingDimensionsFor(model string) int {
+	name := model
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name, _, _ = strings.Cut(name, ":")
+	return embeddingDimensions[name]
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestEmbedBatches(t *testing.T) {
+	var batches [][]string
+	embed := func(_ context.Context, batch []string) ([][]float32, error) {
+		batches = append(batches, batch)
+		vectors := make([][]float32, len(batch))
+		for i, text := range batch {
+			vectors[i] = []float32{float32(len(text))}
+		}
+		return vectors, nil
+	}
+
+	vectors, err := EmbedBatches(context.Background(), []string{"a", "bb", "ccc", "dddd", "eeeee"}, 2, embed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batches) != 3 || len(batches[2]) != 1 {
+		t.Errorf("unexpected batches: %v", batches)
+	}
+	for i, v := range vectors {
+		if v[0] != float32(i+1) {
+			t.Errorf("vector %d out of order: %v", i, v)
+		}
+	}
+
+	// 返回数量不一致时报错
+	_, err = EmbedBatches(context.Background(), []string{"a", "b"}, 0, func(context.Context, []string) ([][]float32, error) {
+		return [][]float32{{1}}, nil
+	})
+	if llmErr, ok := AsLLMError(err); !ok || llmErr.Type != ErrorTypeServerError {
+		t.Errorf("expected server error, got %v", err)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 0}, []float32{1, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 2}, 0},
+		{[]float32{1, 1}, []float32{-1, -1}, -1},
+		{[]float32{1, 0}, []float32{1, 0, 0}, 0},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := CosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("CosineSimilarity(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if n := EmbeddingDimensionsFor("openai/text-embedding-3-large"); n != 3072 {
+		t.Errorf("unexpected dimensions: %d", n)
+	}
+	if n := EmbeddingDimensionsFor("nomic-embed-text:latest"); n != 768 {
+		t.Errorf("unexpected dimensions: %d", n)
+	}
+}
@@ -1,6 +1,12 @@
 package llm
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
 
 // ErrorType 错误类型
 type ErrorType string
@@ -92,6 +98,62 @@ func (e *LLMError) IsRetryable() bool {
 	}
 }
 
+// AsLLMError 从错误链中取出 LLMError
+func AsLLMError(err error) (*LLMError, bool) {
+	var llmErr *LLMError
+	if errors.As(err, &llmErr) {
+		return llmErr, true
+	}
+	return nil, false
+}
+
+// IsRetryable 检查错误链中是否包含可重试的 LLMError
+func IsRetryable(err error) bool {
+	llmErr, ok := AsLLMError(err)
+	return ok && llmErr.IsRetryable()
+}
+
+// ParseRetryAfter 解析 Retry-After 响应头 (秒数或 HTTP 日期)，无法解析时返回 0
+func ParseRetryAfter(value string) int {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return seconds
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return int((d + time.Second - 1) / time.Second)
+		}
+	}
+	return 0
+}
+
+// ErrorFromStatus 根据 HTTP 状态码创建错误
+// 用于响应体不是提供商错误格式的情况 (如网关返回的 HTML 错误页)
+func ErrorFromStatus(statusCode int, message string) *LLMError {
+	var typ ErrorType
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		typ = ErrorTypeAuthentication
+	case statusCode == http.StatusForbidden:
+		typ = ErrorTypePermission
+	case statusCode == http.StatusNotFound:
+		typ = ErrorTypeNotFound
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		typ = ErrorTypeTimeout
+	case statusCode == http.StatusTooManyRequests:
+		typ = ErrorTypeRateLimit
+	case statusCode == http.StatusServiceUnavailable || statusCode == 529:
+		typ = ErrorTypeOverloaded
+	case statusCode >= 500:
+		typ = ErrorTypeServerError
+	default:
+		typ = ErrorTypeInvalidRequest
+	}
+	return NewLLMError(typ, message).WithStatusCode(statusCode)
+}
+
 // 错误构造函数
 
 // ErrInvalidRequest 请求无效错误
@@ -0,0 +1,347 @@
+// Package gemini 提供 Google Gemini API 客户端
+//
+// 使用 generateContent / streamGenerateContent 接口，
+// 支持工具调用 (functionDeclarations)、图像输入和 SSE 流式响应。
+package gemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// Config Gemini 客户端配置
+type Config struct {
+	APIKey     string
+	BaseURL    string
+	Model      string
+	MaxTokens  int
+	Timeout    time.Duration
+	HTTPClient *http.Client
+}
+
+// Option 配置选项函数
+type Option func(*Config)
+
+// WithAPIKey 设置 API Key
+func WithAPIKey(key string) Option {
+	return func(c *Config) {
+		c.APIKey = key
+	}
+}
+
+// WithBaseURL 设置 API 地址
+func WithBaseURL(url string) Option {
+	return func(c *Config) {
+		c.BaseURL = url
+	}
+}
+
+// WithModel 设置模型
+func WithModel(model string) Option {
+	return func(c *Config) {
+		c.Model = model
+	}
+}
+
+// WithMaxTokens 设置最大 token
+func WithMaxTokens(tokens int) Option {
+	return func(c *Config) {
+		c.MaxTokens = tokens
+	}
+}
+
+// WithTimeout 设置超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.Timeout = timeout
+	}
+}
+
+// WithHTTPClient 设置 HTTP 客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) {
+		c.HTTPClient = client
+	}
+}
+
+// Client Gemini 客户端
+type Client struct {
+	config *Config
+	client *http.Client
+}
+
+// NewClient 创建 Gemini 客户端
+func NewClient(opts ...Option) (*Client, error) {
+	config := &Config{
+		BaseURL:   DefaultBaseURL,
+		Model:     DefaultModel,
+		MaxTokens: DefaultMaxTokens,
+		Timeout:   DefaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("API Key 不能为空")
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{
+			Timeout: config.Timeout,
+		}
+	}
+
+	return &Client{
+		config: config,
+		client: client,
+	}, nil
+}
+
+// ModelName 返回模型名称
+func (c *Client) ModelName() string {
+	return c.config.Model
+}
+
+// ProviderName 返回提供商名称
+func (c *Client) ProviderName() string {
+	return "gemini"
+}
+
+// ContextWindow 返回模型的上下文窗口大小
+func (c *Client) ContextWindow() int {
+	return llm.ContextWindowFor(c.config.Model)
+}
+
+// SupportsStructuredOutput 支持 responseSchema 结构化输出
+func (c *Client) SupportsStructuredOutput() bool {
+	return true
+}
+
+// Complete 发送非流式请求
+func (c *Client) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	c.fillDefaults(req)
+	req.Stream = false
+
+	resp, err := c.send(ctx, req, ":generateContent")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	// 解析响应
+	var gemResp ChatResponse
+	if err := json.Unmarshal(respBody, &gemResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	result := convertResponse(&gemResp)
+	if result.Model == "" {
+		result.Model = req.Model
+	}
+	return result, nil
+}
+
+// Stream 发送流式请求
+func (c *Client) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	c.fillDefaults(req)
+	req.Stream = true
+
+	resp, err := c.send(ctx, req, ":streamGenerateContent?alt=sse")
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建输出通道
+	ch := make(chan *llm.StreamChunk, 100)
+
+	// 启动 goroutine 处理 SSE 流
+	go c.processStream(resp, req.Model, ch)
+
+	return ch, nil
+}
+
+// fillDefaults 填充默认值
+func (c *Client) fillDefaults(req *llm.ChatRequest) {
+	if req.Model == "" {
+		req.Model = c.config.Model
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = c.config.MaxTokens
+	}
+}
+
+// send 发送请求，状态码不是 200 时返回 LLMError
+func (c *Client) send(ctx context.Context, req *llm.ChatRequest, method string) (*http.Response, error) {
+	body, err := json.Marshal(convertRequest(req))
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	model := strings.TrimPrefix(req.Model, "models/")
+	endpoint := c.config.BaseURL + "/models/" + model + method
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", c.config.APIKey)
+	if req.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, parseError(resp, respBody)
+	}
+	return resp, nil
+}
+
+// processStream 处理 SSE 流
+// 每个事件是一个完整的 ChatResponse，文本为增量，函数调用每次完整返回
+func (c *Client) processStream(resp *http.Response, model string, ch chan<- *llm.StreamChunk) {
+	defer close(ch)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	finalResp := &llm.ChatResponse{
+		Model: model,
+		Role:  llm.RoleAssistant,
+	}
+	var content strings.Builder
+	var finishReason string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+
+		// 流中的错误事件
+		var errResp ErrorResponse
+		if err := json.Unmarshal([]byte(data), &errResp); err == nil && errResp.Error != nil {
+			ch <- llm.NewErrorChunk(convertError(errResp.Error, errResp.Error.Code))
+			return
+		}
+
+		var gemResp ChatResponse
+		if err := json.Unmarshal([]byte(data), &gemResp); err != nil {
+			ch <- llm.NewErrorChunk(fmt.Errorf("解析流数据失败: %w", err))
+			return
+		}
+
+		if gemResp.ResponseID != "" {
+			finalResp.ID = gemResp.ResponseID
+		}
+		if gemResp.ModelVersion != "" {
+			finalResp.Model = gemResp.ModelVersion
+		}
+		if gemResp.UsageMetadata != nil {
+			finalResp.Usage = convertUsage(gemResp.UsageMetadata)
+		}
+		if len(gemResp.Candidates) == 0 {
+			continue
+		}
+
+		candidate := gemResp.Candidates[0]
+		text, toolCalls := convertParts(candidate.Content.Parts)
+		if text != "" {
+			content.WriteString(text)
+			ch <- llm.NewContentChunk(text)
+		}
+		if len(toolCalls) > 0 {
+			finalResp.ToolCalls = append(finalResp.ToolCalls, toolCalls...)
+			ch <- llm.NewToolUseChunk(toolCalls)
+		}
+		if candidate.FinishReason != "" {
+			finishReason = candidate.FinishReason
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		ch <- llm.NewErrorChunk(fmt.Errorf("读取流失败: %w", err))
+		return
+	}
+
+	// Gemini 在流结束时才给出完整的用量统计，在流关闭后发送完成块
+	finalResp.Content = content.String()
+	finalResp.StopReason = convertFinishReason(finishReason, len(finalResp.ToolCalls) > 0)
+	ch <- llm.NewDoneChunk(finalResp)
+}
+
+// parseError 解析错误响应，带上 Retry-After 响应头
+func parseError(resp *http.Response, body []byte) *llm.LLMError {
+	var llmErr *llm.LLMError
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		llmErr = convertError(errResp.Error, resp.StatusCode)
+	} else {
+		llmErr = llm.ErrorFromStatus(resp.StatusCode, fmt.Sprintf("请求失败: %s (状态码: %d)", string(body), resp.StatusCode)).
+			WithProvider("gemini")
+	}
+	return llmErr.WithRetryAfter(llm.ParseRetryAfter(resp.Header.Get("Retry-After")))
+}
+
+// convertError 转换错误 (按 Google API 的 status 字段)
+func convertError(err *ErrorDetail, statusCode int) *llm.LLMError {
+	var typ llm.ErrorType
+	switch err.Status {
+	case "INVALID_ARGUMENT", "FAILED_PRECONDITION", "OUT_OF_RANGE":
+		typ = llm.ErrorTypeInvalidRequest
+		switch {
+		case strings.Contains(err.Message, "API key not valid"):
+			typ = llm.ErrorTypeAuthentication
+		case strings.Contains(err.Message, "token count") || strings.Contains(err.Message, "exceeds the maximum number of tokens"):
+			typ = llm.ErrorTypeContextLength
+		}
+	case "UNAUTHENTICATED":
+		typ = llm.ErrorTypeAuthentication
+	case "PERMISSION_DENIED":
+		typ = llm.ErrorTypePermission
+	case "NOT_FOUND":
+		typ = llm.ErrorTypeNotFound
+	case "RESOURCE_EXHAUSTED":
+		typ = llm.ErrorTypeRateLimit
+	case "UNAVAILABLE":
+		typ = llm.ErrorTypeOverloaded
+	case "DEADLINE_EXCEEDED":
+		typ = llm.ErrorTypeTimeout
+	case "INTERNAL", "UNKNOWN":
+		typ = llm.ErrorTypeServerError
+	default:
+		return llm.ErrorFromStatus(statusCode, err.Message).WithCode(err.Status).WithProvider("gemini")
+	}
+
+	return llm.NewLLMError(typ, err.Message).
+		WithCode(err.Status).
+		WithStatusCode(statusCode).
+		WithProvider("gemini")
+}
@@ -0,0 +1,280 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/cassette"
+)
+
+const testModel = DefaultModel
+
+// newCassetteClient 使用 testdata/cassettes 中录制的交互创建客户端
+// 重新录制: LLM_CASSETTE_MODE=record GEMINI_API_KEY=... go test ./pkg/llm/gemini
+func newCassetteClient(t *testing.T, name string) (*Client, *cassette.Recorder) {
+	t.Helper()
+	rec, err := cassette.New(filepath.Join("testdata", "cassettes", name+".json"))
+	if err != nil {
+		t.Fatalf("加载录制文件失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Save(); err != nil {
+			t.Errorf("保存录制文件失败: %v", err)
+		}
+	})
+
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		apiKey = "test-key"
+	}
+	client, err := NewClient(WithAPIKey(apiKey), WithModel(testModel), WithHTTPClient(rec.Client()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client, rec
+}
+
+// sentRequest 返回录制器收到的第 i 个请求及其请求体
+func sentRequest(t *testing.T, rec *cassette.Recorder, i int) (*cassette.Request, ChatRequest) {
+	t.Helper()
+	requests := rec.Requests()
+	if len(requests) <= i {
+		t.Fatalf("expected at least %d requests, got %d", i+1, len(requests))
+	}
+	var body ChatRequest
+	if err := json.Unmarshal(requests[i].Body, &body); err != nil {
+		t.Fatalf("invalid request body: %s", requests[i].Body)
+	}
+	return requests[i], body
+}
+
+func weatherTool() *llm.Tool {
+	return llm.NewTool("get_weather", "查询天气", map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"city": map[string]interface{}{"type": "string"},
+		},
+	})
+}
+
+func TestNewClient_RequiresAPIKey(t *testing.T) {
+	if _, err := NewClient(); err == nil {
+		t.Error("expected error without API key")
+	}
+}
+
+func TestComplete_Text(t *testing.T) {
+	client, rec := newCassetteClient(t, "complete_text")
+
+	req := &llm.ChatRequest{
+		System: "你是助手",
+		Messages: []*llm.Message{
+			{Role: llm.RoleSystem, Content: llm.Text("简洁回答")},
+			{Role: llm.RoleUser, Content: llm.NewContentList(llm.Text("你好"), llm.ImageFromBase64("aW1n", "image/png"))},
+		},
+		Stop: []string{"END"},
+	}
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sent, body := sentRequest(t, rec, 0)
+	if sent.URL != DefaultBaseURL+"/models/"+testModel+":generateContent" || sent.Headers["X-Goog-Api-Key"] != cassette.Redacted {
+		t.Errorf("unexpected request: %s %v", sent.URL, sent.Headers)
+	}
+	if body.SystemInstruction == nil || body.SystemInstruction.Parts[0].Text != "你是助手\n\n简洁回答" {
+		t.Errorf("unexpected system instruction: %+v", body.SystemInstruction)
+	}
+	if len(body.Contents) != 1 || len(body.Contents[0].Parts) != 2 || body.Contents[0].Parts[1].InlineData.Data != "aW1n" {
+		t.Errorf("unexpected contents: %+v", body.Contents)
+	}
+	if cfg := body.GenerationConfig; cfg.MaxOutputTokens != DefaultMaxTokens || cfg.StopSequences[0] != "END" {
+		t.Errorf("unexpected generation config: %+v", cfg)
+	}
+
+	if resp.Content != "你好！有什么可以帮你的？" {
+		t.Errorf("expected thought parts to be skipped, got %q", resp.Content)
+	}
+	if resp.ID != "resp-text-1" || resp.StopReason != llm.StopReasonEndTurn {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.Usage.InputTokens != 8 || resp.Usage.OutputTokens != 14 || resp.Usage.TotalTokens != 22 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestComplete_ToolCall(t *testing.T) {
+	client, rec := newCassetteClient(t, "complete_tool_call")
+
+	req := &llm.ChatRequest{
+		Tools: []*llm.Tool{weatherTool()},
+		Messages: []*llm.Message{
+			{Role: llm.RoleUser, Content: llm.Text("北京和上海天气")},
+			{Role: llm.RoleAssistant, ToolCalls: []*llm.ToolCall{
+				llm.NewToolCall("call_1", "get_weather", `{"city":"上海"}`),
+				llm.NewToolCall("call_2", "get_weather", `{"city":"广州"}`),
+			}},
+			{Role: llm.RoleTool, ToolCallID: "call_1", Name: "get_weather", Content: llm.Text("晴")},
+			{Role: llm.RoleTool, ToolCallID: "call_2", Content: llm.Text(`{"weather":"雨"}`)},
+		},
+	}
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 工具定义去掉不支持的字段
+	_, body := sentRequest(t, rec, 0)
+	decl := body.Tools[0].FunctionDeclarations[0]
+	if _, ok := decl.Parameters["additionalProperties"]; ok || decl.Name != "get_weather" {
+		t.Errorf("unexpected function declaration: %+v", decl)
+	}
+
+	// 工具调用和结果
+	contents := body.Contents
+	if len(contents) != 3 {
+		t.Fatalf("expected tool responses merged into one turn, got %d contents", len(contents))
+	}
+	if contents[1].Role != RoleModel || string(contents[1].Parts[0].FunctionCall.Args) != `{"city":"上海"}` {
+		t.Errorf("unexpected model turn: %+v", contents[1])
+	}
+	responses := contents[2].Parts
+	if len(responses) != 2 || string(responses[0].FunctionResponse.Response) != `{"result":"晴"}` {
+		t.Errorf("unexpected function responses: %+v", responses)
+	}
+	if responses[1].FunctionResponse.Name != "get_weather" || string(responses[1].FunctionResponse.Response) != `{"weather":"雨"}` {
+		t.Errorf("expected name resolved from tool call ID, got %+v", responses[1].FunctionResponse)
+	}
+
+	if resp.StopReason != llm.StopReasonToolUse || len(resp.ToolCalls) != 1 {
+		t.Fatalf("expected tool call, got %+v", resp)
+	}
+	tc := resp.ToolCalls[0]
+	if tc.ID == "" || tc.Function.Name != "get_weather" || tc.Function.Arguments != `{"city":"北京"}` {
+		t.Errorf("unexpected tool call: %+v %+v", tc, tc.Function)
+	}
+}
+
+func TestStream_Text(t *testing.T) {
+	client, rec := newCassetteClient(t, "stream_text")
+
+	ch, err := client.Stream(context.Background(), &llm.ChatRequest{
+		Messages: []*llm.Message{{Role: llm.RoleUser, Content: llm.Text("hi")}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent, _ := sentRequest(t, rec, 0); sent.URL != DefaultBaseURL+"/models/"+testModel+":streamGenerateContent?alt=sse" {
+		t.Errorf("unexpected request: %s", sent.URL)
+	}
+
+	var content string
+	var done *llm.ChatResponse
+	for chunk := range ch {
+		if chunk.IsError() {
+			t.Fatalf("unexpected error chunk: %v", chunk.Error)
+		}
+		content += chunk.Content
+		if chunk.IsDone() {
+			done = chunk.Response
+		}
+	}
+	if content != "你好，世界" || done == nil {
+		t.Fatalf("unexpected stream: %q %+v", content, done)
+	}
+	if done.ID != "resp-stream-1" || done.StopReason != llm.StopReasonMaxTokens || done.Usage.TotalTokens != 12 {
+		t.Errorf("unexpected done response: %+v %+v", done, done.Usage)
+	}
+}
+
+func TestStream_ToolCall(t *testing.T) {
+	client, _ := newCassetteClient(t, "stream_tool_call")
+
+	ch, err := client.Stream(context.Background(), &llm.ChatRequest{
+		Tools:    []*llm.Tool{weatherTool()},
+		Messages: []*llm.Message{{Role: llm.RoleUser, Content: llm.Text("上海天气")}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var toolCalls []*llm.ToolCall
+	var done *llm.ChatResponse
+	for chunk := range ch {
+		if chunk.Type == llm.ChunkTypeToolUse {
+			toolCalls = append(toolCalls, chunk.ToolCalls...)
+		}
+		if chunk.IsDone() {
+			done = chunk.Response
+		}
+	}
+	if len(toolCalls) != 1 || toolCalls[0].ID != "fc-1" {
+		t.Fatalf("unexpected tool calls: %+v", toolCalls)
+	}
+	if done.Content != "我来查询。" || done.StopReason != llm.StopReasonToolUse || len(done.ToolCalls) != 1 {
+		t.Errorf("unexpected done response: %+v", done)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		cassette   string
+		status     int
+		typ        llm.ErrorType
+		retryAfter int
+	}{
+		{"error_rate_limit", http.StatusTooManyRequests, llm.ErrorTypeRateLimit, 30},
+		{"error_api_key", http.StatusBadRequest, llm.ErrorTypeAuthentication, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cassette, func(t *testing.T) {
+			client, _ := newCassetteClient(t, tt.cassette)
+
+			_, err := client.Complete(context.Background(), &llm.ChatRequest{
+				Messages: []*llm.Message{{Role: llm.RoleUser, Content: llm.Text("hi")}},
+			})
+			llmErr, ok := llm.AsLLMError(err)
+			if !ok {
+				t.Fatalf("expected LLMError, got %v", err)
+			}
+			if llmErr.Type != tt.typ || llmErr.StatusCode != tt.status || llmErr.RetryAfter != tt.retryAfter || llmErr.Provider != "gemini" {
+				t.Errorf("unexpected error: %+v", llmErr)
+			}
+		})
+	}
+}
+
+func TestConvertRequest_StructuredOutput(t *testing.T) {
+	type report struct {
+		City    string  `json:"city"`
+		Warning *string `json:"warning"`
+	}
+	req := convertRequest(&llm.ChatRequest{
+		ToolChoice:     llm.ToolChoiceFunction("get_weather"),
+		ResponseFormat: &llm.ResponseFormat{Name: "report", Schema: llm.SchemaFor[report]()},
+	})
+
+	config := req.ToolConfig.FunctionCallingConfig
+	if config.Mode != "ANY" || len(config.AllowedFunctionNames) != 1 || config.AllowedFunctionNames[0] != "get_weather" {
+		t.Errorf("unexpected function calling config: %+v", config)
+	}
+
+	gen := req.GenerationConfig
+	if gen.ResponseMimeType != "application/json" {
+		t.Errorf("expected application/json, got %q", gen.ResponseMimeType)
+	}
+	if _, ok := gen.ResponseSchema["additionalProperties"]; ok {
+		t.Error("additionalProperties should be removed")
+	}
+	warning := gen.ResponseSchema["properties"].(map[string]interface{})["warning"].(map[string]interface{})
+	if warning["type"] != "string" || warning["nullable"] != true {
+		t.Errorf("expected nullable string, got %v", warning)
+	}
+}
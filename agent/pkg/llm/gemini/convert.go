@@ -0,0 +1,348 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// callSeq 生成工具调用 ID 的序号 (旧版本模型不返回调用 ID)
+var callSeq atomic.Int64
+
+// unsupportedSchemaKeys Gemini 的 OpenAPI 子集不接受的 JSON Schema 字段
+var unsupportedSchemaKeys = map[string]bool{
+	"$schema":              true,
+	"$id":                  true,
+	"additionalProperties": true,
+}
+
+// convertRequest 将统一请求格式转换为 Gemini 格式
+func convertRequest(req *llm.ChatRequest) *ChatRequest {
+	gemReq := &ChatRequest{
+		GenerationConfig: &GenerationConfig{
+			MaxOutputTokens: req.MaxTokens,
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			StopSequences:   req.Stop,
+		},
+	}
+
+	// 系统提示词: 顶层 System 和系统消息合并为 systemInstruction
+	var system []string
+	if req.System != "" {
+		system = append(system, req.System)
+	}
+	for _, msg := range req.Messages {
+		if msg.Role == llm.RoleSystem {
+			system = append(system, llm.TextString(msg.Content))
+		}
+	}
+	if len(system) > 0 {
+		gemReq.SystemInstruction = &Content{
+			Parts: []Part{{Text: strings.Join(system, "\n\n")}},
+		}
+	}
+
+	gemReq.Contents = convertMessages(req.Messages)
+
+	// 转换工具
+	if len(req.Tools) > 0 {
+		gemReq.Tools = []Tool{{FunctionDeclarations: convertTools(req.Tools)}}
+	}
+	if req.ToolChoice != nil {
+		gemReq.ToolConfig = convertToolChoice(req.ToolChoice)
+	}
+
+	// 转换结构化输出格式
+	if req.ResponseFormat != nil {
+		gemReq.GenerationConfig.ResponseMimeType = "application/json"
+		gemReq.GenerationConfig.ResponseSchema, _ = sanitizeSchema(req.ResponseFormat.Schema).(map[string]interface{})
+	}
+
+	return gemReq
+}
+
+// convertToolChoice 转换工具选择策略
+func convertToolChoice(choice *llm.ToolChoice) *ToolConfig {
+	config := &FunctionCallingConfig{}
+	switch choice.Type {
+	case llm.ToolChoiceTypeNone:
+		config.Mode = "NONE"
+	case llm.ToolChoiceTypeRequired:
+		config.Mode = "ANY"
+	case llm.ToolChoiceTypeTool:
+		config.Mode = "ANY"
+		config.AllowedFunctionNames = []string{choice.Name}
+	default:
+		config.Mode = "AUTO"
+	}
+	return &ToolConfig{FunctionCallingConfig: config}
+}
+
+// convertMessages 转换消息列表
+// 连续的工具响应消息合并为同一轮 user 内容
+func convertMessages(messages []*llm.Message) []Content {
+	result := make([]Content, 0, len(messages))
+	toolNames := make(map[string]string) // 工具调用 ID -> 工具名称
+	var media []Part                     // 工具结果中的图像和文件
+
+	for i, msg := range messages {
+		switch msg.Role {
+		case llm.RoleSystem:
+			continue
+
+		case llm.RoleTool:
+			name := msg.Name
+			if name == "" {
+				name = toolNames[msg.ToolCallID]
+			}
+			// 函数响应只能是 JSON，工具结果中的图像和文件放在同一轮所有函数响应之后
+			text, items := llm.SplitMedia(msg.Content)
+			for _, item := range items {
+				media = append(media, convertContent(item)...)
+			}
+			part := Part{FunctionResponse: &FunctionResponse{
+				Name:     name,
+				Response: toolResponse(text),
+			}}
+			if n := len(result); n > 0 && result[n-1].Role == RoleUser && isFunctionResponses(result[n-1].Parts) {
+				result[n-1].Parts = append(result[n-1].Parts, part)
+			} else {
+				result = append(result, Content{Role: RoleUser, Parts: []Part{part}})
+			}
+			if len(media) > 0 && (i == len(messages)-1 || messages[i+1].Role != llm.RoleTool) {
+				last := &result[len(result)-1]
+				last.Parts = append(last.Parts, media...)
+				media = nil
+			}
+
+		case llm.RoleAssistant:
+			content := Content{Role: RoleModel, Parts: convertContent(msg.Content)}
+			for _, tc := range msg.ToolCalls {
+				if tc.Function == nil {
+					continue
+				}
+				toolNames[tc.ID] = tc.Function.Name
+				content.Parts = append(content.Parts, Part{FunctionCall: &FunctionCall{
+					Name: tc.Function.Name,
+					Args: argumentsJSON(tc.Function.Arguments),
+				}})
+			}
+			result = append(result, content)
+
+		default:
+			result = append(result, Content{Role: RoleUser, Parts: convertContent(msg.Content)})
+		}
+	}
+
+	return result
+}
+
+// convertContent 转换消息内容为 Part 列表
+func convertContent(content llm.Content) []Part {
+	switch v := content.(type) {
+	case nil:
+		return nil
+	case *llm.TextContent:
+		if v.Text == "" {
+			return nil
+		}
+		return []Part{{Text: v.Text}}
+	case *llm.ImageContent:
+		if v.Source.Type == "url" {
+			return []Part{{FileData: &FileData{MimeType: v.Source.MediaType, FileURI: v.Source.URL}}}
+		}
+		return []Part{{InlineData: &Blob{MimeType: v.Source.MediaType, Data: v.Source.Data}}}
+	case *llm.DocumentContent:
+		switch v.Source.Type {
+		case "base64":
+			return []Part{{InlineData: &Blob{MimeType: v.Source.MediaType, Data: v.Source.Data}}}
+		case "url":
+			return []Part{{FileData: &FileData{MimeType: v.Source.MediaType, FileURI: v.Source.URL}}}
+		}
+		return []Part{{Text: llm.PlainText(v)}}
+	case *llm.AudioContent:
+		return []Part{{InlineData: &Blob{MimeType: v.MediaType(), Data: v.Data}}}
+	case *llm.ContentList:
+		var parts []Part
+		for _, item := range v.Items {
+			parts = append(parts, convertContent(item)...)
+		}
+		return parts
+	default:
+		if text := llm.TextString(content); text != "" {
+			return []Part{{Text: text}}
+		}
+		return nil
+	}
+}
+
+// isFunctionResponses 检查 Part 列表是否全部为函数响应
+func isFunctionResponses(parts []Part) bool {
+	for _, p := range parts {
+		if p.FunctionResponse == nil {
+			return false
+		}
+	}
+	return len(parts) > 0
+}
+
+// toolResponse 把工具结果转换为 JSON 对象，非对象结果包装为 {"result": ...}
+func toolResponse(text string) json.RawMessage {
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	data, _ := json.Marshal(map[string]string{"result": text})
+	return data
+}
+
+// argumentsJSON 把参数字符串转换为 JSON 对象
+func argumentsJSON(arguments string) json.RawMessage {
+	if arguments == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
+
+// convertTools 转换工具定义列表
+func convertTools(tools []*llm.Tool) []FunctionDeclaration {
+	result := make([]FunctionDeclaration, 0, len(tools))
+	for _, t := range tools {
+		if t.Function == nil {
+			continue
+		}
+		decl := FunctionDeclaration{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+		}
+		if len(t.Function.Parameters) > 0 {
+			decl.Parameters, _ = sanitizeSchema(t.Function.Parameters).(map[string]interface{})
+		}
+		result = append(result, decl)
+	}
+	return result
+}
+
+// sanitizeSchema 去掉 Gemini 不支持的 JSON Schema 字段 (返回副本)
+// 类型数组 ["string", "null"] 转换为 type + nullable
+func sanitizeSchema(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			if unsupportedSchemaKeys[k] {
+				continue
+			}
+			out[k] = sanitizeSchema(item)
+		}
+		if types, ok := val["type"].([]interface{}); ok {
+			for _, t := range types {
+				if t == "null" {
+					out["nullable"] = true
+				} else {
+					out["type"] = t
+				}
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = sanitizeSchema(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// convertResponse 将 Gemini 响应转换为统一格式
+func convertResponse(resp *ChatResponse) *llm.ChatResponse {
+	if resp == nil {
+		return nil
+	}
+
+	result := &llm.ChatResponse{
+		ID:    resp.ResponseID,
+		Model: resp.ModelVersion,
+		Role:  llm.RoleAssistant,
+		Usage: convertUsage(resp.UsageMetadata),
+	}
+
+	if len(resp.Candidates) == 0 {
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			result.StopReason = llm.StopReason(strings.ToLower(resp.PromptFeedback.BlockReason))
+		}
+		return result
+	}
+
+	candidate := resp.Candidates[0]
+	result.Content, result.ToolCalls = convertParts(candidate.Content.Parts)
+	result.StopReason = convertFinishReason(candidate.FinishReason, len(result.ToolCalls) > 0)
+	return result
+}
+
+// convertParts 提取文本和函数调用，跳过思考过程
+func convertParts(parts []Part) (string, []*llm.ToolCall) {
+	var content strings.Builder
+	var toolCalls []*llm.ToolCall
+	for _, p := range parts {
+		switch {
+		case p.Thought:
+			continue
+		case p.FunctionCall != nil:
+			toolCalls = append(toolCalls, convertFunctionCall(p.FunctionCall))
+		case p.Text != "":
+			content.WriteString(p.Text)
+		}
+	}
+	return content.String(), toolCalls
+}
+
+// convertFunctionCall 转换函数调用，模型未返回 ID 时生成一个
+func convertFunctionCall(fc *FunctionCall) *llm.ToolCall {
+	id := fc.ID
+	if id == "" {
+		id = fmt.Sprintf("call_%d", callSeq.Add(1))
+	}
+	arguments := string(fc.Args)
+	if arguments == "" || arguments == "null" {
+		arguments = "{}"
+	}
+	return llm.NewToolCall(id, fc.Name, arguments)
+}
+
+// convertUsage 转换使用统计
+// 思考 token 按输出计费，计入 OutputTokens
+func convertUsage(usage *UsageMetadata) *llm.Usage {
+	if usage == nil {
+		return nil
+	}
+	return &llm.Usage{
+		InputTokens:     usage.PromptTokenCount,
+		OutputTokens:    usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+		TotalTokens:     usage.TotalTokenCount,
+		CacheReadTokens: usage.CachedContentTokenCount,
+		ReasoningTokens: usage.ThoughtsTokenCount,
+	}
+}
+
+// convertFinishReason 转换完成原因
+func convertFinishReason(reason string, hasToolCalls bool) llm.StopReason {
+	if hasToolCalls {
+		return llm.StopReasonToolUse
+	}
+	switch reason {
+	case "STOP", "":
+		return llm.StopReasonEndTurn
+	case "MAX_TOKENS":
+		return llm.StopReasonMaxTokens
+	default:
+		// SAFETY、RECITATION、MALFORMED_FUNCTION_CALL 等
+		return llm.StopReason(strings.ToLower(reason))
+	}
+}
@@ -0,0 +1,153 @@
+package gemini
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Gemini API 请求和响应类型定义
+
+const (
+	// DefaultBaseURL Gemini API 默认地址
+	DefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	// DefaultModel 默认模型
+	DefaultModel = "gemini-2.5-flash"
+	// DefaultMaxTokens 默认最大 token
+	DefaultMaxTokens = 4096
+	// DefaultTimeout 默认超时
+	DefaultTimeout = 60 * time.Second
+)
+
+// Gemini 角色
+const (
+	RoleUser  = "user"
+	RoleModel = "model"
+)
+
+// ChatRequest generateContent 请求
+type ChatRequest struct {
+	Contents          []Content         `json:"contents"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
+	Tools             []Tool            `json:"tools,omitempty"`
+	ToolConfig        *ToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// ToolConfig 工具调用配置
+type ToolConfig struct {
+	FunctionCallingConfig *FunctionCallingConfig `json:"functionCallingConfig"`
+}
+
+// FunctionCallingConfig 函数调用模式
+type FunctionCallingConfig struct {
+	Mode                 string   `json:"mode"` // "AUTO", "ANY", "NONE"
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+// Content 一轮对话内容
+type Content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []Part `json:"parts"`
+}
+
+// Part 内容部分，每个 Part 只设置一个字段
+type Part struct {
+	Text             string            `json:"text,omitempty"`
+	Thought          bool              `json:"thought,omitempty"`
+	InlineData       *Blob             `json:"inlineData,omitempty"`
+	FileData         *FileData         `json:"fileData,omitempty"`
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// Blob 内联数据 (base64)
+type Blob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// FileData URI 引用的文件
+type FileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// FunctionCall 模型发起的函数调用
+type FunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// FunctionResponse 函数调用结果
+type FunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Tool 工具定义
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations"`
+}
+
+// FunctionDeclaration 函数声明
+type FunctionDeclaration struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// GenerationConfig 生成参数
+type GenerationConfig struct {
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	Temperature     float64  `json:"temperature,omitempty"`
+	TopP            float64  `json:"topP,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+
+	// 结构化输出
+	ResponseMimeType string                 `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]interface{} `json:"responseSchema,omitempty"`
+}
+
+// ChatResponse generateContent 响应 (流式时每个 SSE 事件一个)
+type ChatResponse struct {
+	Candidates     []Candidate     `json:"candidates"`
+	UsageMetadata  *UsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string          `json:"modelVersion,omitempty"`
+	ResponseID     string          `json:"responseId,omitempty"`
+	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+}
+
+// Candidate 候选回复
+type Candidate struct {
+	Content      Content `json:"content"`
+	FinishReason string  `json:"finishReason,omitempty"`
+	Index        int     `json:"index"`
+}
+
+// PromptFeedback 提示词被拦截时的反馈
+type PromptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"`
+}
+
+// UsageMetadata token 使用统计
+type UsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+}
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	Error *ErrorDetail `json:"error"`
+}
+
+// ErrorDetail 错误详情 (Google API 标准错误)
+type ErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
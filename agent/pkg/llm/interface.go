@@ -41,6 +41,9 @@ type ModelInfo interface {
 
 	// ProviderName 返回提供商名称
 	ProviderName() string
+
+	// ContextWindow 返回模型的上下文窗口大小 (token)，未知时返回 0
+	ContextWindow() int
 }
 
 // Client 完整的 LLM 客户端接口
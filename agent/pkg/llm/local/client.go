@@ -0,0 +1,418 @@
+// Package local 提供本地模型客户端
+//
+// 支持两种后端:
+//   - Ollama: /api/chat (原生工具调用、图像输入)
+//   - llama.cpp server: /apply-template + /completion (原生接口没有工具调用，使用提示词工具调用)
+//
+// 不支持原生工具调用的模型可以使用 ToolModePrompt，
+// 把工具定义和 prompt.GenerateToolCallPrompt 的格式说明写入系统提示词，
+// 再从回复中解析 JSON 格式的工具调用。
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+const (
+	// DefaultOllamaURL Ollama 默认地址
+	DefaultOllamaURL = "http://localhost:11434"
+	// DefaultLlamaCppURL llama.cpp server 默认地址
+	DefaultLlamaCppURL = "http://localhost:8080"
+	// DefaultModel 默认模型 (Ollama)
+	DefaultModel = "llama3.1"
+	// DefaultMaxTokens 默认最大 token
+	DefaultMaxTokens = 4096
+	// DefaultTimeout 默认超时 (本地模型首次加载较慢)
+	DefaultTimeout = 5 * time.Minute
+	// DefaultOllamaContext Ollama 未设置 num_ctx 时的上下文窗口
+	DefaultOllamaContext = 4096
+)
+
+// Backend 本地模型服务类型
+type Backend string
+
+const (
+	BackendOllama   Backend = "ollama"   // Ollama
+	BackendLlamaCpp Backend = "llamacpp" // llama.cpp server
+)
+
+// ToolMode 工具调用方式
+type ToolMode string
+
+const (
+	// ToolModeAuto 优先使用原生工具调用，模型不支持时自动改用提示词
+	ToolModeAuto ToolMode = "auto"
+	// ToolModeNative 只使用原生工具调用
+	ToolModeNative ToolMode = "native"
+	// ToolModePrompt 只使用提示词工具调用
+	ToolModePrompt ToolMode = "prompt"
+)
+
+// Config 本地模型客户端配置
+type Config struct {
+	Backend    Backend
+	APIKey     string // 可选，经过鉴权代理时使用
+	BaseURL    string
+	Model      string
+	MaxTokens  int
+	ToolMode   ToolMode
+	KeepAlive  string // Ollama 模型保持加载的时间，如 "5m"、"-1"
+	NumCtx     int    // 上下文窗口大小，Ollama 作为 num_ctx 发送
+	Timeout    time.Duration
+	HTTPClient *http.Client
+
+	// 向量化 (Embedder) 使用
+	Dimensions int // 输出维度，只有支持截断维度的模型有效
+	BatchSize  int // 单次请求的最大输入条数
+}
+
+// Option 配置选项函数
+type Option func(*Config)
+
+// WithBackend 设置后端类型
+func WithBackend(backend Backend) Option {
+	return func(c *Config) {
+		c.Backend = backend
+	}
+}
+
+// WithAPIKey 设置 API Key
+func WithAPIKey(key string) Option {
+	return func(c *Config) {
+		c.APIKey = key
+	}
+}
+
+// WithBaseURL 设置服务地址
+func WithBaseURL(url string) Option {
+	return func(c *Config) {
+		c.BaseURL = url
+	}
+}
+
+// WithModel 设置模型
+func WithModel(model string) Option {
+	return func(c *Config) {
+		c.Model = model
+	}
+}
+
+// WithMaxTokens 设置最大 token
+func WithMaxTokens(tokens int) Option {
+	return func(c *Config) {
+		c.MaxTokens = tokens
+	}
+}
+
+// WithToolMode 设置工具调用方式
+func WithToolMode(mode ToolMode) Option {
+	return func(c *Config) {
+		c.ToolMode = mode
+	}
+}
+
+// WithContextWindow 设置上下文窗口大小
+// Ollama 按此值加载模型 (num_ctx)；llama.cpp 的窗口由服务端 -c 参数决定，这里只用于预算
+func WithContextWindow(tokens int) Option {
+	return func(c *Config) {
+		c.NumCtx = tokens
+	}
+}
+
+// WithKeepAlive 设置 Ollama 模型保持加载的时间
+func WithKeepAlive(keepAlive string) Option {
+	return func(c *Config) {
+		c.KeepAlive = keepAlive
+	}
+}
+
+// WithTimeout 设置超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.Timeout = timeout
+	}
+}
+
+// WithHTTPClient 设置 HTTP 客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) {
+		c.HTTPClient = client
+	}
+}
+
+// WithDimensions 设置向量维度 (Embedder 使用)
+func WithDimensions(dimensions int) Option {
+	return func(c *Config) {
+		c.Dimensions = dimensions
+	}
+}
+
+// WithBatchSize 设置单次向量化请求的最大输入条数 (Embedder 使用)
+func WithBatchSize(size int) Option {
+	return func(c *Config) {
+		c.BatchSize = size
+	}
+}
+
+// Client 本地模型客户端
+type Client struct {
+	config *Config
+	client *http.Client
+
+	// noNativeTools 记录不支持原生工具调用的模型 (ToolModeAuto 下使用)
+	noNativeTools sync.Map
+}
+
+// NewClient 创建本地模型客户端
+func NewClient(opts ...Option) (*Client, error) {
+	config := &Config{
+		Backend:   BackendOllama,
+		Model:     DefaultModel,
+		MaxTokens: DefaultMaxTokens,
+		ToolMode:  ToolModeAuto,
+		Timeout:   DefaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	switch config.Backend {
+	case BackendOllama:
+		if config.BaseURL == "" {
+			config.BaseURL = DefaultOllamaURL
+		}
+	case BackendLlamaCpp:
+		if config.BaseURL == "" {
+			config.BaseURL = DefaultLlamaCppURL
+		}
+		// llama.cpp 原生接口没有工具调用
+		config.ToolMode = ToolModePrompt
+	default:
+		return nil, fmt.Errorf("不支持的后端: %s", config.Backend)
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{
+			Timeout: config.Timeout,
+		}
+	}
+
+	return &Client{
+		config: config,
+		client: client,
+	}, nil
+}
+
+// ModelName 返回模型名称
+func (c *Client) ModelName() string {
+	return c.config.Model
+}
+
+// ProviderName 返回提供商名称
+func (c *Client) ProviderName() string {
+	return string(c.config.Backend)
+}
+
+// ContextWindow 返回上下文窗口大小
+// 未设置时 Ollama 使用默认 num_ctx，超出部分会被服务端静默截断，因此不超过 DefaultOllamaContext
+func (c *Client) ContextWindow() int {
+	if c.config.NumCtx > 0 {
+		return c.config.NumCtx
+	}
+	window := llm.ContextWindowFor(c.config.Model)
+	if c.config.Backend == BackendOllama && (window == 0 || window > DefaultOllamaContext) {
+		return DefaultOllamaContext
+	}
+	return window
+}
+
+// SupportsStructuredOutput 两种后端都支持按 JSON Schema 约束输出
+func (c *Client) SupportsStructuredOutput() bool {
+	return true
+}
+
+// Complete 发送非流式请求
+func (c *Client) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	c.fillDefaults(req)
+	req.Stream = false
+
+	if c.usePromptTools(req) {
+		return c.completePromptTools(ctx, req)
+	}
+
+	resp, err := c.complete(ctx, req)
+	if err != nil && c.fallbackToPrompt(req, err) {
+		return c.completePromptTools(ctx, req)
+	}
+	return resp, err
+}
+
+// Stream 发送流式请求
+func (c *Client) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	c.fillDefaults(req)
+	req.Stream = true
+
+	if c.usePromptTools(req) {
+		return c.streamPromptTools(ctx, req)
+	}
+
+	ch, err := c.stream(ctx, req)
+	if err != nil && c.fallbackToPrompt(req, err) {
+		return c.streamPromptTools(ctx, req)
+	}
+	return ch, err
+}
+
+// fillDefaults 填充默认值
+func (c *Client) fillDefaults(req *llm.ChatRequest) {
+	if req.Model == "" {
+		req.Model = c.config.Model
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = c.config.MaxTokens
+	}
+}
+
+// complete 按后端发送非流式请求
+func (c *Client) complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	if c.config.Backend == BackendLlamaCpp {
+		return c.completeLlamaCpp(ctx, req)
+	}
+	return c.completeOllama(ctx, req)
+}
+
+// stream 按后端发送流式请求
+func (c *Client) stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	if c.config.Backend == BackendLlamaCpp {
+		return c.streamLlamaCpp(ctx, req)
+	}
+	return c.streamOllama(ctx, req)
+}
+
+// completePromptTools 使用提示词工具调用发送非流式请求
+func (c *Client) completePromptTools(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	resp, err := c.complete(ctx, withPromptTools(req))
+	if err != nil {
+		return nil, err
+	}
+	return parsePromptToolCalls(resp), nil
+}
+
+// streamPromptTools 使用提示词工具调用发送流式请求
+func (c *Client) streamPromptTools(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	ch, err := c.stream(ctx, withPromptTools(req))
+	if err != nil {
+		return nil, err
+	}
+	return promptToolStream(ch), nil
+}
+
+// usePromptTools 判断请求是否使用提示词工具调用
+func (c *Client) usePromptTools(req *llm.ChatRequest) bool {
+	if len(req.Tools) == 0 && !hasToolHistory(req.Messages) {
+		return false
+	}
+	switch c.config.ToolMode {
+	case ToolModePrompt:
+		return true
+	case ToolModeAuto:
+		_, unsupported := c.noNativeTools.Load(req.Model)
+		return unsupported
+	default:
+		return false
+	}
+}
+
+// fallbackToPrompt 检查是否因模型不支持原生工具调用而改用提示词
+// 命中后记住该模型，后续请求直接使用提示词
+func (c *Client) fallbackToPrompt(req *llm.ChatRequest, err error) bool {
+	if c.config.ToolMode != ToolModeAuto || len(req.Tools) == 0 {
+		return false
+	}
+	llmErr, ok := llm.AsLLMError(err)
+	if !ok || !strings.Contains(llmErr.Message, "does not support tools") {
+		return false
+	}
+	c.noNativeTools.Store(req.Model, struct{}{})
+	return true
+}
+
+// post 发送 JSON 请求，状态码不是 200 时返回 LLMError
+func (c *Client) post(ctx context.Context, path string, payload interface{}) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, c.parseError(resp, respBody)
+	}
+	return resp, nil
+}
+
+// postJSON 发送 JSON 请求并解析 JSON 响应
+func (c *Client) postJSON(ctx context.Context, path string, payload, out interface{}) error {
+	resp, err := c.post(ctx, path, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+// parseError 解析错误响应
+// Ollama 返回 {"error": "..."}，llama.cpp 返回 {"error": {"code", "message", "type"}}
+func (c *Client) parseError(resp *http.Response, body []byte) *llm.LLMError {
+	var errResp ErrorResponse
+	message := ""
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		message = errResp.Message()
+	}
+	if message == "" {
+		message = fmt.Sprintf("请求失败: %s (状态码: %d)", string(body), resp.StatusCode)
+	}
+
+	llmErr := llm.ErrorFromStatus(resp.StatusCode, message).WithProvider(c.ProviderName())
+	if strings.Contains(message, "context") && (strings.Contains(message, "exceed") || strings.Contains(message, "too long")) {
+		llmErr.Type = llm.ErrorTypeContextLength
+	}
+	return llmErr.WithRetryAfter(llm.ParseRetryAfter(resp.Header.Get("Retry-After")))
+}
@@ -0,0 +1,513 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/cassette"
+)
+
+// newRecorder 加载 testdata/cassettes 中录制的交互
+// 重新录制需要在默认地址运行 Ollama 或 llama.cpp server: LLM_CASSETTE_MODE=record go test ./pkg/llm/local
+func newRecorder(t *testing.T, name string) *cassette.Recorder {
+	t.Helper()
+	rec, err := cassette.New(filepath.Join("testdata", "cassettes", name+".json"))
+	if err != nil {
+		t.Fatalf("加载录制文件失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Save(); err != nil {
+			t.Errorf("保存录制文件失败: %v", err)
+		}
+	})
+	return rec
+}
+
+// newCassetteClient 使用录制的交互创建客户端
+func newCassetteClient(t *testing.T, name string, opts ...Option) (*Client, *cassette.Recorder) {
+	t.Helper()
+	rec := newRecorder(t, name)
+	client, err := NewClient(append([]Option{WithHTTPClient(rec.Client())}, opts...)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client, rec
+}
+
+// sentBodies 返回发往 path 的请求体，按发送顺序排列
+func sentBodies(t *testing.T, rec *cassette.Recorder, path string) []map[string]interface{} {
+	t.Helper()
+	var bodies []map[string]interface{}
+	for _, req := range rec.Requests() {
+		u, err := url.Parse(req.URL)
+		if err != nil || u.Path != path {
+			continue
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			t.Fatalf("invalid request body: %s", req.Body)
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies
+}
+
+func weatherTool() *llm.Tool {
+	return llm.NewTool("get_weather", "查询天气", map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"city": map[string]interface{}{"type": "string", "description": "城市"},
+		},
+		"required": []interface{}{"city"},
+	})
+}
+
+func userRequest(text string) *llm.ChatRequest {
+	return &llm.ChatRequest{
+		Messages: []*llm.Message{{Role: llm.RoleUser, Content: llm.Text(text)}},
+	}
+}
+
+func TestNewClient_Defaults(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.config.BaseURL != DefaultOllamaURL || client.ProviderName() != "ollama" {
+		t.Errorf("unexpected defaults: %s %s", client.config.BaseURL, client.ProviderName())
+	}
+
+	client, _ = NewClient(WithBackend(BackendLlamaCpp))
+	if client.config.BaseURL != DefaultLlamaCppURL || client.config.ToolMode != ToolModePrompt {
+		t.Errorf("unexpected llama.cpp defaults: %s %s", client.config.BaseURL, client.config.ToolMode)
+	}
+
+	if _, err := NewClient(WithBackend("unknown")); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestOllama_Complete(t *testing.T) {
+	client, rec := newCassetteClient(t, "ollama_complete", WithKeepAlive("10m"))
+
+	req := userRequest("hi")
+	req.System = "be nice"
+	req.Temperature = 0.5
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "你好" || resp.StopReason != llm.StopReasonEndTurn {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 3 || resp.Usage.TotalTokens != 15 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+
+	body := sentBodies(t, rec, "/api/chat")[0]
+	messages := body["messages"].([]interface{})
+	if len(messages) != 2 || messages[0].(map[string]interface{})["role"] != "system" {
+		t.Errorf("expected system message first, got %v", messages)
+	}
+	options := body["options"].(map[string]interface{})
+	if options["temperature"] != 0.5 || options["num_predict"] != float64(DefaultMaxTokens) {
+		t.Errorf("unexpected options: %v", options)
+	}
+	if body["keep_alive"] != "10m" || body["stream"] != false {
+		t.Errorf("unexpected request: %v", body)
+	}
+}
+
+func TestOllama_NativeToolCalls(t *testing.T) {
+	client, rec := newCassetteClient(t, "ollama_native_tool_calls")
+
+	req := userRequest("北京天气")
+	req.Tools = []*llm.Tool{weatherTool()}
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StopReason != llm.StopReasonToolUse || len(resp.ToolCalls) != 1 {
+		t.Fatalf("expected tool call, got %+v", resp)
+	}
+	tc := resp.ToolCalls[0]
+	if tc.ID == "" || tc.Function.Name != "get_weather" || tc.Function.Arguments != `{"city":"北京"}` {
+		t.Errorf("unexpected tool call: %+v %+v", tc, tc.Function)
+	}
+	if tools := sentBodies(t, rec, "/api/chat")[0]["tools"].([]interface{}); len(tools) != 1 {
+		t.Errorf("expected native tools in request, got %v", tools)
+	}
+}
+
+func TestOllama_ToolHistoryAndImages(t *testing.T) {
+	client, rec := newCassetteClient(t, "ollama_tool_history")
+
+	req := &llm.ChatRequest{
+		Messages: []*llm.Message{
+			{Role: llm.RoleUser, Content: llm.NewContentList(llm.Text("看图"), llm.ImageFromBase64("aW1n", "image/png"))},
+			{Role: llm.RoleAssistant, ToolCalls: []*llm.ToolCall{llm.NewToolCall("call_1", "get_weather", `{"city":"北京"}`)}},
+			{Role: llm.RoleTool, ToolCallID: "call_1", Name: "get_weather", Content: llm.Text("晴")},
+		},
+	}
+	if _, err := client.Complete(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages := sentBodies(t, rec, "/api/chat")[0]["messages"].([]interface{})
+	user := messages[0].(map[string]interface{})
+	if user["content"] != "看图" || user["images"].([]interface{})[0] != "aW1n" {
+		t.Errorf("unexpected image message: %v", user)
+	}
+	assistant := messages[1].(map[string]interface{})
+	call := assistant["tool_calls"].([]interface{})[0].(map[string]interface{})["function"].(map[string]interface{})
+	if call["arguments"].(map[string]interface{})["city"] != "北京" {
+		t.Errorf("expected arguments as JSON object, got %v", call)
+	}
+	if tool := messages[2].(map[string]interface{}); tool["role"] != "tool" || tool["tool_name"] != "get_weather" {
+		t.Errorf("unexpected tool message: %v", tool)
+	}
+}
+
+func TestOllama_URLImageRejected(t *testing.T) {
+	client, _ := NewClient()
+	req := &llm.ChatRequest{
+		Messages: []*llm.Message{{Role: llm.RoleUser, Content: llm.ImageFromURL("https://example.com/a.png")}},
+	}
+	_, err := client.Complete(context.Background(), req)
+	if llmErr, ok := llm.AsLLMError(err); !ok || llmErr.Type != llm.ErrorTypeInvalidRequest {
+		t.Errorf("expected invalid request error, got %v", err)
+	}
+}
+
+func TestOllama_Stream(t *testing.T) {
+	client, rec := newCassetteClient(t, "ollama_stream")
+
+	ch, err := client.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var content string
+	var done *llm.ChatResponse
+	for chunk := range ch {
+		if chunk.IsError() {
+			t.Fatalf("unexpected error chunk: %v", chunk.Error)
+		}
+		content += chunk.Content
+		if chunk.IsDone() {
+			done = chunk.Response
+		}
+	}
+	if content != "Hello" || done == nil || done.Content != "Hello" || done.StopReason != llm.StopReasonMaxTokens {
+		t.Errorf("unexpected stream result: %q %+v", content, done)
+	}
+	if sentBodies(t, rec, "/api/chat")[0]["stream"] != true {
+		t.Error("expected stream=true")
+	}
+}
+
+func TestOllama_Error(t *testing.T) {
+	client, _ := newCassetteClient(t, "ollama_error", WithModel("nope"))
+
+	_, err := client.Complete(context.Background(), userRequest("hi"))
+	llmErr, ok := llm.AsLLMError(err)
+	if !ok || llmErr.Type != llm.ErrorTypeNotFound || llmErr.Provider != "ollama" || !strings.Contains(llmErr.Message, "not found") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestOllama_AutoFallbackToPromptTools(t *testing.T) {
+	client, rec := newCassetteClient(t, "ollama_prompt_tool_fallback", WithModel("gemma:2b"))
+
+	req := userRequest("上海天气")
+	req.Tools = []*llm.Tool{weatherTool()}
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StopReason != llm.StopReasonToolUse || len(resp.ToolCalls) != 1 || resp.Content != "我来查一下。" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.ToolCalls[0].Function.Arguments != `{"city":"上海"}` {
+		t.Errorf("unexpected arguments: %s", resp.ToolCalls[0].Function.Arguments)
+	}
+
+	// 第二次请求直接使用提示词，不再尝试原生工具
+	if _, err := client.Complete(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bodies := sentBodies(t, rec, "/api/chat")
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(bodies))
+	}
+	system := bodies[2]["messages"].([]interface{})[0].(map[string]interface{})
+	if system["role"] != "system" || !strings.Contains(system["content"].(string), "get_weather") {
+		t.Errorf("expected tool prompt in system message, got %v", system)
+	}
+}
+
+func TestOllama_NativeModeDoesNotFallback(t *testing.T) {
+	client, rec := newCassetteClient(t, "ollama_native_mode", WithToolMode(ToolModeNative))
+
+	req := userRequest("hi")
+	req.Tools = []*llm.Tool{weatherTool()}
+	if _, err := client.Complete(context.Background(), req); err == nil {
+		t.Error("expected error")
+	}
+	if len(sentBodies(t, rec, "/api/chat")) != 1 {
+		t.Errorf("expected no retry in native mode")
+	}
+}
+
+func TestLlamaCpp_Complete(t *testing.T) {
+	client, rec := newCassetteClient(t, "llamacpp_complete", WithBackend(BackendLlamaCpp))
+
+	req := userRequest("hi")
+	req.System = "sys"
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "Hello!" || resp.Model != "qwen2.5" || resp.Usage.TotalTokens != 12 {
+		t.Errorf("unexpected response: %+v %+v", resp, resp.Usage)
+	}
+
+	tmpl := sentBodies(t, rec, "/apply-template")[0]["messages"].([]interface{})
+	if len(tmpl) != 2 || tmpl[0].(map[string]interface{})["content"] != "sys" {
+		t.Errorf("unexpected template messages: %v", tmpl)
+	}
+	comp := sentBodies(t, rec, "/completion")[0]
+	if comp["prompt"] != "<|user|>hi<|assistant|>" || comp["n_predict"] != float64(DefaultMaxTokens) {
+		t.Errorf("unexpected completion request: %v", comp)
+	}
+}
+
+func TestLlamaCpp_Images(t *testing.T) {
+	client, rec := newCassetteClient(t, "llamacpp_images", WithBackend(BackendLlamaCpp))
+
+	req := &llm.ChatRequest{
+		Messages: []*llm.Message{{Role: llm.RoleUser, Content: llm.NewContentList(llm.Text("这是什么? "), llm.ImageFromBase64("aW1n", "image/png"))}},
+	}
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StopReason != llm.StopReasonMaxTokens {
+		t.Errorf("unexpected stop reason: %s", resp.StopReason)
+	}
+
+	prompt := sentBodies(t, rec, "/completion")[0]["prompt"].(map[string]interface{})
+	if prompt["prompt_string"] != "这是什么? "+mediaMarker {
+		t.Errorf("expected media marker in prompt, got %v", prompt["prompt_string"])
+	}
+	if data := prompt["multimodal_data"].([]interface{}); len(data) != 1 || data[0] != "aW1n" {
+		t.Errorf("unexpected multimodal data: %v", data)
+	}
+}
+
+func TestLlamaCpp_StreamPromptTools(t *testing.T) {
+	client, rec := newCassetteClient(t, "llamacpp_stream_prompt_tools", WithBackend(BackendLlamaCpp))
+
+	req := &llm.ChatRequest{
+		Tools: []*llm.Tool{weatherTool()},
+		Messages: []*llm.Message{
+			{Role: llm.RoleUser, Content: llm.Text("广州天气")},
+			{Role: llm.RoleAssistant, ToolCalls: []*llm.ToolCall{llm.NewToolCall("call_1", "get_weather", `{"city":"北京"}`)}},
+			{Role: llm.RoleTool, ToolCallID: "call_1", Name: "get_weather", Content: llm.Text("晴")},
+		},
+	}
+	ch, err := client.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var content string
+	var toolCalls []*llm.ToolCall
+	var done *llm.ChatResponse
+	for chunk := range ch {
+		switch {
+		case chunk.IsError():
+			t.Fatalf("unexpected error chunk: %v", chunk.Error)
+		case chunk.Type == llm.ChunkTypeToolUse:
+			toolCalls = append(toolCalls, chunk.ToolCalls...)
+		case chunk.IsDone():
+			done = chunk.Response
+		default:
+			content += chunk.Content
+		}
+	}
+	if content != "好的，查询中 " {
+		t.Errorf("expected JSON to be held back, got %q", content)
+	}
+	if len(toolCalls) != 1 || toolCalls[0].Function.Name != "get_weather" {
+		t.Errorf("unexpected tool calls: %v", toolCalls)
+	}
+	if done == nil || done.StopReason != llm.StopReasonToolUse || done.Content != "好的，查询中" {
+		t.Errorf("unexpected done response: %+v", done)
+	}
+
+	// 历史中的工具调用和结果改写为文本
+	tmpl := sentBodies(t, rec, "/apply-template")[0]["messages"].([]interface{})
+	if len(tmpl) != 4 {
+		t.Fatalf("unexpected template messages: %v", tmpl)
+	}
+	if sys := tmpl[0].(map[string]interface{})["content"].(string); !strings.Contains(sys, "tool_calls") {
+		t.Errorf("expected tool call format in system prompt, got %q", sys)
+	}
+	if assistant := tmpl[2].(map[string]interface{})["content"].(string); !strings.Contains(assistant, `"name":"get_weather"`) {
+		t.Errorf("expected tool call as text, got %q", assistant)
+	}
+	if tool := tmpl[3].(map[string]interface{}); tool["role"] != "user" || !strings.Contains(tool["content"].(string), "晴") {
+		t.Errorf("expected tool result as user message, got %v", tool)
+	}
+}
+
+func TestExtractToolCalls(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		content string
+		calls   int
+	}{
+		{"无工具调用", "普通回复 {not json}", "普通回复 {not json}", 0},
+		{"代码块", "前言\n```json\n{\"tool_calls\":[{\"name\":\"a\",\"arguments\":{}}]}\n```\n后记", "前言\n\n后记", 1},
+		{"裸 JSON", `{"tool_calls":[{"name":"a"},{"name":"b","arguments":{"x":1}}]}`, "", 2},
+		{"其他代码块", "```go\nfmt.Println()\n```", "```go\nfmt.Println()\n```", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, calls := extractToolCalls(tt.text)
+			if content != tt.content || len(calls) != tt.calls {
+				t.Errorf("extractToolCalls() = %q, %d calls; want %q, %d", content, len(calls), tt.content, tt.calls)
+			}
+			for _, c := range calls {
+				if !json.Valid([]byte(c.Function.Arguments)) {
+					t.Errorf("invalid arguments: %s", c.Function.Arguments)
+				}
+			}
+		})
+	}
+}
+
+func TestPromptToolStream_PlainText(t *testing.T) {
+	in := make(chan *llm.StreamChunk, 4)
+	in <- llm.NewContentChunk("没有")
+	in <- llm.NewContentChunk("工具调用的中文回复")
+	in <- llm.NewDoneChunk(&llm.ChatResponse{Content: "没有工具调用的中文回复"})
+	close(in)
+
+	var content string
+	for chunk := range promptToolStream(in) {
+		content += chunk.Content
+		if chunk.IsDone() && chunk.Response.StopReason == llm.StopReasonToolUse {
+			t.Error("unexpected tool use")
+		}
+	}
+	if content != "没有工具调用的中文回复" {
+		t.Errorf("unexpected content: %q", content)
+	}
+}
+
+func TestStructuredOutput(t *testing.T) {
+	type report struct {
+		City string `json:"city"`
+	}
+
+	for _, backend := range []Backend{BackendOllama, BackendLlamaCpp} {
+		t.Run(string(backend), func(t *testing.T) {
+			client, rec := newCassetteClient(t, "structured_"+string(backend), WithBackend(backend))
+
+			got, err := llm.CompleteStructured[report](context.Background(), client, userRequest("北京"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.City != "北京" {
+				t.Errorf("unexpected result: %+v", got)
+			}
+
+			var schema interface{}
+			if backend == BackendOllama {
+				schema = sentBodies(t, rec, "/api/chat")[0]["format"]
+			} else {
+				schema = sentBodies(t, rec, "/completion")[0]["json_schema"]
+			}
+			if props, _ := schema.(map[string]interface{})["properties"].(map[string]interface{}); props["city"] == nil {
+				t.Errorf("expected schema in request, got %v", schema)
+			}
+		})
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	// Ollama 未设置 num_ctx 时使用服务端默认窗口
+	if client, _ := NewClient(); client.ContextWindow() != DefaultOllamaContext {
+		t.Errorf("expected %d, got %d", DefaultOllamaContext, client.ContextWindow())
+	}
+	if client, _ := NewClient(WithBackend(BackendLlamaCpp), WithModel("qwen2.5-7b")); client.ContextWindow() != 32768 {
+		t.Errorf("expected model window 32768, got %d", client.ContextWindow())
+	}
+
+	client, rec := newCassetteClient(t, "context_window", WithContextWindow(16384))
+	if n := client.ContextWindow(); n != 16384 {
+		t.Errorf("expected 16384, got %d", n)
+	}
+	if _, err := client.Complete(context.Background(), userRequest("hi")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	options := sentBodies(t, rec, "/api/chat")[0]["options"].(map[string]interface{})
+	if options["num_ctx"] != float64(16384) {
+		t.Errorf("expected num_ctx 16384, got %v", options["num_ctx"])
+	}
+}
+
+func TestOllama_Embed(t *testing.T) {
+	rec := newRecorder(t, "ollama_embed")
+	embedder, err := NewEmbedder(WithHTTPClient(rec.Client()), WithModel("custom-embed"), WithBatchSize(2), WithKeepAlive("5m"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if embedder.ModelName() != "custom-embed" || embedder.Dimensions() != 0 {
+		t.Errorf("unexpected model info: %s/%d", embedder.ModelName(), embedder.Dimensions())
+	}
+
+	vectors, err := embedder.Embed(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vectors) != 3 || vectors[0][0] != 1 || vectors[1][0] != 2 || vectors[2][0] != 3 {
+		t.Errorf("unexpected vectors: %v", vectors)
+	}
+	// 按批次大小拆分请求，未知模型从响应中得到维度
+	if n := len(sentBodies(t, rec, "/api/embed")); n != 2 {
+		t.Errorf("expected 2 batches, got %d", n)
+	}
+	if sentBodies(t, rec, "/api/embed")[0]["keep_alive"] != "5m" {
+		t.Errorf("unexpected request: %v", sentBodies(t, rec, "/api/embed")[0])
+	}
+	if embedder.Dimensions() != 3 {
+		t.Errorf("expected dimensions from response, got %d", embedder.Dimensions())
+	}
+
+	defaults, _ := NewEmbedder()
+	if defaults.ModelName() != DefaultEmbeddingModel || defaults.Dimensions() != 768 {
+		t.Errorf("unexpected defaults: %s/%d", defaults.ModelName(), defaults.Dimensions())
+	}
+	if _, err := NewEmbedder(WithBackend(BackendLlamaCpp)); err == nil {
+		t.Error("expected error for llama.cpp backend")
+	}
+}
+
+func TestOllama_EmbedError(t *testing.T) {
+	rec := newRecorder(t, "ollama_embed_error")
+	embedder, _ := NewEmbedder(WithHTTPClient(rec.Client()), WithModel("nope"))
+
+	_, err := embedder.Embed(context.Background(), []string{"hi"})
+	llmErr, ok := llm.AsLLMError(err)
+	if !ok || llmErr.Type != llm.ErrorTypeNotFound || llmErr.Provider != "ollama" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+const (
+	// DefaultEmbeddingModel 默认向量模型 (Ollama)
+	DefaultEmbeddingModel = "nomic-embed-text"
+	// DefaultBatchSize 默认单次向量化请求的输入条数
+	DefaultBatchSize = 64
+)
+
+// Embedder Ollama /api/embed 向量化客户端
+// llama.cpp server 使用 --embeddings 启动后提供 OpenAI 兼容接口，
+// 可以用 openai.NewEmbedder(openai.WithBaseURL("http://localhost:8080/v1")) 访问
+type Embedder struct {
+	client *Client
+
+	// dimensions 从响应中得到的向量维度
+	dimensions atomic.Int64
+}
+
+// NewEmbedder 创建向量化客户端，与 Client 使用相同的配置选项
+func NewEmbedder(opts ...Option) (*Embedder, error) {
+	opts = append([]Option{WithModel(DefaultEmbeddingModel), WithBatchSize(DefaultBatchSize)}, opts...)
+	client, err := NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if client.config.Backend != BackendOllama {
+		return nil, fmt.Errorf("后端 %s 不支持向量化，请使用 OpenAI 兼容接口", client.config.Backend)
+	}
+	return &Embedder{client: client}, nil
+}
+
+// ModelName 返回向量模型名称
+func (e *Embedder) ModelName() string {
+	return e.client.config.Model
+}
+
+// Dimensions 返回向量维度
+// 依次使用 WithDimensions 的设置、已知模型的默认维度和最近一次响应的维度
+func (e *Embedder) Dimensions() int {
+	if e.client.config.Dimensions > 0 {
+		return e.client.config.Dimensions
+	}
+	if n := llm.EmbeddingDimensionsFor(e.client.config.Model); n > 0 {
+		return n
+	}
+	return int(e.dimensions.Load())
+}
+
+// Embed 批量计算文本向量，超过 BatchSize 时分批请求
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return llm.EmbedBatches(ctx, texts, e.client.config.BatchSize, e.embed)
+}
+
+// embed 发送一批向量化请求
+func (e *Embedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	req := &OllamaEmbedRequest{
+		Model:      e.client.config.Model,
+		Input:      texts,
+		KeepAlive:  e.client.config.KeepAlive,
+		Dimensions: e.client.config.Dimensions,
+	}
+	var resp OllamaEmbedResponse
+	if err := e.client.postJSON(ctx, "/api/embed", req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) > 0 {
+		e.dimensions.Store(int64(len(resp.Embeddings[0])))
+	}
+	return resp.Embeddings, nil
+}
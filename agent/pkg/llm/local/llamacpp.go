@@ -0,0 +1,215 @@
+package local
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// mediaMarker llama.cpp server 默认的多模态占位标记
+const mediaMarker = "<__media__>"
+
+// completeLlamaCpp 调用 llama.cpp /completion (非流式)
+func (c *Client) completeLlamaCpp(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	compReq, err := c.convertCompletionRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var compResp CompletionResponse
+	if err := c.postJSON(ctx, "/completion", compReq, &compResp); err != nil {
+		return nil, err
+	}
+
+	return &llm.ChatResponse{
+		Model:      c.completionModel(&compResp, req),
+		Content:    compResp.Content,
+		Role:       llm.RoleAssistant,
+		Usage:      completionUsage(&compResp),
+		StopReason: convertStopType(compResp.StopType),
+	}, nil
+}
+
+// streamLlamaCpp 调用 llama.cpp /completion (流式，SSE)
+func (c *Client) streamLlamaCpp(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	compReq, err := c.convertCompletionRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, "/completion", compReq)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *llm.StreamChunk, 100)
+	go c.processCompletionStream(resp, req, ch)
+	return ch, nil
+}
+
+// processCompletionStream 处理 llama.cpp SSE 流
+func (c *Client) processCompletionStream(resp *http.Response, req *llm.ChatRequest, ch chan<- *llm.StreamChunk) {
+	defer close(ch)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	var accumulatedContent string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+
+		// 流中的错误事件
+		var errResp ErrorResponse
+		if err := json.Unmarshal([]byte(data), &errResp); err == nil && errResp.Message() != "" {
+			ch <- llm.NewErrorChunk(llm.ErrServerError(errResp.Message()).WithProvider(c.ProviderName()))
+			return
+		}
+
+		var compResp CompletionResponse
+		if err := json.Unmarshal([]byte(data), &compResp); err != nil {
+			ch <- llm.NewErrorChunk(fmt.Errorf("解析流数据失败: %w", err))
+			return
+		}
+
+		if compResp.Content != "" {
+			accumulatedContent += compResp.Content
+			ch <- llm.NewContentChunk(compResp.Content)
+		}
+
+		if compResp.Stop {
+			ch <- llm.NewDoneChunk(&llm.ChatResponse{
+				Model:      c.completionModel(&compResp, req),
+				Content:    accumulatedContent,
+				Role:       llm.RoleAssistant,
+				Usage:      completionUsage(&compResp),
+				StopReason: convertStopType(compResp.StopType),
+			})
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		ch <- llm.NewErrorChunk(fmt.Errorf("读取流失败: %w", err))
+	}
+}
+
+// convertCompletionRequest 套用模型的聊天模板生成 /completion 请求
+func (c *Client) convertCompletionRequest(ctx context.Context, req *llm.ChatRequest) (*CompletionRequest, error) {
+	tmplReq := &TemplateRequest{}
+	if req.System != "" {
+		tmplReq.Messages = append(tmplReq.Messages, TemplateMessage{
+			Role:    string(llm.RoleSystem),
+			Content: req.System,
+		})
+	}
+
+	var images []string
+	for _, msg := range req.Messages {
+		role := msg.Role
+		// 提示词工具调用已把工具结果转换为用户消息，这里兜底处理
+		if role == llm.RoleTool {
+			role = llm.RoleUser
+		}
+		text, msgImages, err := templateContent(msg.Content)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, msgImages...)
+		tmplReq.Messages = append(tmplReq.Messages, TemplateMessage{
+			Role:    string(role),
+			Content: text,
+		})
+	}
+
+	var tmplResp TemplateResponse
+	if err := c.postJSON(ctx, "/apply-template", tmplReq, &tmplResp); err != nil {
+		return nil, err
+	}
+
+	compReq := &CompletionRequest{
+		Prompt:      tmplResp.Prompt,
+		NPredict:    req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+		Stream:      req.Stream,
+		CachePrompt: true,
+	}
+	if req.ResponseFormat != nil {
+		compReq.JSONSchema = req.ResponseFormat.Schema
+	}
+	if len(images) > 0 {
+		compReq.Prompt = MultimodalPrompt{
+			PromptString:   tmplResp.Prompt,
+			MultimodalData: images,
+		}
+	}
+	return compReq, nil
+}
+
+// templateContent 把内容转换为文本，图像替换为媒体标记并按顺序收集 base64 数据
+func templateContent(content llm.Content) (string, []string, error) {
+	switch v := content.(type) {
+	case nil:
+		return "", nil, nil
+	case *llm.ImageContent:
+		images, err := collectImages(v)
+		if err != nil {
+			return "", nil, err
+		}
+		return mediaMarker, images, nil
+	case *llm.ContentList:
+		var sb strings.Builder
+		var images []string
+		for _, item := range v.Items {
+			text, itemImages, err := templateContent(item)
+			if err != nil {
+				return "", nil, err
+			}
+			sb.WriteString(text)
+			images = append(images, itemImages...)
+		}
+		return sb.String(), images, nil
+	default:
+		return llm.PlainText(content), nil, nil
+	}
+}
+
+// completionModel 返回响应中的模型名称，服务端未返回时使用请求的模型
+func (c *Client) completionModel(resp *CompletionResponse, req *llm.ChatRequest) string {
+	if resp.Model != "" {
+		return resp.Model
+	}
+	return req.Model
+}
+
+// completionUsage 转换使用统计
+func completionUsage(resp *CompletionResponse) *llm.Usage {
+	return &llm.Usage{
+		InputTokens:  resp.TokensEvaluated,
+		OutputTokens: resp.TokensPredicted,
+		TotalTokens:  resp.TokensEvaluated + resp.TokensPredicted,
+	}
+}
+
+// convertStopType 转换停止类型
+func convertStopType(stopType string) llm.StopReason {
+	switch stopType {
+	case "limit":
+		return llm.StopReasonMaxTokens
+	case "word":
+		return llm.StopReasonStopSeq
+	default:
+		return llm.StopReasonEndTurn
+	}
+}
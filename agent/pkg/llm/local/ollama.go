@@ -0,0 +1,309 @@
+package local
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// callSeq 生成工具调用 ID 的序号 (Ollama 不返回调用 ID)
+var callSeq atomic.Int64
+
+// newCallID 生成工具调用 ID
+func newCallID() string {
+	return fmt.Sprintf("call_%d", callSeq.Add(1))
+}
+
+// completeOllama 调用 Ollama /api/chat (非流式)
+func (c *Client) completeOllama(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	ollamaReq, err := c.convertOllamaRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var ollamaResp OllamaResponse
+	if err := c.postJSON(ctx, "/api/chat", ollamaReq, &ollamaResp); err != nil {
+		return nil, err
+	}
+	if ollamaResp.Error != "" {
+		return nil, llm.ErrServerError(ollamaResp.Error).WithProvider(c.ProviderName())
+	}
+
+	return convertOllamaResponse(&ollamaResp), nil
+}
+
+// streamOllama 调用 Ollama /api/chat (流式，每行一个 JSON 对象)
+func (c *Client) streamOllama(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	ollamaReq, err := c.convertOllamaRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, "/api/chat", ollamaReq)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *llm.StreamChunk, 100)
+	go c.processOllamaStream(resp, ch)
+	return ch, nil
+}
+
+// processOllamaStream 处理 Ollama 流
+func (c *Client) processOllamaStream(resp *http.Response, ch chan<- *llm.StreamChunk) {
+	defer close(ch)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	var accumulatedContent string
+	var accumulatedToolCalls []*llm.ToolCall
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var ollamaResp OllamaResponse
+		if err := json.Unmarshal(line, &ollamaResp); err != nil {
+			ch <- llm.NewErrorChunk(fmt.Errorf("解析流数据失败: %w", err))
+			return
+		}
+		if ollamaResp.Error != "" {
+			ch <- llm.NewErrorChunk(llm.ErrServerError(ollamaResp.Error).WithProvider(c.ProviderName()))
+			return
+		}
+
+		// 处理内容增量
+		if ollamaResp.Message.Content != "" {
+			accumulatedContent += ollamaResp.Message.Content
+			ch <- llm.NewContentChunk(ollamaResp.Message.Content)
+		}
+
+		// Ollama 每次返回完整的工具调用
+		if len(ollamaResp.Message.ToolCalls) > 0 {
+			toolCalls := convertOllamaToolCalls(ollamaResp.Message.ToolCalls)
+			accumulatedToolCalls = append(accumulatedToolCalls, toolCalls...)
+			ch <- llm.NewToolUseChunk(toolCalls)
+		}
+
+		// 处理完成
+		if ollamaResp.Done {
+			ch <- llm.NewDoneChunk(&llm.ChatResponse{
+				Model:      ollamaResp.Model,
+				Content:    accumulatedContent,
+				Role:       llm.RoleAssistant,
+				ToolCalls:  accumulatedToolCalls,
+				Usage:      ollamaUsage(&ollamaResp),
+				StopReason: convertDoneReason(ollamaResp.DoneReason, len(accumulatedToolCalls) > 0),
+			})
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		ch <- llm.NewErrorChunk(fmt.Errorf("读取流失败: %w", err))
+	}
+}
+
+// convertOllamaRequest 将统一请求格式转换为 Ollama 格式
+func (c *Client) convertOllamaRequest(req *llm.ChatRequest) (*OllamaRequest, error) {
+	ollamaReq := &OllamaRequest{
+		Model:     req.Model,
+		Stream:    req.Stream,
+		KeepAlive: c.config.KeepAlive,
+		Options: &OllamaOptions{
+			NumCtx:      c.config.NumCtx,
+			NumPredict:  req.MaxTokens,
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+			Stop:        req.Stop,
+		},
+	}
+
+	// 结构化输出: Ollama 直接接受 JSON Schema 作为 format
+	if req.ResponseFormat != nil {
+		ollamaReq.Format = req.ResponseFormat.Schema
+	}
+
+	// 顶层系统提示词作为第一条系统消息
+	if req.System != "" {
+		ollamaReq.Messages = append(ollamaReq.Messages, OllamaMessage{
+			Role:    string(llm.RoleSystem),
+			Content: req.System,
+		})
+	}
+
+	for _, msg := range req.Messages {
+		ollamaMsg, err := convertOllamaMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		ollamaReq.Messages = append(ollamaReq.Messages, ollamaMsg)
+	}
+
+	// 转换工具
+	for _, t := range req.Tools {
+		if t.Function == nil {
+			continue
+		}
+		ollamaReq.Tools = append(ollamaReq.Tools, OllamaTool{
+			Type: "function",
+			Function: OllamaFunctionDef{
+				Name:        t.Function.Name,
+				Description: t.Function.Description,
+				Parameters:  t.Function.Parameters,
+			},
+		})
+	}
+
+	return ollamaReq, nil
+}
+
+// convertOllamaMessage 转换单条消息
+func convertOllamaMessage(msg *llm.Message) (OllamaMessage, error) {
+	ollamaMsg := OllamaMessage{
+		Role: string(msg.Role),
+	}
+
+	if msg.Content != nil {
+		ollamaMsg.Content = textWithoutImages(msg.Content)
+		images, err := collectImages(msg.Content)
+		if err != nil {
+			return ollamaMsg, err
+		}
+		ollamaMsg.Images = images
+	}
+
+	// 工具响应消息通过 tool_name 关联工具
+	if msg.Role == llm.RoleTool {
+		ollamaMsg.ToolName = msg.Name
+	}
+
+	// 转换助手的工具调用
+	for _, tc := range msg.ToolCalls {
+		if tc.Function == nil {
+			continue
+		}
+		ollamaMsg.ToolCalls = append(ollamaMsg.ToolCalls, OllamaToolCall{
+			Function: OllamaFunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: argumentsJSON(tc.Function.Arguments),
+			},
+		})
+	}
+
+	return ollamaMsg, nil
+}
+
+// textWithoutImages 返回内容中的文本，图像通过 images 字段单独发送
+// 本地模型不支持的文档和音频替换为文本说明
+func textWithoutImages(content llm.Content) string {
+	switch v := content.(type) {
+	case *llm.ImageContent:
+		return ""
+	case *llm.ContentList:
+		var sb strings.Builder
+		for _, item := range v.Items {
+			sb.WriteString(textWithoutImages(item))
+		}
+		return sb.String()
+	}
+	return llm.PlainText(content)
+}
+
+// collectImages 收集内容中的 base64 图像
+// 本地模型服务无法访问 URL 图像，遇到时返回请求无效错误
+func collectImages(content llm.Content) ([]string, error) {
+	switch v := content.(type) {
+	case *llm.ImageContent:
+		if v.Source.Type != "base64" {
+			return nil, llm.ErrInvalidRequest("本地模型只支持 base64 图像，不支持 URL: " + v.Source.URL)
+		}
+		return []string{v.Source.Data}, nil
+	case *llm.ContentList:
+		var images []string
+		for _, item := range v.Items {
+			itemImages, err := collectImages(item)
+			if err != nil {
+				return nil, err
+			}
+			images = append(images, itemImages...)
+		}
+		return images, nil
+	}
+	return nil, nil
+}
+
+// argumentsJSON 把参数字符串转换为 JSON 对象，无效 JSON 作为字符串传递
+func argumentsJSON(arguments string) json.RawMessage {
+	if arguments == "" {
+		return json.RawMessage("{}")
+	}
+	if json.Valid([]byte(arguments)) {
+		return json.RawMessage(arguments)
+	}
+	raw, _ := json.Marshal(arguments)
+	return raw
+}
+
+// convertOllamaResponse 将 Ollama 响应转换为统一格式
+func convertOllamaResponse(resp *OllamaResponse) *llm.ChatResponse {
+	toolCalls := convertOllamaToolCalls(resp.Message.ToolCalls)
+	return &llm.ChatResponse{
+		Model:      resp.Model,
+		Content:    resp.Message.Content,
+		Role:       llm.RoleAssistant,
+		ToolCalls:  toolCalls,
+		Usage:      ollamaUsage(resp),
+		StopReason: convertDoneReason(resp.DoneReason, len(toolCalls) > 0),
+	}
+}
+
+// convertOllamaToolCalls 转换工具调用并生成调用 ID
+func convertOllamaToolCalls(toolCalls []OllamaToolCall) []*llm.ToolCall {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+	result := make([]*llm.ToolCall, len(toolCalls))
+	for i, tc := range toolCalls {
+		arguments := string(tc.Function.Arguments)
+		if arguments == "" || arguments == "null" {
+			arguments = "{}"
+		}
+		result[i] = llm.NewToolCall(newCallID(), tc.Function.Name, arguments)
+	}
+	return result
+}
+
+// ollamaUsage 转换使用统计
+func ollamaUsage(resp *OllamaResponse) *llm.Usage {
+	return &llm.Usage{
+		InputTokens:  resp.PromptEvalCount,
+		OutputTokens: resp.EvalCount,
+		TotalTokens:  resp.PromptEvalCount + resp.EvalCount,
+	}
+}
+
+// convertDoneReason 转换完成原因
+func convertDoneReason(reason string, hasToolCalls bool) llm.StopReason {
+	if hasToolCalls {
+		return llm.StopReasonToolUse
+	}
+	switch reason {
+	case "", "stop":
+		return llm.StopReasonEndTurn
+	case "length":
+		return llm.StopReasonMaxTokens
+	default:
+		return llm.StopReason(reason)
+	}
+}
@@ -0,0 +1,266 @@
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+	"github.com/wangtengda0310/gobee/agent/pkg/prompt"
+)
+
+// 提示词工具调用
+//
+// 把工具定义和调用格式写入系统提示词，历史中的工具调用和结果改写为普通文本，
+// 再从模型回复里解析 prompt.GenerateToolCallPrompt 约定的 JSON:
+//
+//	{"tool_calls": [{"name": "...", "arguments": {...}}]}
+
+// promptToolCalls 回复中的工具调用 JSON
+type promptToolCalls struct {
+	ToolCalls []promptToolCall `json:"tool_calls"`
+}
+
+// promptToolCall 单个工具调用
+type promptToolCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// 流式输出时遇到这些标记就暂停输出，等整段回复结束后再判断是否为工具调用
+var toolCallMarkers = []string{"```", `{"tool_calls"`}
+
+// hasToolHistory 检查消息历史中是否有工具调用或工具结果
+func hasToolHistory(messages []*llm.Message) bool {
+	for _, msg := range messages {
+		if msg.Role == llm.RoleTool || len(msg.ToolCalls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// withPromptTools 返回改写为提示词工具调用的请求副本
+func withPromptTools(req *llm.ChatRequest) *llm.ChatRequest {
+	clone := *req
+	clone.Tools = nil
+
+	var system []string
+	if req.System != "" {
+		system = append(system, req.System)
+	}
+	if len(req.Tools) > 0 {
+		system = append(system, prompt.GenerateToolPrompt(req.Tools...), prompt.GenerateToolCallPrompt())
+	}
+	clone.System = strings.Join(system, "\n\n")
+
+	clone.Messages = make([]*llm.Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		clone.Messages[i] = rewriteToolMessage(msg)
+	}
+	return &clone
+}
+
+// rewriteToolMessage 把工具调用和工具结果改写为普通文本消息
+func rewriteToolMessage(msg *llm.Message) *llm.Message {
+	switch {
+	case msg.Role == llm.RoleAssistant && len(msg.ToolCalls) > 0:
+		calls := promptToolCalls{}
+		for _, tc := range msg.ToolCalls {
+			if tc.Function == nil {
+				continue
+			}
+			calls.ToolCalls = append(calls.ToolCalls, promptToolCall{
+				Name:      tc.Function.Name,
+				Arguments: argumentsJSON(tc.Function.Arguments),
+			})
+		}
+		data, _ := json.Marshal(calls)
+
+		text := llm.TextString(msg.Content)
+		if text != "" {
+			text += "\n\n"
+		}
+		text += "```json\n" + string(data) + "\n```"
+		return &llm.Message{Role: llm.RoleAssistant, Content: llm.Text(text)}
+
+	case msg.Role == llm.RoleTool:
+		text := fmt.Sprintf("工具 %s 的执行结果:\n%s", msg.Name, llm.PlainText(msg.Content))
+		return &llm.Message{Role: llm.RoleUser, Content: llm.Text(text)}
+
+	default:
+		return msg
+	}
+}
+
+// parsePromptToolCalls 从回复文本中解析工具调用
+// 解析成功时去掉回复中的 JSON 块，StopReason 设为 tool_use
+func parsePromptToolCalls(resp *llm.ChatResponse) *llm.ChatResponse {
+	if resp == nil {
+		return nil
+	}
+	content, toolCalls := extractToolCalls(resp.Content)
+	if len(toolCalls) == 0 {
+		return resp
+	}
+	result := *resp
+	result.Content = content
+	result.ToolCalls = append(result.ToolCalls, toolCalls...)
+	result.StopReason = llm.StopReasonToolUse
+	return &result
+}
+
+// extractToolCalls 提取文本中的工具调用 JSON，返回去掉 JSON 后的文本
+// 先查找代码块，再查找裸 JSON 对象
+func extractToolCalls(text string) (string, []*llm.ToolCall) {
+	// 代码块: ```json ... ``` 或 ``` ... ```
+	for offset := 0; ; {
+		start := strings.Index(text[offset:], "```")
+		if start < 0 {
+			break
+		}
+		start += offset
+		bodyStart := start + 3
+		if nl := strings.IndexByte(text[bodyStart:], '\n'); nl >= 0 {
+			bodyStart += nl + 1
+		}
+		end := strings.Index(text[bodyStart:], "```")
+		if end < 0 {
+			break
+		}
+		end += bodyStart
+		if calls := decodeToolCalls(text[bodyStart:end]); len(calls) > 0 {
+			return strings.TrimSpace(text[:start] + text[end+3:]), calls
+		}
+		offset = end + 3
+	}
+
+	// 裸 JSON 对象
+	for offset := 0; ; {
+		start := strings.IndexByte(text[offset:], '{')
+		if start < 0 {
+			break
+		}
+		start += offset
+		dec := json.NewDecoder(strings.NewReader(text[start:]))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == nil {
+			if calls := decodeToolCalls(string(raw)); len(calls) > 0 {
+				end := start + int(dec.InputOffset())
+				return strings.TrimSpace(text[:start] + text[end:]), calls
+			}
+		}
+		offset = start + 1
+	}
+
+	return text, nil
+}
+
+// decodeToolCalls 解析工具调用 JSON
+func decodeToolCalls(s string) []*llm.ToolCall {
+	var calls promptToolCalls
+	if err := json.Unmarshal([]byte(strings.TrimSpace(s)), &calls); err != nil {
+		return nil
+	}
+	var result []*llm.ToolCall
+	for _, tc := range calls.ToolCalls {
+		if tc.Name == "" {
+			continue
+		}
+		arguments := string(tc.Arguments)
+		if arguments == "" || arguments == "null" {
+			arguments = "{}"
+		}
+		result = append(result, llm.NewToolCall(newCallID(), tc.Name, arguments))
+	}
+	return result
+}
+
+// promptToolStream 在流中解析提示词工具调用
+//
+// 内容照常逐块输出，遇到可能是工具调用 JSON 的标记后暂停输出；
+// 流结束时解析完整回复，是工具调用则发出工具调用块，否则补发暂停的内容。
+func promptToolStream(in <-chan *llm.StreamChunk) <-chan *llm.StreamChunk {
+	out := make(chan *llm.StreamChunk, cap(in))
+	go func() {
+		defer close(out)
+
+		var content strings.Builder
+		emitted := 0
+		holding := false
+
+		flush := func(end int) {
+			if end > emitted {
+				out <- llm.NewContentChunk(content.String()[emitted:end])
+				emitted = end
+			}
+		}
+
+		for chunk := range in {
+			switch {
+			case chunk.Type == llm.ChunkTypeContent:
+				content.WriteString(chunk.Content)
+				if holding {
+					continue
+				}
+				text := content.String()
+				if i := indexMarker(text[emitted:]); i >= 0 {
+					flush(emitted + i)
+					holding = true
+					continue
+				}
+				// 保留可能是标记前缀的结尾部分
+				flush(safeCut(text, len(text)-maxMarkerLen()+1, emitted))
+
+			case chunk.IsDone():
+				resp := parsePromptToolCalls(chunk.Response)
+				if resp != nil && len(resp.ToolCalls) > 0 {
+					out <- llm.NewToolUseChunk(resp.ToolCalls)
+				} else {
+					flush(content.Len())
+				}
+				out <- llm.NewDoneChunk(resp)
+				return
+
+			default:
+				out <- chunk
+			}
+		}
+		flush(content.Len())
+	}()
+	return out
+}
+
+// indexMarker 返回第一个工具调用标记的位置
+func indexMarker(s string) int {
+	first := -1
+	for _, marker := range toolCallMarkers {
+		if i := strings.Index(s, marker); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	return first
+}
+
+// maxMarkerLen 返回最长标记的长度
+func maxMarkerLen() int {
+	n := 0
+	for _, marker := range toolCallMarkers {
+		if len(marker) > n {
+			n = len(marker)
+		}
+	}
+	return n
+}
+
+// safeCut 返回不超过 cut 且不小于 min 的 UTF-8 字符边界
+func safeCut(s string, cut, min int) int {
+	if cut <= min {
+		return min
+	}
+	for cut > min && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return cut
+}
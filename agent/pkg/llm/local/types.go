@@ -0,0 +1,155 @@
+package local
+
+import "encoding/json"
+
+// Ollama /api/chat 请求和响应类型定义
+
+// OllamaRequest Ollama 聊天请求
+type OllamaRequest struct {
+	Model     string          `json:"model"`
+	Messages  []OllamaMessage `json:"messages"`
+	Tools     []OllamaTool    `json:"tools,omitempty"`
+	Stream    bool            `json:"stream"`
+	Options   *OllamaOptions  `json:"options,omitempty"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+	Format    interface{}     `json:"format,omitempty"` // "json" 或 JSON Schema
+}
+
+// OllamaOptions 模型参数
+type OllamaOptions struct {
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature float64  `json:"temperature,omitempty"`
+	TopP        float64  `json:"top_p,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// OllamaMessage Ollama 消息格式
+type OllamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Images    []string         `json:"images,omitempty"` // base64 编码的图像
+	ToolCalls []OllamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+// OllamaTool 工具定义
+type OllamaTool struct {
+	Type     string            `json:"type"`
+	Function OllamaFunctionDef `json:"function"`
+}
+
+// OllamaFunctionDef 函数定义
+type OllamaFunctionDef struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// OllamaToolCall 工具调用 (Ollama 不返回调用 ID，参数是 JSON 对象)
+type OllamaToolCall struct {
+	Function OllamaFunctionCall `json:"function"`
+}
+
+// OllamaFunctionCall 函数调用
+type OllamaFunctionCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// OllamaResponse Ollama 聊天响应 (流式时每行一个)
+type OllamaResponse struct {
+	Model           string        `json:"model"`
+	CreatedAt       string        `json:"created_at"`
+	Message         OllamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason,omitempty"`
+	PromptEvalCount int           `json:"prompt_eval_count,omitempty"`
+	EvalCount       int           `json:"eval_count,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// OllamaEmbedRequest Ollama /api/embed 请求
+type OllamaEmbedRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	KeepAlive  string   `json:"keep_alive,omitempty"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+// OllamaEmbedResponse Ollama /api/embed 响应
+type OllamaEmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+}
+
+// llama.cpp server 原生接口类型定义
+
+// TemplateRequest /apply-template 请求
+type TemplateRequest struct {
+	Messages []TemplateMessage `json:"messages"`
+}
+
+// TemplateMessage 套用聊天模板的消息
+type TemplateMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// TemplateResponse /apply-template 响应
+type TemplateResponse struct {
+	Prompt string `json:"prompt"`
+}
+
+// CompletionRequest /completion 请求
+type CompletionRequest struct {
+	Prompt      interface{} `json:"prompt"` // 字符串，带多模态数据时为 {"prompt_string", "multimodal_data"}
+	NPredict    int         `json:"n_predict,omitempty"`
+	Temperature float64     `json:"temperature,omitempty"`
+	TopP        float64     `json:"top_p,omitempty"`
+	Stop        []string    `json:"stop,omitempty"`
+	Stream      bool        `json:"stream"`
+	CachePrompt bool        `json:"cache_prompt"`
+	JSONSchema  interface{} `json:"json_schema,omitempty"` // 按 JSON Schema 约束输出 (grammar)
+}
+
+// MultimodalPrompt 带图像的提示词，prompt_string 中每个媒体标记对应一项 multimodal_data
+type MultimodalPrompt struct {
+	PromptString   string   `json:"prompt_string"`
+	MultimodalData []string `json:"multimodal_data"`
+}
+
+// CompletionResponse /completion 响应 (流式时每个 SSE 事件一个)
+type CompletionResponse struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	StopType        string `json:"stop_type,omitempty"` // "eos", "limit", "word"
+	StoppingWord    string `json:"stopping_word,omitempty"`
+	Model           string `json:"model,omitempty"`
+	TokensPredicted int    `json:"tokens_predicted,omitempty"`
+	TokensEvaluated int    `json:"tokens_evaluated,omitempty"`
+}
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	Error json.RawMessage `json:"error"`
+}
+
+// Message 返回错误消息，兼容字符串和对象两种格式
+func (e *ErrorResponse) Message() string {
+	if len(e.Error) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(e.Error, &s); err == nil {
+		return s
+	}
+	var detail struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(e.Error, &detail); err == nil {
+		return detail.Message
+	}
+	return ""
+}
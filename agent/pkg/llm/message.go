@@ -3,6 +3,7 @@ package llm
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
 )
 
 // Role 消息角色
@@ -31,6 +32,12 @@ type Message struct {
 
 	// ToolCalls 助手发起的工具调用
 	ToolCalls []*ToolCall `json:"tool_calls,omitempty"`
+
+	// Thinking 助手消息的思考内容，启用扩展思考时需要随工具调用一起发回
+	Thinking []*ThinkingBlock `json:"thinking,omitempty"`
+
+	// CacheControl 提示缓存断点 (可选)
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 // Content 接口表示消息内容 (支持文本和多模态)
@@ -110,6 +117,15 @@ func (i *ImageContent) ToOpenAI() interface{} {
 }
 
 func (i *ImageContent) ToAnthropic() interface{} {
+	if i.Source.Type == "url" {
+		return map[string]interface{}{
+			"type": "image",
+			"source": map[string]interface{}{
+				"type": "url",
+				"url":  i.Source.URL,
+			},
+		}
+	}
 	return map[string]interface{}{
 		"type": "image",
 		"source": map[string]interface{}{
@@ -120,6 +136,129 @@ func (i *ImageContent) ToAnthropic() interface{} {
 	}
 }
 
+// DocumentContent 文档内容 (如 PDF)
+type DocumentContent struct {
+	// Source 文档源
+	Source DocumentSource `json:"source"`
+
+	// Title 文档标题 (可选)，OpenAI 用作文件名
+	Title string `json:"title,omitempty"`
+}
+
+// DocumentSource 文档源
+type DocumentSource struct {
+	// Type 源类型: "base64"、"url" 或 "text"
+	Type string `json:"type"`
+
+	// MediaType 媒体类型 (如 "application/pdf", "text/plain")
+	MediaType string `json:"media_type,omitempty"`
+
+	// Data base64 编码数据，Type 为 "text" 时为纯文本
+	Data string `json:"data,omitempty"`
+
+	// URL 文档 URL
+	URL string `json:"url,omitempty"`
+}
+
+func (d *DocumentContent) ContentType() string {
+	return "document"
+}
+
+// ToOpenAI 转换为 file 内容部分
+// 纯文本文档作为文本发送；Chat Completions 不支持 URL 文档，替换为文本说明
+func (d *DocumentContent) ToOpenAI() interface{} {
+	switch d.Source.Type {
+	case "base64":
+		return map[string]interface{}{
+			"type": "file",
+			"file": map[string]string{
+				"filename":  d.filename(),
+				"file_data": fmt.Sprintf("data:%s;base64,%s", d.Source.MediaType, d.Source.Data),
+			},
+		}
+	default:
+		return map[string]interface{}{
+			"type": "text",
+			"text": PlainText(d),
+		}
+	}
+}
+
+func (d *DocumentContent) ToAnthropic() interface{} {
+	source := map[string]interface{}{"type": d.Source.Type}
+	switch d.Source.Type {
+	case "url":
+		source["url"] = d.Source.URL
+	case "text":
+		source["media_type"] = "text/plain"
+		source["data"] = d.Source.Data
+	default:
+		source["media_type"] = d.Source.MediaType
+		source["data"] = d.Source.Data
+	}
+	block := map[string]interface{}{
+		"type":   "document",
+		"source": source,
+	}
+	if d.Title != "" {
+		block["title"] = d.Title
+	}
+	return block
+}
+
+// filename 返回文件名，没有标题时按媒体类型生成
+func (d *DocumentContent) filename() string {
+	if d.Title != "" {
+		return d.Title
+	}
+	if d.Source.MediaType == "application/pdf" {
+		return "document.pdf"
+	}
+	return "document"
+}
+
+// AudioContent 音频输入
+type AudioContent struct {
+	// Data base64 编码数据
+	Data string `json:"data"`
+
+	// Format 音频格式 (如 "wav", "mp3")
+	Format string `json:"format"`
+}
+
+func (a *AudioContent) ContentType() string {
+	return "audio"
+}
+
+func (a *AudioContent) ToOpenAI() interface{} {
+	return map[string]interface{}{
+		"type": "input_audio",
+		"input_audio": map[string]string{
+			"data":   a.Data,
+			"format": a.Format,
+		},
+	}
+}
+
+// ToAnthropic Anthropic 不支持音频输入，替换为文本说明
+func (a *AudioContent) ToAnthropic() interface{} {
+	return map[string]interface{}{
+		"type": "text",
+		"text": PlainText(a),
+	}
+}
+
+// MediaType 返回音频的媒体类型
+func (a *AudioContent) MediaType() string {
+	switch a.Format {
+	case "mp3":
+		return "audio/mpeg"
+	case "":
+		return "audio/wav"
+	}
+	return "audio/" + a.Format
+}
+
 // ContentList 多内容块列表
 type ContentList struct {
 	Items []Content `json:"-"`
@@ -137,9 +276,13 @@ func (c *ContentList) ToOpenAI() interface{} {
 		}
 	}
 
-	// 多个内容返回数组
+	// 多个内容返回数组，文本需要包装为内容部分
 	parts := make([]interface{}, len(c.Items))
 	for i, item := range c.Items {
+		if text, ok := item.(*TextContent); ok {
+			parts[i] = map[string]interface{}{"type": "text", "text": text.Text}
+			continue
+		}
 		parts[i] = item.ToOpenAI()
 	}
 	return parts
@@ -175,6 +318,69 @@ func TextString(c Content) string {
 	return ""
 }
 
+// PlainText 把内容转换为纯文本，非文本内容替换为说明
+// 用于不支持对应内容类型的提供商，避免内容被静默丢弃
+func PlainText(c Content) string {
+	switch v := c.(type) {
+	case *TextContent:
+		return v.Text
+	case *ImageContent:
+		if v.Source.Type == "url" {
+			return fmt.Sprintf("[图像: %s]", v.Source.URL)
+		}
+		return "[图像]"
+	case *DocumentContent:
+		if v.Source.Type == "text" {
+			if v.Title != "" {
+				return fmt.Sprintf("[文档: %s]\n%s", v.Title, v.Source.Data)
+			}
+			return v.Source.Data
+		}
+		switch {
+		case v.Title != "":
+			return fmt.Sprintf("[文档: %s]", v.Title)
+		case v.Source.Type == "url":
+			return fmt.Sprintf("[文档: %s]", v.Source.URL)
+		}
+		return "[文档]"
+	case *AudioContent:
+		return "[音频]"
+	case *ContentList:
+		var sb strings.Builder
+		for _, item := range v.Items {
+			sb.WriteString(PlainText(item))
+		}
+		return sb.String()
+	}
+	return TextString(c)
+}
+
+// SplitMedia 把内容拆分为文本和非文本部分
+// 用于只接受文本的位置 (如 OpenAI 的工具结果)，非文本部分由调用者另行发送
+func SplitMedia(c Content) (string, []Content) {
+	switch v := c.(type) {
+	case nil:
+		return "", nil
+	case *TextContent:
+		return v.Text, nil
+	case *DocumentContent:
+		if v.Source.Type == "text" {
+			return PlainText(v), nil
+		}
+		return "", []Content{v}
+	case *ContentList:
+		var sb strings.Builder
+		var media []Content
+		for _, item := range v.Items {
+			text, itemMedia := SplitMedia(item)
+			sb.WriteString(text)
+			media = append(media, itemMedia...)
+		}
+		return sb.String(), media
+	}
+	return "", []Content{c}
+}
+
 // ImageFromBase64 从 base64 数据创建图像内容
 func ImageFromBase64(data, mediaType string) Content {
 	return &ImageContent{
@@ -201,6 +407,59 @@ func ImageFromBase64Bytes(data []byte, mediaType string) Content {
 	return ImageFromBase64(base64.StdEncoding.EncodeToString(data), mediaType)
 }
 
+// DocumentFromBase64 从 base64 数据创建文档内容
+func DocumentFromBase64(data, mediaType string) *DocumentContent {
+	return &DocumentContent{
+		Source: DocumentSource{
+			Type:      "base64",
+			MediaType: mediaType,
+			Data:      data,
+		},
+	}
+}
+
+// DocumentFromBytes 从原始字节创建文档内容
+func DocumentFromBytes(data []byte, mediaType string) *DocumentContent {
+	return DocumentFromBase64(base64.StdEncoding.EncodeToString(data), mediaType)
+}
+
+// PDFFromBytes 从 PDF 文件内容创建文档内容
+func PDFFromBytes(data []byte) *DocumentContent {
+	return DocumentFromBytes(data, "application/pdf")
+}
+
+// DocumentFromURL 从 URL 创建文档内容
+func DocumentFromURL(url string) *DocumentContent {
+	return &DocumentContent{
+		Source: DocumentSource{
+			Type: "url",
+			URL:  url,
+		},
+	}
+}
+
+// DocumentFromText 从纯文本创建文档内容
+func DocumentFromText(text, title string) *DocumentContent {
+	return &DocumentContent{
+		Source: DocumentSource{
+			Type:      "text",
+			MediaType: "text/plain",
+			Data:      text,
+		},
+		Title: title,
+	}
+}
+
+// AudioFromBase64 从 base64 数据创建音频内容
+func AudioFromBase64(data, format string) *AudioContent {
+	return &AudioContent{Data: data, Format: format}
+}
+
+// AudioFromBytes 从原始字节创建音频内容
+func AudioFromBytes(data []byte, format string) *AudioContent {
+	return AudioFromBase64(base64.StdEncoding.EncodeToString(data), format)
+}
+
 // NewContentList 创建内容列表
 func NewContentList(items ...Content) *ContentList {
 	return &ContentList{Items: items}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+const (
+	// DefaultFailureThreshold 默认连续失败多少次后打开熔断器
+	DefaultFailureThreshold = 5
+	// DefaultOpenTimeout 默认熔断器打开后多久进入半开状态
+	DefaultOpenTimeout = 30 * time.Second
+)
+
+// ErrCircuitOpen 熔断器打开，请求未发送
+var ErrCircuitOpen = errors.New("熔断器已打开，暂停调用")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	// BreakerClosed 关闭：正常放行请求
+	BreakerClosed BreakerState = iota
+	// BreakerOpen 打开：直接拒绝请求
+	BreakerOpen
+	// BreakerHalfOpen 半开：放行一个探测请求，成功则关闭，失败则重新打开
+	BreakerHalfOpen
+)
+
+// String 返回状态字符串
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerOption 熔断器配置选项
+type BreakerOption func(*Breaker)
+
+// WithFailureThreshold 设置连续失败多少次后打开熔断器
+func WithFailureThreshold(n int) BreakerOption {
+	return func(b *Breaker) {
+		if n > 0 {
+			b.threshold = n
+		}
+	}
+}
+
+// WithOpenTimeout 设置熔断器打开后多久进入半开状态
+func WithOpenTimeout(d time.Duration) BreakerOption {
+	return func(b *Breaker) {
+		if d > 0 {
+			b.openTimeout = d
+		}
+	}
+}
+
+// WithFailureIf 设置判断错误是否计为失败的函数 (默认 IsTransient)
+// 请求无效、认证失败等与服务健康无关的错误默认不计为失败
+func WithFailureIf(fn func(error) bool) BreakerOption {
+	return func(b *Breaker) {
+		b.isFailure = fn
+	}
+}
+
+// WithOnStateChange 设置状态变化回调
+func WithOnStateChange(fn func(from, to BreakerState)) BreakerOption {
+	return func(b *Breaker) {
+		b.onStateChange = fn
+	}
+}
+
+// CircuitBreaker 创建熔断器中间件
+// 连续失败达到阈值后打开，打开期间直接返回 ErrCircuitOpen，
+// 超时后进入半开状态放行一个探测请求
+func CircuitBreaker(opts ...BreakerOption) Middleware {
+	return func(next llm.ChatCompleter) llm.ChatCompleter {
+		return NewBreaker(next, opts...)
+	}
+}
+
+// NewBreaker 创建熔断器，需要查询状态时使用
+func NewBreaker(next llm.ChatCompleter, opts ...BreakerOption) *Breaker {
+	b := &Breaker{
+		base:        base{next: next},
+		threshold:   DefaultFailureThreshold,
+		openTimeout: DefaultOpenTimeout,
+		isFailure:   IsTransient,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Breaker 熔断器，实现 llm.ChatCompleter
+type Breaker struct {
+	base
+	threshold     int
+	openTimeout   time.Duration
+	isFailure     func(error) bool
+	onStateChange func(from, to BreakerState)
+	now           func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	pending  [][2]BreakerState // 待触发的状态变化
+}
+
+// Complete 熔断器允许时发送请求
+func (b *Breaker) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	resp, err := b.next.Complete(ctx, req)
+	b.record(err)
+	return resp, err
+}
+
+// Stream 熔断器允许时发送流式请求，以第一个数据块判断成功或失败
+func (b *Breaker) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	ch, err := openStream(ctx, b.next, req)
+	b.record(err)
+	return ch, err
+}
+
+// State 返回当前状态
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow 检查是否放行请求
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return b.openError()
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+	case BreakerHalfOpen:
+		// 半开状态同一时间只放行一个探测请求
+		if b.probing {
+			return b.openError()
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record 记录请求结果
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	wasProbe := b.probing
+	b.probing = false
+
+	// 上下文取消不能说明服务是否健康
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	if err == nil || !b.isFailure(err) {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if (b.state == BreakerHalfOpen && wasProbe) || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.openedAt = b.now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// setState 切换状态 (调用者持有锁)，回调在释放锁之后触发
+func (b *Breaker) setState(state BreakerState) {
+	if from := b.state; from != state && b.onStateChange != nil {
+		b.pending = append(b.pending, [2]BreakerState{from, state})
+	}
+	b.state = state
+}
+
+// unlock 释放锁并触发状态变化回调
+func (b *Breaker) unlock() {
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	for _, change := range pending {
+		b.onStateChange(change[0], change[1])
+	}
+}
+
+// openError 返回熔断错误 (调用者持有锁)
+func (b *Breaker) openError() error {
+	retryIn := b.openTimeout - b.now().Sub(b.openedAt)
+	if retryIn < 0 {
+		retryIn = 0
+	}
+	return fmt.Errorf("%w: %s (%s 后重试)", ErrCircuitOpen, providerName(b.next), retryIn.Round(time.Second))
+}
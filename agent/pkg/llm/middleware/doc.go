@@ -0,0 +1,38 @@
+// Package middleware 提供 ChatCompleter 装饰器。
+//
+// 每个中间件都包装一个 llm.ChatCompleter 并返回新的 llm.ChatCompleter，
+// 可以自由组合:
+//
+//	client := middleware.Chain(anthropicClient,
+//	    middleware.Retry(middleware.WithMaxRetries(3)),
+//	    middleware.RateLimit(middleware.WithRequestsPerMinute(50), middleware.WithTokensPerMinute(40000)),
+//	    middleware.CircuitBreaker(middleware.WithFailureThreshold(5)),
+//	)
+//
+//	// 主提供商不可用时依次尝试备用提供商
+//	client = middleware.Fallback(client, openaiClient, localClient)
+//
+//	agent.New(agent.WithLLM(client))
+//
+// 流式请求只在第一个数据块发出之前重试或切换提供商，
+// 之后的错误原样传给调用者，避免输出重复内容。
+//
+// TrackUsage 按会话、标签、提供商和模型记录 token 用量和费用，并执行预算限制:
+//
+//	tracker := middleware.NewUsageTracker(
+//	    middleware.WithSessionBudget(0.5),
+//	    middleware.WithDailyBudget(20),
+//	)
+//	client = middleware.Chain(client, middleware.TrackUsage(tracker))
+//
+//	ctx = middleware.WithTags(middleware.WithSession(ctx, sessionID), "agent")
+//	// 超出预算时返回 *middleware.BudgetExceededError
+//
+//	tracker.ExportCSV(w, middleware.UsageFilter{Since: monthStart})
+//
+// 内存中默认只保留最近 DefaultMaxRecords 条记录，长期账本用 WithOnRecord 写入外部存储。
+//
+// RetryEmbedder 使用与 Retry 相同的选项为 llm.Embedder 添加重试。
+//
+// 包装后的客户端如果内层实现了 llm.ModelInfo，也会实现 llm.ModelInfo。
+package middleware
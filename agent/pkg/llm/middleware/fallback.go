@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// FallbackError 所有提供商都失败
+type FallbackError struct {
+	// Providers 依次尝试的提供商
+	Providers []string
+
+	// Errors 各提供商返回的错误
+	Errors []error
+}
+
+func (e *FallbackError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		parts[i] = fmt.Sprintf("%s: %v", e.Providers[i], err)
+	}
+	return "所有提供商均调用失败: " + strings.Join(parts, "; ")
+}
+
+// Unwrap 返回各提供商的错误 (用于 errors.Is / errors.As)
+func (e *FallbackError) Unwrap() []error {
+	return e.Errors
+}
+
+// FallbackClient 按顺序尝试多个提供商
+// ModelName 和 ProviderName 返回第一个提供商的信息
+type FallbackClient struct {
+	base
+	clients        []llm.ChatCompleter
+	shouldFallback func(error) bool
+	onFallback     func(from, to string, err error)
+}
+
+// Fallback 创建提供商降级链，如 anthropic → openai → 本地模型
+// 默认除上下文取消和请求无效之外的错误都切换到下一个提供商，
+// 每个提供商使用请求的副本，互不影响 Model 等默认值。
+func Fallback(primary llm.ChatCompleter, fallbacks ...llm.ChatCompleter) *FallbackClient {
+	return &FallbackClient{
+		base:           base{next: primary},
+		clients:        append([]llm.ChatCompleter{primary}, fallbacks...),
+		shouldFallback: shouldFallback,
+	}
+}
+
+// WithFallbackIf 设置判断错误是否切换提供商的函数
+func (f *FallbackClient) WithFallbackIf(fn func(error) bool) *FallbackClient {
+	f.shouldFallback = fn
+	return f
+}
+
+// WithOnFallback 设置切换提供商时的回调
+func (f *FallbackClient) WithOnFallback(fn func(from, to string, err error)) *FallbackClient {
+	f.onFallback = fn
+	return f
+}
+
+// SupportsStructuredOutput 所有候选客户端都支持时才使用原生结构化输出
+// 否则切换到备用客户端后 ResponseFormat 会被忽略
+func (f *FallbackClient) SupportsStructuredOutput() bool {
+	for _, client := range f.clients {
+		if !supportsStructuredOutput(client) {
+			return false
+		}
+	}
+	return true
+}
+
+// Complete 依次尝试各提供商
+func (f *FallbackClient) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	var resp *llm.ChatResponse
+	err := f.try(ctx, func(client llm.ChatCompleter) error {
+		var err error
+		resp, err = client.Complete(ctx, cloneRequest(req))
+		return err
+	})
+	return resp, err
+}
+
+// Stream 依次尝试各提供商，第一个数据块发出后不再切换
+func (f *FallbackClient) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	var ch <-chan *llm.StreamChunk
+	err := f.try(ctx, func(client llm.ChatCompleter) error {
+		var err error
+		ch, err = openStream(ctx, client, cloneRequest(req))
+		return err
+	})
+	return ch, err
+}
+
+// try 依次调用 call 直到成功或不应切换
+func (f *FallbackClient) try(ctx context.Context, call func(client llm.ChatCompleter) error) error {
+	fallbackErr := &FallbackError{}
+	for i, client := range f.clients {
+		err := call(client)
+		if err == nil {
+			markServed(ctx, client)
+			return nil
+		}
+		fallbackErr.Providers = append(fallbackErr.Providers, providerName(client))
+		fallbackErr.Errors = append(fallbackErr.Errors, err)
+
+		if ctx.Err() != nil || !f.shouldFallback(err) {
+			return err
+		}
+		if i+1 < len(f.clients) && f.onFallback != nil {
+			f.onFallback(providerName(client), providerName(f.clients[i+1]), err)
+		}
+	}
+	return fallbackErr
+}
+
+// servedKey 外层中间件 (TrackUsage) 通过 context 获取实际提供服务的客户端
+type servedKey struct{}
+
+// served 实际提供服务的提供商和模型
+type served struct {
+	provider string
+	model    string
+}
+
+// withServed 返回带 served 记录的 context
+func withServed(ctx context.Context, s *served) context.Context {
+	return context.WithValue(ctx, servedKey{}, s)
+}
+
+// markServed 记录成功的客户端，嵌套的降级链中最内层先成功，外层不再覆盖
+func markServed(ctx context.Context, client llm.ChatCompleter) {
+	s, ok := ctx.Value(servedKey{}).(*served)
+	if !ok || s.provider != "" {
+		return
+	}
+	s.provider = providerName(client)
+	if info, ok := client.(llm.ModelInfo); ok {
+		s.model = info.ModelName()
+	}
+}
+
+// shouldFallback 默认的切换条件
+// 请求无效在任何提供商上都会失败，不切换
+func shouldFallback(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if llmErr, ok := llm.AsLLMError(err); ok && llmErr.Type == llm.ErrorTypeInvalidRequest {
+		return false
+	}
+	return true
+}
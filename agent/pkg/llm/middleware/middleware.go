@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// Middleware ChatCompleter 装饰器
+type Middleware func(next llm.ChatCompleter) llm.ChatCompleter
+
+// Chain 按顺序应用中间件，第一个中间件在最外层
+func Chain(next llm.ChatCompleter, middlewares ...Middleware) llm.ChatCompleter {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		next = middlewares[i](next)
+	}
+	return next
+}
+
+// base 转发 llm.ModelInfo 到内层客户端
+type base struct {
+	next llm.ChatCompleter
+}
+
+// ModelName 返回内层客户端的模型名称
+func (b base) ModelName() string {
+	if info, ok := b.next.(llm.ModelInfo); ok {
+		return info.ModelName()
+	}
+	return ""
+}
+
+// ProviderName 返回内层客户端的提供商名称
+func (b base) ProviderName() string {
+	if info, ok := b.next.(llm.ModelInfo); ok {
+		return info.ProviderName()
+	}
+	return ""
+}
+
+// ContextWindow 返回内层客户端的上下文窗口大小
+func (b base) ContextWindow() int {
+	if info, ok := b.next.(llm.ModelInfo); ok {
+		return info.ContextWindow()
+	}
+	return 0
+}
+
+// SupportsStructuredOutput 返回内层客户端是否支持原生结构化输出
+func (b base) SupportsStructuredOutput() bool {
+	return supportsStructuredOutput(b.next)
+}
+
+// supportsStructuredOutput 检查客户端是否实现并支持 llm.StructuredOutputSupporter
+func supportsStructuredOutput(client llm.ChatCompleter) bool {
+	s, ok := client.(llm.StructuredOutputSupporter)
+	return ok && s.SupportsStructuredOutput()
+}
+
+// IsTransient 检查错误是否为暂时性错误 (可重试的 LLMError 或网络错误)
+// 上下文取消和超时不算暂时性错误
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if llm.IsRetryable(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// cloneRequest 复制请求
+// 客户端会填充 Model 等默认值，重试和切换提供商时使用副本避免互相影响
+func cloneRequest(req *llm.ChatRequest) *llm.ChatRequest {
+	clone := *req
+	return &clone
+}
+
+// firstChunk 等待流的第一个数据块
+// ok 为 false 表示流在发出任何数据块之前就结束了
+func firstChunk(ctx context.Context, ch <-chan *llm.StreamChunk) (chunk *llm.StreamChunk, ok bool, err error) {
+	select {
+	case chunk, ok = <-ch:
+		return chunk, ok, nil
+	case <-ctx.Done():
+		go drain(ch)
+		return nil, false, ctx.Err()
+	}
+}
+
+// chunkError 返回数据块中的错误
+func chunkError(chunk *llm.StreamChunk) error {
+	if chunk == nil || !chunk.IsError() {
+		return nil
+	}
+	if chunk.Error != nil {
+		return chunk.Error
+	}
+	return llm.NewStreamError("stream_error", "流式响应错误")
+}
+
+// prepend 把已读取的第一个数据块放回流的开头
+func prepend(first *llm.StreamChunk, rest <-chan *llm.StreamChunk) <-chan *llm.StreamChunk {
+	out := make(chan *llm.StreamChunk, cap(rest)+1)
+	go func() {
+		defer close(out)
+		out <- first
+		for chunk := range rest {
+			out <- chunk
+		}
+	}()
+	return out
+}
+
+// forward 把 in 中的数据块依次交给 observe 并转发到 out
+// ctx 取消后不再转发 (消费者可能已经不读 out)，但继续读完 in 交给 observe，
+// 让内层 goroutine 退出，用量照常统计
+func forward(ctx context.Context, in <-chan *llm.StreamChunk, out chan<- *llm.StreamChunk, observe func(*llm.StreamChunk)) {
+	for chunk := range in {
+		observe(chunk)
+		select {
+		case out <- chunk:
+		case <-ctx.Done():
+			for chunk := range in {
+				observe(chunk)
+			}
+			return
+		}
+	}
+}
+
+// closedStream 返回已关闭的空流
+func closedStream() <-chan *llm.StreamChunk {
+	ch := make(chan *llm.StreamChunk)
+	close(ch)
+	return ch
+}
+
+// drain 丢弃流中剩余的数据块，让生产者 goroutine 退出
+func drain(ch <-chan *llm.StreamChunk) {
+	for range ch {
+	}
+}
+
+// sleep 等待指定时间，上下文取消时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// openStream 发起流式请求并等待第一个数据块
+// 第一个数据块就是错误时返回该错误：此时还没有输出任何内容，可以安全地重试或切换提供商
+func openStream(ctx context.Context, next llm.ChatCompleter, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	ch, err := next.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	first, ok, err := firstChunk(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return closedStream(), nil
+	}
+	if err := chunkError(first); err != nil {
+		go drain(ch)
+		return nil, err
+	}
+	return prepend(first, ch), nil
+}
+
+// providerName 返回客户端的提供商名称，未实现 llm.ModelInfo 时返回类型名
+func providerName(c llm.ChatCompleter) string {
+	if info, ok := c.(llm.ModelInfo); ok && info.ProviderName() != "" {
+		return info.ProviderName()
+	}
+	return fmt.Sprintf("%T", c)
+}
@@ -0,0 +1,978 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// scriptedClient 按顺序返回预设结果的客户端
+type scriptedClient struct {
+	mu       sync.Mutex
+	name     string
+	errs     []error              // 第 i 次调用返回的错误，超出部分视为成功
+	streams  [][]*llm.StreamChunk // 第 i 次流式调用输出的数据块
+	calls    int
+	requests []*llm.ChatRequest
+}
+
+func (c *scriptedClient) next(req *llm.ChatRequest) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.calls
+	c.calls++
+	c.requests = append(c.requests, req)
+	if i < len(c.errs) {
+		return i, c.errs[i]
+	}
+	return i, nil
+}
+
+func (c *scriptedClient) Complete(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	req.Model = c.name + "-model"
+	if _, err := c.next(req); err != nil {
+		return nil, err
+	}
+	return &llm.ChatResponse{
+		Content: c.name,
+		Usage:   &llm.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (c *scriptedClient) Stream(_ context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	i, err := c.next(req)
+	if err != nil {
+		return nil, err
+	}
+	chunks := []*llm.StreamChunk{llm.NewContentChunk(c.name)}
+	if i < len(c.streams) {
+		chunks = c.streams[i]
+	}
+	ch := make(chan *llm.StreamChunk, len(chunks))
+	for _, chunk := range chunks {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (c *scriptedClient) ModelName() string    { return c.name + "-model" }
+func (c *scriptedClient) ProviderName() string { return c.name }
+func (c *scriptedClient) ContextWindow() int   { return 1000 }
+
+func (c *scriptedClient) callCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+// noSleep 记录等待时间但不真正等待
+type noSleep struct {
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (s *noSleep) sleep(ctx context.Context, d time.Duration) error {
+	s.mu.Lock()
+	s.delays = append(s.delays, d)
+	s.mu.Unlock()
+	return ctx.Err()
+}
+
+func testRequest() *llm.ChatRequest {
+	return &llm.ChatRequest{
+		Messages: []*llm.Message{{Role: llm.RoleUser, Content: llm.Text("Hello")}},
+	}
+}
+
+func collect(ch <-chan *llm.StreamChunk) []string {
+	var out []string
+	for chunk := range ch {
+		if chunk.IsError() {
+			out = append(out, "error")
+			continue
+		}
+		out = append(out, chunk.Content)
+	}
+	return out
+}
+
+func newTestRetry(next llm.ChatCompleter, s *noSleep, opts ...RetryOption) *retryClient {
+	r := Retry(opts...)(next).(*retryClient)
+	r.sleep = s.sleep
+	r.jitter = func() float64 { return 1 }
+	return r
+}
+
+func TestChain_Order(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next llm.ChatCompleter) llm.ChatCompleter {
+			order = append(order, name)
+			return next
+		}
+	}
+	Chain(&scriptedClient{name: "a"}, mark("outer"), mark("inner"))
+	// 从内到外包装
+	if len(order) != 2 || order[0] != "inner" || order[1] != "outer" {
+		t.Errorf("unexpected wrap order: %v", order)
+	}
+}
+
+func TestChain_ForwardsModelInfo(t *testing.T) {
+	client := Chain(&scriptedClient{name: "anthropic"}, Retry(), RateLimit(), CircuitBreaker())
+	info, ok := client.(llm.ModelInfo)
+	if !ok {
+		t.Fatal("expected wrapped client to implement ModelInfo")
+	}
+	if info.ProviderName() != "anthropic" || info.ModelName() != "anthropic-model" || info.ContextWindow() != 1000 {
+		t.Errorf("unexpected model info: %s/%s/%d", info.ProviderName(), info.ModelName(), info.ContextWindow())
+	}
+}
+
+func TestRetry_RetriesTransientErrors(t *testing.T) {
+	inner := &scriptedClient{name: "a", errs: []error{
+		llm.ErrRateLimit("slow down"),
+		llm.ErrServerError("boom"),
+	}}
+	s := &noSleep{}
+	client := newTestRetry(inner, s, WithBackoff(time.Second, 10*time.Second))
+
+	resp, err := client.Complete(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "a" {
+		t.Errorf("unexpected response: %s", resp.Content)
+	}
+	if inner.callCount() != 3 {
+		t.Errorf("expected 3 calls, got %d", inner.callCount())
+	}
+	if len(s.delays) != 2 || s.delays[0] != time.Second || s.delays[1] != 2*time.Second {
+		t.Errorf("unexpected delays: %v", s.delays)
+	}
+}
+
+func TestRetry_HonoursRetryAfter(t *testing.T) {
+	inner := &scriptedClient{name: "a", errs: []error{
+		llm.ErrRateLimit("slow down").WithRetryAfter(7),
+	}}
+	s := &noSleep{}
+	client := newTestRetry(inner, s)
+
+	if _, err := client.Complete(context.Background(), testRequest()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.delays) != 1 || s.delays[0] != 7*time.Second {
+		t.Errorf("expected RetryAfter delay, got %v", s.delays)
+	}
+}
+
+func TestRetry_StopsOnPermanentError(t *testing.T) {
+	inner := &scriptedClient{name: "a", errs: []error{llm.ErrInvalidRequest("bad")}}
+	client := newTestRetry(inner, &noSleep{})
+
+	_, err := client.Complete(context.Background(), testRequest())
+	if llmErr, ok := llm.AsLLMError(err); !ok || llmErr.Type != llm.ErrorTypeInvalidRequest {
+		t.Errorf("expected invalid request error, got %v", err)
+	}
+	if inner.callCount() != 1 {
+		t.Errorf("expected 1 call, got %d", inner.callCount())
+	}
+}
+
+func TestRetry_MaxRetries(t *testing.T) {
+	overloaded := llm.NewLLMError(llm.ErrorTypeOverloaded, "busy")
+	inner := &scriptedClient{name: "a", errs: []error{overloaded, overloaded, overloaded}}
+	var attempts []int
+	client := newTestRetry(inner, &noSleep{}, WithMaxRetries(2), WithOnRetry(func(attempt int, _ error, _ time.Duration) {
+		attempts = append(attempts, attempt)
+	}))
+
+	_, err := client.Complete(context.Background(), testRequest())
+	if !errors.Is(err, overloaded) {
+		t.Errorf("expected last error, got %v", err)
+	}
+	if inner.callCount() != 3 {
+		t.Errorf("expected 3 calls, got %d", inner.callCount())
+	}
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("unexpected retry attempts: %v", attempts)
+	}
+}
+
+func TestRetry_UsesRequestCopy(t *testing.T) {
+	inner := &scriptedClient{name: "a", errs: []error{llm.ErrServerError("boom")}}
+	client := newTestRetry(inner, &noSleep{})
+
+	req := testRequest()
+	if _, err := client.Complete(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Model != "" {
+		t.Errorf("expected caller's request to be untouched, got model %q", req.Model)
+	}
+}
+
+func TestRetry_StreamBeforeFirstChunk(t *testing.T) {
+	inner := &scriptedClient{
+		name: "a",
+		streams: [][]*llm.StreamChunk{
+			{llm.NewErrorChunk(llm.ErrOverloaded("busy"))},
+			{llm.NewContentChunk("hello"), llm.NewContentChunk(" world")},
+		},
+	}
+	client := newTestRetry(inner, &noSleep{})
+
+	ch, err := client.Stream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := collect(ch)
+	if len(got) != 2 || got[0] != "hello" || got[1] != " world" {
+		t.Errorf("unexpected chunks: %v", got)
+	}
+	if inner.callCount() != 2 {
+		t.Errorf("expected 2 calls, got %d", inner.callCount())
+	}
+}
+
+func TestRetry_StreamAfterFirstChunk(t *testing.T) {
+	inner := &scriptedClient{
+		name: "a",
+		streams: [][]*llm.StreamChunk{
+			{llm.NewContentChunk("hello"), llm.NewErrorChunk(llm.ErrOverloaded("busy"))},
+		},
+	}
+	client := newTestRetry(inner, &noSleep{})
+
+	ch, err := client.Stream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := collect(ch)
+	if len(got) != 2 || got[0] != "hello" || got[1] != "error" {
+		t.Errorf("expected error to be passed through, got %v", got)
+	}
+	if inner.callCount() != 1 {
+		t.Errorf("expected no retry after first chunk, got %d calls", inner.callCount())
+	}
+}
+
+func TestRetry_ContextCanceled(t *testing.T) {
+	inner := &scriptedClient{name: "a", errs: []error{llm.ErrServerError("boom")}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := newTestRetry(inner, &noSleep{})
+
+	if _, err := client.Complete(ctx, testRequest()); err == nil {
+		t.Error("expected error")
+	}
+	if inner.callCount() != 1 {
+		t.Errorf("expected no retry after cancel, got %d calls", inner.callCount())
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{llm.ErrRateLimit("x"), true},
+		{llm.ErrTimeout("x"), true},
+		{llm.ErrAuthentication("x"), false},
+		{context.Canceled, false},
+		{errors.New("plain"), false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestBucket_Reserve(t *testing.T) {
+	clock := newFakeClock()
+	b := newBucket(60, time.Minute, clock.Now)
+
+	if _, wait := b.reserve(60); wait != 0 {
+		t.Errorf("expected no wait for full bucket, got %v", wait)
+	}
+	if _, wait := b.reserve(1); wait != time.Second {
+		t.Errorf("expected 1s wait, got %v", wait)
+	}
+
+	clock.Advance(time.Minute)
+	if _, wait := b.reserve(30); wait != 0 {
+		t.Errorf("expected no wait after refill, got %v", wait)
+	}
+
+	// 超过容量按容量计算
+	b2 := newBucket(10, time.Minute, clock.Now)
+	if reserved, wait := b2.reserve(100); reserved != 10 || wait != 0 {
+		t.Errorf("expected oversize request to be capped, got %v, %v", reserved, wait)
+	}
+}
+
+func TestRateLimit_RequestsPerMinute(t *testing.T) {
+	clock := newFakeClock()
+	s := &noSleep{}
+	inner := &scriptedClient{name: "a"}
+	client := RateLimit()(inner).(*rateLimitClient)
+	client.now = clock.Now
+	WithRequestsPerMinute(2)(client)
+	client.sleep = s.sleep
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Complete(context.Background(), testRequest()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(s.delays) != 3 || s.delays[0] != 0 || s.delays[1] != 0 || s.delays[2] != 30*time.Second {
+		t.Errorf("unexpected delays: %v", s.delays)
+	}
+}
+
+func TestRateLimit_SettlesActualUsage(t *testing.T) {
+	clock := newFakeClock()
+	inner := &scriptedClient{name: "a"}
+	client := RateLimit()(inner).(*rateLimitClient)
+	client.now = clock.Now
+	WithTokensPerMinute(100)(client)
+	client.estimate = func(*llm.ChatRequest) int { return 50 }
+	client.sleep = (&noSleep{}).sleep
+
+	if _, err := client.Complete(context.Background(), testRequest()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 预扣 50，实际 15，余额应为 85
+	if client.tokens.tokens != 85 {
+		t.Errorf("expected 85 tokens left, got %v", client.tokens.tokens)
+	}
+}
+
+func TestRateLimit_StreamSettlesOnce(t *testing.T) {
+	clock := newFakeClock()
+	usage := &llm.Usage{TotalTokens: 20}
+	inner := &scriptedClient{name: "a", streams: [][]*llm.StreamChunk{{
+		{Type: llm.ChunkTypeMessageDelta, Usage: usage},
+		llm.NewDoneChunk(&llm.ChatResponse{Usage: usage}),
+	}}}
+	client := RateLimit()(inner).(*rateLimitClient)
+	client.now = clock.Now
+	WithTokensPerMinute(100)(client)
+	client.estimate = func(*llm.ChatRequest) int { return 50 }
+	client.sleep = (&noSleep{}).sleep
+
+	ch, err := client.Stream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	collect(ch)
+	// 流结束后才结算，等待输出 goroutine 完成
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		client.tokens.mu.Lock()
+		left := client.tokens.tokens
+		client.tokens.mu.Unlock()
+		if left == 80 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("expected 80 tokens left after settlement")
+}
+
+// TestRateLimit_OversizeRequest 估算值超过桶容量时只结算实际预留的令牌
+func TestRateLimit_OversizeRequest(t *testing.T) {
+	clock := newFakeClock()
+	inner := &scriptedClient{name: "a"}
+	client := RateLimit()(inner).(*rateLimitClient)
+	client.now = clock.Now
+	WithTokensPerMinute(100)(client)
+	client.estimate = func(*llm.ChatRequest) int { return 500 }
+	client.sleep = (&noSleep{}).sleep
+
+	if _, err := client.Complete(context.Background(), testRequest()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 预扣 100 (容量)，实际 15，余额应为 85
+	if client.tokens.tokens != 85 {
+		t.Errorf("expected 85 tokens left, got %v", client.tokens.tokens)
+	}
+
+	// 等待时取消，只归还实际预留的令牌
+	client.tokens.tokens = 50
+	client.sleep = func(context.Context, time.Duration) error { return context.Canceled }
+	if _, err := client.Complete(context.Background(), testRequest()); err == nil {
+		t.Fatal("expected error on cancel")
+	}
+	if client.tokens.tokens != 50 {
+		t.Errorf("expected 50 tokens after cancel, got %v", client.tokens.tokens)
+	}
+}
+
+// countChars 按字符数计数
+type countChars struct{}
+
+func (countChars) CountTokens(text string) int { return len(text) }
+
+func TestRateLimit_TokenCounter(t *testing.T) {
+	client := RateLimit(WithTokensPerMinute(1000), WithTokenCounter(countChars{}))(&scriptedClient{name: "a"}).(*rateLimitClient)
+	req := testRequest()
+	if got, want := client.estimate(req), llm.CountRequestTokens(countChars{}, req); got != want {
+		t.Errorf("expected %d, got %d", want, got)
+	}
+}
+
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	clock := newFakeClock()
+	serverErr := llm.ErrServerError("boom")
+	inner := &scriptedClient{name: "a", errs: []error{serverErr, serverErr, serverErr}}
+	var changes []string
+	b := NewBreaker(inner,
+		WithFailureThreshold(2),
+		WithOpenTimeout(10*time.Second),
+		WithOnStateChange(func(from, to BreakerState) {
+			changes = append(changes, from.String()+"->"+to.String())
+		}),
+	)
+	b.now = clock.Now
+	ctx := context.Background()
+
+	b.Complete(ctx, testRequest())
+	b.Complete(ctx, testRequest())
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected open, got %s", b.State())
+	}
+
+	if _, err := b.Complete(ctx, testRequest()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if inner.callCount() != 2 {
+		t.Errorf("expected open breaker to reject without calling, got %d calls", inner.callCount())
+	}
+
+	// 半开探测失败，重新打开
+	clock.Advance(10 * time.Second)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("expected half_open, got %s", b.State())
+	}
+	b.Complete(ctx, testRequest())
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected reopen after failed probe, got %s", b.State())
+	}
+
+	// 半开探测成功，关闭
+	clock.Advance(10 * time.Second)
+	if _, err := b.Complete(ctx, testRequest()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.State() != BreakerClosed {
+		t.Errorf("expected closed, got %s", b.State())
+	}
+
+	want := []string{"closed->open", "open->half_open", "half_open->open", "open->half_open", "half_open->closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("unexpected state changes: %v", changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d: expected %s, got %s", i, want[i], changes[i])
+		}
+	}
+}
+
+func TestBreaker_IgnoresNonTransientErrors(t *testing.T) {
+	badReq := llm.ErrInvalidRequest("bad")
+	inner := &scriptedClient{name: "a", errs: []error{badReq, badReq, badReq}}
+	b := NewBreaker(inner, WithFailureThreshold(2))
+
+	for i := 0; i < 3; i++ {
+		b.Complete(context.Background(), testRequest())
+	}
+	if b.State() != BreakerClosed {
+		t.Errorf("expected closed, got %s", b.State())
+	}
+}
+
+func TestFallback_SwitchesProvider(t *testing.T) {
+	primary := &scriptedClient{name: "anthropic", errs: []error{llm.ErrOverloaded("busy")}}
+	secondary := &scriptedClient{name: "openai"}
+	var switched string
+	client := Fallback(primary, secondary).WithOnFallback(func(from, to string, _ error) {
+		switched = from + "->" + to
+	})
+
+	req := testRequest()
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "openai" {
+		t.Errorf("expected fallback response, got %s", resp.Content)
+	}
+	if switched != "anthropic->openai" {
+		t.Errorf("unexpected fallback callback: %s", switched)
+	}
+	// 每个提供商使用独立的请求副本
+	if secondary.requests[0].Model != "openai-model" || req.Model != "" {
+		t.Errorf("expected independent request copies")
+	}
+	if client.ProviderName() != "anthropic" {
+		t.Errorf("expected primary provider name, got %s", client.ProviderName())
+	}
+}
+
+func TestFallback_AllFail(t *testing.T) {
+	primary := &scriptedClient{name: "anthropic", errs: []error{llm.ErrOverloaded("busy")}}
+	secondary := &scriptedClient{name: "openai", errs: []error{llm.ErrRateLimit("slow")}}
+	client := Fallback(primary, secondary)
+
+	_, err := client.Complete(context.Background(), testRequest())
+	var fbErr *FallbackError
+	if !errors.As(err, &fbErr) {
+		t.Fatalf("expected FallbackError, got %v", err)
+	}
+	if len(fbErr.Providers) != 2 || fbErr.Providers[1] != "openai" {
+		t.Errorf("unexpected providers: %v", fbErr.Providers)
+	}
+	if llmErr, ok := llm.AsLLMError(err); !ok || llmErr.Type != llm.ErrorTypeOverloaded {
+		t.Errorf("expected errors.As to reach provider errors, got %v", err)
+	}
+}
+
+func TestFallback_InvalidRequestDoesNotSwitch(t *testing.T) {
+	primary := &scriptedClient{name: "anthropic", errs: []error{llm.ErrInvalidRequest("bad")}}
+	secondary := &scriptedClient{name: "openai"}
+	client := Fallback(primary, secondary)
+
+	if _, err := client.Complete(context.Background(), testRequest()); err == nil {
+		t.Error("expected error")
+	}
+	if secondary.callCount() != 0 {
+		t.Errorf("expected no fallback for invalid request")
+	}
+}
+
+func TestFallback_Stream(t *testing.T) {
+	primary := &scriptedClient{name: "anthropic", streams: [][]*llm.StreamChunk{
+		{llm.NewErrorChunk(llm.ErrOverloaded("busy"))},
+	}}
+	secondary := &scriptedClient{name: "openai"}
+	client := Fallback(primary, secondary)
+
+	ch, err := client.Stream(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := collect(ch)
+	if len(got) != 1 || got[0] != "openai" {
+		t.Errorf("unexpected chunks: %v", got)
+	}
+}
+
+func TestPrice_Cost(t *testing.T) {
+	price := Price{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+	// InputTokens 已包含缓存部分: 1000 未缓存 + 2000 读取 + 1000 写入
+	usage := &llm.Usage{InputTokens: 4000, OutputTokens: 1000, CacheReadTokens: 2000, CacheCreationTokens: 1000}
+	want := (1000*3 + 2000*0.3 + 1000*3.75 + 1000*15) / 1e6
+	if got := price.Cost(usage); math.Abs(got-want) > 1e-12 {
+		t.Errorf("Cost() = %v, want %v", got, want)
+	}
+
+	// 没有缓存价格时按输入价格计算
+	if got := (Price{Input: 1, Output: 2}).Cost(usage); math.Abs(got-(4000*1+1000*2)/1e6) > 1e-12 {
+		t.Errorf("Cost() without cache price = %v", got)
+	}
+}
+
+func TestPriceTable_Lookup(t *testing.T) {
+	prices := DefaultPrices()
+	tests := []struct {
+		model string
+		want  float64
+		ok    bool
+	}{
+		{"gpt-4o-mini-2024-07-18", 0.15, true},
+		{"gpt-4o-2024-08-06", 2.5, true},
+		{"openai/gpt-4.1-mini", 0.4, true},
+		{"claude-sonnet-4-20250514", 3, true},
+		{"llama3", 0, false},
+	}
+	for _, tt := range tests {
+		price, ok := prices.Lookup(tt.model)
+		if ok != tt.ok || price.Input != tt.want {
+			t.Errorf("Lookup(%q) = %+v, %v", tt.model, price, ok)
+		}
+	}
+}
+
+// newTestTracker 创建使用固定时间和价格的统计器
+func newTestTracker(opts ...UsageOption) (*UsageTracker, *time.Time) {
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	opts = append([]UsageOption{
+		WithPrices(PriceTable{"a-model": {Input: 1, Output: 2}}),
+		WithLocation(time.UTC),
+	}, opts...)
+	tracker := NewUsageTracker(opts...)
+	tracker.now = func() time.Time { return now }
+	return tracker, &now
+}
+
+func TestTrackUsage_RecordsPerSessionAndTag(t *testing.T) {
+	tracker, _ := newTestTracker()
+	var callbacks int
+	WithOnRecord(func(UsageRecord) { callbacks++ })(tracker)
+
+	a := TrackUsage(tracker)(&scriptedClient{name: "a"})
+	b := TrackUsage(tracker)(&scriptedClient{name: "b"})
+
+	ctx := WithTags(WithSession(context.Background(), "s1"), "agent")
+	for _, client := range []llm.ChatCompleter{a, a, b} {
+		if _, err := client.Complete(ctx, &llm.ChatRequest{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := a.Complete(WithTags(WithSession(context.Background(), "s2"), "summarize"), &llm.ChatRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if callbacks != 4 {
+		t.Errorf("expected 4 callbacks, got %d", callbacks)
+	}
+	total := tracker.Total(UsageFilter{})
+	if total.Requests != 4 || total.InputTokens != 40 || total.OutputTokens != 20 {
+		t.Errorf("unexpected totals: %+v", total)
+	}
+	// a-model: 10*1 + 5*2 = 20 / 1e6 每次；b-model 不在价格表中
+	if math.Abs(total.Cost-3*20/1e6) > 1e-12 {
+		t.Errorf("unexpected cost: %v", total.Cost)
+	}
+
+	s1 := tracker.Total(UsageFilter{Session: "s1"})
+	if s1.Requests != 3 || math.Abs(s1.Cost-2*20/1e6) > 1e-12 {
+		t.Errorf("unexpected session totals: %+v", s1)
+	}
+	byModel := tracker.GroupBy(GroupByModel, UsageFilter{})
+	if byModel["a-model"].Requests != 3 || byModel["b-model"].Requests != 1 || byModel["b-model"].Cost != 0 {
+		t.Errorf("unexpected model groups: %+v", byModel)
+	}
+	byTag := tracker.GroupBy(GroupByTag, UsageFilter{Provider: "a"})
+	if byTag["agent"].Requests != 2 || byTag["summarize"].Requests != 1 {
+		t.Errorf("unexpected tag groups: %+v", byTag)
+	}
+	records := tracker.Records(UsageFilter{Model: "b-model"})
+	if len(records) != 1 || records[0].Priced || records[0].Provider != "b" || records[0].Session != "s1" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
+
+func TestTrackUsage_StreamRecordsOnce(t *testing.T) {
+	tracker, _ := newTestTracker()
+	inner := &scriptedClient{name: "a", streams: [][]*llm.StreamChunk{{
+		llm.NewContentChunk("hi"),
+		{Type: llm.ChunkTypeContent, Usage: &llm.Usage{InputTokens: 10}},
+		llm.NewDoneChunk(&llm.ChatResponse{Model: "a-model-2025", Usage: &llm.Usage{InputTokens: 10, OutputTokens: 7, ReasoningTokens: 3}}),
+	}}}
+	client := TrackUsage(tracker)(inner)
+
+	ch, err := client.Stream(context.Background(), &llm.ChatRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var n int
+	for range ch {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("expected 3 chunks, got %d", n)
+	}
+
+	records := tracker.Records(UsageFilter{})
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	r := records[0]
+	if r.Model != "a-model-2025" || r.OutputTokens != 7 || r.ReasoningTokens != 3 || !r.Priced {
+		t.Errorf("unexpected record: %+v", r)
+	}
+}
+
+func TestTrackUsage_FallbackProvider(t *testing.T) {
+	tracker, _ := newTestTracker()
+	primary := &scriptedClient{name: "a", errs: []error{llm.ErrOverloaded("busy"), llm.ErrOverloaded("busy")}}
+	secondary := &scriptedClient{name: "b"}
+	client := TrackUsage(tracker)(Fallback(primary, secondary))
+
+	if _, err := client.Complete(context.Background(), &llm.ChatRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ch, err := client.Stream(context.Background(), &llm.ChatRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	collect(ch)
+
+	// 用量记在实际提供服务的提供商上，而不是降级链的第一个提供商
+	if records := tracker.Records(UsageFilter{Provider: "b", Model: "b-model"}); len(records) != 1 {
+		t.Errorf("expected complete recorded for b, got %+v", tracker.Records(UsageFilter{}))
+	}
+	if records := tracker.Records(UsageFilter{Provider: "a"}); len(records) != 0 {
+		t.Errorf("unexpected records for a: %+v", records)
+	}
+}
+
+// slowStream 用无缓冲 channel 输出数据块，done 在生产者退出时关闭
+type slowStream struct {
+	scriptedClient
+	chunks []*llm.StreamChunk
+	done   chan struct{}
+}
+
+func (c *slowStream) Stream(_ context.Context, _ *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	ch := make(chan *llm.StreamChunk)
+	go func() {
+		defer close(c.done)
+		defer close(ch)
+		for _, chunk := range c.chunks {
+			ch <- chunk
+		}
+	}()
+	return ch, nil
+}
+
+func TestStream_ConsumerGoneAfterCancel(t *testing.T) {
+	tracker, _ := newTestTracker()
+	inner := &slowStream{
+		scriptedClient: scriptedClient{name: "a"},
+		chunks: []*llm.StreamChunk{
+			llm.NewContentChunk("hi"),
+			llm.NewContentChunk("there"),
+			llm.NewDoneChunk(&llm.ChatResponse{Usage: &llm.Usage{InputTokens: 10, OutputTokens: 5}}),
+		},
+		done: make(chan struct{}),
+	}
+	// TrackUsage 在内层读完整个流，RateLimit 在外层转发
+	client := Chain(inner, RateLimit(WithTokensPerMinute(1000)), TrackUsage(tracker))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := client.Stream(ctx, &llm.ChatRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 消费者不再读取输出，取消后中间件仍要读完内层流
+	cancel()
+
+	select {
+	case <-inner.done:
+	case <-time.After(time.Second):
+		t.Fatal("inner stream blocked after context canceled")
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(tracker.Records(UsageFilter{})) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if records := tracker.Records(UsageFilter{}); len(records) != 1 || records[0].OutputTokens != 5 {
+		t.Errorf("expected usage recorded after cancel, got %+v", records)
+	}
+}
+
+func TestTrackUsage_Budgets(t *testing.T) {
+	// 每次调用 20/1e6 美元
+	tracker, now := newTestTracker(WithSessionBudget(30/1e6), WithDailyBudget(50/1e6))
+	inner := &scriptedClient{name: "a"}
+	client := TrackUsage(tracker)(inner)
+	s1 := WithSession(context.Background(), "s1")
+	s2 := WithSession(context.Background(), "s2")
+
+	// 第二次调用后会话花费 40 超出 30
+	for i := 0; i < 2; i++ {
+		if _, err := client.Complete(s1, &llm.ChatRequest{}); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	_, err := client.Complete(s1, &llm.ChatRequest{})
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) || budgetErr.Scope != BudgetScopeSession || budgetErr.Key != "s1" {
+		t.Fatalf("expected session budget error, got %v", err)
+	}
+	if !errors.Is(err, ErrBudgetExceeded) || IsTransient(err) {
+		t.Errorf("budget error should match ErrBudgetExceeded and not be transient: %v", err)
+	}
+	if inner.callCount() != 2 {
+		t.Errorf("request should not be sent when over budget, calls = %d", inner.callCount())
+	}
+
+	// 其他会话不受会话预算影响，但当天合计 60 超出 50
+	if _, err := client.Complete(s2, &llm.ChatRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Stream(s2, &llm.ChatRequest{})
+	if !errors.As(err, &budgetErr) || budgetErr.Scope != BudgetScopeDay || budgetErr.Key != "2025-01-02" {
+		t.Fatalf("expected daily budget error, got %v", err)
+	}
+
+	// 第二天重新计算
+	*now = now.Add(24 * time.Hour)
+	if _, err := client.Complete(s2, &llm.ChatRequest{}); err != nil {
+		t.Errorf("unexpected error on next day: %v", err)
+	}
+	if days := tracker.GroupBy(GroupByDay, UsageFilter{}); len(days) != 2 || days["2025-01-02"].Requests != 3 {
+		t.Errorf("unexpected day groups: %+v", days)
+	}
+}
+
+// TestUsageTracker_Retention 测试记录数上限和过期的预算统计被丢弃
+func TestUsageTracker_Retention(t *testing.T) {
+	var sunk int
+	tracker, now := newTestTracker(WithMaxRecords(2), WithSessionBudget(1), WithSessionTTL(time.Hour),
+		WithOnRecord(func(UsageRecord) { sunk++ }))
+	usage := &llm.Usage{InputTokens: 10}
+
+	for i, session := range []string{"s1", "s2", "s3"} {
+		*now = now.Add(time.Duration(i) * time.Hour)
+		tracker.Record(WithSession(context.Background(), session), "a", "a-model", usage)
+	}
+	records := tracker.Records(UsageFilter{})
+	if len(records) != 2 || records[0].Session != "s2" || records[1].Session != "s3" || sunk != 3 {
+		t.Errorf("expected the 2 latest records and 3 callbacks, got %+v, %d", records, sunk)
+	}
+
+	// s1、s2 超过 TTL 没有调用，只保留 s3
+	tracker.mu.Lock()
+	if len(tracker.sessions) != 1 || tracker.sessions["s3"] == nil {
+		t.Errorf("expected only s3 to be kept, got %v", tracker.sessions)
+	}
+	tracker.mu.Unlock()
+
+	// 跨天后只保留当天的花费
+	*now = now.Add(24 * time.Hour)
+	tracker.Record(context.Background(), "a", "a-model", usage)
+	if tracker.spentDay != "2025-01-03" || tracker.spentDayCost != 10/1e6 {
+		t.Errorf("expected only today's spend, got %s %v", tracker.spentDay, tracker.spentDayCost)
+	}
+
+	// 0 不保留记录
+	none, _ := newTestTracker(WithMaxRecords(0))
+	none.Record(context.Background(), "a", "a-model", usage)
+	if len(none.Records(UsageFilter{})) != 0 {
+		t.Error("expected no records to be kept")
+	}
+}
+
+func TestUsageTracker_Export(t *testing.T) {
+	tracker, _ := newTestTracker()
+	ctx := WithTags(WithSession(context.Background(), "s1"), "agent", "tools")
+	tracker.Record(ctx, "a", "a-model", &llm.Usage{InputTokens: 100, OutputTokens: 50, CacheReadTokens: 80})
+	tracker.Record(context.Background(), "b", "b-model", &llm.Usage{InputTokens: 1, OutputTokens: 1})
+
+	var jsonl bytes.Buffer
+	if err := tracker.ExportJSONL(&jsonl, UsageFilter{Session: "s1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(jsonl.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %q", jsonl.String())
+	}
+	var record UsageRecord
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record.Model != "a-model" || record.CacheReadTokens != 80 || len(record.Tags) != 2 || math.Abs(record.Cost-200/1e6) > 1e-12 {
+		t.Errorf("unexpected record: %+v", record)
+	}
+
+	var out bytes.Buffer
+	if err := tracker.ExportCSV(&out, UsageFilter{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 || rows[0][0] != "time" {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+	want := []string{"2025-01-02T10:00:00Z", "s1", "agent;tools", "a", "a-model", "100", "50", "80", "0", "0", "0.000200", "true"}
+	if strings.Join(rows[1], ",") != strings.Join(want, ",") {
+		t.Errorf("row = %v, want %v", rows[1], want)
+	}
+	if rows[2][10] != "0.000000" || rows[2][11] != "false" {
+		t.Errorf("unpriced row = %v", rows[2])
+	}
+}
+
+// flakyEmbedder 前几次调用返回错误的向量化客户端
+type flakyEmbedder struct {
+	errs  []error
+	calls int
+}
+
+func (e *flakyEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	i := e.calls
+	e.calls++
+	if i < len(e.errs) {
+		return nil, e.errs[i]
+	}
+	return make([][]float32, len(texts)), nil
+}
+
+func (e *flakyEmbedder) Dimensions() int   { return 8 }
+func (e *flakyEmbedder) ModelName() string { return "embed" }
+
+func TestRetryEmbedder(t *testing.T) {
+	inner := &flakyEmbedder{errs: []error{llm.ErrOverloaded("busy"), llm.ErrRateLimit("slow down")}}
+	s := &noSleep{}
+	embedder := RetryEmbedder(inner, WithBackoff(time.Second, 10*time.Second))
+	embedder.(*retryEmbedder).retry.sleep = s.sleep
+
+	vectors, err := embedder.Embed(context.Background(), []string{"a", "b"})
+	if err != nil || len(vectors) != 2 {
+		t.Fatalf("unexpected result: %v %v", vectors, err)
+	}
+	if inner.calls != 3 || len(s.delays) != 2 {
+		t.Errorf("expected 3 calls and 2 waits, got %d/%d", inner.calls, len(s.delays))
+	}
+	if embedder.Dimensions() != 8 || embedder.ModelName() != "embed" {
+		t.Errorf("model info should be forwarded")
+	}
+
+	// 不可重试的错误直接返回
+	inner = &flakyEmbedder{errs: []error{llm.ErrAuthentication("bad key")}}
+	embedder = RetryEmbedder(inner)
+	if _, err := embedder.Embed(context.Background(), []string{"a"}); err == nil || inner.calls != 1 {
+		t.Errorf("expected immediate failure, got %v after %d calls", err, inner.calls)
+	}
+}
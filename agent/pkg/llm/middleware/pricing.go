@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// Price 模型价格，单位为美元 / 百万 token
+type Price struct {
+	// Input 未命中缓存的输入
+	Input float64 `json:"input"`
+
+	// Output 输出 (包括思考/推理 token)
+	Output float64 `json:"output"`
+
+	// CacheRead 从缓存读取的输入，0 时按 Input 计价
+	CacheRead float64 `json:"cache_read,omitempty"`
+
+	// CacheWrite 写入缓存的输入，0 时按 Input 计价
+	CacheWrite float64 `json:"cache_write,omitempty"`
+}
+
+// Cost 计算一次调用的费用 (美元)
+func (p Price) Cost(usage *llm.Usage) float64 {
+	if usage == nil {
+		return 0
+	}
+	cacheRead, cacheWrite := p.CacheRead, p.CacheWrite
+	if cacheRead == 0 {
+		cacheRead = p.Input
+	}
+	if cacheWrite == 0 {
+		cacheWrite = p.Input
+	}
+	uncached := usage.InputTokens - usage.CacheReadTokens - usage.CacheCreationTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	return (float64(uncached)*p.Input +
+		float64(usage.CacheReadTokens)*cacheRead +
+		float64(usage.CacheCreationTokens)*cacheWrite +
+		float64(usage.OutputTokens)*p.Output) / 1e6
+}
+
+// PriceTable 模型名称前缀 -> 价格，最长前缀优先
+type PriceTable map[string]Price
+
+// Lookup 查找模型价格，忽略 "provider/" 前缀
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var best string
+	for prefix := range t {
+		if strings.HasPrefix(name, strings.ToLower(prefix)) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t[best], true
+}
+
+// DefaultPrices 常用模型的参考价格，以提供商官网为准，可用 WithPrices 覆盖或补充
+// 本地模型不计费
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		// Anthropic: 缓存读取 0.1 倍，写入 (5 分钟) 1.25 倍
+		"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+		"claude-haiku-4":    {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+
+		// OpenAI: 自动缓存，不收取写入费用
+		"gpt-4o":       {Input: 2.5, Output: 10, CacheRead: 1.25},
+		"gpt-4o-mini":  {Input: 0.15, Output: 0.6, CacheRead: 0.075},
+		"gpt-4.1":      {Input: 2, Output: 8, CacheRead: 0.5},
+		"gpt-4.1-mini": {Input: 0.4, Output: 1.6, CacheRead: 0.1},
+		"gpt-4.1-nano": {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+		"gpt-5":        {Input: 1.25, Output: 10, CacheRead: 0.125},
+		"gpt-5-mini":   {Input: 0.25, Output: 2, CacheRead: 0.025},
+		"o3":           {Input: 2, Output: 8, CacheRead: 0.5},
+		"o3-mini":      {Input: 1.1, Output: 4.4, CacheRead: 0.55},
+		"o4-mini":      {Input: 1.1, Output: 4.4, CacheRead: 0.275},
+
+		// Gemini (200K token 以内的价格)
+		"gemini-2.5-pro":   {Input: 1.25, Output: 10, CacheRead: 0.31},
+		"gemini-2.5-flash": {Input: 0.3, Output: 2.5, CacheRead: 0.075},
+		"gemini-2.0-flash": {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+	}
+}
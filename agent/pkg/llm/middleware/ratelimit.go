@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/tokenizer"
+)
+
+// RateLimitOption 限流配置选项
+type RateLimitOption func(*rateLimitClient)
+
+// WithRequestsPerMinute 设置每分钟请求数上限
+func WithRequestsPerMinute(n int) RateLimitOption {
+	return func(r *rateLimitClient) {
+		if n > 0 {
+			r.requests = newBucket(float64(n), time.Minute, r.now)
+		}
+	}
+}
+
+// WithTokensPerMinute 设置每分钟 token 数上限
+// 请求前按估算值扣除，收到响应后按 Usage 实际值多退少补
+func WithTokensPerMinute(n int) RateLimitOption {
+	return func(r *rateLimitClient) {
+		if n > 0 {
+			r.tokens = newBucket(float64(n), time.Minute, r.now)
+		}
+	}
+}
+
+// WithTokenCounter 设置请求前估算 token 数的计数器 (默认 tokenizer.Heuristic)
+func WithTokenCounter(counter llm.TokenCounter) RateLimitOption {
+	return func(r *rateLimitClient) {
+		if counter != nil {
+			r.estimate = countRequest(counter)
+		}
+	}
+}
+
+// RateLimit 创建令牌桶限流中间件
+// 超过限额的请求会等待，直到桶中有足够的令牌或上下文取消
+func RateLimit(opts ...RateLimitOption) Middleware {
+	return func(next llm.ChatCompleter) llm.ChatCompleter {
+		r := &rateLimitClient{
+			base:     base{next: next},
+			estimate: countRequest(tokenizer.Heuristic{}),
+			now:      time.Now,
+			sleep:    sleep,
+		}
+		for _, opt := range opts {
+			opt(r)
+		}
+		return r
+	}
+}
+
+// countRequest 返回按 counter 估算请求输入 token 数的函数
+func countRequest(counter llm.TokenCounter) func(req *llm.ChatRequest) int {
+	return func(req *llm.ChatRequest) int {
+		return llm.CountRequestTokens(counter, req)
+	}
+}
+
+// rateLimitClient 限流中间件
+type rateLimitClient struct {
+	base
+	requests *bucket
+	tokens   *bucket
+	estimate func(req *llm.ChatRequest) int
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Complete 等待限额后发送请求
+func (r *rateLimitClient) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	reserved, err := r.acquire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.next.Complete(ctx, req)
+	if resp != nil {
+		r.settle(reserved, resp.Usage)
+	}
+	return resp, err
+}
+
+// Stream 等待限额后发送流式请求，流结束时按实际用量结算
+func (r *rateLimitClient) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	reserved, err := r.acquire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := r.next.Stream(ctx, req)
+	if err != nil || r.tokens == nil {
+		return ch, err
+	}
+
+	out := make(chan *llm.StreamChunk, cap(ch))
+	go func() {
+		defer close(out)
+		// 多个数据块可能都带有用量统计，以最后一个为准，流结束时只结算一次
+		var usage *llm.Usage
+		forward(ctx, ch, out, func(chunk *llm.StreamChunk) {
+			if u := chunkUsage(chunk); u != nil {
+				usage = u
+			}
+		})
+		r.settle(reserved, usage)
+	}()
+	return out, nil
+}
+
+// acquire 预留一个请求和估算的 token，返回实际预留的 token 数
+// 估算值超过桶容量时只预留容量，差额在 settle 时按实际用量补扣
+func (r *rateLimitClient) acquire(ctx context.Context, req *llm.ChatRequest) (float64, error) {
+	var wait time.Duration
+	reserved := 0.0
+	if r.requests != nil {
+		_, wait = r.requests.reserve(1)
+	}
+	if r.tokens != nil {
+		var w time.Duration
+		reserved, w = r.tokens.reserve(float64(r.estimate(req)))
+		if w > wait {
+			wait = w
+		}
+	}
+	if err := r.sleep(ctx, wait); err != nil {
+		// 取消时归还预留的令牌
+		if r.requests != nil {
+			r.requests.adjust(1)
+		}
+		if r.tokens != nil {
+			r.tokens.adjust(reserved)
+		}
+		return 0, err
+	}
+	return reserved, nil
+}
+
+// settle 按实际用量结算预留的 token
+func (r *rateLimitClient) settle(reserved float64, usage *llm.Usage) {
+	if r.tokens == nil || usage == nil {
+		return
+	}
+	actual := usage.TotalTokens
+	if actual == 0 {
+		actual = usage.InputTokens + usage.OutputTokens
+	}
+	r.tokens.adjust(reserved - float64(actual))
+}
+
+// chunkUsage 返回数据块中的用量统计
+func chunkUsage(chunk *llm.StreamChunk) *llm.Usage {
+	if chunk.Response != nil && chunk.Response.Usage != nil {
+		return chunk.Response.Usage
+	}
+	return chunk.Usage
+}
+
+// bucket 令牌桶
+// 预留令牌时允许余额为负，调用者按返回的时间等待，保证先到先得
+type bucket struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64 // 每秒补充的令牌数
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+// newBucket 创建令牌桶，每个 period 补满 capacity 个令牌
+func newBucket(capacity float64, period time.Duration, now func() time.Time) *bucket {
+	return &bucket{
+		capacity: capacity,
+		rate:     capacity / period.Seconds(),
+		tokens:   capacity,
+		last:     now(),
+		now:      now,
+	}
+}
+
+// refill 按经过的时间补充令牌 (调用者持有锁)
+func (b *bucket) refill() {
+	now := b.now()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+}
+
+// reserve 预留 n 个令牌，返回实际预留的令牌数和需要等待的时间
+// n 超过容量时只预留容量，避免永远等不到
+func (b *bucket) reserve(n float64) (float64, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if n > b.capacity {
+		n = b.capacity
+	}
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return n, 0
+	}
+	return n, time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// adjust 归还 (n > 0) 或追加扣除 (n < 0) 令牌
+func (b *bucket) adjust(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens += n
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+const (
+	// DefaultMaxRetries 默认最大重试次数
+	DefaultMaxRetries = 3
+	// DefaultBaseDelay 默认首次重试等待时间
+	DefaultBaseDelay = time.Second
+	// DefaultMaxDelay 默认最长退避时间 (不限制 RetryAfter)
+	DefaultMaxDelay = 30 * time.Second
+)
+
+// RetryOption 重试配置选项
+type RetryOption func(*retryClient)
+
+// WithMaxRetries 设置最大重试次数 (不含首次请求)
+func WithMaxRetries(n int) RetryOption {
+	return func(r *retryClient) {
+		if n >= 0 {
+			r.maxRetries = n
+		}
+	}
+}
+
+// WithBackoff 设置指数退避的首次等待时间和最长等待时间
+func WithBackoff(baseDelay, maxDelay time.Duration) RetryOption {
+	return func(r *retryClient) {
+		if baseDelay > 0 {
+			r.baseDelay = baseDelay
+		}
+		if maxDelay > 0 {
+			r.maxDelay = maxDelay
+		}
+	}
+}
+
+// WithRetryIf 设置判断错误是否重试的函数 (默认 IsTransient)
+func WithRetryIf(fn func(error) bool) RetryOption {
+	return func(r *retryClient) {
+		r.shouldRetry = fn
+	}
+}
+
+// WithOnRetry 设置重试回调，attempt 从 1 开始
+func WithOnRetry(fn func(attempt int, err error, delay time.Duration)) RetryOption {
+	return func(r *retryClient) {
+		r.onRetry = fn
+	}
+}
+
+// Retry 创建重试中间件
+//
+// 可重试的错误 (速率限制、过载、服务器错误、超时和网络错误) 按指数退避重试，
+// 等待时间加入随机抖动；LLMError.RetryAfter 大于退避时间时按 RetryAfter 等待。
+// 流式请求只在第一个数据块之前重试。
+func Retry(opts ...RetryOption) Middleware {
+	return func(next llm.ChatCompleter) llm.ChatCompleter {
+		return newRetryClient(next, opts)
+	}
+}
+
+// RetryEmbedder 为向量化客户端添加重试，选项和退避策略与 Retry 相同
+// 分批请求时整个 Embed 调用作为一次尝试
+func RetryEmbedder(next llm.Embedder, opts ...RetryOption) llm.Embedder {
+	return &retryEmbedder{Embedder: next, retry: newRetryClient(nil, opts)}
+}
+
+// newRetryClient 创建重试中间件
+func newRetryClient(next llm.ChatCompleter, opts []RetryOption) *retryClient {
+	r := &retryClient{
+		base:        base{next: next},
+		maxRetries:  DefaultMaxRetries,
+		baseDelay:   DefaultBaseDelay,
+		maxDelay:    DefaultMaxDelay,
+		shouldRetry: IsTransient,
+		sleep:       sleep,
+		jitter:      rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// retryClient 重试中间件
+type retryClient struct {
+	base
+	maxRetries  int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	shouldRetry func(error) bool
+	onRetry     func(attempt int, err error, delay time.Duration)
+
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func() float64
+}
+
+// Complete 发送请求，失败时按退避策略重试
+func (r *retryClient) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := r.next.Complete(ctx, cloneRequest(req))
+		if err == nil {
+			return resp, nil
+		}
+		if werr := r.wait(ctx, attempt, err); werr != nil {
+			return nil, werr
+		}
+	}
+}
+
+// Stream 发送流式请求，第一个数据块之前失败时重试
+func (r *retryClient) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	for attempt := 0; ; attempt++ {
+		ch, err := openStream(ctx, r.next, cloneRequest(req))
+		if err == nil {
+			return ch, nil
+		}
+		if werr := r.wait(ctx, attempt, err); werr != nil {
+			return nil, werr
+		}
+	}
+}
+
+// wait 判断是否重试并等待，不再重试时返回原错误
+func (r *retryClient) wait(ctx context.Context, attempt int, err error) error {
+	if attempt >= r.maxRetries || ctx.Err() != nil || !r.shouldRetry(err) {
+		return err
+	}
+	delay := r.delay(attempt, err)
+	if r.onRetry != nil {
+		r.onRetry(attempt+1, err, delay)
+	}
+	if serr := r.sleep(ctx, delay); serr != nil {
+		return err
+	}
+	return nil
+}
+
+// delay 计算第 attempt 次失败后的等待时间
+// 退避时间为 baseDelay * 2^attempt (不超过 maxDelay)，取其 50%~100% 作为抖动
+func (r *retryClient) delay(attempt int, err error) time.Duration {
+	backoff := r.baseDelay << attempt
+	if backoff <= 0 || backoff > r.maxDelay {
+		backoff = r.maxDelay
+	}
+	delay := backoff/2 + time.Duration(r.jitter()*float64(backoff/2))
+
+	if llmErr, ok := llm.AsLLMError(err); ok && llmErr.RetryAfter > 0 {
+		if retryAfter := time.Duration(llmErr.RetryAfter) * time.Second; retryAfter > delay {
+			delay = retryAfter
+		}
+	}
+	return delay
+}
+
+// retryEmbedder 向量化重试，Dimensions 和 ModelName 转发给内层
+type retryEmbedder struct {
+	llm.Embedder
+	retry *retryClient
+}
+
+// Embed 计算向量，失败时按退避策略重试
+func (r *retryEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	for attempt := 0; ; attempt++ {
+		vectors, err := r.Embedder.Embed(ctx, texts)
+		if err == nil {
+			return vectors, nil
+		}
+		if werr := r.retry.wait(ctx, attempt, err); werr != nil {
+			return nil, werr
+		}
+	}
+}
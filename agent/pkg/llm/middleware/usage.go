@@ -0,0 +1,577 @@
+package middleware
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// ErrBudgetExceeded 预算已用完，请求未发送
+var ErrBudgetExceeded = errors.New("预算已用完")
+
+// BudgetScope 预算范围
+type BudgetScope string
+
+const (
+	// BudgetScopeSession 单个会话的预算
+	BudgetScopeSession BudgetScope = "session"
+	// BudgetScopeDay 每天的预算 (所有会话合计)
+	BudgetScopeDay BudgetScope = "day"
+)
+
+// BudgetExceededError 预算超出错误
+// errors.Is(err, ErrBudgetExceeded) 为 true，不会被 Retry 重试
+type BudgetExceededError struct {
+	// Scope 超出的预算范围
+	Scope BudgetScope
+
+	// Key 会话 ID 或日期 (2006-01-02)
+	Key string
+
+	// Limit 预算 (美元)
+	Limit float64
+
+	// Spent 已花费 (美元)
+	Spent float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s: %s %s 已花费 $%.4f，预算 $%.4f", ErrBudgetExceeded, e.Scope, e.Key, e.Spent, e.Limit)
+}
+
+// Is 与 ErrBudgetExceeded 匹配
+func (e *BudgetExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// 会话和标签通过 context 传递
+type (
+	sessionKey struct{}
+	tagsKey    struct{}
+)
+
+// WithSession 返回带会话 ID 的 context，用于按会话统计用量和预算
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFrom 返回 context 中的会话 ID
+func SessionFrom(ctx context.Context) string {
+	session, _ := ctx.Value(sessionKey{}).(string)
+	return session
+}
+
+// WithTags 返回追加了标签的 context，用于按用途 (如 "summarize"、"agent") 统计用量
+func WithTags(ctx context.Context, tags ...string) context.Context {
+	merged := append(append([]string(nil), TagsFrom(ctx)...), tags...)
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// TagsFrom 返回 context 中的标签
+func TagsFrom(ctx context.Context) []string {
+	tags, _ := ctx.Value(tagsKey{}).([]string)
+	return tags
+}
+
+// UsageRecord 一次调用的用量记录
+type UsageRecord struct {
+	Time                time.Time `json:"time"`
+	Session             string    `json:"session,omitempty"`
+	Tags                []string  `json:"tags,omitempty"`
+	Provider            string    `json:"provider,omitempty"`
+	Model               string    `json:"model"`
+	InputTokens         int       `json:"input_tokens"`
+	OutputTokens        int       `json:"output_tokens"`
+	CacheReadTokens     int       `json:"cache_read_tokens,omitempty"`
+	CacheCreationTokens int       `json:"cache_creation_tokens,omitempty"`
+	ReasoningTokens     int       `json:"reasoning_tokens,omitempty"`
+
+	// Cost 费用 (美元)，价格表中没有该模型时为 0
+	Cost float64 `json:"cost_usd"`
+
+	// Priced 价格表中是否有该模型
+	Priced bool `json:"priced"`
+}
+
+// UsageTotals 用量合计
+type UsageTotals struct {
+	Requests            int     `json:"requests"`
+	InputTokens         int     `json:"input_tokens"`
+	OutputTokens        int     `json:"output_tokens"`
+	CacheReadTokens     int     `json:"cache_read_tokens"`
+	CacheCreationTokens int     `json:"cache_creation_tokens"`
+	ReasoningTokens     int     `json:"reasoning_tokens"`
+	Cost                float64 `json:"cost_usd"`
+}
+
+// add 累加一条记录
+func (t *UsageTotals) add(r *UsageRecord) {
+	t.Requests++
+	t.InputTokens += r.InputTokens
+	t.OutputTokens += r.OutputTokens
+	t.CacheReadTokens += r.CacheReadTokens
+	t.CacheCreationTokens += r.CacheCreationTokens
+	t.ReasoningTokens += r.ReasoningTokens
+	t.Cost += r.Cost
+}
+
+// UsageFilter 用量查询条件，零值字段不过滤
+type UsageFilter struct {
+	Session  string
+	Tag      string
+	Provider string
+	Model    string
+
+	// Since 包含，Until 不包含
+	Since time.Time
+	Until time.Time
+}
+
+// match 检查记录是否满足条件
+func (f UsageFilter) match(r *UsageRecord) bool {
+	if f.Session != "" && r.Session != f.Session {
+		return false
+	}
+	if f.Provider != "" && r.Provider != f.Provider {
+		return false
+	}
+	if f.Model != "" && r.Model != f.Model {
+		return false
+	}
+	if f.Tag != "" && !containsTag(r.Tags, f.Tag) {
+		return false
+	}
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// UsageGroup 用量分组维度
+type UsageGroup string
+
+const (
+	GroupByModel    UsageGroup = "model"
+	GroupByProvider UsageGroup = "provider"
+	GroupBySession  UsageGroup = "session"
+	GroupByTag      UsageGroup = "tag" // 带多个标签的记录计入每个标签
+	GroupByDay      UsageGroup = "day"
+)
+
+const (
+	// DefaultMaxRecords 默认在内存中保留的记录数
+	DefaultMaxRecords = 10000
+
+	// DefaultSessionTTL 默认的会话花费保留时间，超过该时间没有调用的会话不再计入会话预算
+	DefaultSessionTTL = 24 * time.Hour
+)
+
+// UsageOption 用量统计配置选项
+type UsageOption func(*UsageTracker)
+
+// WithPrices 设置价格表，与默认价格表合并，同名前缀覆盖默认价格
+func WithPrices(prices PriceTable) UsageOption {
+	return func(t *UsageTracker) {
+		for prefix, price := range prices {
+			t.prices[prefix] = price
+		}
+	}
+}
+
+// WithSessionBudget 设置每个会话的预算 (美元)，没有会话 ID 的请求不受限制
+func WithSessionBudget(usd float64) UsageOption {
+	return func(t *UsageTracker) {
+		t.sessionBudget = usd
+	}
+}
+
+// WithDailyBudget 设置每天的预算 (美元)，按 WithLocation 的时区划分日期
+func WithDailyBudget(usd float64) UsageOption {
+	return func(t *UsageTracker) {
+		t.dailyBudget = usd
+	}
+}
+
+// WithLocation 设置划分日期的时区 (默认 time.Local)
+func WithLocation(loc *time.Location) UsageOption {
+	return func(t *UsageTracker) {
+		if loc != nil {
+			t.location = loc
+		}
+	}
+}
+
+// WithMaxRecords 设置在内存中保留的最近记录数 (默认 DefaultMaxRecords)
+// 超出时丢弃最早的记录，Records、Total、GroupBy 和导出只包含保留的记录；
+// 0 不保留记录，只通过 WithOnRecord 输出；负数不限制
+func WithMaxRecords(n int) UsageOption {
+	return func(t *UsageTracker) {
+		t.maxRecords = n
+	}
+}
+
+// WithSessionTTL 设置会话花费的保留时间 (默认 DefaultSessionTTL)
+// 会话超过该时间没有调用时丢弃其花费，之后按新会话计算预算
+func WithSessionTTL(d time.Duration) UsageOption {
+	return func(t *UsageTracker) {
+		if d > 0 {
+			t.sessionTTL = d
+		}
+	}
+}
+
+// WithOnRecord 设置记录回调，可用于实时写入账本文件或上报监控
+// 回调在请求所在的 goroutine 中执行，不持有锁
+func WithOnRecord(fn func(UsageRecord)) UsageOption {
+	return func(t *UsageTracker) {
+		t.onRecord = fn
+	}
+}
+
+// UsageTracker 记录每次调用的 token 用量和费用，并执行预算限制
+// 同一个 UsageTracker 可以通过 TrackUsage 包装多个客户端，合并统计
+// 内存中只保留最近的记录、当天的花费和仍在活动的会话的花费
+type UsageTracker struct {
+	prices        PriceTable
+	sessionBudget float64
+	dailyBudget   float64
+	location      *time.Location
+	maxRecords    int
+	sessionTTL    time.Duration
+	onRecord      func(UsageRecord)
+	now           func() time.Time
+
+	mu           sync.Mutex
+	records      []UsageRecord
+	sessions     map[string]*sessionSpent
+	lastSweep    time.Time
+	spentDay     string  // 当前统计的日期
+	spentDayCost float64 // spentDay 当天的花费
+}
+
+// sessionSpent 会话的花费
+type sessionSpent struct {
+	cost float64
+	last time.Time // 最近一次调用的时间
+}
+
+// NewUsageTracker 创建用量统计器
+func NewUsageTracker(opts ...UsageOption) *UsageTracker {
+	t := &UsageTracker{
+		prices:     DefaultPrices(),
+		location:   time.Local,
+		maxRecords: DefaultMaxRecords,
+		sessionTTL: DefaultSessionTTL,
+		now:        time.Now,
+		sessions:   make(map[string]*sessionSpent),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// TrackUsage 创建用量统计中间件
+// 请求前检查预算，超出时返回 *BudgetExceededError；收到响应后按 Usage 记录用量。
+// 预算在请求前检查，最后一次请求可能使花费略微超出预算。
+// 包装 Fallback 时用量记在实际成功的提供商上；流式请求的 ctx 取消后只记录已收到的用量。
+func TrackUsage(tracker *UsageTracker) Middleware {
+	return func(next llm.ChatCompleter) llm.ChatCompleter {
+		return &usageClient{base: base{next: next}, tracker: tracker}
+	}
+}
+
+// Record 手动记录一次调用，返回计算费用后的记录
+func (t *UsageTracker) Record(ctx context.Context, provider, model string, usage *llm.Usage) UsageRecord {
+	record := UsageRecord{
+		Time:     t.now(),
+		Session:  SessionFrom(ctx),
+		Tags:     TagsFrom(ctx),
+		Provider: provider,
+		Model:    model,
+	}
+	if usage != nil {
+		record.InputTokens = usage.InputTokens
+		record.OutputTokens = usage.OutputTokens
+		record.CacheReadTokens = usage.CacheReadTokens
+		record.CacheCreationTokens = usage.CacheCreationTokens
+		record.ReasoningTokens = usage.ReasoningTokens
+	}
+	if price, ok := t.prices.Lookup(model); ok {
+		record.Cost = price.Cost(usage)
+		record.Priced = true
+	}
+
+	t.mu.Lock()
+	t.keep(record)
+	if record.Session != "" && t.sessionBudget > 0 {
+		s := t.sessions[record.Session]
+		if s == nil {
+			s = &sessionSpent{}
+			t.sessions[record.Session] = s
+		}
+		s.cost += record.Cost
+		s.last = record.Time
+	}
+	if day := t.day(record.Time); day != t.spentDay {
+		t.spentDay, t.spentDayCost = day, 0
+	}
+	t.spentDayCost += record.Cost
+	t.sweepSessions(record.Time)
+	t.mu.Unlock()
+
+	if t.onRecord != nil {
+		t.onRecord(record)
+	}
+	return record
+}
+
+// CheckBudget 检查 context 对应的会话和当天是否还有预算
+func (t *UsageTracker) CheckBudget(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if session := SessionFrom(ctx); session != "" && t.sessionBudget > 0 {
+		if s := t.sessions[session]; s != nil && now.Sub(s.last) < t.sessionTTL && s.cost >= t.sessionBudget {
+			return &BudgetExceededError{Scope: BudgetScopeSession, Key: session, Limit: t.sessionBudget, Spent: s.cost}
+		}
+	}
+	if t.dailyBudget > 0 {
+		day := t.day(now)
+		if day == t.spentDay && t.spentDayCost >= t.dailyBudget {
+			return &BudgetExceededError{Scope: BudgetScopeDay, Key: day, Limit: t.dailyBudget, Spent: t.spentDayCost}
+		}
+	}
+	return nil
+}
+
+// keep 保存记录，超出 maxRecords 时丢弃最早的记录 (调用者持有锁)
+func (t *UsageTracker) keep(record UsageRecord) {
+	if t.maxRecords == 0 {
+		return
+	}
+	t.records = append(t.records, record)
+	if t.maxRecords > 0 && len(t.records) > t.maxRecords {
+		// 重新切片，append 扩容时只复制保留的记录
+		t.records = t.records[len(t.records)-t.maxRecords:]
+	}
+}
+
+// sweepSessions 丢弃超过 sessionTTL 没有调用的会话，每个 TTL 周期最多扫描一次 (调用者持有锁)
+func (t *UsageTracker) sweepSessions(now time.Time) {
+	if now.Sub(t.lastSweep) < t.sessionTTL {
+		return
+	}
+	t.lastSweep = now
+	for session, s := range t.sessions {
+		if now.Sub(s.last) >= t.sessionTTL {
+			delete(t.sessions, session)
+		}
+	}
+}
+
+// day 返回时间所在的日期
+func (t *UsageTracker) day(tm time.Time) string {
+	return tm.In(t.location).Format("2006-01-02")
+}
+
+// Records 返回满足条件的记录副本
+func (t *UsageTracker) Records(filter UsageFilter) []UsageRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var result []UsageRecord
+	for i := range t.records {
+		if filter.match(&t.records[i]) {
+			result = append(result, t.records[i])
+		}
+	}
+	return result
+}
+
+// Total 返回满足条件的记录合计
+func (t *UsageTracker) Total(filter UsageFilter) UsageTotals {
+	var totals UsageTotals
+	for _, r := range t.Records(filter) {
+		totals.add(&r)
+	}
+	return totals
+}
+
+// GroupBy 按维度分组合计，没有会话或标签的记录归入空字符串
+func (t *UsageTracker) GroupBy(group UsageGroup, filter UsageFilter) map[string]UsageTotals {
+	result := make(map[string]UsageTotals)
+	add := func(key string, r *UsageRecord) {
+		totals := result[key]
+		totals.add(r)
+		result[key] = totals
+	}
+
+	for _, r := range t.Records(filter) {
+		r := r
+		switch group {
+		case GroupByModel:
+			add(r.Model, &r)
+		case GroupByProvider:
+			add(r.Provider, &r)
+		case GroupBySession:
+			add(r.Session, &r)
+		case GroupByDay:
+			add(t.day(r.Time), &r)
+		case GroupByTag:
+			if len(r.Tags) == 0 {
+				add("", &r)
+			}
+			for _, tag := range r.Tags {
+				add(tag, &r)
+			}
+		}
+	}
+	return result
+}
+
+// ExportJSONL 把满足条件的记录按每行一个 JSON 对象写入 w
+func (t *UsageTracker) ExportJSONL(w io.Writer, filter UsageFilter) error {
+	encoder := json.NewEncoder(w)
+	for _, r := range t.Records(filter) {
+		if err := encoder.Encode(r); err != nil {
+			return fmt.Errorf("写入用量记录失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// csvHeader CSV 导出的列
+var csvHeader = []string{
+	"time", "session", "tags", "provider", "model",
+	"input_tokens", "output_tokens", "cache_read_tokens", "cache_creation_tokens", "reasoning_tokens",
+	"cost_usd", "priced",
+}
+
+// ExportCSV 把满足条件的记录写入 CSV，多个标签用分号分隔
+func (t *UsageTracker) ExportCSV(w io.Writer, filter UsageFilter) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("写入用量记录失败: %w", err)
+	}
+
+	records := t.Records(filter)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	for _, r := range records {
+		row := []string{
+			r.Time.Format(time.RFC3339),
+			r.Session,
+			strings.Join(r.Tags, ";"),
+			r.Provider,
+			r.Model,
+			strconv.Itoa(r.InputTokens),
+			strconv.Itoa(r.OutputTokens),
+			strconv.Itoa(r.CacheReadTokens),
+			strconv.Itoa(r.CacheCreationTokens),
+			strconv.Itoa(r.ReasoningTokens),
+			strconv.FormatFloat(r.Cost, 'f', 6, 64),
+			strconv.FormatBool(r.Priced),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("写入用量记录失败: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// usageClient 用量统计中间件
+type usageClient struct {
+	base
+	tracker *UsageTracker
+}
+
+// Complete 检查预算后发送请求并记录用量
+func (u *usageClient) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	if err := u.tracker.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	s := &served{}
+	resp, err := u.next.Complete(withServed(ctx, s), req)
+	if resp != nil && resp.Usage != nil {
+		u.tracker.Record(ctx, u.provider(s), u.model(req, resp, s), resp.Usage)
+	}
+	return resp, err
+}
+
+// Stream 检查预算后发送流式请求，流结束时记录用量
+func (u *usageClient) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	if err := u.tracker.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	s := &served{}
+	ch, err := u.next.Stream(withServed(ctx, s), req)
+	if err != nil {
+		return ch, err
+	}
+
+	out := make(chan *llm.StreamChunk, cap(ch))
+	go func() {
+		defer close(out)
+		// 以最后一个带用量的数据块为准，流结束时只记录一次
+		var usage *llm.Usage
+		var resp *llm.ChatResponse
+		forward(ctx, ch, out, func(chunk *llm.StreamChunk) {
+			if u := chunkUsage(chunk); u != nil {
+				usage = u
+			}
+			if chunk.Response != nil {
+				resp = chunk.Response
+			}
+		})
+		if usage != nil {
+			u.tracker.Record(ctx, u.provider(s), u.model(req, resp, s), usage)
+		}
+	}()
+	return out, nil
+}
+
+// provider 返回实际提供服务的提供商，经过 Fallback 时为成功的那个提供商
+func (u *usageClient) provider(s *served) string {
+	if s.provider != "" {
+		return s.provider
+	}
+	return u.ProviderName()
+}
+
+// model 返回实际使用的模型，依次取响应、请求和实际提供服务的客户端的模型名称
+func (u *usageClient) model(req *llm.ChatRequest, resp *llm.ChatResponse, s *served) string {
+	if resp != nil && resp.Model != "" {
+		return resp.Model
+	}
+	if req.Model != "" {
+		return req.Model
+	}
+	if s.model != "" {
+		return s.model
+	}
+	return u.ModelName()
+}
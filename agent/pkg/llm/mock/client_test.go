@@ -239,3 +239,35 @@ func TestClient_AddResponse(t *testing.T) {
 		t.Errorf("expected InputTokens 10, got %d", result.Usage.InputTokens)
 	}
 }
+
+func TestEmbedder_Deterministic(t *testing.T) {
+	embedder := NewEmbedder(0)
+	if embedder.Dimensions() != DefaultEmbeddingDimensions {
+		t.Errorf("unexpected dimensions: %d", embedder.Dimensions())
+	}
+
+	texts := []string{"The cat sat on the mat", "the cat sat on a mat!", "量子计算机的纠错码", "今天天气怎么样"}
+	first, err := embedder.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := embedder.Embed(context.Background(), texts)
+	for i := range first {
+		if len(first[i]) != DefaultEmbeddingDimensions || llm.CosineSimilarity(first[i], second[i]) < 0.9999 {
+			t.Errorf("vector %d is not deterministic", i)
+		}
+	}
+
+	// 用词相近的文本相似度更高
+	if similar, different := llm.CosineSimilarity(first[0], first[1]), llm.CosineSimilarity(first[0], first[2]); similar <= different {
+		t.Errorf("expected similar texts to score higher: %v <= %v", similar, different)
+	}
+	if embedder.GetCallCount() != 2 || len(embedder.Inputs[0]) != 4 {
+		t.Errorf("unexpected call records: %d %v", embedder.GetCallCount(), embedder.Inputs)
+	}
+
+	embedder.Error = llm.ErrServerError("boom")
+	if _, err := embedder.Embed(context.Background(), texts); err == nil {
+		t.Error("expected preset error")
+	}
+}
@@ -0,0 +1,134 @@
+package mock
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultEmbeddingDimensions Mock 向量的默认维度
+const DefaultEmbeddingDimensions = 64
+
+// Embedder 确定性的模拟向量化客户端
+// 按词 (中文按字) 做特征哈希，相同文本得到相同向量，用词相近的文本相似度更高
+type Embedder struct {
+	mu sync.Mutex
+
+	// Dims 向量维度
+	Dims int
+
+	// Model 模型名称
+	Model string
+
+	// CallCount 调用计数
+	CallCount int
+
+	// Inputs 每次调用的输入
+	Inputs [][]string
+
+	// Error 预设错误
+	Error error
+}
+
+// NewEmbedder 创建 Mock 向量化客户端，dimensions 不大于 0 时使用默认维度
+func NewEmbedder(dimensions int) *Embedder {
+	if dimensions <= 0 {
+		dimensions = DefaultEmbeddingDimensions
+	}
+	return &Embedder{
+		Dims:  dimensions,
+		Model: "mock-embedding",
+	}
+}
+
+// Embed 实现 llm.Embedder 接口
+func (m *Embedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	m.mu.Lock()
+	m.CallCount++
+	m.Inputs = append(m.Inputs, append([]string(nil), texts...))
+	err := m.Error
+	m.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = m.vector(text)
+	}
+	return vectors, nil
+}
+
+// Dimensions 返回向量维度
+func (m *Embedder) Dimensions() int {
+	return m.Dims
+}
+
+// ModelName 返回模型名称
+func (m *Embedder) ModelName() string {
+	return m.Model
+}
+
+// GetCallCount 获取调用次数
+func (m *Embedder) GetCallCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.CallCount
+}
+
+// vector 计算文本的特征哈希向量并归一化
+func (m *Embedder) vector(text string) []float32 {
+	vec := make([]float32, m.Dims)
+	for _, token := range tokenize(text) {
+		h := fnv.New64a()
+		h.Write([]byte(token))
+		sum := h.Sum64()
+		// 低位决定维度，高位决定符号
+		if sum>>63 == 0 {
+			vec[sum%uint64(m.Dims)]++
+		} else {
+			vec[sum%uint64(m.Dims)]--
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+// tokenize 按空白和标点切分为小写词，中日韩文字每个字作为一个词
+func tokenize(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,87 @@
+package llm
+
+import "strings"
+
+// ModelSpec 模型元数据
+type ModelSpec struct {
+	// ContextWindow 上下文窗口大小 (输入和输出 token 总数)
+	ContextWindow int
+
+	// MaxOutputTokens 单次最大输出 token 数，0 表示未知
+	MaxOutputTokens int
+}
+
+// modelSpecs 已知模型的元数据，按模型名称前缀匹配，最长前缀优先
+var modelSpecs = map[string]ModelSpec{
+	// Anthropic
+	"claude-opus-4":     {ContextWindow: 200000, MaxOutputTokens: 32000},
+	"claude-sonnet-4":   {ContextWindow: 200000, MaxOutputTokens: 64000},
+	"claude-3-7-sonnet": {ContextWindow: 200000, MaxOutputTokens: 64000},
+	"claude-3-5-sonnet": {ContextWindow: 200000, MaxOutputTokens: 8192},
+	"claude-3-5-haiku":  {ContextWindow: 200000, MaxOutputTokens: 8192},
+	"claude-3":          {ContextWindow: 200000, MaxOutputTokens: 4096},
+
+	// OpenAI
+	"gpt-5":         {ContextWindow: 400000, MaxOutputTokens: 128000},
+	"gpt-4.1":       {ContextWindow: 1047576, MaxOutputTokens: 32768},
+	"gpt-4o":        {ContextWindow: 128000, MaxOutputTokens: 16384},
+	"gpt-4-turbo":   {ContextWindow: 128000, MaxOutputTokens: 4096},
+	"gpt-4-32k":     {ContextWindow: 32768, MaxOutputTokens: 4096},
+	"gpt-4":         {ContextWindow: 8192, MaxOutputTokens: 4096},
+	"gpt-3.5-turbo": {ContextWindow: 16385, MaxOutputTokens: 4096},
+	"o1":            {ContextWindow: 200000, MaxOutputTokens: 100000},
+	"o3":            {ContextWindow: 200000, MaxOutputTokens: 100000},
+	"o4-mini":       {ContextWindow: 200000, MaxOutputTokens: 100000},
+
+	// Google
+	"gemini-2.5":     {ContextWindow: 1048576, MaxOutputTokens: 65536},
+	"gemini-2.0":     {ContextWindow: 1048576, MaxOutputTokens: 8192},
+	"gemini-1.5-pro": {ContextWindow: 2097152, MaxOutputTokens: 8192},
+	"gemini-1.5":     {ContextWindow: 1048576, MaxOutputTokens: 8192},
+
+	// 本地模型 (模型支持的最大值，实际窗口取决于服务端配置)
+	"llama3.1":  {ContextWindow: 131072},
+	"llama3.2":  {ContextWindow: 131072},
+	"llama3.3":  {ContextWindow: 131072},
+	"llama3":    {ContextWindow: 8192},
+	"qwen2.5":   {ContextWindow: 32768},
+	"qwen3":     {ContextWindow: 40960},
+	"mistral":   {ContextWindow: 32768},
+	"gemma3":    {ContextWindow: 131072},
+	"deepseek":  {ContextWindow: 65536},
+	"phi4":      {ContextWindow: 16384},
+	"phi3":      {ContextWindow: 131072},
+	"codellama": {ContextWindow: 16384},
+}
+
+// LookupModel 查找模型元数据
+// 忽略 "models/" 和 "openai/" 等路由前缀，按最长前缀匹配
+func LookupModel(model string) (ModelSpec, bool) {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var best string
+	for prefix := range modelSpecs {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelSpec{}, false
+	}
+	return modelSpecs[best], true
+}
+
+// RegisterModel 注册或覆盖模型元数据，名称作为前缀匹配
+// 应在初始化时调用
+func RegisterModel(prefix string, spec ModelSpec) {
+	modelSpecs[strings.ToLower(prefix)] = spec
+}
+
+// ContextWindowFor 返回模型的上下文窗口大小，未知模型返回 0
+func ContextWindowFor(model string) int {
+	spec, _ := LookupModel(model)
+	return spec.ContextWindow
+}
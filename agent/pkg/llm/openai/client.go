@@ -33,6 +33,10 @@ type Config struct {
 	MaxTokens  int
 	Timeout    time.Duration
 	HTTPClient *http.Client
+
+	// 向量化 (Embedder) 使用
+	Dimensions int // 输出维度，只有 text-embedding-3 及以后的模型支持
+	BatchSize  int // 单次请求的最大输入条数
 }
 
 // Option 配置选项函数
@@ -80,6 +84,20 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithDimensions 设置向量维度 (Embedder 使用)
+func WithDimensions(dimensions int) Option {
+	return func(c *Config) {
+		c.Dimensions = dimensions
+	}
+}
+
+// WithBatchSize 设置单次向量化请求的最大输入条数 (Embedder 使用)
+func WithBatchSize(size int) Option {
+	return func(c *Config) {
+		c.BatchSize = size
+	}
+}
+
 // Client OpenAI 客户端
 type Client struct {
 	config *Config
@@ -126,6 +144,16 @@ func (c *Client) ProviderName() string {
 	return "openai"
 }
 
+// ContextWindow 返回模型的上下文窗口大小
+func (c *Client) ContextWindow() int {
+	return llm.ContextWindowFor(c.config.Model)
+}
+
+// SupportsStructuredOutput 支持 response_format 结构化输出
+func (c *Client) SupportsStructuredOutput() bool {
+	return true
+}
+
 // Complete 发送非流式请求
 func (c *Client) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
 	// 填充默认值
@@ -168,11 +196,7 @@ func (c *Client) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatR
 
 	// 检查错误
 	if resp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error != nil {
-			return nil, convertError(errResp.Error, resp.StatusCode)
-		}
-		return nil, fmt.Errorf("请求失败: %s (状态码: %d)", string(respBody), resp.StatusCode)
+		return nil, parseError(resp, respBody)
 	}
 
 	// 解析响应
@@ -222,11 +246,7 @@ func (c *Client) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.
 	if resp.StatusCode != http.StatusOK {
 		defer resp.Body.Close()
 		respBody, _ := io.ReadAll(resp.Body)
-		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error != nil {
-			return nil, convertError(errResp.Error, resp.StatusCode)
-		}
-		return nil, fmt.Errorf("请求失败: %s (状态码: %d)", string(respBody), resp.StatusCode)
+		return nil, parseError(resp, respBody)
 	}
 
 	// 创建输出通道
@@ -246,6 +266,8 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 	scanner := bufio.NewScanner(resp.Body)
 	var accumulatedContent string
 	var accumulatedToolCalls []*llm.ToolCall
+	var toolIndexes []int
+	var accumulatedReasoning string
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -272,6 +294,7 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 				Role:       llm.RoleAssistant,
 				ToolCalls:  accumulatedToolCalls,
 				StopReason: llm.StopReasonEndTurn,
+				Thinking:   reasoningBlocks(accumulatedReasoning),
 			}
 			ch <- llm.NewDoneChunk(finalResp)
 			return
@@ -288,6 +311,12 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 		if len(streamResp.Choices) > 0 {
 			choice := streamResp.Choices[0]
 
+			// 处理推理内容增量
+			if choice.Delta != nil && choice.Delta.ReasoningContent != "" {
+				accumulatedReasoning += choice.Delta.ReasoningContent
+				ch <- llm.NewThinkingChunk(choice.Delta.ReasoningContent)
+			}
+
 			// 处理内容增量
 			if choice.Delta != nil && choice.Delta.Content != "" {
 				accumulatedContent += choice.Delta.Content
@@ -297,16 +326,21 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 			// 处理工具调用增量
 			if choice.Delta != nil && len(choice.Delta.ToolCalls) > 0 {
 				for _, tc := range choice.Delta.ToolCalls {
-					// 查找或创建工具调用
+					// 查找或创建工具调用，有序号时按序号合并
 					found := false
 					for i := range accumulatedToolCalls {
-						if accumulatedToolCalls[i].ID == tc.ID {
+						if (tc.Index != nil && toolIndexes[i] == *tc.Index) || (tc.Index == nil && accumulatedToolCalls[i].ID == tc.ID) {
 							accumulatedToolCalls[i].Function.Arguments += tc.Function.Arguments
 							found = true
 							break
 						}
 					}
 					if !found {
+						index := len(accumulatedToolCalls)
+						if tc.Index != nil {
+							index = *tc.Index
+						}
+						toolIndexes = append(toolIndexes, index)
 						accumulatedToolCalls = append(accumulatedToolCalls, &llm.ToolCall{
 							ID:   tc.ID,
 							Type: tc.Type,
@@ -328,13 +362,10 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 					Role:       llm.RoleAssistant,
 					ToolCalls:  accumulatedToolCalls,
 					StopReason: convertFinishReason(choice.FinishReason),
+					Thinking:   reasoningBlocks(accumulatedReasoning),
 				}
 				if streamResp.Usage != nil {
-					finalResp.Usage = &llm.Usage{
-						InputTokens:  streamResp.Usage.PromptTokens,
-						OutputTokens: streamResp.Usage.CompletionTokens,
-						TotalTokens:  streamResp.Usage.TotalTokens,
-					}
+					finalResp.Usage = convertUsage(streamResp.Usage)
 				}
 				ch <- llm.NewDoneChunk(finalResp)
 				return
@@ -347,6 +378,20 @@ func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk)
 	}
 }
 
+// parseError 解析错误响应，带上 Retry-After 响应头
+// 响应体不是 OpenAI 错误格式时按状态码确定错误类型
+func parseError(resp *http.Response, body []byte) *llm.LLMError {
+	var llmErr *llm.LLMError
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		llmErr = convertError(errResp.Error, resp.StatusCode)
+	} else {
+		llmErr = llm.ErrorFromStatus(resp.StatusCode, fmt.Sprintf("请求失败: %s (状态码: %d)", string(body), resp.StatusCode)).
+			WithProvider("openai")
+	}
+	return llmErr.WithRetryAfter(llm.ParseRetryAfter(resp.Header.Get("Retry-After")))
+}
+
 // convertError 转换错误
 func convertError(err *ErrorDetail, statusCode int) *llm.LLMError {
 	var typ llm.ErrorType
@@ -364,7 +409,12 @@ func convertError(err *ErrorDetail, statusCode int) *llm.LLMError {
 	case "server_error":
 		typ = llm.ErrorTypeServerError
 	default:
-		typ = llm.ErrorTypeServerError
+		// 速率限制等错误的 type 是 "requests"、"tokens"，按状态码确定类型
+		if statusCode > 0 {
+			typ = llm.ErrorFromStatus(statusCode, "").Type
+		} else {
+			typ = llm.ErrorTypeServerError
+		}
 	}
 
 	return llm.NewLLMError(typ, err.Message).
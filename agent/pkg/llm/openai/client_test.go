@@ -0,0 +1,352 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/cassette"
+)
+
+const testModel = "gpt-4o-mini"
+
+// newCassetteClient 使用 testdata/cassettes 中录制的交互创建客户端
+// 重新录制: LLM_CASSETTE_MODE=record OPENAI_API_KEY=... go test ./pkg/llm/openai
+func newCassetteClient(t *testing.T, name string) *Client {
+	t.Helper()
+	rec, err := cassette.New(filepath.Join("testdata", "cassettes", name+".json"))
+	if err != nil {
+		t.Fatalf("加载录制文件失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Save(); err != nil {
+			t.Errorf("保存录制文件失败: %v", err)
+		}
+	})
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		apiKey = "test-key"
+	}
+	client, err := NewClient(
+		WithAPIKey(apiKey),
+		WithModel(testModel),
+		WithMaxTokens(256),
+		WithHTTPClient(rec.Client()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client
+}
+
+func weatherTool() *llm.Tool {
+	return llm.NewTool("get_weather", "查询城市天气", map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"city": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"city"},
+	})
+}
+
+func textRequest() *llm.ChatRequest {
+	return &llm.ChatRequest{
+		System: "用一句话回答",
+		Messages: []*llm.Message{
+			{Role: llm.RoleUser, Content: llm.Text("你好")},
+		},
+	}
+}
+
+func toolRequest() *llm.ChatRequest {
+	return &llm.ChatRequest{
+		Tools: []*llm.Tool{weatherTool()},
+		Messages: []*llm.Message{
+			{Role: llm.RoleUser, Content: llm.Text("北京和上海的天气怎么样？")},
+		},
+	}
+}
+
+// collect 读取流直到结束
+func collect(t *testing.T, ch <-chan *llm.StreamChunk) (string, *llm.ChatResponse) {
+	t.Helper()
+	var content string
+	var done *llm.ChatResponse
+	for chunk := range ch {
+		if chunk.IsError() {
+			t.Fatalf("unexpected error chunk: %v", chunk.Error)
+		}
+		content += chunk.Content
+		if chunk.IsDone() {
+			done = chunk.Response
+		}
+	}
+	if done == nil {
+		t.Fatal("stream ended without done chunk")
+	}
+	return content, done
+}
+
+func TestCassette_CompleteText(t *testing.T) {
+	client := newCassetteClient(t, "complete_text")
+
+	resp, err := client.Complete(context.Background(), textRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "你好！有什么可以帮你的吗？" || resp.StopReason != llm.StopReasonEndTurn {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.Usage == nil || resp.Usage.InputTokens != 19 || resp.Usage.OutputTokens != 9 || resp.Usage.TotalTokens != 28 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestCassette_CompleteToolCall(t *testing.T) {
+	client := newCassetteClient(t, "complete_tool_call")
+
+	resp, err := client.Complete(context.Background(), toolRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StopReason != llm.StopReasonToolUse || len(resp.ToolCalls) != 2 {
+		t.Fatalf("expected two tool calls, got %+v", resp)
+	}
+	for i, city := range []string{"北京", "上海"} {
+		tc := resp.ToolCalls[i]
+		if tc.Function.Name != "get_weather" || tc.Function.Arguments != `{"city":"`+city+`"}` {
+			t.Errorf("unexpected tool call %d: %+v", i, tc.Function)
+		}
+	}
+}
+
+func TestCassette_StreamText(t *testing.T) {
+	client := newCassetteClient(t, "stream_text")
+
+	ch, err := client.Stream(context.Background(), textRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, done := collect(t, ch)
+	if content != "你好！有什么可以帮你的吗？" || done.Content != content {
+		t.Errorf("unexpected content: %q / %q", content, done.Content)
+	}
+	if done.ID == "" || done.StopReason != llm.StopReasonEndTurn {
+		t.Errorf("unexpected done response: %+v", done)
+	}
+}
+
+func TestCassette_StreamToolCall(t *testing.T) {
+	client := newCassetteClient(t, "stream_tool_call")
+
+	ch, err := client.Stream(context.Background(), toolRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, done := collect(t, ch)
+
+	// 后续增量只带 index，需要合并到同一个工具调用
+	if done.StopReason != llm.StopReasonToolUse || len(done.ToolCalls) != 2 {
+		t.Fatalf("expected two tool calls, got %+v", done.ToolCalls)
+	}
+	for i, city := range []string{"北京", "上海"} {
+		tc := done.ToolCalls[i]
+		if tc.ID == "" || tc.Function.Name != "get_weather" || tc.Function.Arguments != `{"city":"`+city+`"}` {
+			t.Errorf("unexpected tool call %d: %+v %+v", i, tc, tc.Function)
+		}
+	}
+}
+
+func TestCassette_RateLimit(t *testing.T) {
+	client := newCassetteClient(t, "error_rate_limit")
+
+	_, err := client.Complete(context.Background(), textRequest())
+	llmErr, ok := llm.AsLLMError(err)
+	if !ok {
+		t.Fatalf("expected LLMError, got %v", err)
+	}
+	if llmErr.Type != llm.ErrorTypeRateLimit || llmErr.RetryAfter != 20 {
+		t.Errorf("unexpected error: %+v", llmErr)
+	}
+}
+
+func TestCassette_RequestMismatch(t *testing.T) {
+	if mode := os.Getenv(cassette.EnvMode); mode != "" && mode != string(cassette.ModeReplay) {
+		t.Skip("只在回放模式下检查")
+	}
+	client := newCassetteClient(t, "complete_text")
+
+	// 请求转换发生变化时回放失败，而不是返回录制的响应
+	req := textRequest()
+	req.System = "另一个系统提示"
+	if _, err := client.Complete(context.Background(), req); !errors.Is(err, cassette.ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+}
+
+func TestConvertResponse_UsageDetailsAndReasoning(t *testing.T) {
+	raw := `{
+		"id": "chatcmpl-1",
+		"model": "o3-mini",
+		"choices": [{"index": 0, "finish_reason": "stop", "message": {
+			"role": "assistant", "content": "42", "reasoning_content": "先算一下"
+		}}],
+		"usage": {
+			"prompt_tokens": 1500, "completion_tokens": 300, "total_tokens": 1800,
+			"prompt_tokens_details": {"cached_tokens": 1280},
+			"completion_tokens_details": {"reasoning_tokens": 256}
+		}
+	}`
+	var oaiResp ChatResponse
+	if err := json.Unmarshal([]byte(raw), &oaiResp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp := convertResponse(&oaiResp)
+	if resp.Content != "42" || resp.ThinkingText() != "先算一下" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	// prompt_tokens 已包含缓存部分，completion_tokens 已包含推理部分
+	u := resp.Usage
+	if u.InputTokens != 1500 || u.CacheReadTokens != 1280 || u.OutputTokens != 300 || u.ReasoningTokens != 256 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+
+	// 推理内容只在响应中出现，不随请求发回
+	req := convertRequest(&llm.ChatRequest{Messages: []*llm.Message{
+		{Role: llm.RoleAssistant, Content: llm.Text("42"), Thinking: resp.Thinking},
+	}})
+	data, _ := json.Marshal(req)
+	if strings.Contains(string(data), "reasoning_content") {
+		t.Errorf("reasoning content should not be sent: %s", data)
+	}
+}
+
+func TestConvertRequest_MultimodalToolResults(t *testing.T) {
+	req := convertRequest(&llm.ChatRequest{Messages: []*llm.Message{
+		{Role: llm.RoleUser, Content: llm.NewContentList(
+			llm.Text("听一下并读这个文件"),
+			llm.AudioFromBase64("UklGRg==", "wav"),
+			llm.PDFFromBytes([]byte("%PDF-")),
+		)},
+		{Role: llm.RoleAssistant, ToolCalls: []*llm.ToolCall{
+			llm.NewToolCall("call_1", "screenshot", "{}"),
+			llm.NewToolCall("call_2", "get_time", "{}"),
+		}},
+		{Role: llm.RoleTool, ToolCallID: "call_1", Name: "screenshot", Content: llm.ImageFromBase64("iVBORw0KGgo=", "image/png")},
+		{Role: llm.RoleTool, ToolCallID: "call_2", Name: "get_time", Content: llm.Text("12:00")},
+		{Role: llm.RoleUser, Content: llm.Text("继续")},
+	}})
+
+	data, err := json.Marshal(req.Messages)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []struct {
+		Role       string      `json:"role"`
+		Content    interface{} `json:"content"`
+		ToolCallID string      `json:"tool_call_id"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 工具消息只有文本，图像在这组工具消息之后作为用户消息发送
+	roles := make([]string, len(got))
+	for i, m := range got {
+		roles[i] = m.Role
+	}
+	if strings.Join(roles, ",") != "user,assistant,tool,tool,user,user" {
+		t.Fatalf("unexpected roles: %v", roles)
+	}
+	if _, ok := got[2].Content.(string); !ok || got[3].Content != "12:00" {
+		t.Errorf("tool messages should be text: %v / %v", got[2].Content, got[3].Content)
+	}
+	media := got[4].Content.([]interface{})
+	if len(media) != 2 || media[1].(map[string]interface{})["type"] != "image_url" {
+		t.Errorf("unexpected media message: %v", media)
+	}
+
+	parts := got[0].Content.([]interface{})
+	types := make([]string, len(parts))
+	for i, p := range parts {
+		types[i] = p.(map[string]interface{})["type"].(string)
+	}
+	if strings.Join(types, ",") != "text,input_audio,file" {
+		t.Errorf("unexpected part types: %v", types)
+	}
+	file := parts[2].(map[string]interface{})["file"].(map[string]interface{})
+	if file["filename"] != "document.pdf" || !strings.HasPrefix(file["file_data"].(string), "data:application/pdf;base64,") {
+		t.Errorf("unexpected file part: %v", file)
+	}
+}
+
+func TestEmbedder_Batches(t *testing.T) {
+	var requests []EmbeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected request: %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req EmbeddingRequest
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &req)
+		requests = append(requests, req)
+
+		// 按倒序返回，客户端需要按 index 排序
+		resp := EmbeddingResponse{Model: req.Model}
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, EmbeddingData{Index: i, Embedding: []float32{float32(len(req.Input[i])), 0}})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	embedder, err := NewEmbedder(WithAPIKey("test-key"), WithBaseURL(server.URL), WithBatchSize(2), WithDimensions(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if embedder.ModelName() != DefaultEmbeddingModel || embedder.Dimensions() != 2 {
+		t.Errorf("unexpected model info: %s/%d", embedder.ModelName(), embedder.Dimensions())
+	}
+
+	vectors, err := embedder.Embed(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vectors) != 3 || vectors[0][0] != 1 || vectors[1][0] != 2 || vectors[2][0] != 3 {
+		t.Errorf("unexpected vectors: %v", vectors)
+	}
+	if len(requests) != 2 || len(requests[0].Input) != 2 || requests[0].Dimensions != 2 || requests[0].EncodingFormat != "float" {
+		t.Errorf("unexpected requests: %+v", requests)
+	}
+
+	if vectors, err := embedder.Embed(context.Background(), nil); err != nil || vectors != nil || len(requests) != 2 {
+		t.Errorf("empty input should not send a request: %v %v", vectors, err)
+	}
+}
+
+func TestEmbedder_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`)
+	}))
+	defer server.Close()
+
+	embedder, _ := NewEmbedder(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	_, err := embedder.Embed(context.Background(), []string{"hi"})
+	llmErr, ok := llm.AsLLMError(err)
+	if !ok || llmErr.Type != llm.ErrorTypeRateLimit || llmErr.RetryAfter != 3 || !llmErr.IsRetryable() {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
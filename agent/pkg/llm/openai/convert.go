@@ -1,6 +1,8 @@
 package openai
 
 import (
+	"fmt"
+
 	"github.com/wangtengda0310/gobee/agent/pkg/llm"
 )
 
@@ -15,22 +17,83 @@ func convertRequest(req *llm.ChatRequest) *ChatRequest {
 		Stop:        req.Stop,
 	}
 
-	// 转换消息
-	oaiReq.Messages = convertMessages(req.Messages)
+	// 转换消息，System 作为第一条系统消息
+	if req.System != "" {
+		oaiReq.Messages = append(oaiReq.Messages, Message{
+			Role:    string(llm.RoleSystem),
+			Content: req.System,
+		})
+	}
+	oaiReq.Messages = append(oaiReq.Messages, convertMessages(req.Messages)...)
 
 	// 转换工具
 	if len(req.Tools) > 0 {
 		oaiReq.Tools = convertTools(req.Tools)
 	}
+	if req.ToolChoice != nil {
+		oaiReq.ToolChoice = convertToolChoice(req.ToolChoice)
+	}
+
+	// 转换结构化输出格式
+	if req.ResponseFormat != nil {
+		oaiReq.ResponseFormat = &ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &JSONSchema{
+				Name:   req.ResponseFormat.Name,
+				Schema: req.ResponseFormat.Schema,
+				Strict: req.ResponseFormat.Strict,
+			},
+		}
+	}
 
 	return oaiReq
 }
 
+// convertToolChoice 转换工具选择策略
+func convertToolChoice(choice *llm.ToolChoice) interface{} {
+	if choice.Type == llm.ToolChoiceTypeTool {
+		return ToolChoiceFunction{
+			Type:     "function",
+			Function: ToolChoiceTarget{Name: choice.Name},
+		}
+	}
+	return string(choice.Type)
+}
+
 // convertMessages 转换消息列表
+// 工具消息只能包含文本，工具结果中的图像、文档和音频在这一组工具消息之后
+// 作为一条用户消息发送
 func convertMessages(messages []*llm.Message) []Message {
-	result := make([]Message, len(messages))
+	result := make([]Message, 0, len(messages))
+	var media []interface{}
 	for i, msg := range messages {
-		result[i] = convertMessage(msg)
+		if msg.Role != llm.RoleTool {
+			result = append(result, convertMessage(msg))
+			continue
+		}
+
+		text, items := llm.SplitMedia(msg.Content)
+		oaiMsg := convertMessage(msg)
+		oaiMsg.Content = text
+		if len(items) > 0 {
+			if text == "" {
+				oaiMsg.Content = "结果见下一条消息"
+			}
+			media = append(media, map[string]interface{}{
+				"type": "text",
+				"text": fmt.Sprintf("工具 %s (%s) 返回的内容:", msg.Name, msg.ToolCallID),
+			})
+			for _, item := range items {
+				media = append(media, item.ToOpenAI())
+			}
+		}
+		result = append(result, oaiMsg)
+
+		// 一组工具消息结束
+		if len(media) > 0 && (i == len(messages)-1 || messages[i+1].Role != llm.RoleTool) {
+			result = append(result, Message{Role: string(llm.RoleUser), Content: media})
+			media = nil
+		}
 	}
 	return result
 }
@@ -46,6 +109,10 @@ func convertMessage(msg *llm.Message) Message {
 	// 转换内容
 	if msg.Content != nil {
 		oaiMsg.Content = msg.Content.ToOpenAI()
+		// 单个图像、文档或音频需要包装为内容部分数组
+		if part, ok := oaiMsg.Content.(map[string]interface{}); ok {
+			oaiMsg.Content = []interface{}{part}
+		}
 	}
 
 	// 转换工具调用
@@ -122,13 +189,36 @@ func convertResponse(resp *ChatResponse) *llm.ChatResponse {
 		}
 	}
 
+	// 推理内容
+	result.Thinking = reasoningBlocks(choice.Message.ReasoningContent)
+
 	// 转换使用统计
-	result.Usage = &llm.Usage{
-		InputTokens:  resp.Usage.PromptTokens,
-		OutputTokens: resp.Usage.CompletionTokens,
-		TotalTokens:  resp.Usage.TotalTokens,
+	result.Usage = convertUsage(&resp.Usage)
+
+	return result
+}
+
+// reasoningBlocks 把推理内容转换为思考块
+func reasoningBlocks(reasoning string) []*llm.ThinkingBlock {
+	if reasoning == "" {
+		return nil
 	}
+	return []*llm.ThinkingBlock{{Thinking: reasoning}}
+}
 
+// convertUsage 转换使用统计，prompt_tokens 已包含缓存命中的部分
+func convertUsage(usage *Usage) *llm.Usage {
+	result := &llm.Usage{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+		TotalTokens:  usage.TotalTokens,
+	}
+	if usage.PromptTokensDetails != nil {
+		result.CacheReadTokens = usage.PromptTokensDetails.CachedTokens
+	}
+	if usage.CompletionTokensDetails != nil {
+		result.ReasoningTokens = usage.CompletionTokensDetails.ReasoningTokens
+	}
 	return result
 }
 
@@ -0,0 +1,135 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+const (
+	// DefaultEmbeddingModel 默认向量模型
+	DefaultEmbeddingModel = "text-embedding-3-small"
+	// DefaultBatchSize 默认单次向量化请求的输入条数 (API 上限为 2048)
+	DefaultBatchSize = 256
+)
+
+// Embedder OpenAI 兼容的 /embeddings 向量化客户端
+// 与 Client 使用相同的配置选项，也可以通过 WithBaseURL 访问其他兼容服务
+type Embedder struct {
+	config *Config
+	client *http.Client
+
+	// dimensions 从响应中得到的向量维度
+	dimensions atomic.Int64
+}
+
+// NewEmbedder 创建向量化客户端
+func NewEmbedder(opts ...Option) (*Embedder, error) {
+	config := &Config{
+		BaseURL:   DefaultBaseURL,
+		Model:     DefaultEmbeddingModel,
+		Timeout:   DefaultTimeout,
+		BatchSize: DefaultBatchSize,
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("API Key 不能为空")
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{
+			Timeout: config.Timeout,
+		}
+	}
+
+	return &Embedder{
+		config: config,
+		client: client,
+	}, nil
+}
+
+// ModelName 返回向量模型名称
+func (e *Embedder) ModelName() string {
+	return e.config.Model
+}
+
+// Dimensions 返回向量维度
+// 依次使用 WithDimensions 的设置、已知模型的默认维度和最近一次响应的维度
+func (e *Embedder) Dimensions() int {
+	if e.config.Dimensions > 0 {
+		return e.config.Dimensions
+	}
+	if n := llm.EmbeddingDimensionsFor(e.config.Model); n > 0 {
+		return n
+	}
+	return int(e.dimensions.Load())
+}
+
+// Embed 批量计算文本向量，超过 BatchSize 时分批请求
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return llm.EmbedBatches(ctx, texts, e.config.BatchSize, e.embed)
+}
+
+// embed 发送一批向量化请求
+func (e *Embedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(&EmbeddingRequest{
+		Model:          e.config.Model,
+		Input:          texts,
+		EncodingFormat: "float",
+		Dimensions:     e.config.Dimensions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", e.config.BaseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+e.config.APIKey)
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseError(resp, respBody)
+	}
+
+	var embResp EmbeddingResponse
+	if err := json.Unmarshal(respBody, &embResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	// 按 index 排序，保证与输入顺序一致
+	sort.Slice(embResp.Data, func(i, j int) bool { return embResp.Data[i].Index < embResp.Data[j].Index })
+	vectors := make([][]float32, len(embResp.Data))
+	for i, d := range embResp.Data {
+		vectors[i] = d.Embedding
+	}
+	if len(vectors) > 0 {
+		e.dimensions.Store(int64(len(vectors[0])))
+	}
+	return vectors, nil
+}
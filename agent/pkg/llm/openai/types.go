@@ -12,6 +12,33 @@ type ChatRequest struct {
 	Tools       []Tool    `json:"tools,omitempty"`
 	Stream      bool      `json:"stream,omitempty"`
 	Stop        []string  `json:"stop,omitempty"`
+
+	ToolChoice     interface{}     `json:"tool_choice,omitempty"` // "auto"、"none"、"required" 或 ToolChoiceFunction
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ToolChoiceFunction 指定调用的函数
+type ToolChoiceFunction struct {
+	Type     string           `json:"type"` // "function"
+	Function ToolChoiceTarget `json:"function"`
+}
+
+// ToolChoiceTarget 指定调用的函数名称
+type ToolChoiceTarget struct {
+	Name string `json:"name"`
+}
+
+// ResponseFormat 响应格式
+type ResponseFormat struct {
+	Type       string      `json:"type"` // "json_schema" 或 "json_object"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema 结构化输出的 Schema
+type JSONSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict,omitempty"`
 }
 
 // Message OpenAI 消息格式
@@ -21,6 +48,8 @@ type Message struct {
 	ToolCallID string      `json:"tool_call_id,omitempty"`
 	Name       string      `json:"name,omitempty"`
 	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
+	// ReasoningContent 兼容服务 (如 DeepSeek) 返回的推理内容，只出现在响应中
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 // ContentPart 多模态内容部分
@@ -51,6 +80,8 @@ type FunctionDef struct {
 
 // ToolCall 工具调用
 type ToolCall struct {
+	// Index 流式增量中工具调用的序号，后续增量只带序号不带 ID
+	Index    *int         `json:"index,omitempty"`
 	ID       string       `json:"id"`
 	Type     string       `json:"type"`
 	Function FunctionCall `json:"function"`
@@ -82,16 +113,50 @@ type Choice struct {
 
 // Delta 流式增量
 type Delta struct {
-	Role      string     `json:"role,omitempty"`
-	Content   string     `json:"content,omitempty"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Role             string     `json:"role,omitempty"`
+	Content          string     `json:"content,omitempty"`
+	ReasoningContent string     `json:"reasoning_content,omitempty"`
+	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
 }
 
 // Usage token 使用统计
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens            int                      `json:"prompt_tokens"`
+	CompletionTokens        int                      `json:"completion_tokens"`
+	TotalTokens             int                      `json:"total_tokens"`
+	PromptTokensDetails     *PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails 输入 token 明细
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// CompletionTokensDetails 输出 token 明细
+type CompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// EmbeddingRequest /embeddings 请求
+type EmbeddingRequest struct {
+	Model          string   `json:"model"`
+	Input          []string `json:"input"`
+	EncodingFormat string   `json:"encoding_format,omitempty"`
+	Dimensions     int      `json:"dimensions,omitempty"`
+}
+
+// EmbeddingResponse /embeddings 响应
+type EmbeddingResponse struct {
+	Model string          `json:"model"`
+	Data  []EmbeddingData `json:"data"`
+	Usage Usage           `json:"usage"`
+}
+
+// EmbeddingData 单条输入的向量
+type EmbeddingData struct {
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
 }
 
 // ErrorResponse 错误响应
@@ -0,0 +1,338 @@
+// Package openairesponses 提供 OpenAI Responses API (/responses) 客户端
+//
+// 与 chat completions 相比，Responses API 的工具定义是平铺格式，
+// 工具调用和工具结果是独立的输入/输出项 (function_call / function_call_output)，
+// 流式响应使用带类型的语义事件 (response.output_text.delta 等)。
+//
+// 默认 store=false，不在服务端保存对话状态，每次请求发送完整历史。
+package openairesponses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// Config Responses API 客户端配置
+type Config struct {
+	APIKey     string
+	BaseURL    string
+	Model      string
+	MaxTokens  int
+	Timeout    time.Duration
+	HTTPClient *http.Client
+}
+
+// Option 配置选项函数
+type Option func(*Config)
+
+// WithAPIKey 设置 API Key
+func WithAPIKey(key string) Option {
+	return func(c *Config) {
+		c.APIKey = key
+	}
+}
+
+// WithBaseURL 设置 API 地址
+func WithBaseURL(url string) Option {
+	return func(c *Config) {
+		c.BaseURL = url
+	}
+}
+
+// WithModel 设置模型
+func WithModel(model string) Option {
+	return func(c *Config) {
+		c.Model = model
+	}
+}
+
+// WithMaxTokens 设置最大 token
+func WithMaxTokens(tokens int) Option {
+	return func(c *Config) {
+		c.MaxTokens = tokens
+	}
+}
+
+// WithTimeout 设置超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.Timeout = timeout
+	}
+}
+
+// WithHTTPClient 设置 HTTP 客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) {
+		c.HTTPClient = client
+	}
+}
+
+// Client Responses API 客户端
+type Client struct {
+	config *Config
+	client *http.Client
+}
+
+// NewClient 创建 Responses API 客户端
+func NewClient(opts ...Option) (*Client, error) {
+	config := &Config{
+		BaseURL:   DefaultBaseURL,
+		Model:     DefaultModel,
+		MaxTokens: DefaultMaxTokens,
+		Timeout:   DefaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("API Key 不能为空")
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{
+			Timeout: config.Timeout,
+		}
+	}
+
+	return &Client{
+		config: config,
+		client: client,
+	}, nil
+}
+
+// ModelName 返回模型名称
+func (c *Client) ModelName() string {
+	return c.config.Model
+}
+
+// ProviderName 返回提供商名称
+func (c *Client) ProviderName() string {
+	return "openai"
+}
+
+// ContextWindow 返回模型的上下文窗口大小
+func (c *Client) ContextWindow() int {
+	return llm.ContextWindowFor(c.config.Model)
+}
+
+// SupportsStructuredOutput 支持 text.format 结构化输出
+func (c *Client) SupportsStructuredOutput() bool {
+	return true
+}
+
+// Complete 发送非流式请求
+func (c *Client) Complete(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	c.fillDefaults(req)
+	req.Stream = false
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	// 解析响应
+	var respResp ChatResponse
+	if err := json.Unmarshal(respBody, &respResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if respResp.Status == "failed" && respResp.Error != nil {
+		return nil, convertError(respResp.Error, resp.StatusCode)
+	}
+
+	return convertResponse(&respResp), nil
+}
+
+// Stream 发送流式请求
+func (c *Client) Stream(ctx context.Context, req *llm.ChatRequest) (<-chan *llm.StreamChunk, error) {
+	c.fillDefaults(req)
+	req.Stream = true
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建输出通道
+	ch := make(chan *llm.StreamChunk, 100)
+
+	// 启动 goroutine 处理 SSE 流
+	go c.processStream(resp, ch)
+
+	return ch, nil
+}
+
+// fillDefaults 填充默认值
+func (c *Client) fillDefaults(req *llm.ChatRequest) {
+	if req.Model == "" {
+		req.Model = c.config.Model
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = c.config.MaxTokens
+	}
+}
+
+// send 发送请求，状态码不是 200 时返回 LLMError
+func (c *Client) send(ctx context.Context, req *llm.ChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(convertRequest(req))
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/responses", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	if req.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, parseError(resp, respBody)
+	}
+	return resp, nil
+}
+
+// processStream 处理 SSE 流
+func (c *Client) processStream(resp *http.Response, ch chan<- *llm.StreamChunk) {
+	defer close(ch)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// 事件类型在 data 中也有，跳过 event: 行
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+
+		var event StreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			ch <- llm.NewErrorChunk(fmt.Errorf("解析流数据失败: %w", err))
+			return
+		}
+
+		switch event.Type {
+		case EventTypeOutputTextDelta:
+			if event.Delta != "" {
+				ch <- llm.NewContentChunk(event.Delta)
+			}
+
+		case EventTypeOutputItemDone:
+			// 函数调用在参数完整后一次性发出
+			if event.Item != nil && event.Item.Type == ItemTypeFunctionCall {
+				ch <- llm.NewToolUseChunk([]*llm.ToolCall{convertFunctionCall(event.Item)})
+			}
+
+		case EventTypeCompleted, EventTypeIncomplete:
+			// 最终响应包含完整的输出和用量统计
+			ch <- llm.NewDoneChunk(convertResponse(event.Response))
+			return
+
+		case EventTypeFailed:
+			detail := &ErrorDetail{Message: "响应生成失败", Type: "server_error"}
+			if event.Response != nil && event.Response.Error != nil {
+				detail = event.Response.Error
+			}
+			ch <- llm.NewErrorChunk(convertError(detail, 0))
+			return
+
+		case EventTypeError:
+			ch <- llm.NewErrorChunk(convertError(&ErrorDetail{
+				Message: event.Message,
+				Code:    unquote(event.Code),
+			}, 0))
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		ch <- llm.NewErrorChunk(fmt.Errorf("读取流失败: %w", err))
+	}
+}
+
+// unquote 把 JSON 字符串或 null 转换为字符串
+func unquote(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return ""
+}
+
+// parseError 解析错误响应，带上 Retry-After 响应头
+func parseError(resp *http.Response, body []byte) *llm.LLMError {
+	var llmErr *llm.LLMError
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		llmErr = convertError(errResp.Error, resp.StatusCode)
+	} else {
+		llmErr = llm.ErrorFromStatus(resp.StatusCode, fmt.Sprintf("请求失败: %s (状态码: %d)", string(body), resp.StatusCode)).
+			WithProvider("openai")
+	}
+	return llmErr.WithRetryAfter(llm.ParseRetryAfter(resp.Header.Get("Retry-After")))
+}
+
+// convertError 转换错误
+// 先按 code 判断 (上下文超限、限流等)，再按 type 判断，都不匹配时按状态码
+func convertError(err *ErrorDetail, statusCode int) *llm.LLMError {
+	var typ llm.ErrorType
+	switch {
+	case err.Code == "context_length_exceeded":
+		typ = llm.ErrorTypeContextLength
+	case err.Code == "rate_limit_exceeded" || err.Type == "rate_limit_error":
+		typ = llm.ErrorTypeRateLimit
+	case err.Code == "server_is_overloaded" || err.Code == "slow_down":
+		typ = llm.ErrorTypeOverloaded
+	case err.Code == "invalid_api_key" || err.Type == "authentication_error":
+		typ = llm.ErrorTypeAuthentication
+	case err.Type == "invalid_request_error":
+		typ = llm.ErrorTypeInvalidRequest
+	case err.Type == "permission_error":
+		typ = llm.ErrorTypePermission
+	case err.Type == "not_found_error":
+		typ = llm.ErrorTypeNotFound
+	case err.Type == "server_error" || statusCode == 0:
+		typ = llm.ErrorTypeServerError
+	default:
+		return llm.ErrorFromStatus(statusCode, err.Message).WithCode(err.Code).WithProvider("openai")
+	}
+
+	return llm.NewLLMError(typ, err.Message).
+		WithCode(err.Code).
+		WithStatusCode(statusCode).
+		WithProvider("openai")
+}
@@ -0,0 +1,263 @@
+package openairesponses
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/cassette"
+)
+
+const testModel = "gpt-4o-mini"
+
+// newCassetteClient 使用 testdata/cassettes 中录制的交互创建客户端
+// 重新录制: LLM_CASSETTE_MODE=record OPENAI_API_KEY=... go test ./pkg/llm/openairesponses
+func newCassetteClient(t *testing.T, name string) (*Client, *cassette.Recorder) {
+	t.Helper()
+	rec, err := cassette.New(filepath.Join("testdata", "cassettes", name+".json"))
+	if err != nil {
+		t.Fatalf("加载录制文件失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Save(); err != nil {
+			t.Errorf("保存录制文件失败: %v", err)
+		}
+	})
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		apiKey = "sk-test"
+	}
+	client, err := NewClient(WithAPIKey(apiKey), WithModel(testModel), WithHTTPClient(rec.Client()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client, rec
+}
+
+// sentRequest 返回录制器收到的第 i 个请求及其请求体
+func sentRequest(t *testing.T, rec *cassette.Recorder, i int) (*cassette.Request, ChatRequest) {
+	t.Helper()
+	requests := rec.Requests()
+	if len(requests) <= i {
+		t.Fatalf("expected at least %d requests, got %d", i+1, len(requests))
+	}
+	var body ChatRequest
+	if err := json.Unmarshal(requests[i].Body, &body); err != nil {
+		t.Fatalf("invalid request body: %s", requests[i].Body)
+	}
+	return requests[i], body
+}
+
+func userRequest(text string) *llm.ChatRequest {
+	return &llm.ChatRequest{
+		Messages: []*llm.Message{{Role: llm.RoleUser, Content: llm.Text(text)}},
+	}
+}
+
+func TestNewClient_RequiresAPIKey(t *testing.T) {
+	if _, err := NewClient(); err == nil {
+		t.Error("expected error without API key")
+	}
+}
+
+func TestComplete_Text(t *testing.T) {
+	client, rec := newCassetteClient(t, "complete_text")
+
+	req := userRequest("你好")
+	req.System = "你是助手"
+	req.Messages[0].Content = llm.NewContentList(llm.Text("你好"), llm.ImageFromURL("https://example.com/a.png"))
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sent, body := sentRequest(t, rec, 0)
+	if sent.URL != DefaultBaseURL+"/responses" || sent.Headers["Authorization"] != cassette.Redacted {
+		t.Errorf("unexpected request: %s %v", sent.URL, sent.Headers)
+	}
+	if body.Instructions != "你是助手" || body.MaxOutputTokens != DefaultMaxTokens || body.Store {
+		t.Errorf("unexpected request body: %+v", body)
+	}
+	parts := body.Input[0].Content
+	if len(parts) != 2 || parts[0].Type != PartTypeInputText || parts[1].Type != PartTypeInputImage || parts[1].ImageURL != "https://example.com/a.png" {
+		t.Errorf("unexpected input parts: %+v", parts)
+	}
+
+	if resp.ID != "resp_text_1" || resp.Content != "你好！有什么可以帮你？" || resp.StopReason != llm.StopReasonEndTurn {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.Usage.InputTokens != 36 || resp.Usage.OutputTokens != 12 || resp.Usage.TotalTokens != 48 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestComplete_ToolCall(t *testing.T) {
+	client, rec := newCassetteClient(t, "complete_tool_call")
+
+	req := &llm.ChatRequest{
+		Tools: []*llm.Tool{llm.NewTool("get_weather", "查询天气", map[string]interface{}{"type": "object"})},
+		Messages: []*llm.Message{
+			{Role: llm.RoleUser, Content: llm.Text("上海天气")},
+			{Role: llm.RoleAssistant, Content: llm.Text("查询中"), ToolCalls: []*llm.ToolCall{
+				llm.NewToolCall("call_prev", "get_weather", `{"city":"上海"}`),
+			}},
+			{Role: llm.RoleTool, ToolCallID: "call_prev", Name: "get_weather", Content: llm.Text("晴")},
+			{Role: llm.RoleUser, Content: llm.Text("北京呢")},
+		},
+	}
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, body := sentRequest(t, rec, 0)
+	if tool := body.Tools[0]; tool.Type != "function" || tool.Name != "get_weather" {
+		t.Errorf("expected flat tool definition, got %+v", tool)
+	}
+	input := body.Input
+	if len(input) != 5 {
+		t.Fatalf("unexpected input items: %+v", input)
+	}
+	if input[1].Type != ItemTypeMessage || input[1].Content[0].Type != PartTypeOutputText {
+		t.Errorf("unexpected assistant message: %+v", input[1])
+	}
+	if input[2].Type != ItemTypeFunctionCall || input[2].CallID != "call_prev" || input[2].Arguments != `{"city":"上海"}` {
+		t.Errorf("unexpected function call item: %+v", input[2])
+	}
+	if input[3].Type != ItemTypeFunctionCallOutput || input[3].CallID != "call_prev" || input[3].Output != "晴" {
+		t.Errorf("unexpected function output item: %+v", input[3])
+	}
+
+	if resp.StopReason != llm.StopReasonToolUse || len(resp.ToolCalls) != 1 {
+		t.Fatalf("expected tool call, got %+v", resp)
+	}
+	tc := resp.ToolCalls[0]
+	if tc.ID != "call_abc" || tc.Function.Name != "get_weather" || tc.Function.Arguments != `{"city":"北京"}` {
+		t.Errorf("unexpected tool call: %+v %+v", tc, tc.Function)
+	}
+}
+
+func TestComplete_Incomplete(t *testing.T) {
+	client, _ := newCassetteClient(t, "complete_incomplete")
+
+	resp, err := client.Complete(context.Background(), userRequest("写长文"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StopReason != llm.StopReasonMaxTokens || resp.Content != "很长的回答" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestStream_ToolCall(t *testing.T) {
+	client, rec := newCassetteClient(t, "stream_tool_call")
+
+	ch, err := client.Stream(context.Background(), userRequest("上海天气"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, body := sentRequest(t, rec, 0); !body.Stream {
+		t.Error("expected stream=true")
+	}
+
+	var content string
+	var toolCalls []*llm.ToolCall
+	var done *llm.ChatResponse
+	for chunk := range ch {
+		switch {
+		case chunk.IsError():
+			t.Fatalf("unexpected error chunk: %v", chunk.Error)
+		case chunk.Type == llm.ChunkTypeToolUse:
+			toolCalls = append(toolCalls, chunk.ToolCalls...)
+		case chunk.IsDone():
+			done = chunk.Response
+		default:
+			content += chunk.Content
+		}
+	}
+	if content != "我来查询。" {
+		t.Errorf("unexpected content: %q", content)
+	}
+	if len(toolCalls) != 1 || toolCalls[0].ID != "call_s1" || toolCalls[0].Function.Arguments != `{"city":"上海"}` {
+		t.Errorf("unexpected tool calls: %+v", toolCalls)
+	}
+	if done == nil || done.ID != "resp_s1" || done.StopReason != llm.StopReasonToolUse || done.Usage.TotalTokens != 70 {
+		t.Errorf("unexpected done response: %+v", done)
+	}
+}
+
+func TestStream_Error(t *testing.T) {
+	client, _ := newCassetteClient(t, "stream_error")
+
+	ch, err := client.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var streamErr error
+	for chunk := range ch {
+		if chunk.IsError() {
+			streamErr = chunk.Error
+		}
+	}
+	llmErr, ok := llm.AsLLMError(streamErr)
+	if !ok || llmErr.Type != llm.ErrorTypeOverloaded || !llmErr.IsRetryable() {
+		t.Errorf("expected retryable overloaded error, got %v", streamErr)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		cassette   string
+		status     int
+		typ        llm.ErrorType
+		retryAfter int
+	}{
+		{"error_context_length", http.StatusBadRequest, llm.ErrorTypeContextLength, 0},
+		{"error_rate_limit", http.StatusTooManyRequests, llm.ErrorTypeRateLimit, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cassette, func(t *testing.T) {
+			client, _ := newCassetteClient(t, tt.cassette)
+
+			_, err := client.Complete(context.Background(), userRequest("hi"))
+			llmErr, ok := llm.AsLLMError(err)
+			if !ok {
+				t.Fatalf("expected LLMError, got %v", err)
+			}
+			if llmErr.Type != tt.typ || llmErr.StatusCode != tt.status || llmErr.RetryAfter != tt.retryAfter || llmErr.Provider != "openai" {
+				t.Errorf("unexpected error: %+v", llmErr)
+			}
+		})
+	}
+}
+
+func TestConvertRequest_StructuredOutput(t *testing.T) {
+	schema := map[string]interface{}{"type": "object"}
+	req := convertRequest(&llm.ChatRequest{
+		ToolChoice:     llm.ToolChoiceFunction("get_weather"),
+		ResponseFormat: &llm.ResponseFormat{Name: "report", Schema: schema, Strict: true},
+	})
+
+	data, _ := json.Marshal(req)
+	var body map[string]interface{}
+	_ = json.Unmarshal(data, &body)
+
+	choice := body["tool_choice"].(map[string]interface{})
+	if choice["type"] != "function" || choice["name"] != "get_weather" {
+		t.Errorf("unexpected tool_choice: %v", choice)
+	}
+	format := body["text"].(map[string]interface{})["format"].(map[string]interface{})
+	if format["type"] != "json_schema" || format["name"] != "report" || format["strict"] != true {
+		t.Errorf("unexpected text format: %v", format)
+	}
+
+	req = convertRequest(&llm.ChatRequest{ToolChoice: llm.ToolChoiceRequired()})
+	if req.ToolChoice != "required" {
+		t.Errorf("expected required, got %v", req.ToolChoice)
+	}
+}
@@ -0,0 +1,253 @@
+package openairesponses
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+// convertRequest 将统一请求格式转换为 Responses API 格式
+func convertRequest(req *llm.ChatRequest) *ChatRequest {
+	respReq := &ChatRequest{
+		Model:           req.Model,
+		Instructions:    req.System,
+		MaxOutputTokens: req.MaxTokens,
+		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		Stream:          req.Stream,
+	}
+
+	// 转换消息
+	respReq.Input = convertMessages(req.Messages)
+
+	// 转换工具
+	for _, t := range req.Tools {
+		if t.Function == nil {
+			continue
+		}
+		respReq.Tools = append(respReq.Tools, Tool{
+			Type:        "function",
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+		})
+	}
+	if req.ToolChoice != nil {
+		respReq.ToolChoice = convertToolChoice(req.ToolChoice)
+	}
+
+	// 转换结构化输出格式
+	if req.ResponseFormat != nil {
+		respReq.Text = &TextConfig{Format: &TextFormat{
+			Type:   "json_schema",
+			Name:   req.ResponseFormat.Name,
+			Schema: req.ResponseFormat.Schema,
+			Strict: req.ResponseFormat.Strict,
+		}}
+	}
+
+	return respReq
+}
+
+// convertToolChoice 转换工具选择策略
+func convertToolChoice(choice *llm.ToolChoice) interface{} {
+	if choice.Type == llm.ToolChoiceTypeTool {
+		return ToolChoiceFunction{Type: "function", Name: choice.Name}
+	}
+	return string(choice.Type)
+}
+
+// convertMessages 转换消息列表
+// 助手的工具调用和工具结果是独立的输入项，不属于消息
+func convertMessages(messages []*llm.Message) []InputItem {
+	result := make([]InputItem, 0, len(messages))
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case llm.RoleTool:
+			// 工具输出只能是文本，图像和文件作为紧随其后的用户消息发送
+			text, media := llm.SplitMedia(msg.Content)
+			result = append(result, InputItem{
+				Type:   ItemTypeFunctionCallOutput,
+				CallID: msg.ToolCallID,
+				Output: text,
+			})
+			if len(media) > 0 {
+				content := []ContentPart{{
+					Type: PartTypeInputText,
+					Text: fmt.Sprintf("工具 %s (%s) 返回的内容:", msg.Name, msg.ToolCallID),
+				}}
+				for _, item := range media {
+					content = append(content, convertContent(item)...)
+				}
+				result = append(result, InputItem{
+					Type:    ItemTypeMessage,
+					Role:    string(llm.RoleUser),
+					Content: content,
+				})
+			}
+
+		case llm.RoleAssistant:
+			if text := llm.TextString(msg.Content); text != "" {
+				result = append(result, InputItem{
+					Type:    ItemTypeMessage,
+					Role:    string(llm.RoleAssistant),
+					Content: []ContentPart{{Type: PartTypeOutputText, Text: text}},
+				})
+			}
+			for _, tc := range msg.ToolCalls {
+				if tc.Function == nil {
+					continue
+				}
+				result = append(result, InputItem{
+					Type:      ItemTypeFunctionCall,
+					CallID:    tc.ID,
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				})
+			}
+
+		default:
+			result = append(result, InputItem{
+				Type:    ItemTypeMessage,
+				Role:    string(msg.Role),
+				Content: convertContent(msg.Content),
+			})
+		}
+	}
+
+	return result
+}
+
+// convertContent 转换消息内容为输入部分
+func convertContent(content llm.Content) []ContentPart {
+	switch v := content.(type) {
+	case nil:
+		return []ContentPart{}
+	case *llm.TextContent:
+		return []ContentPart{{Type: PartTypeInputText, Text: v.Text}}
+	case *llm.ImageContent:
+		url := v.Source.URL
+		if v.Source.Type != "url" {
+			url = fmt.Sprintf("data:%s;base64,%s", v.Source.MediaType, v.Source.Data)
+		}
+		return []ContentPart{{Type: PartTypeInputImage, ImageURL: url}}
+	case *llm.DocumentContent:
+		switch v.Source.Type {
+		case "base64":
+			return []ContentPart{{
+				Type:     PartTypeInputFile,
+				Filename: documentFilename(v),
+				FileData: fmt.Sprintf("data:%s;base64,%s", v.Source.MediaType, v.Source.Data),
+			}}
+		case "url":
+			return []ContentPart{{Type: PartTypeInputFile, FileURL: v.Source.URL}}
+		}
+		return []ContentPart{{Type: PartTypeInputText, Text: llm.PlainText(v)}}
+	case *llm.ContentList:
+		parts := make([]ContentPart, 0, len(v.Items))
+		for _, item := range v.Items {
+			parts = append(parts, convertContent(item)...)
+		}
+		return parts
+	default:
+		// 音频等不支持的内容替换为文本说明
+		return []ContentPart{{Type: PartTypeInputText, Text: llm.PlainText(content)}}
+	}
+}
+
+// documentFilename 返回文档的文件名
+func documentFilename(doc *llm.DocumentContent) string {
+	if doc.Title != "" {
+		return doc.Title
+	}
+	if doc.Source.MediaType == "application/pdf" {
+		return "document.pdf"
+	}
+	return "document"
+}
+
+// convertResponse 将 Responses API 响应转换为统一格式
+func convertResponse(resp *ChatResponse) *llm.ChatResponse {
+	if resp == nil {
+		return nil
+	}
+
+	result := &llm.ChatResponse{
+		ID:    resp.ID,
+		Model: resp.Model,
+		Role:  llm.RoleAssistant,
+		Usage: convertUsage(resp.Usage),
+	}
+
+	var content strings.Builder
+	for _, item := range resp.Output {
+		switch item.Type {
+		case ItemTypeMessage:
+			content.WriteString(outputText(item.Content))
+		case ItemTypeFunctionCall:
+			result.ToolCalls = append(result.ToolCalls, convertFunctionCall(&item))
+		}
+	}
+	result.Content = content.String()
+	result.StopReason = convertStatus(resp, len(result.ToolCalls) > 0)
+
+	return result
+}
+
+// outputText 拼接输出文本，拒绝回答的说明也作为文本返回
+func outputText(parts []ContentPart) string {
+	var sb strings.Builder
+	for _, p := range parts {
+		switch p.Type {
+		case PartTypeOutputText:
+			sb.WriteString(p.Text)
+		case PartTypeRefusal:
+			sb.WriteString(p.Refusal)
+		}
+	}
+	return sb.String()
+}
+
+// convertFunctionCall 转换函数调用，使用 call_id 关联工具结果
+func convertFunctionCall(item *OutputItem) *llm.ToolCall {
+	arguments := item.Arguments
+	if arguments == "" {
+		arguments = "{}"
+	}
+	return llm.NewToolCall(item.CallID, item.Name, arguments)
+}
+
+// convertUsage 转换使用统计
+func convertUsage(usage *Usage) *llm.Usage {
+	if usage == nil {
+		return nil
+	}
+	result := &llm.Usage{
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.TotalTokens,
+	}
+	if usage.InputTokensDetails != nil {
+		result.CacheReadTokens = usage.InputTokensDetails.CachedTokens
+	}
+	if usage.OutputTokensDetails != nil {
+		result.ReasoningTokens = usage.OutputTokensDetails.ReasoningTokens
+	}
+	return result
+}
+
+// convertStatus 根据响应状态确定停止原因
+func convertStatus(resp *ChatResponse, hasToolCalls bool) llm.StopReason {
+	if resp.Status == "incomplete" && resp.IncompleteDetails != nil {
+		if resp.IncompleteDetails.Reason == "max_output_tokens" {
+			return llm.StopReasonMaxTokens
+		}
+		return llm.StopReason(resp.IncompleteDetails.Reason)
+	}
+	if hasToolCalls {
+		return llm.StopReasonToolUse
+	}
+	return llm.StopReasonEndTurn
+}
@@ -0,0 +1,194 @@
+package openairesponses
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OpenAI Responses API 请求和响应类型定义
+
+const (
+	// DefaultBaseURL OpenAI API 默认地址
+	DefaultBaseURL = "https://api.openai.com/v1"
+	// DefaultModel 默认模型
+	DefaultModel = "gpt-4.1"
+	// DefaultMaxTokens 默认最大 token
+	DefaultMaxTokens = 4096
+	// DefaultTimeout 默认超时
+	DefaultTimeout = 60 * time.Second
+)
+
+// 输入/输出项类型
+const (
+	ItemTypeMessage            = "message"
+	ItemTypeFunctionCall       = "function_call"
+	ItemTypeFunctionCallOutput = "function_call_output"
+	ItemTypeReasoning          = "reasoning"
+)
+
+// 内容部分类型
+const (
+	PartTypeInputText  = "input_text"
+	PartTypeInputImage = "input_image"
+	PartTypeInputFile  = "input_file"
+	PartTypeOutputText = "output_text"
+	PartTypeRefusal    = "refusal"
+)
+
+// SSE 事件类型常量
+const (
+	EventTypeCreated                = "response.created"
+	EventTypeOutputItemAdded        = "response.output_item.added"
+	EventTypeOutputItemDone         = "response.output_item.done"
+	EventTypeOutputTextDelta        = "response.output_text.delta"
+	EventTypeFunctionArgumentsDelta = "response.function_call_arguments.delta"
+	EventTypeFunctionArgumentsDone  = "response.function_call_arguments.done"
+	EventTypeCompleted              = "response.completed"
+	EventTypeIncomplete             = "response.incomplete"
+	EventTypeFailed                 = "response.failed"
+	EventTypeError                  = "error"
+)
+
+// ChatRequest /responses 请求
+type ChatRequest struct {
+	Model           string      `json:"model"`
+	Input           []InputItem `json:"input"`
+	Instructions    string      `json:"instructions,omitempty"`
+	Tools           []Tool      `json:"tools,omitempty"`
+	MaxOutputTokens int         `json:"max_output_tokens,omitempty"`
+	Temperature     float64     `json:"temperature,omitempty"`
+	TopP            float64     `json:"top_p,omitempty"`
+	Stream          bool        `json:"stream,omitempty"`
+	Store           bool        `json:"store"`
+	ToolChoice      interface{} `json:"tool_choice,omitempty"` // "auto"、"none"、"required" 或 ToolChoiceFunction
+	Text            *TextConfig `json:"text,omitempty"`
+}
+
+// ToolChoiceFunction 指定调用的函数
+type ToolChoiceFunction struct {
+	Type string `json:"type"` // "function"
+	Name string `json:"name"`
+}
+
+// TextConfig 文本输出配置
+type TextConfig struct {
+	Format *TextFormat `json:"format"`
+}
+
+// TextFormat 文本输出格式
+type TextFormat struct {
+	Type   string                 `json:"type"` // "text", "json_schema", "json_object"
+	Name   string                 `json:"name,omitempty"`
+	Schema map[string]interface{} `json:"schema,omitempty"`
+	Strict bool                   `json:"strict,omitempty"`
+}
+
+// InputItem 输入项: 消息、函数调用或函数调用结果
+type InputItem struct {
+	Type string `json:"type"`
+
+	// message
+	Role    string        `json:"role,omitempty"`
+	Content []ContentPart `json:"content,omitempty"`
+
+	// function_call / function_call_output
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+// ContentPart 内容部分
+type ContentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	Refusal  string `json:"refusal,omitempty"`
+
+	// 文件输入 (input_file)
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
+	FileURL  string `json:"file_url,omitempty"`
+}
+
+// Tool 工具定义 (函数字段平铺，不同于 chat completions 的嵌套格式)
+type Tool struct {
+	Type        string                 `json:"type"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ChatResponse /responses 响应
+type ChatResponse struct {
+	ID                string             `json:"id"`
+	Object            string             `json:"object"`
+	Model             string             `json:"model"`
+	Status            string             `json:"status"` // completed, incomplete, failed, in_progress
+	Output            []OutputItem       `json:"output"`
+	IncompleteDetails *IncompleteDetails `json:"incomplete_details,omitempty"`
+	Error             *ErrorDetail       `json:"error,omitempty"`
+	Usage             *Usage             `json:"usage,omitempty"`
+}
+
+// OutputItem 输出项
+type OutputItem struct {
+	Type      string        `json:"type"`
+	ID        string        `json:"id"`
+	Status    string        `json:"status,omitempty"`
+	Role      string        `json:"role,omitempty"`
+	Content   []ContentPart `json:"content,omitempty"`
+	CallID    string        `json:"call_id,omitempty"`
+	Name      string        `json:"name,omitempty"`
+	Arguments string        `json:"arguments,omitempty"`
+}
+
+// IncompleteDetails 未完成原因
+type IncompleteDetails struct {
+	Reason string `json:"reason"` // max_output_tokens, content_filter
+}
+
+// Usage token 使用统计
+type Usage struct {
+	InputTokens         int                  `json:"input_tokens"`
+	OutputTokens        int                  `json:"output_tokens"`
+	TotalTokens         int                  `json:"total_tokens"`
+	InputTokensDetails  *InputTokensDetails  `json:"input_tokens_details,omitempty"`
+	OutputTokensDetails *OutputTokensDetails `json:"output_tokens_details,omitempty"`
+}
+
+// InputTokensDetails 输入 token 明细
+type InputTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// OutputTokensDetails 输出 token 明细
+type OutputTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// StreamEvent 流式事件 (SSE data)
+type StreamEvent struct {
+	Type           string          `json:"type"`
+	OutputIndex    int             `json:"output_index"`
+	ItemID         string          `json:"item_id,omitempty"`
+	Delta          string          `json:"delta,omitempty"`
+	Arguments      string          `json:"arguments,omitempty"`
+	Item           *OutputItem     `json:"item,omitempty"`
+	Response       *ChatResponse   `json:"response,omitempty"`
+	Code           json.RawMessage `json:"code,omitempty"`
+	Message        string          `json:"message,omitempty"`
+	SequenceNumber int             `json:"sequence_number,omitempty"`
+}
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	Error *ErrorDetail `json:"error"`
+}
+
+// ErrorDetail 错误详情
+type ErrorDetail struct {
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"`
+	Code    string `json:"code,omitempty"`
+}
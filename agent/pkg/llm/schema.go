@@ -0,0 +1,281 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// SchemaFor 根据类型 T 生成 JSON Schema
+//
+// 字段名取 json 标签，带 omitempty 的字段和指针字段是可选的，其余字段都是必需的，
+// 指针字段允许为 null。
+// 可以用 description 和 enum 标签补充说明:
+//
+//	type Weather struct {
+//	    City    string  `json:"city" description:"城市名称"`
+//	    Sky     string  `json:"sky" enum:"sunny,cloudy,rain"`
+//	    TempC   float64 `json:"temp_c"`
+//	    Warning *string `json:"warning,omitempty"`
+//	}
+func SchemaFor[T any]() map[string]interface{} {
+	return SchemaOf(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// SchemaOf 根据反射类型生成 JSON Schema
+func SchemaOf(t reflect.Type) map[string]interface{} {
+	return schemaOf(t, make(map[reflect.Type]bool))
+}
+
+// schemaOf 生成 JSON Schema，visiting 记录正在展开的结构体，递归类型不再展开
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		// []byte 按 encoding/json 的规则序列化为 base64 字符串
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": schemaOf(t.Elem(), visiting),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": schemaOf(t.Elem(), visiting),
+		}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		return structSchema(t, visiting)
+	default:
+		// interface{} 等任意值
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema 生成结构体的 JSON Schema
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]interface{}, 0)
+
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+
+			// 没有 json 名称的嵌入结构体，字段提升到外层
+			if field.Anonymous && name == "" {
+				ft := field.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					addFields(ft)
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			prop := schemaOf(field.Type, visiting)
+			// 指针字段可以为 null
+			if typ, ok := prop["type"].(string); ok && field.Type.Kind() == reflect.Pointer {
+				prop["type"] = []interface{}{typ, "null"}
+			}
+			if desc := field.Tag.Get("description"); desc != "" {
+				prop["description"] = desc
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				values := make([]interface{}, 0)
+				for _, v := range strings.Split(enum, ",") {
+					v = strings.TrimSpace(v)
+					// 非字符串字段的枚举值按 JSON 解析，如数字
+					var value interface{} = v
+					if prop["type"] != "string" {
+						if err := json.Unmarshal([]byte(v), &value); err != nil {
+							value = v
+						}
+					}
+					values = append(values, value)
+				}
+				prop["enum"] = values
+			}
+			properties[name] = prop
+
+			if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// StrictSchema 把 Schema 转换为 OpenAI strict 模式接受的形式，不修改原 Schema
+//
+// strict 模式要求对象的所有属性都在 required 中且 additionalProperties 为 false：
+// 原来可选的属性 (omitempty、指针字段) 改为必需但允许 null；
+// map 和没有声明 properties 的对象 (如递归类型) 无法表示，返回 ErrInvalidRequest。
+func StrictSchema(schema map[string]interface{}) (map[string]interface{}, error) {
+	return strictSchema(schema, "$")
+}
+
+// strictSchema 递归转换 Schema，path 用于错误信息
+func strictSchema(schema map[string]interface{}, path string) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		out[k] = v
+	}
+
+	// 允许 null 的枚举必须包含 null
+	if enum, ok := out["enum"].([]interface{}); ok && hasSchemaType(out["type"], "null") && !containsValue(enum, nil) {
+		out["enum"] = append(append([]interface{}(nil), enum...), nil)
+	}
+
+	if items, ok := out["items"].(map[string]interface{}); ok {
+		s, err := strictSchema(items, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		out["items"] = s
+	}
+	if anyOf, ok := out["anyOf"].([]interface{}); ok {
+		converted := make([]interface{}, len(anyOf))
+		for i, sub := range anyOf {
+			m, ok := sub.(map[string]interface{})
+			if !ok {
+				return nil, ErrInvalidRequest(fmt.Sprintf("strict 模式: %s 的 anyOf 不是对象", path))
+			}
+			s, err := strictSchema(m, path)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = s
+		}
+		out["anyOf"] = converted
+	}
+
+	if !hasSchemaType(out["type"], "object") {
+		return out, nil
+	}
+	properties, ok := out["properties"].(map[string]interface{})
+	if !ok || out["additionalProperties"] != false {
+		return nil, ErrInvalidRequest(fmt.Sprintf("strict 模式不支持 map 或未声明 properties 的对象: %s", path))
+	}
+
+	required := make(map[string]bool)
+	for _, name := range stringList(out["required"]) {
+		required[name] = true
+	}
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	props := make(map[string]interface{}, len(properties))
+	allRequired := make([]interface{}, len(names))
+	for i, name := range names {
+		prop, ok := properties[name].(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidRequest(fmt.Sprintf("strict 模式: %s.%s 的 Schema 不是对象", path, name))
+		}
+		if !required[name] {
+			prop = nullableSchema(prop)
+		}
+		s, err := strictSchema(prop, path+"."+name)
+		if err != nil {
+			return nil, err
+		}
+		props[name] = s
+		allRequired[i] = name
+	}
+	out["properties"] = props
+	out["required"] = allRequired
+	return out, nil
+}
+
+// nullableSchema 返回允许 null 的 Schema 副本，没有 type 的 Schema 本身就接受 null
+func nullableSchema(schema map[string]interface{}) map[string]interface{} {
+	typ, ok := schema["type"]
+	if !ok || hasSchemaType(typ, "null") {
+		return schema
+	}
+	types := []interface{}{}
+	if s, ok := typ.(string); ok {
+		types = append(types, s)
+	} else {
+		for _, t := range stringList(typ) {
+			types = append(types, t)
+		}
+	}
+	out := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		out[k] = v
+	}
+	out["type"] = append(types, "null")
+	return out
+}
+
+// hasSchemaType 检查 type (字符串或字符串数组) 是否包含 want
+func hasSchemaType(typ interface{}, want string) bool {
+	if s, ok := typ.(string); ok {
+		return s == want
+	}
+	for _, t := range stringList(typ) {
+		if t == want {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ type ChunkType string
 
 const (
 	ChunkTypeContent      ChunkType = "content"       // 内容块
+	ChunkTypeThinking     ChunkType = "thinking"      // 思考内容块
 	ChunkTypeToolUse      ChunkType = "tool_use"      // 工具调用
 	ChunkTypeError        ChunkType = "error"         // 错误
 	ChunkTypeDone         ChunkType = "done"          // 完成
@@ -23,6 +24,9 @@ type StreamChunk struct {
 	// Content 文本内容增量
 	Content string `json:"content,omitempty"`
 
+	// Thinking 思考内容增量
+	Thinking string `json:"thinking,omitempty"`
+
 	// ToolCalls 工具调用增量
 	ToolCalls []*ToolCall `json:"tool_calls,omitempty"`
 
@@ -63,6 +67,14 @@ func NewContentChunk(content string) *StreamChunk {
 	}
 }
 
+// NewThinkingChunk 创建思考内容块
+func NewThinkingChunk(thinking string) *StreamChunk {
+	return &StreamChunk{
+		Type:     ChunkTypeThinking,
+		Thinking: thinking,
+	}
+}
+
 // NewToolUseChunk 创建工具调用块
 func NewToolUseChunk(toolCalls []*ToolCall) *StreamChunk {
 	return &StreamChunk{
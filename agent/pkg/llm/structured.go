@@ -0,0 +1,311 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultStructuredRetries 默认校验失败后重新提示的次数
+	DefaultStructuredRetries = 2
+	// DefaultStructuredName 默认的输出格式名称 (也是工具模式下的工具名称)
+	DefaultStructuredName = "structured_output"
+)
+
+// ResponseFormat 结构化输出格式
+type ResponseFormat struct {
+	// Name 格式名称
+	Name string `json:"name"`
+
+	// Schema JSON Schema
+	Schema map[string]interface{} `json:"schema"`
+
+	// Strict 要求提供商严格按 Schema 生成 (OpenAI strict 模式)
+	Strict bool `json:"strict,omitempty"`
+}
+
+// StructuredOutputSupporter 支持原生结构化输出的客户端
+// 实现此接口并返回 true 的客户端会收到带 ResponseFormat 的请求，
+// 其他客户端通过强制调用工具获得结构化输出
+type StructuredOutputSupporter interface {
+	SupportsStructuredOutput() bool
+}
+
+// StructuredMode 结构化输出方式
+type StructuredMode string
+
+const (
+	// StructuredModeAuto 客户端支持时使用原生结构化输出，否则强制调用工具；
+	// 启用扩展思考时不能强制调用工具，改为在系统提示词中说明 Schema
+	StructuredModeAuto StructuredMode = "auto"
+	// StructuredModeNative 使用提供商的原生结构化输出 (ResponseFormat)
+	StructuredModeNative StructuredMode = "native"
+	// StructuredModeTool 强制模型调用一个以 Schema 为参数的工具
+	// 启用扩展思考时 Anthropic 不允许强制调用工具，改为在系统提示词中要求调用
+	StructuredModeTool StructuredMode = "tool"
+	// StructuredModePrompt 只在系统提示词中说明 Schema
+	StructuredModePrompt StructuredMode = "prompt"
+)
+
+// StructuredOption 结构化输出选项
+type StructuredOption func(*structuredConfig)
+
+// structuredConfig 结构化输出配置
+type structuredConfig struct {
+	name        string
+	description string
+	schema      map[string]interface{}
+	mode        StructuredMode
+	retries     int
+	strict      bool
+}
+
+// WithStructuredMode 设置结构化输出方式
+func WithStructuredMode(mode StructuredMode) StructuredOption {
+	return func(c *structuredConfig) {
+		c.mode = mode
+	}
+}
+
+// WithStructuredRetries 设置校验失败后重新提示的次数
+func WithStructuredRetries(n int) StructuredOption {
+	return func(c *structuredConfig) {
+		if n >= 0 {
+			c.retries = n
+		}
+	}
+}
+
+// WithSchemaName 设置输出格式名称和说明
+func WithSchemaName(name, description string) StructuredOption {
+	return func(c *structuredConfig) {
+		c.name = name
+		c.description = description
+	}
+}
+
+// WithSchema 使用自定义 Schema 代替从类型生成的 Schema
+func WithSchema(schema map[string]interface{}) StructuredOption {
+	return func(c *structuredConfig) {
+		c.schema = schema
+	}
+}
+
+// WithStrictSchema 要求提供商严格按 Schema 生成
+// 发送前用 StrictSchema 转换 Schema：可选字段改为必需但允许 null，
+// Schema 中有 map 等 strict 模式无法表示的类型时 CompleteStructured 直接返回错误
+func WithStrictSchema() StructuredOption {
+	return func(c *structuredConfig) {
+		c.strict = true
+	}
+}
+
+// StructuredOutputError 多次尝试后仍未得到符合 Schema 的输出
+type StructuredOutputError struct {
+	// Attempts 尝试次数
+	Attempts int
+
+	// Raw 最后一次的原始输出
+	Raw string
+
+	// Err 最后一次的解析或校验错误
+	Err error
+}
+
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("结构化输出失败 (尝试 %d 次): %v", e.Attempts, e.Err)
+}
+
+// Unwrap 返回底层错误
+func (e *StructuredOutputError) Unwrap() error {
+	return e.Err
+}
+
+// CompleteStructured 发送请求并把回复解码为 T
+//
+// Schema 由 SchemaFor[T] 生成。客户端支持原生结构化输出时使用 ResponseFormat，
+// 否则强制模型调用一个以 Schema 为参数的工具。回复不符合 Schema 时，
+// 把校验错误发回给模型重新生成，最多重试 WithStructuredRetries 次。
+//
+//	type Weather struct {
+//	    City  string  `json:"city"`
+//	    TempC float64 `json:"temp_c"`
+//	}
+//	weather, err := llm.CompleteStructured[Weather](ctx, client, req)
+func CompleteStructured[T any](ctx context.Context, client ChatCompleter, req *ChatRequest, opts ...StructuredOption) (T, error) {
+	var result T
+
+	cfg := &structuredConfig{
+		name:    DefaultStructuredName,
+		mode:    StructuredModeAuto,
+		retries: DefaultStructuredRetries,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.schema == nil {
+		cfg.schema = SchemaFor[T]()
+	}
+	if cfg.strict {
+		schema, err := StrictSchema(cfg.schema)
+		if err != nil {
+			return result, err
+		}
+		cfg.schema = schema
+	}
+	if cfg.mode == StructuredModeAuto {
+		cfg.mode = StructuredModeTool
+		if s, ok := client.(StructuredOutputSupporter); ok && s.SupportsStructuredOutput() {
+			cfg.mode = StructuredModeNative
+		} else if req.Thinking != nil {
+			cfg.mode = StructuredModePrompt
+		}
+	}
+
+	structReq := cfg.prepare(req)
+	var lastErr error
+	var raw string
+
+	for attempt := 1; attempt <= cfg.retries+1; attempt++ {
+		resp, err := client.Complete(ctx, cloneForAttempt(structReq))
+		if err != nil {
+			return result, err
+		}
+
+		raw, lastErr = cfg.extract(resp)
+		if lastErr == nil {
+			lastErr = ValidateSchema(cfg.schema, []byte(raw))
+		}
+		if lastErr == nil {
+			if err := json.Unmarshal([]byte(raw), &result); err != nil {
+				lastErr = err
+			} else {
+				return result, nil
+			}
+		}
+
+		// 把错误发回给模型重新生成
+		structReq.Messages = append(structReq.Messages, cfg.feedback(resp, lastErr)...)
+	}
+
+	return result, &StructuredOutputError{Attempts: cfg.retries + 1, Raw: raw, Err: lastErr}
+}
+
+// prepare 按输出方式生成请求副本
+func (c *structuredConfig) prepare(req *ChatRequest) *ChatRequest {
+	structReq := *req
+	structReq.Messages = append([]*Message(nil), req.Messages...)
+	structReq.Stream = false
+
+	switch c.mode {
+	case StructuredModeNative:
+		structReq.ResponseFormat = &ResponseFormat{Name: c.name, Schema: c.schema, Strict: c.strict}
+	case StructuredModeTool:
+		description := c.description
+		if description == "" {
+			description = "按指定格式输出最终结果"
+		}
+		structReq.Tools = []*Tool{NewTool(c.name, description, c.schema)}
+		if req.Thinking == nil {
+			structReq.ToolChoice = ToolChoiceFunction(c.name)
+		} else {
+			structReq.ToolChoice = ToolChoiceAuto()
+			appendSystem(&structReq, "调用 "+c.name+" 工具输出最终结果，只调用一次，不要输出其他内容。")
+		}
+	case StructuredModePrompt:
+		schemaJSON, _ := json.MarshalIndent(c.schema, "", "  ")
+		appendSystem(&structReq, "你的输出必须是有效的 JSON，不要输出任何其他内容。\nJSON Schema:\n```json\n"+string(schemaJSON)+"\n```")
+	}
+	return &structReq
+}
+
+// appendSystem 在系统提示词末尾追加说明
+func appendSystem(req *ChatRequest, instruction string) {
+	if req.System != "" {
+		req.System += "\n\n" + instruction
+	} else {
+		req.System = instruction
+	}
+}
+
+// extract 从回复中取出 JSON 文本
+// 工具模式取工具调用参数，其他模式取回复内容 (去掉代码块标记)
+// 回复包含多个工具调用时无法确定以哪个为准，返回错误让模型重新生成
+func (c *structuredConfig) extract(resp *ChatResponse) (string, error) {
+	var raw string
+	found := false
+	for _, tc := range resp.ToolCalls {
+		if tc.Function != nil && tc.Function.Name == c.name && !found {
+			raw, found = tc.Function.Arguments, true
+		}
+	}
+	if len(resp.ToolCalls) > 1 {
+		return raw, fmt.Errorf("只能调用一次 %s 工具，实际调用了 %d 个工具", c.name, len(resp.ToolCalls))
+	}
+	if found {
+		return raw, nil
+	}
+	return extractJSON(resp.Content), nil
+}
+
+// feedback 生成把校验错误发回给模型的消息
+// 回复带工具调用时为每个工具调用返回一条工具结果，思考内容随助手消息一起发回
+func (c *structuredConfig) feedback(resp *ChatResponse, err error) []*Message {
+	var detail string
+	var schemaErr *SchemaError
+	if errors.As(err, &schemaErr) {
+		detail = "- " + strings.Join(schemaErr.Errors, "\n- ")
+	} else {
+		detail = err.Error()
+	}
+	text := "输出不符合要求的 JSON Schema，请修正以下问题后重新输出完整结果:\n" + detail
+
+	assistant := &Message{Role: RoleAssistant, Content: Text(resp.Content), Thinking: resp.Thinking}
+	if len(resp.ToolCalls) == 0 {
+		return []*Message{assistant, {Role: RoleUser, Content: Text(text)}}
+	}
+
+	assistant.ToolCalls = resp.ToolCalls
+	msgs := []*Message{assistant}
+	for _, tc := range resp.ToolCalls {
+		msg := &Message{Role: RoleTool, ToolCallID: tc.ID, Content: Text(text)}
+		if tc.Function != nil {
+			msg.Name = tc.Function.Name
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// cloneForAttempt 复制请求，客户端填充的默认值不影响下一次尝试
+func cloneForAttempt(req *ChatRequest) *ChatRequest {
+	clone := *req
+	return &clone
+}
+
+// extractJSON 从文本中取出 JSON，兼容 ```json 代码块和前后说明文字
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if start := strings.Index(text, "```"); start >= 0 {
+		body := text[start+3:]
+		if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+			body = body[nl+1:]
+		}
+		if end := strings.Index(body, "```"); end >= 0 {
+			return strings.TrimSpace(body[:end])
+		}
+	}
+	if json.Valid([]byte(text)) {
+		return text
+	}
+	// 取第一个 { 或 [ 到最后一个 } 或 ] 之间的内容
+	start := strings.IndexAny(text, "{[")
+	end := strings.LastIndexAny(text, "}]")
+	if start >= 0 && end > start {
+		return text[start : end+1]
+	}
+	return text
+}
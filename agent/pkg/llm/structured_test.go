@@ -0,0 +1,339 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeClient 按顺序返回预设回复并记录请求
+type fakeClient struct {
+	responses  []*ChatResponse
+	requests   []*ChatRequest
+	structured bool
+}
+
+func (f *fakeClient) Complete(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	f.requests = append(f.requests, req)
+	if len(f.responses) == 0 {
+		return nil, errors.New("no response")
+	}
+	resp := f.responses[0]
+	f.responses = f.responses[1:]
+	return resp, nil
+}
+
+func (f *fakeClient) Stream(ctx context.Context, req *ChatRequest) (<-chan *StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeClient) SupportsStructuredOutput() bool {
+	return f.structured
+}
+
+type weather struct {
+	City    string   `json:"city" description:"城市名称"`
+	Sky     string   `json:"sky" enum:"sunny,cloudy,rain"`
+	TempC   float64  `json:"temp_c"`
+	Level   int      `json:"level,omitempty" enum:"1,2,3"`
+	Tags    []string `json:"tags,omitempty"`
+	Warning *string  `json:"warning"`
+	ignored string
+}
+
+func userRequest() *ChatRequest {
+	return &ChatRequest{Messages: []*Message{{Role: RoleUser, Content: Text("北京天气")}}}
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema := SchemaFor[weather]()
+
+	if schema["type"] != "object" || schema["additionalProperties"] != false {
+		t.Errorf("unexpected object schema: %v", schema)
+	}
+	wantRequired := []interface{}{"city", "sky", "temp_c"}
+	if !reflect.DeepEqual(schema["required"], wantRequired) {
+		t.Errorf("required = %v, want %v", schema["required"], wantRequired)
+	}
+
+	props := schema["properties"].(map[string]interface{})
+	if len(props) != 6 {
+		t.Errorf("expected 6 properties, got %d", len(props))
+	}
+	city := props["city"].(map[string]interface{})
+	if city["type"] != "string" || city["description"] != "城市名称" {
+		t.Errorf("unexpected city schema: %v", city)
+	}
+	sky := props["sky"].(map[string]interface{})
+	if !reflect.DeepEqual(sky["enum"], []interface{}{"sunny", "cloudy", "rain"}) {
+		t.Errorf("unexpected sky enum: %v", sky["enum"])
+	}
+	level := props["level"].(map[string]interface{})
+	if level["type"] != "integer" || !reflect.DeepEqual(level["enum"], []interface{}{1.0, 2.0, 3.0}) {
+		t.Errorf("unexpected level schema: %v", level)
+	}
+	tags := props["tags"].(map[string]interface{})
+	if tags["type"] != "array" || tags["items"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("unexpected tags schema: %v", tags)
+	}
+}
+
+func TestStrictSchema(t *testing.T) {
+	original := SchemaFor[weather]()
+	schema, err := StrictSchema(original)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantRequired := []interface{}{"city", "level", "sky", "tags", "temp_c", "warning"}
+	if !reflect.DeepEqual(schema["required"], wantRequired) {
+		t.Errorf("required = %v, want %v", schema["required"], wantRequired)
+	}
+	if !reflect.DeepEqual(original["required"], []interface{}{"city", "sky", "temp_c"}) {
+		t.Errorf("original schema modified: %v", original["required"])
+	}
+
+	props := schema["properties"].(map[string]interface{})
+	if city := props["city"].(map[string]interface{}); city["type"] != "string" {
+		t.Errorf("required field should stay non-null: %v", city)
+	}
+	level := props["level"].(map[string]interface{})
+	if !reflect.DeepEqual(level["type"], []interface{}{"integer", "null"}) || !reflect.DeepEqual(level["enum"], []interface{}{1.0, 2.0, 3.0, nil}) {
+		t.Errorf("unexpected level schema: %v", level)
+	}
+	if tags := props["tags"].(map[string]interface{}); !reflect.DeepEqual(tags["type"], []interface{}{"array", "null"}) {
+		t.Errorf("unexpected tags schema: %v", tags)
+	}
+	if err := ValidateSchema(schema, []byte(`{"city":"北京","sky":"sunny","temp_c":1,"level":null,"tags":null,"warning":null}`)); err != nil {
+		t.Errorf("null optional fields should be valid: %v", err)
+	}
+
+	type withMap struct {
+		Name  string            `json:"name"`
+		Attrs map[string]string `json:"attrs"`
+	}
+	if _, err := StrictSchema(SchemaFor[withMap]()); err == nil || !strings.Contains(err.Error(), "$.attrs") {
+		t.Errorf("expected map error, got %v", err)
+	}
+
+	client := &fakeClient{structured: true}
+	_, err = CompleteStructured[withMap](context.Background(), client, userRequest(), WithStrictSchema())
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) || len(client.requests) != 0 {
+		t.Errorf("expected error before sending, got %v (%d requests)", err, len(client.requests))
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := SchemaFor[weather]()
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr []string
+	}{
+		{"valid", `{"city":"北京","sky":"sunny","temp_c":21.5,"warning":null}`, nil},
+		{"missing required", `{"city":"北京","sky":"sunny"}`, []string{`缺少必需字段 "temp_c"`}},
+		{"wrong type", `{"city":1,"sky":"sunny","temp_c":"hot"}`, []string{"$.city", "$.temp_c"}},
+		{"enum", `{"city":"北京","sky":"snow","temp_c":1}`, []string{"$.sky"}},
+		{"additional property", `{"city":"北京","sky":"sunny","temp_c":1,"wind":3}`, []string{`"wind"`}},
+		{"array items", `{"city":"北京","sky":"sunny","temp_c":1,"tags":["a",2]}`, []string{"$.tags[1]"}},
+		{"invalid json", `{"city":`, []string{"JSON"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(schema, []byte(tt.data))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompleteStructured_Native(t *testing.T) {
+	client := &fakeClient{
+		structured: true,
+		responses: []*ChatResponse{{
+			Content: "```json\n{\"city\":\"北京\",\"sky\":\"sunny\",\"temp_c\":21,\"level\":null,\"tags\":null,\"warning\":null}\n```",
+		}},
+	}
+
+	got, err := CompleteStructured[weather](context.Background(), client, userRequest(), WithStrictSchema())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.City != "北京" || got.TempC != 21 {
+		t.Errorf("unexpected result: %+v", got)
+	}
+
+	req := client.requests[0]
+	if req.ResponseFormat == nil || req.ResponseFormat.Name != DefaultStructuredName || !req.ResponseFormat.Strict {
+		t.Errorf("unexpected response format: %+v", req.ResponseFormat)
+	}
+	if required := req.ResponseFormat.Schema["required"].([]interface{}); len(required) != 6 {
+		t.Errorf("strict schema required = %v, want all 6 properties", required)
+	}
+	if len(req.Tools) != 0 || req.ToolChoice != nil {
+		t.Error("native mode should not add tools")
+	}
+}
+
+func TestCompleteStructured_ToolRetry(t *testing.T) {
+	client := &fakeClient{
+		responses: []*ChatResponse{
+			{ToolCalls: []*ToolCall{NewToolCall("call_1", "report", `{"city":"北京","sky":"snow"}`)}},
+			{ToolCalls: []*ToolCall{NewToolCall("call_2", "report", `{"city":"北京","sky":"rain","temp_c":3}`)}},
+		},
+	}
+	req := userRequest()
+	req.Tools = []*Tool{NewTool("search", "搜索", nil)}
+
+	got, err := CompleteStructured[weather](context.Background(), client, req, WithSchemaName("report", "天气报告"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Sky != "rain" || got.TempC != 3 {
+		t.Errorf("unexpected result: %+v", got)
+	}
+	if len(client.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(client.requests))
+	}
+
+	first := client.requests[0]
+	if len(first.Tools) != 1 || first.Tools[0].Function.Name != "report" {
+		t.Errorf("tool mode should replace tools: %+v", first.Tools)
+	}
+	if first.ToolChoice == nil || first.ToolChoice.Type != ToolChoiceTypeTool || first.ToolChoice.Name != "report" {
+		t.Errorf("unexpected tool choice: %+v", first.ToolChoice)
+	}
+
+	// 重试请求带上工具调用和校验错误
+	second := client.requests[1].Messages
+	if len(second) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(second))
+	}
+	if second[1].Role != RoleAssistant || len(second[1].ToolCalls) != 1 {
+		t.Errorf("expected assistant tool call, got %+v", second[1])
+	}
+	feedback := second[2]
+	if feedback.Role != RoleTool || feedback.ToolCallID != "call_1" {
+		t.Errorf("expected tool feedback, got %+v", feedback)
+	}
+	text := TextString(feedback.Content)
+	if !strings.Contains(text, "temp_c") || !strings.Contains(text, "$.sky") {
+		t.Errorf("feedback missing validation errors: %s", text)
+	}
+
+	// 原请求不受影响
+	if len(req.Messages) != 1 || len(req.Tools) != 1 || req.Tools[0].Function.Name != "search" {
+		t.Error("original request was modified")
+	}
+}
+
+func TestCompleteStructured_PromptExhausted(t *testing.T) {
+	client := &fakeClient{
+		structured: true,
+		responses: []*ChatResponse{
+			{Content: "不知道"},
+			{Content: `{"city":"北京"}`},
+		},
+	}
+
+	_, err := CompleteStructured[weather](context.Background(), client, userRequest(),
+		WithStructuredMode(StructuredModePrompt), WithStructuredRetries(1))
+
+	var structErr *StructuredOutputError
+	if !errors.As(err, &structErr) {
+		t.Fatalf("expected StructuredOutputError, got %v", err)
+	}
+	if structErr.Attempts != 2 || structErr.Raw != `{"city":"北京"}` {
+		t.Errorf("unexpected error: %+v", structErr)
+	}
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Errorf("expected SchemaError, got %v", structErr.Err)
+	}
+
+	first := client.requests[0]
+	if first.ResponseFormat != nil || !strings.Contains(first.System, "JSON Schema") {
+		t.Errorf("prompt mode should describe schema in system prompt: %q", first.System)
+	}
+	second := client.requests[1].Messages
+	if last := second[len(second)-1]; last.Role != RoleUser {
+		t.Errorf("expected user feedback, got %s", last.Role)
+	}
+}
+
+func TestCompleteStructured_Thinking(t *testing.T) {
+	answer := `{"city":"北京","sky":"rain","temp_c":3}`
+	thinking := []*ThinkingBlock{{Thinking: "想一想", Signature: "sig"}}
+
+	// 自动模式启用扩展思考时不强制调用工具，改用提示词
+	client := &fakeClient{responses: []*ChatResponse{{Content: answer}}}
+	req := userRequest()
+	req.Thinking = &ThinkingConfig{BudgetTokens: 1024}
+	if _, err := CompleteStructured[weather](context.Background(), client, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first := client.requests[0]; first.ToolChoice != nil || len(first.Tools) != 0 || !strings.Contains(first.System, "JSON Schema") {
+		t.Errorf("auto mode with thinking should use prompt mode: %+v", first)
+	}
+
+	// 指定工具模式时不强制 tool_choice，重试时带回思考内容
+	client = &fakeClient{responses: []*ChatResponse{
+		{Thinking: thinking, ToolCalls: []*ToolCall{NewToolCall("call_1", DefaultStructuredName, `{}`)}},
+		{ToolCalls: []*ToolCall{NewToolCall("call_2", DefaultStructuredName, answer)}},
+	}}
+	if _, err := CompleteStructured[weather](context.Background(), client, req, WithStructuredMode(StructuredModeTool)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := client.requests[0]
+	if first.ToolChoice == nil || first.ToolChoice.Type == ToolChoiceTypeTool || !strings.Contains(first.System, DefaultStructuredName) {
+		t.Errorf("tool mode with thinking should not force the tool: %+v", first.ToolChoice)
+	}
+	if msgs := client.requests[1].Messages; len(msgs[1].Thinking) != 1 {
+		t.Errorf("expected thinking in assistant feedback message, got %+v", msgs[1])
+	}
+}
+
+func TestCompleteStructured_MultipleToolCalls(t *testing.T) {
+	answer := `{"city":"北京","sky":"rain","temp_c":3}`
+	client := &fakeClient{responses: []*ChatResponse{
+		{ToolCalls: []*ToolCall{
+			NewToolCall("call_1", DefaultStructuredName, answer),
+			NewToolCall("call_2", DefaultStructuredName, `{"city":"上海","sky":"sunny","temp_c":9}`),
+		}},
+		{ToolCalls: []*ToolCall{NewToolCall("call_3", DefaultStructuredName, answer)}},
+	}}
+
+	got, err := CompleteStructured[weather](context.Background(), client, userRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.City != "北京" || len(client.requests) != 2 {
+		t.Errorf("expected retry after multiple tool calls, got %+v in %d requests", got, len(client.requests))
+	}
+
+	// 每个工具调用都有对应的工具结果
+	second := client.requests[1].Messages
+	if len(second) != 4 || second[2].ToolCallID != "call_1" || second[3].ToolCallID != "call_2" {
+		t.Fatalf("expected feedback for both tool calls, got %+v", second)
+	}
+	if text := TextString(second[2].Content); !strings.Contains(text, "只能调用一次") {
+		t.Errorf("feedback should explain multiple tool calls: %s", text)
+	}
+}
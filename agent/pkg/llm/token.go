@@ -0,0 +1,95 @@
+package llm
+
+import "encoding/json"
+
+// TokenCounter token 计数器
+// 实现见 tokenizer 包 (BPE 分词和按字符估算)
+type TokenCounter interface {
+	// CountTokens 返回文本的 token 数
+	CountTokens(text string) int
+}
+
+const (
+	// tokensPerMessage 每条消息的格式开销 (角色和分隔标记)
+	tokensPerMessage = 3
+	// tokensPerName 消息带 name 时的额外开销
+	tokensPerName = 1
+	// tokensReplyPriming 回复开头的格式开销
+	tokensReplyPriming = 3
+
+	// ImageTokenEstimate 每张图像的估算 token 数
+	// 按较大的图像估算 (约 1.15 百万像素)，宁可高估也不超出上下文窗口
+	ImageTokenEstimate = 1600
+
+	// DocumentTokenEstimate 每个二进制文档 (如 PDF) 的估算 token 数
+	// 无法得知页数，按约 1 页的文字和图像估算，长文档需要调用者自行预留
+	DocumentTokenEstimate = 3000
+
+	// AudioTokenEstimate 每段音频的估算 token 数 (约 1 分钟)
+	AudioTokenEstimate = 1500
+)
+
+// CountMessageTokens 估算单条消息的 token 数，包括格式开销、工具调用和图像
+func CountMessageTokens(counter TokenCounter, msg *Message) int {
+	n := tokensPerMessage + counter.CountTokens(string(msg.Role)) + countContentTokens(counter, msg.Content)
+	if msg.Name != "" {
+		n += tokensPerName + counter.CountTokens(msg.Name)
+	}
+	for _, tc := range msg.ToolCalls {
+		if tc.Function != nil {
+			n += counter.CountTokens(tc.Function.Name) + counter.CountTokens(tc.Function.Arguments)
+		}
+	}
+	return n
+}
+
+// CountMessagesTokens 估算消息列表的 token 数
+func CountMessagesTokens(counter TokenCounter, msgs []*Message) int {
+	n := 0
+	for _, msg := range msgs {
+		n += CountMessageTokens(counter, msg)
+	}
+	return n
+}
+
+// CountRequestTokens 估算请求的输入 token 数 (不含 MaxTokens)
+// 包括系统提示词、消息和工具定义
+func CountRequestTokens(counter TokenCounter, req *ChatRequest) int {
+	n := tokensReplyPriming + CountMessagesTokens(counter, req.Messages)
+	if req.System != "" {
+		n += tokensPerMessage + counter.CountTokens(req.System)
+	}
+	for _, t := range req.Tools {
+		if data, err := json.Marshal(t); err == nil {
+			n += counter.CountTokens(string(data))
+		}
+	}
+	return n
+}
+
+// countContentTokens 估算消息内容的 token 数
+func countContentTokens(counter TokenCounter, content Content) int {
+	switch v := content.(type) {
+	case nil:
+		return 0
+	case *TextContent:
+		return counter.CountTokens(v.Text)
+	case *ImageContent:
+		return ImageTokenEstimate
+	case *DocumentContent:
+		if v.Source.Type == "text" {
+			return counter.CountTokens(v.Source.Data)
+		}
+		return DocumentTokenEstimate
+	case *AudioContent:
+		return AudioTokenEstimate
+	case *ContentList:
+		n := 0
+		for _, item := range v.Items {
+			n += countContentTokens(counter, item)
+		}
+		return n
+	default:
+		return counter.CountTokens(TextString(content))
+	}
+}
@@ -0,0 +1,56 @@
+package llm
+
+import "testing"
+
+// byteCounter 每个字节算一个 token
+type byteCounter struct{}
+
+func (byteCounter) CountTokens(text string) int {
+	return len(text)
+}
+
+func TestCountRequestTokens(t *testing.T) {
+	counter := byteCounter{}
+
+	msg := &Message{Role: RoleUser, Content: Text("hello")}
+	if n := CountMessageTokens(counter, msg); n != tokensPerMessage+len("user")+len("hello") {
+		t.Errorf("unexpected message tokens: %d", n)
+	}
+
+	image := &Message{Role: RoleUser, Content: NewContentList(Text("hi"), ImageFromURL("https://example.com/a.png"))}
+	if n := CountMessageTokens(counter, image); n != tokensPerMessage+len("user")+len("hi")+ImageTokenEstimate {
+		t.Errorf("unexpected image message tokens: %d", n)
+	}
+
+	call := &Message{Role: RoleAssistant, ToolCalls: []*ToolCall{NewToolCall("call_1", "search", `{"q":"go"}`)}}
+	if n := CountMessageTokens(counter, call); n != tokensPerMessage+len("assistant")+len("search")+len(`{"q":"go"}`) {
+		t.Errorf("unexpected tool call tokens: %d", n)
+	}
+
+	req := &ChatRequest{System: "sys", Messages: []*Message{msg}}
+	want := tokensReplyPriming + CountMessageTokens(counter, msg) + tokensPerMessage + len("sys")
+	if n := CountRequestTokens(counter, req); n != want {
+		t.Errorf("expected %d, got %d", want, n)
+	}
+	req.Tools = []*Tool{NewTool("search", "搜索", nil)}
+	if n := CountRequestTokens(counter, req); n <= want {
+		t.Errorf("tool definitions should be counted, got %d", n)
+	}
+}
+
+func TestLookupModel(t *testing.T) {
+	tests := map[string]int{
+		"claude-sonnet-4-20250514": 200000,
+		"gpt-4o-mini":              128000,
+		"gpt-4":                    8192,
+		"gpt-4-turbo-2024-04-09":   128000,
+		"models/gemini-2.5-flash":  1048576,
+		"openai/gpt-4.1-mini":      1047576,
+		"unknown-model":            0,
+	}
+	for model, want := range tests {
+		if got := ContextWindowFor(model); got != want {
+			t.Errorf("ContextWindowFor(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Encoding BPE 编码，与 tiktoken 的 encode_ordinary 结果一致
+// 特殊 token (如 <|endoftext|>) 按普通文本编码
+type Encoding struct {
+	name    string
+	ranks   map[string]int
+	decoder map[int]string
+	split   splitter
+}
+
+// NewEncoding 根据合并优先级表和预分词规则创建编码
+// ranks: token 字节序列 -> 编号 (编号越小越先合并)
+func NewEncoding(name string, ranks map[string]int, pattern Pattern) (*Encoding, error) {
+	split, err := newSplitter(pattern)
+	if err != nil {
+		return nil, err
+	}
+	decoder := make(map[int]string, len(ranks))
+	for token, rank := range ranks {
+		decoder[rank] = token
+	}
+	return &Encoding{
+		name:    name,
+		ranks:   ranks,
+		decoder: decoder,
+		split:   split,
+	}, nil
+}
+
+// LoadRanks 读取 tiktoken 格式的词表
+// 每行为 "base64(token) 编号"
+func LoadRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		encoded, rankText, ok := bytes.Cut(text, []byte(" "))
+		if !ok {
+			return nil, fmt.Errorf("词表第 %d 行格式错误", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("词表第 %d 行 token 解码失败: %w", line, err)
+		}
+		rank, err := strconv.Atoi(string(rankText))
+		if err != nil {
+			return nil, fmt.Errorf("词表第 %d 行编号无效: %w", line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取词表失败: %w", err)
+	}
+	return ranks, nil
+}
+
+// Name 返回编码名称
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode 把文本编码为 token 编号
+func (e *Encoding) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range e.split(text) {
+		if rank, ok := e.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		tokens = e.bytePairEncode(piece, tokens)
+	}
+	return tokens
+}
+
+// Decode 把 token 编号还原为文本，未知编号忽略
+func (e *Encoding) Decode(tokens []int) string {
+	var buf bytes.Buffer
+	for _, t := range tokens {
+		buf.WriteString(e.decoder[t])
+	}
+	return buf.String()
+}
+
+// CountTokens 返回文本的 token 数
+// 实现 llm.TokenCounter 接口
+func (e *Encoding) CountTokens(text string) int {
+	n := 0
+	for _, piece := range e.split(text) {
+		if _, ok := e.ranks[piece]; ok {
+			n++
+			continue
+		}
+		n += len(e.bytePairMerge(piece)) - 1
+	}
+	return n
+}
+
+// bytePairEncode 把一个预分词片段编码后追加到 tokens
+func (e *Encoding) bytePairEncode(piece string, tokens []int) []int {
+	parts := e.bytePairMerge(piece)
+	for i := 0; i < len(parts)-1; i++ {
+		tokens = append(tokens, e.ranks[piece[parts[i]:parts[i+1]]])
+	}
+	return tokens
+}
+
+// bytePairMerge 从单字节开始，反复合并优先级最高 (编号最小) 的相邻片段
+// 返回片段边界，长度为片段数 + 1
+func (e *Encoding) bytePairMerge(piece string) []int {
+	parts := make([]int, len(piece)+1)
+	for i := range parts {
+		parts[i] = i
+	}
+
+	for len(parts) > 2 {
+		minRank, minIdx := math.MaxInt, -1
+		for i := 0; i < len(parts)-2; i++ {
+			if rank, ok := e.ranks[piece[parts[i]:parts[i+2]]]; ok && rank < minRank {
+				minRank, minIdx = rank, i
+			}
+		}
+		if minIdx < 0 {
+			break
+		}
+		parts = append(parts[:minIdx+1], parts[minIdx+2:]...)
+	}
+	return parts
+}
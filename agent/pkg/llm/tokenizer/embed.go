@@ -0,0 +1,24 @@
+//go:build tiktoken_embed
+
+package tokenizer
+
+import "embed"
+
+// 使用 tiktoken_embed 构建标签时把 vocab/ 下的词表编译进二进制:
+//
+//	go generate ./pkg/llm/tokenizer
+//	go build -tags tiktoken_embed ./...
+//
+// 词表不在仓库中，由 go generate 下载并校验 SHA-256 (见 gen_vocab.go)。
+// 没有下载词表时仍可以构建，对应编码按未嵌入处理。
+
+//go:embed vocab/*
+var vocabFS embed.FS
+
+func init() {
+	for name := range patterns {
+		if data, err := vocabFS.ReadFile("vocab/" + name + ".tiktoken"); err == nil {
+			embeddedVocab[name] = data
+		}
+	}
+}
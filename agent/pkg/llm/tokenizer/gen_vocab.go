@@ -0,0 +1,76 @@
+//go:build ignore
+
+// gen_vocab 下载内置编码的词表到 vocab/ 并校验 SHA-256，供 tiktoken_embed 构建标签嵌入
+//
+//	go generate ./pkg/llm/tokenizer
+//	go build -tags tiktoken_embed ./...
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// vocabs 编码名称 -> 下载地址和 SHA-256 (与 tiktoken 的 openai_public.py 一致)
+var vocabs = map[string]struct{ url, sha256 string }{
+	"cl100k_base": {
+		url:    "https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken",
+		sha256: "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7",
+	},
+	"o200k_base": {
+		url:    "https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken",
+		sha256: "446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d",
+	},
+}
+
+func main() {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	for name, v := range vocabs {
+		path := filepath.Join("vocab", name+".tiktoken")
+		if data, err := os.ReadFile(path); err == nil && checksum(data) == v.sha256 {
+			log.Printf("%s 已存在，跳过", path)
+			continue
+		}
+		if err := fetch(client, v.url, v.sha256, path); err != nil {
+			log.Fatalf("下载 %s 失败: %v", name, err)
+		}
+		log.Printf("已写入 %s", path)
+	}
+}
+
+// fetch 下载文件，校验通过后再写入 path
+func fetch(client *http.Client, url, want, path string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if got := checksum(data); got != want {
+		return fmt.Errorf("SHA-256 不匹配: 期望 %s，实际 %s", want, got)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,42 @@
+package tokenizer
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultCharsPerToken 英文文本平均每个 token 的字符数
+const DefaultCharsPerToken = 4.0
+
+// Heuristic 按字符数估算 token，用于没有公开词表的模型 (Claude、Gemini、本地模型)
+//
+// ASCII 字符按 CharsPerToken 个字符一个 token，
+// 中日韩文字每个字符一个 token，其他非 ASCII 字符每两个字符一个 token。
+// 估算值通常略高于实际值，适合做上下文预算。
+type Heuristic struct {
+	// CharsPerToken 每个 token 的 ASCII 字符数，0 使用 DefaultCharsPerToken
+	CharsPerToken float64
+}
+
+// CountTokens 估算文本的 token 数
+// 实现 llm.TokenCounter 接口
+func (h Heuristic) CountTokens(text string) int {
+	perToken := h.CharsPerToken
+	if perToken <= 0 {
+		perToken = DefaultCharsPerToken
+	}
+
+	var ascii, cjk, other int
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+			ascii++
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+		default:
+			other++
+		}
+	}
+	return int(math.Ceil(float64(ascii)/perToken)) + cjk + (other+1)/2
+}
@@ -0,0 +1,88 @@
+package tokenizer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Pattern 预分词正则 (Go regexp 语法)
+//
+// tiktoken 的原始规则包含 \s+(?!\S)，Go regexp 不支持前瞻，
+// 这里去掉该分支，由 splitter 在匹配后处理: 纯空白片段后面紧跟非空白字符时，
+// 最后一个空白字符留给下一个片段 (如 " world" 的前导空格)。
+// \s 在 Go 中只匹配 ASCII 空白，这里展开为 Unicode 空白。
+type Pattern string
+
+// whitespace Unicode 空白字符集合 (与 Rust regex 的 \s 一致)
+const whitespace = `\t\n\v\f\r\x{85}\p{Z}`
+
+const (
+	// PatternCL100K cl100k_base 的预分词规则
+	PatternCL100K Pattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)` +
+		`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n]*` +
+		`|[` + whitespace + `]*[\r\n]+` +
+		`|[` + whitespace + `]+`
+
+	// PatternO200K o200k_base 的预分词规则
+	PatternO200K Pattern = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n/]*` +
+		`|[` + whitespace + `]*[\r\n]+` +
+		`|[` + whitespace + `]+`
+)
+
+// splitter 把文本切分为预分词片段
+type splitter func(text string) []string
+
+// newSplitter 编译预分词规则
+func newSplitter(pattern Pattern) (splitter, error) {
+	re, err := regexp.Compile(string(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("预分词规则无效: %w", err)
+	}
+
+	return func(text string) []string {
+		var pieces []string
+		for pos := 0; pos < len(text); {
+			loc := re.FindStringIndex(text[pos:])
+			if loc == nil || loc[0] != 0 || loc[1] == 0 {
+				// 规则覆盖所有字符，不会发生；防御性地按单个字符切分
+				_, size := utf8.DecodeRuneInString(text[pos:])
+				pieces = append(pieces, text[pos:pos+size])
+				pos += size
+				continue
+			}
+			end := pos + giveBackSpace(text[pos:pos+loc[1]], text[pos+loc[1]:])
+			pieces = append(pieces, text[pos:end])
+			pos = end
+		}
+		return pieces
+	}, nil
+}
+
+// giveBackSpace 模拟 \s+(?!\S): 返回片段应保留的长度
+func giveBackSpace(piece, rest string) int {
+	if rest == "" || strings.ContainsAny(piece, "\r\n") {
+		return len(piece)
+	}
+	next, _ := utf8.DecodeRuneInString(rest)
+	if unicode.IsSpace(next) {
+		return len(piece)
+	}
+	for _, r := range piece {
+		if !unicode.IsSpace(r) {
+			return len(piece)
+		}
+	}
+	last, size := utf8.DecodeLastRuneInString(piece)
+	if size == len(piece) || !unicode.IsSpace(last) {
+		return len(piece)
+	}
+	return len(piece) - size
+}
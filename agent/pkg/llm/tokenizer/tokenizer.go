@@ -0,0 +1,158 @@
+// Package tokenizer 提供 llm.TokenCounter 的实现
+//
+// OpenAI 模型使用与 tiktoken 兼容的 BPE 编码 (cl100k_base、o200k_base)，
+// 没有公开词表的模型 (Claude、Gemini、本地模型) 使用 Heuristic 按字符估算。
+//
+// 词表按以下顺序查找:
+//   - RegisterEncoding 注册的编码
+//   - 使用 tiktoken_embed 构建标签编译时嵌入的词表 (vocab/<编码名>.tiktoken)
+//   - 环境变量 TIKTOKEN_VOCAB_DIR 目录下的 <编码名>.tiktoken 文件
+//
+// 词表文件即 tiktoken 发布的 cl100k_base.tiktoken 和 o200k_base.tiktoken，不在仓库中，
+// 用 go generate 下载到 vocab/ 并校验 SHA-256。
+// OpenAI 模型找不到词表时 ForModel 返回 ErrVocabNotFound，同时返回 Heuristic 供调用方退回估算。
+//
+//	counter, err := tokenizer.ForModel("gpt-4o")
+//	n := llm.CountRequestTokens(counter, req)
+package tokenizer
+
+//go:generate go run gen_vocab.go
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+)
+
+const (
+	// CL100KBase GPT-4、GPT-3.5 和 text-embedding-3 使用的编码
+	CL100KBase = "cl100k_base"
+	// O200KBase GPT-4o、GPT-4.1、o 系列使用的编码
+	O200KBase = "o200k_base"
+
+	// VocabDirEnv 词表目录环境变量
+	VocabDirEnv = "TIKTOKEN_VOCAB_DIR"
+)
+
+// ErrVocabNotFound 找不到编码的词表
+var ErrVocabNotFound = errors.New("找不到词表")
+
+// patterns 内置编码的预分词规则
+var patterns = map[string]Pattern{
+	CL100KBase: PatternCL100K,
+	O200KBase:  PatternO200K,
+}
+
+// modelEncodings 模型名称前缀 -> 编码，最长前缀优先
+var modelEncodings = map[string]string{
+	"gpt-5":                  O200KBase,
+	"gpt-4.1":                O200KBase,
+	"gpt-4.5":                O200KBase,
+	"gpt-4o":                 O200KBase,
+	"chatgpt-4o":             O200KBase,
+	"o1":                     O200KBase,
+	"o3":                     O200KBase,
+	"o4":                     O200KBase,
+	"gpt-4":                  CL100KBase,
+	"gpt-3.5-turbo":          CL100KBase,
+	"text-embedding-3":       CL100KBase,
+	"text-embedding-ada-002": CL100KBase,
+}
+
+// embeddedVocab 编译时嵌入的词表 (见 embed.go)
+var embeddedVocab = map[string][]byte{}
+
+var (
+	mu        sync.Mutex
+	encodings = make(map[string]*Encoding)
+)
+
+// RegisterEncoding 注册编码，覆盖同名编码
+func RegisterEncoding(enc *Encoding) {
+	mu.Lock()
+	defer mu.Unlock()
+	encodings[enc.Name()] = enc
+}
+
+// GetEncoding 按名称获取内置编码，首次使用时加载词表
+func GetEncoding(name string) (*Encoding, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if enc, ok := encodings[name]; ok {
+		return enc, nil
+	}
+	pattern, ok := patterns[name]
+	if !ok {
+		return nil, fmt.Errorf("未知编码: %s", name)
+	}
+
+	ranks, err := loadVocab(name)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := NewEncoding(name, ranks, pattern)
+	if err != nil {
+		return nil, err
+	}
+	encodings[name] = enc
+	return enc, nil
+}
+
+// loadVocab 从嵌入数据或词表目录加载词表
+func loadVocab(name string) (map[string]int, error) {
+	if data, ok := embeddedVocab[name]; ok {
+		return LoadRanks(bytes.NewReader(data))
+	}
+
+	dir := os.Getenv(VocabDirEnv)
+	if dir == "" {
+		return nil, fmt.Errorf("%w: %s (未嵌入词表，也未设置 %s；运行 go generate ./pkg/llm/tokenizer 下载后用 -tags tiktoken_embed 构建)", ErrVocabNotFound, name, VocabDirEnv)
+	}
+	f, err := os.Open(filepath.Join(dir, name+".tiktoken"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrVocabNotFound, err)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return LoadRanks(f)
+}
+
+// EncodingForModel 返回模型使用的编码名称，不是 OpenAI 模型时返回空字符串
+func EncodingForModel(model string) string {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var best string
+	for prefix := range modelEncodings {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	return modelEncodings[best]
+}
+
+// ForModel 返回模型的 token 计数器
+// OpenAI 模型使用 BPE 编码，找不到词表时返回 Heuristic 和 ErrVocabNotFound；
+// 其他模型使用 Heuristic
+func ForModel(model string) (llm.TokenCounter, error) {
+	name := EncodingForModel(model)
+	if name == "" {
+		return Heuristic{}, nil
+	}
+	enc, err := GetEncoding(name)
+	if err != nil {
+		return Heuristic{}, err
+	}
+	return enc, nil
+}
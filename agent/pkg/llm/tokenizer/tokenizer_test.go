@@ -0,0 +1,231 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testRanks 单字节 token 按字节值编号，再加上几个合并规则
+func testRanks() map[string]int {
+	ranks := make(map[string]int)
+	for i := 0; i < 256; i++ {
+		ranks[string([]byte{byte(i)})] = i
+	}
+	for i, merged := range []string{"lo", "he", "hel", "hello", " w", " wo", " wor", "ld"} {
+		ranks[merged] = 256 + i
+	}
+	return ranks
+}
+
+func newTestEncoding(t *testing.T) *Encoding {
+	t.Helper()
+	enc, err := NewEncoding("test", testRanks(), PatternCL100K)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return enc
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		pattern Pattern
+		text    string
+		want    []string
+	}{
+		{PatternCL100K, "Hello world", []string{"Hello", " world"}},
+		{PatternCL100K, "hello   world", []string{"hello", "  ", " world"}},
+		{PatternCL100K, "I'm 12345!", []string{"I", "'m", " ", "123", "45", "!"}},
+		{PatternCL100K, "a\n\nb", []string{"a", "\n\n", "b"}},
+		{PatternCL100K, "end  ", []string{"end", "  "}},
+		{PatternCL100K, "x = {\"k\": 1}\n", []string{"x", " =", " {\"", "k", "\":", " ", "1", "}\n"}},
+		{PatternCL100K, "你好，世界", []string{"你好", "，世界"}},
+		{PatternCL100K, "a　　b", []string{"a", "　", "　b"}},
+		{PatternO200K, "HelloWorld don't", []string{"Hello", "World", " don't"}},
+		{PatternO200K, "x.\n/y", []string{"x", ".\n/", "y"}},
+	}
+
+	for _, tt := range tests {
+		split, err := newSplitter(tt.pattern)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := split(tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if strings.Join(got, "") != tt.text {
+			t.Errorf("split(%q) lost characters: %q", tt.text, got)
+		}
+	}
+}
+
+func TestEncoding_EncodeDecode(t *testing.T) {
+	enc := newTestEncoding(t)
+
+	tests := []struct {
+		text string
+		want []int
+	}{
+		// "hello" 在词表中直接作为一个 token，" world" 依次合并为 " w"、" wo"、" wor" 和 "ld"
+		{"hello world", []int{259, 262, 263}},
+		// "lo" 编号更小先合并，"hel" 不再可能
+		{"helo", []int{257, 256}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := enc.Encode(tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+		if n := enc.CountTokens(tt.text); n != len(tt.want) {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, n, len(tt.want))
+		}
+		if decoded := enc.Decode(got); decoded != tt.text {
+			t.Errorf("Decode(Encode(%q)) = %q", tt.text, decoded)
+		}
+	}
+
+	// 多字节字符按 UTF-8 字节编码，解码后还原
+	text := "héllo 世界"
+	if decoded := enc.Decode(enc.Encode(text)); decoded != text {
+		t.Errorf("round trip failed: %q", decoded)
+	}
+}
+
+func TestLoadRanks(t *testing.T) {
+	data := fmt.Sprintf("%s 0\n%s 1\n\n%s 2\n",
+		base64.StdEncoding.EncodeToString([]byte("a")),
+		base64.StdEncoding.EncodeToString([]byte(" b")),
+		base64.StdEncoding.EncodeToString([]byte{0xe4, 0xb8}))
+
+	ranks, err := LoadRanks(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]int{"a": 0, " b": 1, "\xe4\xb8": 2}
+	if !reflect.DeepEqual(ranks, want) {
+		t.Errorf("got %v, want %v", ranks, want)
+	}
+
+	if _, err := LoadRanks(strings.NewReader("YQ==\n")); err == nil {
+		t.Error("expected error for missing rank")
+	}
+}
+
+func TestHeuristic(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"hello world", 3},
+		{"你好世界", 4},
+		{"héllo", 2},
+	}
+	for _, tt := range tests {
+		if got := (Heuristic{}).CountTokens(tt.text); got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+	if got := (Heuristic{CharsPerToken: 2}).CountTokens("abcd"); got != 2 {
+		t.Errorf("expected 2, got %d", got)
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4o-mini":              O200KBase,
+		"openai/gpt-4.1":           O200KBase,
+		"o3-mini":                  O200KBase,
+		"gpt-4-turbo":              CL100KBase,
+		"gpt-3.5-turbo-0125":       CL100KBase,
+		"claude-sonnet-4-20250514": "",
+		"models/gemini-2.5-flash":  "",
+		"text-embedding-3-small":   CL100KBase,
+	}
+	for model, want := range tests {
+		if got := EncodingForModel(model); got != want {
+			t.Errorf("EncodingForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestForModel(t *testing.T) {
+	// 没有词表时返回错误和估算
+	t.Setenv(VocabDirEnv, t.TempDir())
+	if len(embeddedVocab) == 0 {
+		counter, err := ForModel("gpt-4")
+		if !errors.Is(err, ErrVocabNotFound) {
+			t.Fatalf("expected ErrVocabNotFound, got %v", err)
+		}
+		if _, ok := counter.(Heuristic); !ok {
+			t.Error("expected heuristic fallback")
+		}
+	}
+	if counter, err := ForModel("claude-sonnet-4"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if _, ok := counter.(Heuristic); !ok {
+		t.Error("expected heuristic for non-OpenAI model")
+	}
+
+	// 从词表目录加载
+	dir := t.TempDir()
+	var sb strings.Builder
+	for token, rank := range testRanks() {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	if err := os.WriteFile(filepath.Join(dir, CL100KBase+".tiktoken"), []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(VocabDirEnv, dir)
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(encodings, CL100KBase)
+		mu.Unlock()
+	})
+
+	counter, err := ForModel("gpt-4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	enc, ok := counter.(*Encoding)
+	if !ok {
+		t.Fatal("expected BPE encoding")
+	}
+	if enc.Name() != CL100KBase || enc.CountTokens("hello world") != 3 {
+		t.Errorf("unexpected encoding %s: %d tokens", enc.Name(), enc.CountTokens("hello world"))
+	}
+}
+
+func TestForModel_Vocab(t *testing.T) {
+	// 使用真实词表 (嵌入或 TIKTOKEN_VOCAB_DIR) 计数
+	tests := []struct {
+		model string
+		text  string
+		want  int
+	}{
+		{"gpt-4", "hello world", 2},
+		{"gpt-4", "tiktoken is great!", 6},
+		{"gpt-4o", "hello world", 2},
+	}
+	for _, tt := range tests {
+		counter, err := ForModel(tt.model)
+		if errors.Is(err, ErrVocabNotFound) {
+			t.Skipf("未找到词表: %v", err)
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := counter.CountTokens(tt.text); got != tt.want {
+			t.Errorf("%s CountTokens(%q) = %d, want %d", tt.model, tt.text, got, tt.want)
+		}
+	}
+}
@@ -42,6 +42,45 @@ type FunctionCall struct {
 	Arguments string `json:"arguments"`
 }
 
+// ToolChoiceType 工具选择类型
+type ToolChoiceType string
+
+const (
+	ToolChoiceTypeAuto     ToolChoiceType = "auto"     // 由模型决定是否调用工具
+	ToolChoiceTypeNone     ToolChoiceType = "none"     // 不调用工具
+	ToolChoiceTypeRequired ToolChoiceType = "required" // 必须调用任意一个工具
+	ToolChoiceTypeTool     ToolChoiceType = "tool"     // 必须调用指定工具
+)
+
+// ToolChoice 工具选择策略
+type ToolChoice struct {
+	// Type 选择类型
+	Type ToolChoiceType `json:"type"`
+
+	// Name 工具名称 (仅 Type 为 "tool" 时使用)
+	Name string `json:"name,omitempty"`
+}
+
+// ToolChoiceAuto 由模型决定是否调用工具
+func ToolChoiceAuto() *ToolChoice {
+	return &ToolChoice{Type: ToolChoiceTypeAuto}
+}
+
+// ToolChoiceNone 不调用工具
+func ToolChoiceNone() *ToolChoice {
+	return &ToolChoice{Type: ToolChoiceTypeNone}
+}
+
+// ToolChoiceRequired 必须调用任意一个工具
+func ToolChoiceRequired() *ToolChoice {
+	return &ToolChoice{Type: ToolChoiceTypeRequired}
+}
+
+// ToolChoiceFunction 必须调用指定工具
+func ToolChoiceFunction(name string) *ToolChoice {
+	return &ToolChoice{Type: ToolChoiceTypeTool, Name: name}
+}
+
 // NewTool 创建工具定义
 func NewTool(name, description string, parameters map[string]interface{}) *Tool {
 	return &Tool{
@@ -28,6 +28,15 @@ type ChatRequest struct {
 
 	// Stop 停止生成的序列
 	Stop []string `json:"stop,omitempty"`
+
+	// ToolChoice 工具选择策略 (可选，默认由模型决定)
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	// ResponseFormat 结构化输出格式 (可选，提供商不支持时忽略)
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	// Thinking 扩展思考配置 (可选，Anthropic 使用，其他提供商忽略)
+	Thinking *ThinkingConfig `json:"thinking,omitempty"`
 }
 
 // ChatResponse 表示聊天补全响应
@@ -52,6 +61,18 @@ type ChatResponse struct {
 
 	// StopReason 停止原因
 	StopReason StopReason `json:"stop_reason,omitempty"`
+
+	// Thinking 模型的思考内容 (启用扩展思考或推理模型返回时)
+	Thinking []*ThinkingBlock `json:"thinking,omitempty"`
+}
+
+// ThinkingText 返回拼接后的思考文本
+func (r *ChatResponse) ThinkingText() string {
+	var text string
+	for _, block := range r.Thinking {
+		text += block.Thinking
+	}
+	return text
 }
 
 // Usage token 使用统计
@@ -64,6 +85,54 @@ type Usage struct {
 
 	// TotalTokens 总 token 数
 	TotalTokens int `json:"total_tokens"`
+
+	// CacheReadTokens 从提示缓存读取的输入 token 数 (包含在 InputTokens 中)
+	CacheReadTokens int `json:"cache_read_tokens,omitempty"`
+
+	// CacheCreationTokens 写入提示缓存的输入 token 数 (包含在 InputTokens 中)
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
+
+	// ReasoningTokens 思考/推理使用的 token 数 (包含在 OutputTokens 中)
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+}
+
+// CacheTypeEphemeral 临时提示缓存
+const CacheTypeEphemeral = "ephemeral"
+
+// CacheControl 提示缓存断点
+// 标记在消息上时，该消息及之前的全部内容 (工具定义、系统提示、历史消息) 会被缓存，
+// 后续请求前缀相同时按缓存读取计费。目前由 Anthropic 使用，OpenAI 自动缓存无需标记。
+type CacheControl struct {
+	// Type 缓存类型，目前只有 "ephemeral"
+	Type string `json:"type"`
+
+	// TTL 缓存时长，"5m" (默认) 或 "1h"
+	TTL string `json:"ttl,omitempty"`
+}
+
+// EphemeralCache 创建默认时长的缓存断点
+func EphemeralCache() *CacheControl {
+	return &CacheControl{Type: CacheTypeEphemeral}
+}
+
+// ThinkingConfig 扩展思考配置
+type ThinkingConfig struct {
+	// BudgetTokens 思考可使用的最大 token 数
+	// Anthropic 要求至少 1024，且小于 MaxTokens
+	BudgetTokens int `json:"budget_tokens"`
+}
+
+// ThinkingBlock 模型返回的思考内容
+// 工具调用轮次中需要随助手消息原样发回 (包括签名)，否则 Anthropic 会拒绝请求
+type ThinkingBlock struct {
+	// Thinking 思考文本
+	Thinking string `json:"thinking,omitempty"`
+
+	// Signature 思考内容签名 (Anthropic)
+	Signature string `json:"signature,omitempty"`
+
+	// RedactedData 被加密的思考内容 (Anthropic redacted_thinking)
+	RedactedData string `json:"redacted_data,omitempty"`
 }
 
 // StopReason 表示响应停止的原因
@@ -0,0 +1,274 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SchemaError JSON 数据不符合 Schema
+type SchemaError struct {
+	// Errors 每个不符合的位置一条，格式为 "$.path: 说明"
+	Errors []string
+}
+
+func (e *SchemaError) Error() string {
+	return "JSON 不符合 Schema: " + strings.Join(e.Errors, "; ")
+}
+
+// ValidateSchema 校验 JSON 数据是否符合 Schema
+//
+// 支持 JSON Schema 的常用子集: type、properties、required、additionalProperties、
+// items、enum、const、minimum/maximum、minLength/maxLength、minItems/maxItems、pattern、anyOf。
+// 数据不是有效 JSON 时返回解析错误，不符合 Schema 时返回 *SchemaError。
+func ValidateSchema(schema map[string]interface{}, data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("无效的 JSON: %w", err)
+	}
+	v := &validator{}
+	v.validate("$", schema, value)
+	if len(v.errors) > 0 {
+		return &SchemaError{Errors: v.errors}
+	}
+	return nil
+}
+
+// validator 收集校验错误
+type validator struct {
+	errors []string
+}
+
+func (v *validator) addf(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+// validate 校验 value 是否符合 schema
+func (v *validator) validate(path string, schema map[string]interface{}, value interface{}) {
+	if len(schema) == 0 {
+		return
+	}
+
+	// anyOf: 任意一个子 Schema 通过即可
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			subSchema, _ := sub.(map[string]interface{})
+			trial := &validator{}
+			trial.validate(path, subSchema, value)
+			if len(trial.errors) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.addf(path, "不符合 anyOf 中的任何一个 Schema")
+			return
+		}
+	}
+
+	if !v.checkType(path, schema["type"], value) {
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		v.addf(path, "值 %s 不在枚举 %s 中", compactJSON(value), compactJSON(enum))
+	}
+	if c, ok := schema["const"]; ok && !equalValue(c, value) {
+		v.addf(path, "值必须为 %s", compactJSON(c))
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(path, schema, val)
+	case []interface{}:
+		v.validateArray(path, schema, val)
+	case string:
+		v.validateString(path, schema, val)
+	case float64:
+		v.validateNumber(path, schema, val)
+	}
+}
+
+// checkType 检查类型，type 可以是字符串或字符串数组
+func (v *validator) checkType(path string, typ interface{}, value interface{}) bool {
+	var types []string
+	switch t := typ.(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+	case []string:
+		types = t
+	default:
+		return true
+	}
+
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	v.addf(path, "类型应为 %s，实际为 %s", strings.Join(types, " 或 "), actual)
+	return false
+}
+
+// validateObject 校验对象的属性
+func (v *validator) validateObject(path string, schema map[string]interface{}, obj map[string]interface{}) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			v.addf(path, "缺少必需字段 %q", name)
+		}
+	}
+
+	// 按字段名排序，错误顺序稳定
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fieldPath := path + "." + name
+		if propSchema, ok := properties[name].(map[string]interface{}); ok {
+			v.validate(fieldPath, propSchema, obj[name])
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				v.addf(path, "不允许的字段 %q", name)
+			}
+		case map[string]interface{}:
+			v.validate(fieldPath, extra, obj[name])
+		}
+	}
+}
+
+// validateArray 校验数组元素和长度
+func (v *validator) validateArray(path string, schema map[string]interface{}, arr []interface{}) {
+	if n, ok := number(schema["minItems"]); ok && float64(len(arr)) < n {
+		v.addf(path, "至少需要 %v 个元素，实际 %d 个", n, len(arr))
+	}
+	if n, ok := number(schema["maxItems"]); ok && float64(len(arr)) > n {
+		v.addf(path, "最多 %v 个元素，实际 %d 个", n, len(arr))
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range arr {
+			v.validate(fmt.Sprintf("%s[%d]", path, i), items, item)
+		}
+	}
+}
+
+// validateString 校验字符串长度和格式
+func (v *validator) validateString(path string, schema map[string]interface{}, s string) {
+	length := len([]rune(s))
+	if n, ok := number(schema["minLength"]); ok && float64(length) < n {
+		v.addf(path, "长度至少为 %v，实际为 %d", n, length)
+	}
+	if n, ok := number(schema["maxLength"]); ok && float64(length) > n {
+		v.addf(path, "长度最多为 %v，实际为 %d", n, length)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(s) {
+			v.addf(path, "不匹配正则表达式 %s", pattern)
+		}
+	}
+}
+
+// validateNumber 校验数值范围
+func (v *validator) validateNumber(path string, schema map[string]interface{}, n float64) {
+	if min, ok := number(schema["minimum"]); ok && n < min {
+		v.addf(path, "不能小于 %v，实际为 %v", min, n)
+	}
+	if max, ok := number(schema["maximum"]); ok && n > max {
+		v.addf(path, "不能大于 %v，实际为 %v", max, n)
+	}
+}
+
+// jsonType 返回 JSON 值的类型名称，整数值返回 integer
+func jsonType(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// number 把 Schema 中的数值转换为 float64
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// stringList 把 required 等字段转换为字符串列表
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		result := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// containsValue 检查枚举中是否包含 value
+func containsValue(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if equalValue(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValue 按 JSON 语义比较两个值 (Go 代码中的 int 与 JSON 中的 float64 视为相等)
+func equalValue(a, b interface{}) bool {
+	return compactJSON(a) == compactJSON(b)
+}
+
+// compactJSON 把值序列化为紧凑的 JSON 字符串
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -1,5 +1,7 @@
 package memory
 
+import "github.com/wangtengda0310/gobee/agent/pkg/llm"
+
 // Option Memory 配置选项
 type Option func(*SlidingWindow)
 
@@ -26,6 +28,13 @@ func WithCompressor(compressor Compressor) Option {
 	}
 }
 
+// WithTokenCounter 设置 token 计数器，用于统计 Stats.TotalTokens
+func WithTokenCounter(counter llm.TokenCounter) Option {
+	return func(s *SlidingWindow) {
+		s.counter = counter
+	}
+}
+
 // SessionOption 会话配置选项
 type SessionOption func(*Session)
 
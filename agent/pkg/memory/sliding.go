@@ -14,6 +14,7 @@ type SlidingWindow struct {
 	maxSize     int
 	preserveSys bool
 	compressor  Compressor
+	counter     llm.TokenCounter
 	mu          sync.RWMutex
 }
 
@@ -142,6 +143,9 @@ func (s *SlidingWindow) GetStats() *Stats {
 	stats := &Stats{TotalMessages: len(s.messages)}
 
 	for _, msg := range s.messages {
+		if s.counter != nil {
+			stats.TotalTokens += llm.CountMessageTokens(s.counter, msg)
+		}
 		switch msg.Role {
 		case llm.RoleUser:
 			stats.UserMessages++
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/wangtengda0310/gobee/agent/pkg/llm"
+	"github.com/wangtengda0310/gobee/agent/pkg/llm/tokenizer"
 )
 
 func TestNewSlidingWindow(t *testing.T) {
@@ -268,3 +269,28 @@ func TestSlidingWindow_GetContext_Copy(t *testing.T) {
 		t.Errorf("expected 1 message, got %d", len(msgs2))
 	}
 }
+
+func TestSlidingWindow_TokenStats(t *testing.T) {
+	counter := tokenizer.Heuristic{}
+	mem := NewSlidingWindow(10, WithTokenCounter(counter))
+	ctx := context.Background()
+
+	msgs := []*llm.Message{
+		{Role: llm.RoleUser, Content: llm.Text("hello world")},
+		{Role: llm.RoleAssistant, Content: llm.Text("你好")},
+	}
+	mem.AddBatch(ctx, msgs)
+
+	want := llm.CountMessagesTokens(counter, msgs)
+	if stats := mem.GetStats(); stats.TotalTokens != want || want == 0 {
+		t.Errorf("expected TotalTokens %d, got %d", want, stats.TotalTokens)
+	}
+
+	wrapper := NewMessageWrapper("msg-1", msgs[0], counter)
+	if wrapper.TokenCount != llm.CountMessageTokens(counter, msgs[0]) || wrapper.Content != "hello world" || wrapper.Role != "user" {
+		t.Errorf("unexpected wrapper: %+v", wrapper)
+	}
+	if NewMessageWrapper("msg-2", msgs[0], nil).TokenCount != 0 {
+		t.Error("expected no token count without counter")
+	}
+}
@@ -3,6 +3,8 @@ package memory
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/wangtengda0310/gobee/agent/pkg/llm"
 )
 
 // Session 会话
@@ -46,6 +48,21 @@ type MessageWrapper struct {
 	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
+// NewMessageWrapper 从 llm.Message 创建消息包装器
+// counter 不为 nil 时按 llm.CountMessageTokens 填充 TokenCount
+func NewMessageWrapper(id string, msg *llm.Message, counter llm.TokenCounter) *MessageWrapper {
+	w := &MessageWrapper{
+		ID:        id,
+		Role:      string(msg.Role),
+		Content:   llm.TextString(msg.Content),
+		Timestamp: time.Now().Unix(),
+	}
+	if counter != nil {
+		w.TokenCount = llm.CountMessageTokens(counter, msg)
+	}
+	return w
+}
+
 // NewSession 创建新会话
 func NewSession(id string) *Session {
 	now := time.Now().Unix()
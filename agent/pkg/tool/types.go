@@ -11,7 +11,8 @@ type ToolResult struct {
 	Name string
 
 	// Result 执行结果
-	// 可以是任意 JSON 可序列化的值
+	// 可以是任意 JSON 可序列化的值；返回 llm.Content 时作为多模态内容发给模型
+	// (如截图工具返回 llm.NewContentList(llm.Text("截图"), llm.ImageFromBase64(...)))
 	Result any
 
 	// Error 执行错误
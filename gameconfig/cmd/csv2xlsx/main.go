@@ -0,0 +1,279 @@
+// CSV to Excel 合并工具（xlsx2csv 的反向操作）
+//
+// 用法:
+//
+//	csv2xlsx -source <CSV目录> -target <Excel目录> [选项]
+//
+// 示例:
+//
+//	csv2xlsx -source ./config/csv -target ./config -base HEAD~1
+//	csv2xlsx -source ./config/csv -target ./config -base ./backup/csv -dry-run
+//
+// 功能:
+//   - 递归扫描 target 目录下的所有 .xlsx 文件，合并 {source}/{Excel名}/{Sheet名}.csv
+//   - 按主键（默认第一列）匹配行，只改写有变化的单元格，批注、样式、列宽、版本行保持不变
+//   - 指定 -base（上次导出的 CSV 目录或 git 版本）时按三方合并处理，两边都修改时报告冲突
+//   - 存在冲突时退出码为 2，冲突的单元格默认保留 Excel 的值
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/wangtengda0310/gobee/gameconfig/internal/config"
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	// source 源目录（存放 CSV 文件）
+	source string
+	// target 目标目录（包含 Excel 文件）
+	target string
+	// base 上次导出的 CSV：目录或 git 版本
+	base string
+	// sheets 要合并的 Sheet 列表（逗号分隔，为空则合并全部）
+	sheets string
+	// key 主键列名
+	key string
+	// headerRow 表头行索引
+	headerRow int
+	// typeRow 类型行索引
+	typeRow int
+	// prefer 冲突时使用哪一方的值
+	prefer string
+	// format 输出格式
+	format string
+	// dryRun 只报告不保存
+	dryRun bool
+)
+
+func init() {
+	flag.StringVar(&source, "source", "", "源目录（存放 CSV 文件）")
+	flag.StringVar(&target, "target", "", "目标目录（包含 Excel 文件）")
+	flag.StringVar(&base, "base", "", "上次导出的 CSV：目录或 git 版本（为空时以 CSV 为准，不检测冲突）")
+	flag.StringVar(&sheets, "sheets", "", "要合并的 Sheet 列表，逗号分隔（为空则合并全部）")
+	flag.StringVar(&key, "key", "", "主键列名（默认第一列）")
+	flag.IntVar(&headerRow, "header-row", 0, "表头行索引")
+	flag.IntVar(&typeRow, "type-row", 0, "类型行索引")
+	flag.StringVar(&prefer, "prefer", "excel", "冲突时使用的值: excel、csv")
+	flag.StringVar(&format, "format", "text", "输出格式: text、json")
+	flag.BoolVar(&dryRun, "dry-run", false, "只报告，不保存 Excel")
+}
+
+func main() {
+	flag.Parse()
+
+	// 验证参数
+	if source == "" || target == "" {
+		fmt.Println("用法: csv2xlsx -source <CSV目录> -target <Excel目录> [选项]")
+		fmt.Println("示例: csv2xlsx -source ./config/csv -target ./config -base HEAD~1")
+		fmt.Println()
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if prefer != "excel" && prefer != "csv" {
+		log.Fatalf("不支持的 -prefer: %s", prefer)
+	}
+	if format != "text" && format != "json" {
+		log.Fatalf("不支持的输出格式: %s", format)
+	}
+
+	results, err := mergeDirectory(source, target)
+	if err != nil {
+		log.Fatalf("合并失败: %v", err)
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			log.Fatalf("输出失败: %v", err)
+		}
+	} else {
+		renderText(os.Stdout, results)
+	}
+
+	for _, result := range results {
+		if len(result.Conflicts) > 0 {
+			os.Exit(2)
+		}
+	}
+}
+
+// fileResult 单个 Excel 文件的合并结果
+type fileResult struct {
+	File string `json:"file"`
+	*config.MergeResult
+}
+
+// mergeDirectory 合并目录下的所有 Excel 文件
+func mergeDirectory(csvDir, excelDir string) ([]fileResult, error) {
+	var results []fileResult
+
+	err := filepath.Walk(excelDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(strings.ToLower(info.Name()), ".xlsx") {
+			return nil
+		}
+		// 跳过 Excel 的临时文件
+		if strings.HasPrefix(info.Name(), "~$") {
+			return nil
+		}
+
+		fileResults, err := mergeFile(path, csvDir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		results = append(results, fileResults...)
+		return nil
+	})
+
+	return results, err
+}
+
+// mergeFile 将 CSV 合并到单个 Excel 文件
+func mergeFile(excelPath, csvDir string) ([]fileResult, error) {
+	file, err := excelize.OpenFile(excelPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开 Excel 文件失败: %w", err)
+	}
+	defer file.Close()
+
+	excelName := strings.TrimSuffix(filepath.Base(excelPath), filepath.Ext(excelPath))
+	changed := false
+	var results []fileResult
+
+	for _, sheet := range sheetList(file) {
+		csvPath := filepath.Join(csvDir, excelName, sheet+".csv")
+		rows, err := readCSVFile(csvPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		options := config.MergeOptions{
+			Sheet:     sheet,
+			Key:       key,
+			Load:      config.LoadOptions{HeaderRow: headerRow, TypeRow: typeRow},
+			PreferCSV: prefer == "csv",
+		}
+		if base != "" {
+			if options.Base, err = readBase(csvPath, filepath.Join(excelName, sheet+".csv")); err != nil {
+				return nil, err
+			}
+		}
+
+		result, err := config.MergeCSV(file, rows, options)
+		if err != nil {
+			return nil, fmt.Errorf("合并 Sheet '%s' 失败: %w", sheet, err)
+		}
+		changed = changed || result.Changed()
+		results = append(results, fileResult{File: excelPath, MergeResult: result})
+	}
+
+	if changed && !dryRun {
+		if err := file.Save(); err != nil {
+			return nil, fmt.Errorf("保存 Excel 文件失败: %w", err)
+		}
+	}
+	return results, nil
+}
+
+// sheetList 要合并的 Sheet 列表
+func sheetList(file *excelize.File) []string {
+	if sheets == "" {
+		return file.GetSheetList()
+	}
+	return strings.Split(sheets, ",")
+}
+
+// readBase 读取上次导出的 CSV
+// base 是目录时读取 {base}/{Excel名}/{Sheet名}.csv，否则作为 git 版本读取 csvPath 的历史内容
+// base 中不存在该文件时返回空表（所有行都视为新增）
+func readBase(csvPath, rel string) ([][]string, error) {
+	if info, err := os.Stat(base); err == nil && info.IsDir() {
+		rows, err := readCSVFile(filepath.Join(base, rel))
+		if errors.Is(err, os.ErrNotExist) {
+			return [][]string{}, nil
+		}
+		return rows, err
+	}
+
+	// git show <rev>:./<file> 按当前目录解析路径
+	cmd := exec.Command("git", "show", base+":./"+filepath.Base(csvPath))
+	cmd.Dir = filepath.Dir(csvPath)
+	content, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), "exists on disk, but not in") {
+			return [][]string{}, nil
+		}
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("git show %s:%s 失败: %s", base, csvPath, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git show %s:%s 失败: %w", base, csvPath, err)
+	}
+	return readCSV(strings.NewReader(string(content)))
+}
+
+// readCSVFile 读取 CSV 文件
+func readCSVFile(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rows, err := readCSV(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	return rows, nil
+}
+
+// readCSV 读取 CSV 数据
+// xlsx2csv 导出的行会去掉末尾的空单元格，各行列数可能不同
+func readCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
+
+// renderText 以文本形式输出合并结果
+func renderText(w io.Writer, results []fileResult) {
+	for _, result := range results {
+		if !result.Changed() && len(result.Conflicts) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s [%s]: 修改 %d 个单元格，新增 %d 行，删除 %d 行\n",
+			result.File, result.Sheet, len(result.Updated), len(result.Added), len(result.Deleted))
+		for _, cell := range result.Updated {
+			fmt.Fprintf(w, "  ~ %s: %q -> %q\n", cell.Column, cell.Old, cell.New)
+		}
+		for _, k := range result.Added {
+			fmt.Fprintf(w, "  + %s\n", k)
+		}
+		for _, k := range result.Deleted {
+			fmt.Fprintf(w, "  - %s\n", k)
+		}
+		for _, conflict := range result.Conflicts {
+			fmt.Fprintf(w, "  ! 冲突 %s\n", conflict)
+		}
+	}
+	if dryRun {
+		fmt.Fprintln(w, "（-dry-run：未保存）")
+	}
+}
@@ -0,0 +1,262 @@
+// 配置表差异对比工具
+//
+// 用法:
+//
+//	gameconfig-diff -old <源> -new <源> [选项]
+//
+// 源可以是文件路径（.xlsx / .csv），也可以是 git 对象 "<revision>:<path>"
+//
+// 示例:
+//
+//	gameconfig-diff -old HEAD~1:config/装备表.xlsx -new config/装备表.xlsx -sheets 武器
+//	gameconfig-diff -old old/武器.csv -new new/武器.csv -format html > diff.html
+//
+// 功能:
+//   - 按主键（默认第一列）匹配行，输出新增、删除和逐单元格的修改
+//   - 按列名对比单元格，列顺序调整不会产生差异
+//   - 报告新增、删除的列和类型变化
+//   - 输出格式: text、json、html
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/wangtengda0310/gobee/gameconfig/internal/config"
+)
+
+var (
+	// oldSource 旧版本
+	oldSource string
+	// newSource 新版本
+	newSource string
+	// sheets 要对比的 Sheet 列表（逗号分隔，为空则对比全部）
+	sheets string
+	// key 主键列名
+	key string
+	// format 输出格式
+	format string
+	// headerRow 表头行索引
+	headerRow int
+	// typeRow 类型行索引
+	typeRow int
+	// exitCode 存在差异时以退出码 1 退出
+	exitCode bool
+)
+
+func init() {
+	flag.StringVar(&oldSource, "old", "", "旧版本：文件路径或 git 对象 <revision>:<path>")
+	flag.StringVar(&newSource, "new", "", "新版本：文件路径或 git 对象 <revision>:<path>")
+	flag.StringVar(&sheets, "sheets", "", "要对比的 Sheet 列表，逗号分隔（为空则对比全部）")
+	flag.StringVar(&key, "key", "", "主键列名（默认第一列）")
+	flag.StringVar(&format, "format", "text", "输出格式: text、json、html")
+	flag.IntVar(&headerRow, "header-row", 0, "表头行索引")
+	flag.IntVar(&typeRow, "type-row", 0, "类型行索引（不大于表头行时根据数据推断类型）")
+	flag.BoolVar(&exitCode, "exit-code", false, "存在差异时退出码为 1")
+}
+
+func main() {
+	flag.Parse()
+
+	// 验证参数
+	if oldSource == "" || newSource == "" {
+		fmt.Println("用法: gameconfig-diff -old <源> -new <源> [选项]")
+		fmt.Println("示例: gameconfig-diff -old HEAD~1:config/装备表.xlsx -new config/装备表.xlsx")
+		fmt.Println()
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	render, ok := renderers[format]
+	if !ok {
+		log.Fatalf("不支持的输出格式: %s", format)
+	}
+
+	oldPath, cleanupOld, err := resolveSource(oldSource)
+	if err != nil {
+		log.Fatalf("读取旧版本失败: %v", err)
+	}
+	defer cleanupOld()
+
+	newPath, cleanupNew, err := resolveSource(newSource)
+	if err != nil {
+		log.Fatalf("读取新版本失败: %v", err)
+	}
+	defer cleanupNew()
+
+	diffs, err := diffFiles(oldPath, newPath)
+	if err != nil {
+		log.Fatalf("对比失败: %v", err)
+	}
+
+	if err := render(os.Stdout, diffs); err != nil {
+		log.Fatalf("输出失败: %v", err)
+	}
+
+	if exitCode {
+		for _, diff := range diffs {
+			if !diff.Empty() {
+				os.Exit(1)
+			}
+		}
+	}
+}
+
+// resolveSource 解析数据源，git 对象会被导出到临时文件
+func resolveSource(source string) (string, func(), error) {
+	noop := func() {}
+	if _, err := os.Stat(source); err == nil {
+		return source, noop, nil
+	}
+
+	i := strings.Index(source, ":")
+	if i <= 0 {
+		return "", noop, fmt.Errorf("%w: %s", config.ErrFileNotFound, source)
+	}
+
+	content, err := exec.Command("git", "show", source).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", noop, fmt.Errorf("git show %s 失败: %s", source, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", noop, fmt.Errorf("git show %s 失败: %w", source, err)
+	}
+
+	// 保留文件名，CSV 的 Sheet 名取自文件名
+	dir, err := os.MkdirTemp("", "gameconfig-diff-")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	path := filepath.Join(dir, filepath.Base(source[i+1:]))
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+	return path, cleanup, nil
+}
+
+// diffFiles 对比两个文件中的表
+func diffFiles(oldPath, newPath string) ([]*config.TableDiff, error) {
+	options := config.LoadOptions{
+		HeaderRow: headerRow,
+		TypeRow:   typeRow,
+	}
+
+	oldSheets, err := sheetNames(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newSheets, err := sheetNames(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// CSV 对比时以文件为单位，不按 Sheet 名匹配
+	isCSV := len(oldSheets) == 1 && len(newSheets) == 1 && isCSVFile(oldPath) && isCSVFile(newPath)
+
+	var names []string
+	switch {
+	case sheets != "":
+		for _, name := range strings.Split(sheets, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	case isCSV:
+		names = newSheets
+	default:
+		names = unionNames(newSheets, oldSheets)
+	}
+
+	var diffs []*config.TableDiff
+	for _, name := range names {
+		oldName := name
+		if isCSV {
+			oldName = oldSheets[0]
+		}
+
+		oldRows, err := readSheet(oldPath, oldName, oldSheets, options)
+		if err != nil {
+			return nil, err
+		}
+		newRows, err := readSheet(newPath, name, newSheets, options)
+		if err != nil {
+			return nil, err
+		}
+
+		diff, err := config.DiffTables(oldRows, newRows, config.DiffOptions{
+			Sheet: name,
+			Key:   key,
+			Load:  options,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Sheet '%s': %w", name, err)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// sheetNames 获取文件中的 Sheet 列表，CSV 文件名即 Sheet 名
+func sheetNames(path string) ([]string, error) {
+	if isCSVFile(path) {
+		return []string{strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}, nil
+	}
+
+	reader, err := config.NewExcelReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return reader.GetSheetNames(), nil
+}
+
+// readSheet 通过 Loader 的数据源规则读取 Sheet，Sheet 不存在时返回空数据
+func readSheet(path, sheet string, available []string, options config.LoadOptions) ([][]string, error) {
+	found := false
+	for _, name := range available {
+		if name == sheet {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return config.ReadRows(path, sheet, options)
+}
+
+// isCSVFile 判断是否为 CSV 文件
+func isCSVFile(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".csv")
+}
+
+// unionNames 合并两个名称列表，保持出现顺序
+func unionNames(a, b []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, name := range append(append([]string(nil), a...), b...) {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// renderers 输出格式 -> 渲染函数
+var renderers = map[string]func(io.Writer, []*config.TableDiff) error{
+	"text": renderText,
+	"json": renderJSON,
+	"html": renderHTML,
+}
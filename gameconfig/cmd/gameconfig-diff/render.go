@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/wangtengda0310/gobee/gameconfig/internal/config"
+)
+
+// renderText 输出文本格式
+func renderText(w io.Writer, diffs []*config.TableDiff) error {
+	var b strings.Builder
+	for _, diff := range diffs {
+		fmt.Fprintf(&b, "=== %s (主键: %s) ===\n", diff.Sheet, diff.Key)
+		if diff.Empty() {
+			b.WriteString("无差异\n\n")
+			continue
+		}
+
+		for _, col := range diff.Schema.AddedColumns {
+			fmt.Fprintf(&b, "+ 列 %s\n", col)
+		}
+		for _, col := range diff.Schema.RemovedColumns {
+			fmt.Fprintf(&b, "- 列 %s\n", col)
+		}
+		for _, change := range diff.Schema.TypeChanges {
+			fmt.Fprintf(&b, "~ 列 %s: %s -> %s\n", change.Column, change.OldType, change.NewType)
+		}
+
+		for _, row := range diff.Added {
+			fmt.Fprintf(&b, "+ [%s] 第 %d 行: %s\n", row.Key, row.Row, formatValues(diff.Columns, row.Values))
+		}
+		for _, row := range diff.Removed {
+			fmt.Fprintf(&b, "- [%s] 第 %d 行: %s\n", row.Key, row.Row, formatValues(diff.Columns, row.Values))
+		}
+		for _, row := range diff.Changed {
+			fmt.Fprintf(&b, "~ [%s] 第 %d 行\n", row.Key, row.Row)
+			for _, cell := range row.Cells {
+				fmt.Fprintf(&b, "    %s: %q -> %q\n", cell.Column, cell.Old, cell.New)
+			}
+		}
+
+		fmt.Fprintf(&b, "新增 %d 行，删除 %d 行，修改 %d 行\n\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatValues 按列顺序格式化整行的值
+func formatValues(columns []string, values map[string]string) string {
+	parts := make([]string, 0, len(values))
+	for _, col := range columns {
+		if value, ok := values[col]; ok {
+			parts = append(parts, fmt.Sprintf("%s=%q", col, value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderJSON 输出 JSON 格式
+func renderJSON(w io.Writer, diffs []*config.TableDiff) error {
+	if diffs == nil {
+		diffs = []*config.TableDiff{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diffs)
+}
+
+// htmlTemplate HTML 报告模板
+var htmlTemplate = template.Must(template.New("diff").Funcs(template.FuncMap{
+	"value": func(values map[string]string, col string) string { return values[col] },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>配置表差异</title>
+<style>
+body { font-family: sans-serif; margin: 20px; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f0f0f0; }
+.added { background: #e6ffed; }
+.removed { background: #ffeef0; }
+.old { color: #b31d28; text-decoration: line-through; }
+.new { color: #22863a; }
+</style>
+</head>
+<body>
+{{range .}}
+<h2>{{.Sheet}} <small>主键: {{.Key}}</small></h2>
+{{if .Empty}}<p>无差异</p>{{else}}
+{{with .Schema}}{{if or .AddedColumns .RemovedColumns .TypeChanges}}
+<h3>表结构</h3>
+<ul>
+{{range .AddedColumns}}<li class="added">新增列 {{.}}</li>{{end}}
+{{range .RemovedColumns}}<li class="removed">删除列 {{.}}</li>{{end}}
+{{range .TypeChanges}}<li>列 {{.Column}} 类型 {{.OldType}} → {{.NewType}}</li>{{end}}
+</ul>
+{{end}}{{end}}
+{{$columns := .Columns}}
+{{if or .Added .Removed}}
+<h3>新增 / 删除的行</h3>
+<table>
+<tr><th></th><th>行</th>{{range $columns}}<th>{{.}}</th>{{end}}</tr>
+{{range .Added}}<tr class="added"><td>+</td><td>{{.Row}}</td>{{$values := .Values}}{{range $columns}}<td>{{value $values .}}</td>{{end}}</tr>
+{{end}}
+{{range .Removed}}<tr class="removed"><td>-</td><td>{{.Row}}</td>{{$values := .Values}}{{range $columns}}<td>{{value $values .}}</td>{{end}}</tr>
+{{end}}
+</table>
+{{end}}
+{{if .Changed}}
+<h3>修改的行</h3>
+<table>
+<tr><th>主键</th><th>行</th><th>列</th><th>旧值</th><th>新值</th></tr>
+{{range .Changed}}{{$row := .}}{{range .Cells}}<tr><td>{{$row.Key}}</td><td>{{$row.Row}}</td><td>{{.Column}}</td><td class="old">{{.Old}}</td><td class="new">{{.New}}</td></tr>
+{{end}}{{end}}
+</table>
+{{end}}
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// renderHTML 输出 HTML 报告
+func renderHTML(w io.Writer, diffs []*config.TableDiff) error {
+	return htmlTemplate.Execute(w, diffs)
+}
@@ -0,0 +1,106 @@
+// 枚举字典常量生成工具
+//
+// 用法:
+//
+//	gameconfig-enum -source <Excel/CSV/目录> -sheet <字典表> -out <Go 文件> [选项]
+//
+// 示例:
+//
+//	gameconfig-enum -source ./config/枚举.xlsx -sheet 字典 -out ./gamedata/enum_gen.go
+//	gameconfig-enum -source ./config/csv -sheet 字典 -out ./gamedata/enum_gen.go -check
+//
+// 功能:
+//   - 读取字典表（enum、name、label、value、comment 列），数据源规则与 Loader 相同
+//   - 每个字典生成一个 Go 类型和 const 块，与 enum:字典名 字段转换出的值一致
+//   - -check 模式下只比较生成结果与现有文件，不一致时以非零状态退出（用于 CI）
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/wangtengda0310/gobee/gameconfig/internal/config"
+)
+
+var (
+	// source 数据源（.xlsx、.csv 等文件或目录）
+	source string
+	// sheet 字典表名
+	sheet string
+	// out 输出的 Go 文件
+	out string
+	// pkg 生成代码的包名（默认使用输出目录名）
+	pkg string
+	// headerRow 表头行索引
+	headerRow int
+	// check 只检查生成代码是否最新
+	check bool
+)
+
+func init() {
+	flag.StringVar(&source, "source", "", "数据源（.xlsx、.csv 等文件或目录）")
+	flag.StringVar(&sheet, "sheet", "", "字典表名（Sheet 名或文件名）")
+	flag.StringVar(&out, "out", "", "输出的 Go 文件")
+	flag.StringVar(&pkg, "package", "", "生成代码的包名（默认使用输出目录名）")
+	flag.IntVar(&headerRow, "header-row", 0, "表头行索引")
+	flag.BoolVar(&check, "check", false, "只检查生成代码是否与字典表一致，不一致时退出码为 1")
+}
+
+func main() {
+	flag.Parse()
+
+	// 验证参数
+	if source == "" || sheet == "" || out == "" {
+		fmt.Println("用法: gameconfig-enum -source <Excel/CSV/目录> -sheet <字典表> -out <Go 文件> [选项]")
+		fmt.Println("示例: gameconfig-enum -source ./config/枚举.xlsx -sheet 字典 -out ./gamedata/enum_gen.go")
+		fmt.Println()
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if pkg == "" {
+		absOut, err := filepath.Abs(out)
+		if err != nil {
+			log.Fatalf("解析输出路径失败: %v", err)
+		}
+		pkg = filepath.Base(filepath.Dir(absOut))
+	}
+
+	enums, err := config.LoadEnums(source, sheet, config.LoadOptions{HeaderRow: headerRow})
+	if err != nil {
+		log.Fatalf("读取字典表失败: %v", err)
+	}
+	code, err := config.GenerateEnums(enums, config.GenerateOptions{
+		Package: pkg,
+		Source:  filepath.ToSlash(filepath.Base(source)) + " [" + sheet + "]",
+	})
+	if err != nil {
+		log.Fatalf("生成失败: %v", err)
+	}
+
+	if check {
+		existing, err := os.ReadFile(out)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Fatalf("读取 %s 失败: %v", out, err)
+		}
+		if !bytes.Equal(existing, code) {
+			fmt.Printf("生成代码已过期: %s（字典表: %s），请重新运行 gameconfig-enum\n", out, sheet)
+			os.Exit(1)
+		}
+		fmt.Printf("生成代码已是最新: %s\n", out)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		log.Fatalf("创建输出目录失败: %v", err)
+	}
+	if err := os.WriteFile(out, code, 0644); err != nil {
+		log.Fatalf("写入 %s 失败: %v", out, err)
+	}
+	fmt.Printf("已生成: %s\n", out)
+}
@@ -0,0 +1,210 @@
+// 配置结构体代码生成工具
+//
+// 用法:
+//
+//	gameconfig-gen -source <Excel/CSV 文件> -out <Go 文件> [选项]
+//
+// 示例:
+//
+//	gameconfig-gen -source ./config/装备表.xlsx -sheets 武器,防具 \
+//	    -types 武器=Weapon,防具=Armor -type-row 1 -out ./gamedata/equipment_gen.go
+//	gameconfig-gen -source ./config/装备表.xlsx -out ./gamedata/equipment_gen.go -check
+//
+// 功能:
+//   - 读取表头行、类型行（可选）和表头批注
+//   - 生成带 excel tag 和字段注释的结构体，以及按主键（第一列）查询的访问器
+//   - -check 模式下只比较生成结果与现有文件，不一致时以非零状态退出（用于 CI）
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wangtengda0310/gobee/gameconfig/internal/config"
+)
+
+var (
+	// source 源文件（.xlsx 或 .csv）
+	source string
+	// sheets 要生成的 Sheet 列表（逗号分隔，为空则生成全部）
+	sheets string
+	// types Sheet 到结构体名的映射（如 武器=Weapon,防具=Armor）
+	types string
+	// out 输出的 Go 文件
+	out string
+	// pkg 生成代码的包名（默认使用输出目录名）
+	pkg string
+	// headerRow 表头行索引
+	headerRow int
+	// typeRow 类型行索引（不大于表头行时根据数据推断类型）
+	typeRow int
+	// check 只检查生成代码是否最新
+	check bool
+)
+
+func init() {
+	flag.StringVar(&source, "source", "", "源文件（.xlsx 或 .csv）")
+	flag.StringVar(&sheets, "sheets", "", "要生成的 Sheet 列表，逗号分隔（为空则生成全部）")
+	flag.StringVar(&types, "types", "", "Sheet 到结构体名的映射，如 武器=Weapon,防具=Armor")
+	flag.StringVar(&out, "out", "", "输出的 Go 文件")
+	flag.StringVar(&pkg, "package", "", "生成代码的包名（默认使用输出目录名）")
+	flag.IntVar(&headerRow, "header-row", 0, "表头行索引")
+	flag.IntVar(&typeRow, "type-row", 0, "类型行索引（不大于表头行时根据数据推断类型）")
+	flag.BoolVar(&check, "check", false, "只检查生成代码是否与源文件一致，不一致时退出码为 1")
+}
+
+func main() {
+	flag.Parse()
+
+	// 验证参数
+	if source == "" || out == "" {
+		fmt.Println("用法: gameconfig-gen -source <Excel/CSV 文件> -out <Go 文件> [选项]")
+		fmt.Println("示例: gameconfig-gen -source ./config/装备表.xlsx -type-row 1 -out ./gamedata/equipment_gen.go")
+		fmt.Println()
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if pkg == "" {
+		absOut, err := filepath.Abs(out)
+		if err != nil {
+			log.Fatalf("解析输出路径失败: %v", err)
+		}
+		pkg = filepath.Base(filepath.Dir(absOut))
+	}
+
+	code, err := generate()
+	if err != nil {
+		log.Fatalf("生成失败: %v", err)
+	}
+
+	if check {
+		existing, err := os.ReadFile(out)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Fatalf("读取 %s 失败: %v", out, err)
+		}
+		if !bytes.Equal(existing, code) {
+			fmt.Printf("生成代码已过期: %s（源文件: %s），请重新运行 gameconfig-gen\n", out, source)
+			os.Exit(1)
+		}
+		fmt.Printf("生成代码已是最新: %s\n", out)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		log.Fatalf("创建输出目录失败: %v", err)
+	}
+	if err := os.WriteFile(out, code, 0644); err != nil {
+		log.Fatalf("写入 %s 失败: %v", out, err)
+	}
+	fmt.Printf("已生成: %s\n", out)
+}
+
+// generate 读取源文件并生成代码
+func generate() ([]byte, error) {
+	typeNames, err := parseTypeNames(types)
+	if err != nil {
+		return nil, err
+	}
+
+	options := config.LoadOptions{
+		HeaderRow: headerRow,
+		TypeRow:   typeRow,
+	}
+
+	schemas, err := readSchemas(options)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]config.TableSpec, 0, len(schemas))
+	for _, schema := range schemas {
+		tables = append(tables, config.TableSpec{
+			Schema:   schema,
+			TypeName: typeNames[schema.Sheet],
+		})
+	}
+
+	return config.GenerateGo(tables, config.GenerateOptions{
+		Package: pkg,
+		Source:  filepath.ToSlash(filepath.Base(source)),
+	})
+}
+
+// readSchemas 读取源文件中各 Sheet 的结构
+func readSchemas(options config.LoadOptions) ([]*config.SheetSchema, error) {
+	lower := strings.ToLower(source)
+
+	// CSV 文件：文件名即 Sheet 名
+	if strings.HasSuffix(lower, ".csv") {
+		reader := config.NewCSVReader(source)
+		defer reader.Close()
+
+		rows, err := reader.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		sheetName := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+		schema, err := config.ReadSheetSchema(sheetName, rows, options)
+		if err != nil {
+			return nil, err
+		}
+		return []*config.SheetSchema{schema}, nil
+	}
+
+	if !strings.HasSuffix(lower, ".xlsx") {
+		return nil, fmt.Errorf("不支持的源文件类型: %s", source)
+	}
+
+	reader, err := config.NewExcelReader(source)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	sheetNames := reader.GetSheetNames()
+	if sheets != "" {
+		sheetNames = splitList(sheets)
+	}
+
+	schemas := make([]*config.SheetSchema, 0, len(sheetNames))
+	for _, sheetName := range sheetNames {
+		schema, err := config.ReadExcelSchema(reader, sheetName, options)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+// parseTypeNames 解析 Sheet 到结构体名的映射
+func parseTypeNames(s string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, item := range splitList(s) {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("无效的类型映射 %q，格式应为 Sheet=TypeName", item)
+		}
+		result[kv[0]] = kv[1]
+	}
+	return result, nil
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -1,7 +1,7 @@
 // Excel to CSV 导出工具
 //
 // 用法:
-//   xlsx2csv -source <目录> -target <目录>
+//   xlsx2csv -source <目录> -target <目录> [-formula evaluate|strict]
 //
 // 示例:
 //   xlsx2csv -source ./config -target ./config/csv
@@ -10,6 +10,7 @@
 //   - 递归扫描 source 目录下的所有 .xlsx 文件
 //   - 将每个 Excel 文件的每个 Sheet 导出为 CSV 文件
 //   - CSV 文件组织方式: {target}/{Excel名}/{Sheet名}.csv
+//   - -formula evaluate 重新计算公式，避免导出未重算工具保存的过期缓存值；strict 在缓存值过期时失败
 package main
 
 import (
@@ -28,6 +29,8 @@ var (
 	source string
 	// target 目标目录（存放 CSV 文件）
 	target string
+	// formula 公式单元格的取值策略
+	formula string
 	// version 显示版本号
 	version bool
 )
@@ -35,6 +38,7 @@ var (
 func init() {
 	flag.StringVar(&source, "source", "", "源目录（包含 Excel 文件）")
 	flag.StringVar(&target, "target", "", "目标目录（存放 CSV 文件）")
+	flag.StringVar(&formula, "formula", "cached", "公式取值: cached（缓存值）、evaluate（重新计算）、strict（缓存值过期时失败）")
 	flag.BoolVar(&version, "version", false, "显示版本号")
 }
 
@@ -57,6 +61,10 @@ func main() {
 		os.Exit(1)
 	}
 
+	if formula != "cached" && formula != string(config.FormulaEvaluate) && formula != string(config.FormulaStrict) {
+		log.Fatalf("不支持的 -formula: %s", formula)
+	}
+
 	// 检查源目录是否存在
 	if _, err := os.Stat(source); os.IsNotExist(err) {
 		log.Fatalf("源目录不存在: %s", source)
@@ -107,5 +115,8 @@ func exportDirectory(sourceDir, targetDir string) (int, error) {
 // exportFile 导出单个 Excel 文件
 func exportFile(excelPath, targetDir string) error {
 	exporter := config.NewExcelExporter(excelPath, targetDir)
+	if formula != "cached" {
+		exporter.SetFormulaPolicy(config.FormulaPolicy(formula), nil)
+	}
 	return exporter.Export()
 }
@@ -5,8 +5,10 @@ go 1.24.0
 toolchain go1.24.5
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/xuri/excelize/v2 v2.10.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,509 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TableInfo 配置表状态
+type TableInfo struct {
+	Name      string    `json:"name"`
+	Group     string    `json:"group,omitempty"`
+	Version   int       `json:"version"`
+	Rows      int       `json:"rows"`
+	Sources   []string  `json:"sources"`
+	LoadedAt  time.Time `json:"loaded_at"`
+	Overrides int       `json:"overrides"` // 生效中的在线覆盖行数
+}
+
+// Patchable 支持按主键查询和在线覆盖的配置表
+type Patchable interface {
+	Reloadable
+	// Info 返回当前生效版本的状态
+	Info() TableInfo
+	// Row 按主键返回当前生效的行
+	Row(key string) (any, bool)
+	// Overrides 返回生效中的在线覆盖：主键 -> 列 -> 值
+	Overrides() map[string]map[string]string
+	// StageOverride 覆盖一行的部分列（values 为空时撤销该行的覆盖），结果作为候选数据
+	// 返回覆盖前后的行，调用 Commit 后生效
+	StageOverride(key string, values map[string]string) (before, after any, err error)
+}
+
+// Info 返回当前生效版本的状态
+func (t *Table[T]) Info() TableInfo {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	info := TableInfo{Name: t.name, Sources: t.SourcePaths()}
+	if state := t.current; state != nil {
+		info.Version = state.version
+		info.Rows = len(state.data)
+		info.LoadedAt = state.loadedAt
+		info.Overrides = len(state.overrides)
+	}
+	return info
+}
+
+// Row 按主键返回当前生效的行
+func (t *Table[T]) Row(key string) (any, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.current == nil {
+		return nil, false
+	}
+	idx := t.find(t.current, key)
+	if idx < 0 {
+		return nil, false
+	}
+	return t.current.data[idx], true
+}
+
+// Overrides 返回生效中的在线覆盖
+func (t *Table[T]) Overrides() map[string]map[string]string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	result := make(map[string]map[string]string)
+	if t.current != nil {
+		for key, values := range t.current.overrides {
+			result[key] = maps.Clone(values)
+		}
+	}
+	return result
+}
+
+// StageOverride 覆盖一行的部分列，结果作为候选数据
+// 覆盖后的行和加载时一样经过映射（类型转换、required、when、computed），失败时不产生候选数据
+// 同一行多次覆盖时合并各次的列；重新加载文件后所有覆盖失效
+func (t *Table[T]) StageOverride(key string, values map[string]string) (any, any, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.current
+	if state == nil {
+		return nil, nil, fmt.Errorf("表 '%s' 尚未加载", t.name)
+	}
+	if state.headers == nil {
+		return nil, nil, fmt.Errorf("表 '%s' 从二进制快照加载，没有原始行，不支持覆盖", t.name)
+	}
+	idx := t.find(state, key)
+	if idx < 0 {
+		return nil, nil, fmt.Errorf("%w: 表 '%s' 中不存在主键 '%s'", ErrRowNotFound, t.name, key)
+	}
+
+	overrides := make(map[string]map[string]string, len(state.overrides)+1)
+	for k, v := range state.overrides {
+		overrides[k] = v
+	}
+	if len(values) == 0 {
+		if _, ok := overrides[key]; !ok {
+			return nil, nil, fmt.Errorf("%w: 表 '%s' 的主键 '%s' 没有覆盖", ErrRowNotFound, t.name, key)
+		}
+		delete(overrides, key)
+	} else {
+		keyColumn := t.keyColumn(state)
+		merged := maps.Clone(overrides[key])
+		if merged == nil {
+			merged = make(map[string]string, len(values))
+		}
+		for column, value := range values {
+			if column == keyColumn {
+				return nil, nil, fmt.Errorf("%w: 不能覆盖主键列 '%s'", ErrInvalidFormat, column)
+			}
+			if !slices.Contains(state.headers, column) {
+				return nil, nil, fmt.Errorf("%w: 表 '%s' 没有列 '%s'", ErrInvalidFormat, t.name, column)
+			}
+			merged[column] = value
+		}
+		overrides[key] = merged
+	}
+
+	// 在文件中的原始行上应用覆盖，重新映射
+	row := make([]string, len(state.headers))
+	copy(row, state.rows[idx])
+	for column, value := range overrides[key] {
+		row[slices.Index(state.headers, column)] = value
+	}
+	item, err := t.loader.mapper.MapRow(state.headers, row)
+	if err != nil {
+		var configErr *ConfigError
+		if errors.As(err, &configErr) {
+			configErr.File = t.name
+			configErr.Row = idx + 1
+		}
+		return nil, nil, err
+	}
+
+	data := slices.Clone(state.data)
+	data[idx] = item
+	t.staged = &tableState[T]{
+		data:      data,
+		headers:   state.headers,
+		rows:      state.rows,
+		overrides: overrides,
+		version:   state.version,
+		loadedAt:  state.loadedAt,
+	}
+	return state.data[idx], item, nil
+}
+
+// keyColumn 主键列：LoadOptions.Key，默认第一列
+func (t *Table[T]) keyColumn(state *tableState[T]) string {
+	if key := t.loader.options.Key; key != "" {
+		return key
+	}
+	if len(state.headers) > 0 {
+		return state.headers[0]
+	}
+	// 二进制快照没有表头，使用第一个映射字段
+	var first *FieldInfo
+	for _, field := range t.loader.mapper.fields {
+		if first == nil || field.Index < first.Index {
+			first = field
+		}
+	}
+	if first == nil {
+		return ""
+	}
+	return first.Name
+}
+
+// find 按主键查找数据行索引，找不到时返回 -1
+func (t *Table[T]) find(state *tableState[T], key string) int {
+	column := t.keyColumn(state)
+	if state.headers != nil {
+		col := slices.Index(state.headers, column)
+		if col < 0 {
+			return -1
+		}
+		for i, row := range state.rows {
+			if col < len(row) && row[col] == key {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// 二进制快照按结构体字段比较
+	field, ok := t.loader.mapper.fields[column]
+	if !ok {
+		return -1
+	}
+	for i, item := range state.data {
+		v := reflect.Indirect(reflect.ValueOf(item))
+		if v.Kind() == reflect.Struct && fmt.Sprint(v.Field(field.Index).Interface()) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Override 在线覆盖一行并生效（values 为空时撤销覆盖）
+// 与文件重载走同一流程：映射通过后，表所在的重载单元及依赖它的单元校验通过才生效，失败时保持原数据
+func (w *DirWatcher) Override(table Patchable, key string, values map[string]string) (before, after any, err error) {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	var group *ReloadGroup
+	for _, g := range w.groups {
+		if slices.Contains(g.tables, Reloadable(table)) {
+			group = g
+			break
+		}
+	}
+	if group == nil {
+		return nil, nil, fmt.Errorf("表 '%s' 不属于任何重载单元", table.Name())
+	}
+	ordered, err := w.affectedGroups([]*ReloadGroup{group})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if before, after, err = table.StageOverride(key, values); err != nil {
+		return nil, nil, err
+	}
+	for _, g := range ordered {
+		if g.validate == nil {
+			continue
+		}
+		if err := g.validate(); err != nil {
+			table.Discard()
+			return nil, nil, fmt.Errorf("重载单元 '%s' 校验失败: %w", g.name, err)
+		}
+	}
+	table.Commit()
+	return before, after, nil
+}
+
+// AuditEntry 在线覆盖的审计记录
+type AuditEntry struct {
+	Time     time.Time         `json:"time"`
+	Action   string            `json:"action"` // override 或 revert
+	Table    string            `json:"table"`
+	Key      string            `json:"key"`
+	Operator string            `json:"operator"`
+	Reason   string            `json:"reason,omitempty"`
+	Values   map[string]string `json:"values,omitempty"`
+	Old      any               `json:"old,omitempty"`
+	New      any               `json:"new,omitempty"`
+	Error    string            `json:"error,omitempty"` // 失败的操作也会记录
+}
+
+// AdminHandler 配置管理 HTTP 接口，可以嵌入到已有的 HTTP 服务中
+//
+//	GET    /tables                               所有表的版本、行数、数据源和加载时间
+//	GET    /tables/{table}                       单张表的状态和生效中的覆盖
+//	GET    /tables/{table}/rows/{key}            按主键查询当前生效的行
+//	PUT    /tables/{table}/rows/{key}/override   覆盖一行的部分列 {"values": {"atk": 15}, "reason": "..."}
+//	DELETE /tables/{table}/rows/{key}/override   撤销覆盖（?reason=...）
+//	GET    /audit                                审计记录
+//
+// 接口本身不做鉴权，挂载时应放在内网或鉴权中间件之后
+type AdminHandler struct {
+	watcher  *DirWatcher
+	mux      *http.ServeMux
+	operator func(r *http.Request) string
+
+	mu       sync.Mutex
+	audit    []AuditEntry
+	auditLog io.Writer
+}
+
+// NewAdminHandler 创建配置管理接口，管理监听器中所有实现了 Patchable 的表
+func NewAdminHandler(watcher *DirWatcher) *AdminHandler {
+	h := &AdminHandler{
+		watcher: watcher,
+		mux:     http.NewServeMux(),
+		operator: func(r *http.Request) string {
+			if name := r.Header.Get("X-Operator"); name != "" {
+				return name
+			}
+			return r.RemoteAddr
+		},
+	}
+	h.mux.HandleFunc("GET /tables", h.listTables)
+	h.mux.HandleFunc("GET /tables/{table}", h.getTable)
+	h.mux.HandleFunc("GET /tables/{table}/rows/{key}", h.getRow)
+	h.mux.HandleFunc("PUT /tables/{table}/rows/{key}/override", h.override)
+	h.mux.HandleFunc("DELETE /tables/{table}/rows/{key}/override", h.revert)
+	h.mux.HandleFunc("GET /audit", h.getAudit)
+	return h
+}
+
+// SetOperator 设置获取操作人的函数（默认取 X-Operator 请求头，没有时使用客户端地址）
+func (h *AdminHandler) SetOperator(fn func(r *http.Request) string) *AdminHandler {
+	h.operator = fn
+	return h
+}
+
+// SetAuditLog 设置审计日志输出，每条记录写一行 JSON
+func (h *AdminHandler) SetAuditLog(w io.Writer) *AdminHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.auditLog = w
+	return h
+}
+
+// Audit 返回所有审计记录
+func (h *AdminHandler) Audit() []AuditEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.audit)
+}
+
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// tables 返回所有可管理的表及其重载单元
+func (h *AdminHandler) tables() ([]Patchable, []string) {
+	var tables []Patchable
+	var groups []string
+	for _, group := range h.watcher.groups {
+		for _, table := range group.tables {
+			if p, ok := table.(Patchable); ok {
+				tables = append(tables, p)
+				groups = append(groups, group.name)
+			}
+		}
+	}
+	return tables, groups
+}
+
+// table 按名称查找表，找不到时写入 404
+func (h *AdminHandler) table(w http.ResponseWriter, r *http.Request) (Patchable, string, bool) {
+	name := r.PathValue("table")
+	tables, groups := h.tables()
+	for i, table := range tables {
+		if table.Name() == name {
+			return table, groups[i], true
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("表 '%s' 不存在", name))
+	return nil, "", false
+}
+
+func (h *AdminHandler) listTables(w http.ResponseWriter, r *http.Request) {
+	tables, groups := h.tables()
+	infos := make([]TableInfo, len(tables))
+	for i, table := range tables {
+		infos[i] = table.Info()
+		infos[i].Group = groups[i]
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (h *AdminHandler) getTable(w http.ResponseWriter, r *http.Request) {
+	table, group, ok := h.table(w, r)
+	if !ok {
+		return
+	}
+	info := table.Info()
+	info.Group = group
+	writeJSON(w, http.StatusOK, struct {
+		TableInfo
+		Overrides map[string]map[string]string `json:"overrides"`
+	}{info, table.Overrides()})
+}
+
+func (h *AdminHandler) getRow(w http.ResponseWriter, r *http.Request) {
+	table, _, ok := h.table(w, r)
+	if !ok {
+		return
+	}
+	key := r.PathValue("key")
+	row, ok := table.Row(key)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: 表 '%s' 中不存在主键 '%s'", ErrRowNotFound, table.Name(), key))
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Key      string            `json:"key"`
+		Row      any               `json:"row"`
+		Override map[string]string `json:"override,omitempty"`
+	}{key, row, table.Overrides()[key]})
+}
+
+// overrideRequest 覆盖请求，值可以是字符串、数字或布尔值，null 表示空单元格
+type overrideRequest struct {
+	Values map[string]json.RawMessage `json:"values"`
+	Reason string                     `json:"reason"`
+}
+
+func (h *AdminHandler) override(w http.ResponseWriter, r *http.Request) {
+	table, _, ok := h.table(w, r)
+	if !ok {
+		return
+	}
+
+	var req overrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("请求格式错误: %w", err))
+		return
+	}
+	if len(req.Values) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("values 不能为空"))
+		return
+	}
+	values := make(map[string]string, len(req.Values))
+	for column, raw := range req.Values {
+		value, err := overrideValue(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("列 '%s' 的值无效: %w", column, err))
+			return
+		}
+		values[column] = value
+	}
+
+	h.apply(w, r, table, AuditEntry{Action: "override", Values: values, Reason: req.Reason})
+}
+
+func (h *AdminHandler) revert(w http.ResponseWriter, r *http.Request) {
+	table, _, ok := h.table(w, r)
+	if !ok {
+		return
+	}
+	h.apply(w, r, table, AuditEntry{Action: "revert", Reason: r.URL.Query().Get("reason")})
+}
+
+// apply 执行覆盖或撤销，记录审计日志
+func (h *AdminHandler) apply(w http.ResponseWriter, r *http.Request, table Patchable, entry AuditEntry) {
+	entry.Time = time.Now()
+	entry.Table = table.Name()
+	entry.Key = r.PathValue("key")
+	entry.Operator = h.operator(r)
+
+	before, after, err := h.watcher.Override(table, entry.Key, entry.Values)
+	if err != nil {
+		entry.Error = err.Error()
+		h.record(entry)
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, ErrRowNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	entry.Old, entry.New = before, after
+	h.record(entry)
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// record 追加审计记录
+func (h *AdminHandler) record(entry AuditEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.audit = append(h.audit, entry)
+	if h.auditLog != nil {
+		if data, err := json.Marshal(entry); err == nil {
+			h.auditLog.Write(append(data, '\n'))
+		}
+	}
+}
+
+func (h *AdminHandler) getAudit(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.Audit())
+}
+
+// overrideValue 将 JSON 值转换为单元格文本
+func overrideValue(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case bytes.Equal(raw, []byte("null")):
+		return "", nil
+	case len(raw) > 0 && raw[0] == '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	default:
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
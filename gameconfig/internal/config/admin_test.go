@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type adminItem struct {
+	ID   int    `excel:"id" json:"id"`
+	Name string `excel:"name,required" json:"name"`
+	Atk  int    `excel:"atk" json:"atk"`
+}
+
+// newAdminServer 创建带组校验（atk 不超过 100）的管理接口
+func newAdminServer(t *testing.T) (*Table[adminItem], *AdminHandler, *httptest.Server) {
+	t.Helper()
+	loader := NewLoader[adminItem]("", "weapon", LoadOptions{
+		Mode: ModeMemory,
+		MockData: [][]string{
+			{"id", "name", "atk"},
+			{"1", "铁剑", "10"},
+			{"2", "长弓", "8"},
+		},
+	})
+	table := NewTable(loader)
+	if err := table.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	group := NewReloadGroup("items", table).SetValidator(func() error {
+		for _, item := range table.Staged() {
+			if item.Atk > 100 {
+				return errors.New("攻击力超过 100")
+			}
+		}
+		return nil
+	})
+	admin := NewAdminHandler(NewDirWatcher(group))
+	server := httptest.NewServer(admin)
+	t.Cleanup(server.Close)
+	return table, admin, server
+}
+
+// adminDo 发送请求，返回状态码和响应体
+func adminDo(t *testing.T, method, url, body string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("X-Operator", "策划A")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error = %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	return resp.StatusCode, buf.String()
+}
+
+// TestAdmin_Query 测试表列表和按主键查询
+func TestAdmin_Query(t *testing.T) {
+	_, _, server := newAdminServer(t)
+
+	status, body := adminDo(t, "GET", server.URL+"/tables", "")
+	var infos []TableInfo
+	if status != http.StatusOK || json.Unmarshal([]byte(body), &infos) != nil {
+		t.Fatalf("GET /tables = %d %s", status, body)
+	}
+	if len(infos) != 1 || infos[0].Name != "weapon" || infos[0].Group != "items" || infos[0].Rows != 2 || infos[0].LoadedAt.IsZero() {
+		t.Errorf("tables = %+v", infos)
+	}
+
+	status, body = adminDo(t, "GET", server.URL+"/tables/weapon/rows/2", "")
+	if status != http.StatusOK || !strings.Contains(body, `"name": "长弓"`) {
+		t.Errorf("GET row = %d %s", status, body)
+	}
+
+	for _, path := range []string{"/tables/armor", "/tables/weapon/rows/9"} {
+		if status, body := adminDo(t, "GET", server.URL+path, ""); status != http.StatusNotFound {
+			t.Errorf("GET %s = %d %s, want 404", path, status, body)
+		}
+	}
+}
+
+// TestAdmin_Override 测试覆盖、校验失败和撤销
+func TestAdmin_Override(t *testing.T) {
+	table, admin, server := newAdminServer(t)
+	var auditLog bytes.Buffer
+	admin.SetAuditLog(&auditLog)
+	url := server.URL + "/tables/weapon/rows/1/override"
+
+	status, body := adminDo(t, "PUT", url, `{"values": {"atk": 15}, "reason": "修复数值"}`)
+	if status != http.StatusOK {
+		t.Fatalf("PUT override = %d %s", status, body)
+	}
+	if got := table.Data()[0]; got != (adminItem{1, "铁剑", 15}) {
+		t.Errorf("Data()[0] = %+v", got)
+	}
+
+	// 映射失败、组校验失败、主键列和未知列都不生效
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"values": {"atk": "abc"}}`, http.StatusUnprocessableEntity},
+		{`{"values": {"name": null}}`, http.StatusUnprocessableEntity},
+		{`{"values": {"atk": 500}}`, http.StatusUnprocessableEntity},
+		{`{"values": {"id": 3}}`, http.StatusUnprocessableEntity},
+		{`{"values": {"def": 1}}`, http.StatusUnprocessableEntity},
+		{`{"values": {}}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if status, body := adminDo(t, "PUT", url, tt.body); status != tt.status {
+			t.Errorf("PUT %s = %d %s, want %d", tt.body, status, body, tt.status)
+		}
+	}
+	if got := table.Data()[0]; got.Atk != 15 {
+		t.Errorf("Data()[0] = %+v, failed overrides should not apply", got)
+	}
+	if status, _ := adminDo(t, "PUT", server.URL+"/tables/weapon/rows/9/override", `{"values": {"atk": 1}}`); status != http.StatusNotFound {
+		t.Errorf("PUT missing row = %d, want 404", status)
+	}
+
+	// 同一行的覆盖合并
+	adminDo(t, "PUT", url, `{"values": {"name": "精铁剑"}}`)
+	if got := table.Data()[0]; got != (adminItem{1, "精铁剑", 15}) {
+		t.Errorf("Data()[0] = %+v", got)
+	}
+	if info := table.Info(); info.Overrides != 1 {
+		t.Errorf("Info().Overrides = %d, want 1", info.Overrides)
+	}
+
+	status, body = adminDo(t, "DELETE", url+"?reason=回滚", "")
+	if status != http.StatusOK {
+		t.Fatalf("DELETE override = %d %s", status, body)
+	}
+	if got := table.Data()[0]; got != (adminItem{1, "铁剑", 10}) {
+		t.Errorf("Data()[0] = %+v, want original", got)
+	}
+	if status, _ := adminDo(t, "DELETE", url, ""); status != http.StatusNotFound {
+		t.Errorf("DELETE without override = %d, want 404", status)
+	}
+
+	audit := admin.Audit()
+	if len(audit) != 10 {
+		t.Fatalf("Audit() = %d entries, want 10", len(audit))
+	}
+	first, revert := audit[0], audit[len(audit)-2]
+	if first.Action != "override" || first.Operator != "策划A" || first.Reason != "修复数值" || first.Values["atk"] != "15" || first.Error != "" {
+		t.Errorf("audit[0] = %+v", first)
+	}
+	if revert.Action != "revert" || revert.Reason != "回滚" {
+		t.Errorf("revert audit = %+v", revert)
+	}
+	if lines := strings.Count(auditLog.String(), "\n"); lines != len(audit) {
+		t.Errorf("audit log lines = %d, want %d", lines, len(audit))
+	}
+
+	status, body = adminDo(t, "GET", server.URL+"/audit", "")
+	if status != http.StatusOK || !strings.Contains(body, "攻击力超过 100") {
+		t.Errorf("GET /audit = %d %s", status, body)
+	}
+}
@@ -0,0 +1,483 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExprIssue 表达式静态检查发现的问题
+type ExprIssue struct {
+	Field  string // 结构体字段名
+	Column string // excel tag
+	Expr   string // 表达式
+	Msg    string
+	// Warning 为 true 时表达式在运行时仍可求值（类型不匹配、列缺失等），加载时不中止
+	Warning bool
+}
+
+func (i ExprIssue) String() string {
+	msg := i.Msg
+	if i.Warning {
+		msg = "警告: " + msg
+	}
+	if i.Expr == "" {
+		return fmt.Sprintf("%s (%s): %s", i.Field, i.Column, msg)
+	}
+	return fmt.Sprintf("%s (%s) '%s': %s", i.Field, i.Column, i.Expr, msg)
+}
+
+// ExprValidationError 表达式静态检查错误，包含全部问题
+type ExprValidationError struct {
+	Issues []ExprIssue
+}
+
+func (e *ExprValidationError) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = "  " + issue.String()
+	}
+	return fmt.Sprintf("表达式检查失败，共 %d 个问题:\n%s", len(e.Issues), strings.Join(lines, "\n"))
+}
+
+func (e *ExprValidationError) Unwrap() error {
+	return ErrInvalidCondition
+}
+
+// Validate 对照表头静态检查所有 when 和 computed 表达式
+// 检查内容：语法、引用的字段和列是否存在、函数和表是否注册、
+// 操作数类型（按结构体字段类型推断，有类型行时用 ValidateWithTypes）、字段之间的循环依赖，
+// 以及不引用任何字段、加载前即可确定恒为假的条件
+// 全部问题（包括警告）以 *ExprValidationError 返回
+func (m *StructMapper[T]) Validate(headers []string) error {
+	return m.ValidateWithTypes(headers, nil)
+}
+
+// ValidateWithTypes 同 Validate，操作数类型优先按类型行推断
+// types 与 headers 按列对应，类型行中无法识别的类型按结构体字段类型推断
+func (m *StructMapper[T]) ValidateWithTypes(headers, types []string) error {
+	if issues := m.exprIssues(headers, types); len(issues) > 0 {
+		return &ExprValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// exprIssues 静态检查表达式，返回全部问题
+func (m *StructMapper[T]) exprIssues(headers, types []string) []ExprIssue {
+	columns := make(map[string]bool, len(headers))
+	columnKinds := make(map[string]ValueKind)
+	for i, h := range headers {
+		columns[h] = true
+		if i < len(types) {
+			if kind, ok := kindOfTypeName(types[i]); ok {
+				columnKinds[h] = kind
+			}
+		}
+	}
+
+	v := &exprValidator{
+		fields:      m.fields,
+		columns:     columns,
+		columnKinds: columnKinds,
+		i18n:        &m.i18n,
+		funcs:       m.funcs,
+	}
+	if v.funcs == nil {
+		v.funcs = DefaultFuncs
+	}
+
+	fields := make([]*FieldInfo, 0, len(m.fields))
+	for _, field := range m.fields {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Index < fields[j].Index })
+
+	parsed := true
+	for _, field := range fields {
+		if dict, ok := field.Options["enum"]; ok {
+			v.checkEnum(field, dict, m.enums)
+		}
+		if field.ConditionStr != "" {
+			if cond, ok := v.parse(field, field.ConditionStr); ok {
+				v.checkCondition(field, cond)
+			} else {
+				parsed = false
+			}
+		}
+		if field.ComputedStr != "" {
+			if expr, ok := v.parse(field, field.ComputedStr); ok {
+				v.checkComputed(field, expr)
+			} else {
+				parsed = false
+			}
+		}
+	}
+
+	// 语法正确时检查循环依赖
+	if parsed {
+		if _, err := m.prepare(); err != nil {
+			var cycle *cycleError
+			if errors.As(err, &cycle) {
+				field := cycle.path[len(cycle.path)-1]
+				v.report(field, "", err.Error())
+			} else {
+				v.report(fields[0], "", err.Error())
+			}
+		}
+	}
+
+	return v.issues
+}
+
+// Validate 对照表头静态检查表达式，返回全部问题（包括警告）
+func (l *Loader[T]) Validate(headers []string) error {
+	return l.mapper.Validate(headers)
+}
+
+// ValidateWithTypes 对照表头和类型行静态检查表达式，返回全部问题（包括警告）
+func (l *Loader[T]) ValidateWithTypes(headers, types []string) error {
+	return l.mapper.ValidateWithTypes(headers, types)
+}
+
+// checkExprs 加载前静态检查表达式，每张表执行一次，types 为类型行（没有时为 nil）
+// 语法错误、引用不存在的字段和循环依赖中止加载，警告交给 OnExprWarning（为空时忽略）
+func (l *Loader[T]) checkExprs(headers, types []string) error {
+	if l.options.SkipExprCheck {
+		return nil
+	}
+	var errs []ExprIssue
+	for _, issue := range l.mapper.exprIssues(headers, types) {
+		if !issue.Warning {
+			errs = append(errs, issue)
+		} else if l.options.OnExprWarning != nil {
+			l.options.OnExprWarning(issue)
+		}
+	}
+	if len(errs) > 0 {
+		return &ExprValidationError{Issues: errs}
+	}
+	return nil
+}
+
+// exprValidator 表达式静态检查
+type exprValidator struct {
+	fields      map[string]*FieldInfo
+	columns     map[string]bool
+	columnKinds map[string]ValueKind // 类型行声明的列类型
+	i18n        *I18nOptions
+	funcs       *FuncRegistry
+	issues      []ExprIssue
+
+	// 当前检查的字段和表达式
+	field *FieldInfo
+	expr  string
+}
+
+// report 记录会中止加载的问题
+func (v *exprValidator) report(field *FieldInfo, expr string, msg string) {
+	v.issues = append(v.issues, ExprIssue{
+		Field:  field.StructField,
+		Column: field.Name,
+		Expr:   expr,
+		Msg:    msg,
+	})
+}
+
+// warn 记录警告
+func (v *exprValidator) warn(field *FieldInfo, expr string, msg string) {
+	v.report(field, expr, msg)
+	v.issues[len(v.issues)-1].Warning = true
+}
+
+// checkEnum 检查 enum 字段的字典和类型
+func (v *exprValidator) checkEnum(field *FieldInfo, dict string, enums *EnumRegistry) {
+	if _, ok := enums.Dict(dict); !ok {
+		v.warn(field, "", fmt.Sprintf("枚举字典 '%s' 不存在（LoadOptions.Enums）", dict))
+	}
+	if !isIntegerKind(field.Type) {
+		v.warn(field, "", fmt.Sprintf("enum 字段必须是整数类型，当前为 %v", field.Type))
+	}
+}
+
+// reportf 记录当前表达式会中止加载的问题
+func (v *exprValidator) reportf(format string, args ...interface{}) {
+	v.report(v.field, v.expr, fmt.Sprintf(format, args...))
+}
+
+// warnf 记录当前表达式的警告
+func (v *exprValidator) warnf(format string, args ...interface{}) {
+	v.warn(v.field, v.expr, fmt.Sprintf(format, args...))
+}
+
+// parse 解析表达式
+func (v *exprValidator) parse(field *FieldInfo, expr string) (Condition, bool) {
+	cond, err := ParseCondition(expr)
+	if err != nil {
+		v.report(field, expr, fmt.Sprintf("语法错误: %v", err))
+		return nil, false
+	}
+	return cond, true
+}
+
+// checkCondition 检查 when 表达式
+func (v *exprValidator) checkCondition(field *FieldInfo, cond Condition) {
+	v.field, v.expr = field, field.ConditionStr
+	before := len(v.issues)
+	v.infer(cond)
+
+	// 不依赖任何字段的条件在加载前就能确定结果
+	if len(v.issues) == before && len(cond.DependentFields()) == 0 {
+		ctx := NewEvalContext()
+		ctx.Funcs = v.funcs
+		if ok, err := cond.Evaluate(ctx); err == nil && !ok {
+			v.warnf("条件恒为假，字段永远不会加载")
+		}
+	}
+}
+
+// checkComputed 检查 computed 表达式及结果类型
+func (v *exprValidator) checkComputed(field *FieldInfo, expr Condition) {
+	v.field, v.expr = field, field.ComputedStr
+	result := v.infer(expr)
+
+	want := kindOfType(field.Type)
+	if result == KindAny || want == KindAny || want == KindString {
+		return
+	}
+	if result != want {
+		v.warnf("结果类型为 %s，字段类型为 %v", result, field.Type)
+	}
+}
+
+// infer 推断表达式的类型，同时检查引用和操作数类型
+func (v *exprValidator) infer(cond Condition) ValueKind {
+	switch c := cond.(type) {
+	case *Literal:
+		return kindOfValue(c.Value)
+
+	case *FieldRef:
+		return v.fieldKind(c.FieldName)
+
+	case *ListLiteral, *RangeLiteral:
+		return KindAny
+
+	case *ArithOp:
+		left, right := v.infer(c.Left), v.infer(c.Right)
+		if c.Operator == "+" && (left == KindString || right == KindString) {
+			if left == KindString && right == KindString {
+				return KindString
+			}
+			if left == KindAny || right == KindAny {
+				return KindAny
+			}
+		}
+		v.expectNumber(c.Operator, c.Left, left)
+		v.expectNumber(c.Operator, c.Right, right)
+		return KindNumber
+
+	case *UnaryOp:
+		operand := v.infer(c.Operand)
+		if c.Operator == "-" {
+			v.expectNumber(c.Operator, c.Operand, operand)
+			return KindNumber
+		}
+		return KindBool
+
+	case *BetweenOp:
+		v.expectNumber("between", c.Field, v.infer(c.Field))
+		for _, bound := range []interface{}{c.Range.Min, c.Range.Max} {
+			if _, err := toFloat64(bound); err != nil {
+				v.warnf("between 的范围 %v 不是数值", bound)
+			}
+		}
+		return KindBool
+
+	case *BinaryOp:
+		left := v.infer(c.Left)
+		right := v.infer(c.Right)
+		switch c.Operator {
+		case ">", ">=", "<", "<=":
+			v.expectNumber(c.Operator, c.Left, left)
+			v.expectNumber(c.Operator, c.Right, right)
+		case "=", "==", "!=":
+			v.expectComparable(c.Operator, c.Left, left, c.Right, right)
+		case "in":
+			if list, ok := c.Right.(*ListLiteral); ok {
+				for _, item := range list.Values {
+					item := &Literal{Value: item}
+					v.expectComparable("in", c.Left, left, item, kindOfValue(item.Value))
+				}
+			} else {
+				v.warnf("in 操作符右侧必须是列表")
+			}
+		}
+		return KindBool
+
+	case *FuncCall:
+		args := make([]ValueKind, len(c.Args))
+		for i, arg := range c.Args {
+			args[i] = v.infer(arg)
+		}
+		fn, ok := v.funcs.Func(c.Name)
+		if !ok {
+			v.warnf("未知函数 %s", c.Name)
+			return KindAny
+		}
+		if err := fn.check(c.Name); err != nil {
+			v.warnf("%v", err)
+			return KindAny
+		}
+		if fn.Variadic && len(args) < len(fn.Params) || !fn.Variadic && len(args) != len(fn.Params) {
+			v.warnf("函数 %s 需要 %d 个参数，实际为 %d 个", c.Name, len(fn.Params), len(args))
+			return fn.Result
+		}
+		for i, kind := range args {
+			want := fn.Params[len(fn.Params)-1]
+			if i < len(fn.Params) {
+				want = fn.Params[i]
+			}
+			if want != KindAny && kind != KindAny && kind != want {
+				v.warnf("函数 %s 第 %d 个参数 %s 的类型为 %s，需要 %s", c.Name, i+1, c.Args[i].String(), kind, want)
+			}
+		}
+		return fn.Result
+
+	case *IndexExpr:
+		v.infer(c.Key)
+		if _, ok := v.funcs.Table(c.Table); !ok {
+			v.warnf("表 '%s' 未注册", c.Table)
+		}
+		return KindAny
+
+	default:
+		return KindAny
+	}
+}
+
+// fieldKind 返回字段的类型，并检查字段和列是否存在
+func (v *exprValidator) fieldKind(name string) ValueKind {
+	field, ok := v.fields[name]
+	if !ok {
+		v.reportf("引用了不存在的字段 '%s'", name)
+		return KindAny
+	}
+	if field == v.field {
+		// 自引用由循环依赖检查报告
+		return kindOfType(field.Type)
+	}
+	if field.ComputedStr == "" && !v.hasColumn(field) {
+		v.warnf("引用的字段 %s (%s) 在表头中不存在", field.StructField, name)
+	}
+	if kind, ok := v.columnKinds[field.Name]; ok && field.ComputedStr == "" {
+		return kind
+	}
+	return kindOfType(field.Type)
+}
+
+// hasColumn 判断字段在表头中是否有对应的列
+func (v *exprValidator) hasColumn(field *FieldInfo) bool {
+	if field.I18n == i18nModeSuffix {
+		for column := range v.columns {
+			if _, ok := v.i18n.languageSuffix(field.Name, column); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return v.columns[field.Name]
+}
+
+// expectNumber 检查操作数为数值
+func (v *exprValidator) expectNumber(op string, operand Condition, kind ValueKind) {
+	if kind == KindString || kind == KindBool {
+		v.warnf("操作符 %s 的操作数 %s 类型为 %s，需要 number", op, operand.String(), kind)
+	}
+}
+
+// expectComparable 检查两个操作数可以比较相等
+// 与运行时一致，数值和字符串按数值比较：string 字段可以与数值比较（如 type = 1），
+// 只有无法解析为数值的字符串字面量（如 level = "abc"）才报告
+func (v *exprValidator) expectComparable(op string, left Condition, lk ValueKind, right Condition, rk ValueKind) {
+	if lk == KindAny || rk == KindAny || lk == rk {
+		return
+	}
+	if lk == KindNumber && rk == KindString && maybeNumeric(right) ||
+		rk == KindNumber && lk == KindString && maybeNumeric(left) {
+		return
+	}
+	v.warnf("操作符 %s 无法比较 %s (%s) 和 %s (%s)", op, left.String(), lk, right.String(), rk)
+}
+
+// maybeNumeric 判断字符串操作数在运行时可能解析为数值
+// 字段和函数结果取决于数据，只有字面量可以在加载前确定
+func maybeNumeric(cond Condition) bool {
+	lit, ok := cond.(*Literal)
+	if !ok {
+		return true
+	}
+	s, ok := lit.Value.(string)
+	if !ok {
+		return true
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// kindOfValue 返回值的类型
+func kindOfValue(val interface{}) ValueKind {
+	switch {
+	case isNumber(val):
+		return KindNumber
+	case val == nil:
+		return KindAny
+	}
+	switch val.(type) {
+	case string:
+		return KindString
+	case bool:
+		return KindBool
+	default:
+		return KindAny
+	}
+}
+
+// kindOfTypeName 返回类型行中的类型名在表达式中的类型
+func kindOfTypeName(typeName string) (ValueKind, bool) {
+	goType, err := goTypeName(strings.TrimSpace(typeName))
+	if err != nil {
+		return KindAny, false
+	}
+	switch goType {
+	case "string":
+		return KindString, true
+	case "bool":
+		return KindBool, true
+	default:
+		return KindNumber, true
+	}
+}
+
+// kindOfType 返回字段类型在表达式中的类型
+func kindOfType(t reflect.Type) ValueKind {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == localizedStringType {
+		return KindString
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return KindNumber
+	case reflect.String:
+		return KindString
+	case reflect.Bool:
+		return KindBool
+	default:
+		return KindAny
+	}
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// validateIssues 执行 Validate 并返回问题列表
+func validateIssues[T any](t *testing.T, headers []string) []ExprIssue {
+	t.Helper()
+	err := NewStructMapper[T]().Validate(headers)
+	if err == nil {
+		return nil
+	}
+	var verr *ExprValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() error = %v, want *ExprValidationError", err)
+	}
+	if !errors.Is(err, ErrInvalidCondition) {
+		t.Errorf("Validate() error should wrap ErrInvalidCondition")
+	}
+	return verr.Issues
+}
+
+// TestValidate_OK 测试正确的表达式
+func TestValidate_OK(t *testing.T) {
+	type item struct {
+		Type   int    `excel:"type"`
+		Tag    string `excel:"tag"`
+		Attack int    `excel:"attack,when:type=1 & startsWith(tag, \"w\")"`
+		Bonus  int    `excel:"bonus,when:attack*2 > 10"`
+		Total  int    `excel:"total,computed:attack+bonus"`
+	}
+
+	if issues := validateIssues[item](t, []string{"type", "tag", "attack", "bonus"}); len(issues) != 0 {
+		t.Errorf("issues = %v, want none", issues)
+	}
+}
+
+// TestValidate_UnknownFields 测试引用不存在的字段和列
+func TestValidate_UnknownFields(t *testing.T) {
+	type item struct {
+		Type   int `excel:"type"`
+		Attack int `excel:"attack,when:typo=1"`
+		Speed  int `excel:"speed,when:type>0"`
+	}
+
+	issues := validateIssues[item](t, []string{"attack", "speed"})
+	if len(issues) != 2 {
+		t.Fatalf("issues = %v, want 2", issues)
+	}
+	if issues[0].Field != "Attack" || !strings.Contains(issues[0].Msg, "typo") {
+		t.Errorf("issues[0] = %v", issues[0])
+	}
+	// type 字段存在但表头中没有 type 列
+	if issues[1].Field != "Speed" || !strings.Contains(issues[1].Msg, "Type") {
+		t.Errorf("issues[1] = %v", issues[1])
+	}
+}
+
+// TestValidate_Cycle 测试条件字段之间的循环依赖
+func TestValidate_Cycle(t *testing.T) {
+	type item struct {
+		Attack  int `excel:"attack,when:defense>0"`
+		Defense int `excel:"defense,when:attack>0"`
+	}
+
+	issues := validateIssues[item](t, []string{"attack", "defense"})
+	if len(issues) != 1 {
+		t.Fatalf("issues = %v, want 1", issues)
+	}
+	if !strings.Contains(issues[0].Msg, "Attack -> Defense -> Attack") {
+		t.Errorf("issue = %v, want cycle with struct field names", issues[0])
+	}
+}
+
+// TestValidate_Types 测试操作数类型检查
+func TestValidate_Types(t *testing.T) {
+	type item struct {
+		Level int    `excel:"level"`
+		Name  string `excel:"name"`
+		A     int    `excel:"a,when:name > 3"`
+		B     int    `excel:"b,when:startsWith(level, \"1\")"`
+		C     int    `excel:"c,when:level = \"abc\""`
+		D     int    `excel:"d,when:level = \"1\""`
+		E     int    `excel:"e,computed:name + \"x\""`
+		F     string `excel:"f,computed:level * 2"`
+	}
+
+	issues := validateIssues[item](t, []string{"level", "name", "a", "b", "c", "d"})
+	got := make(map[string]bool)
+	for _, issue := range issues {
+		got[issue.Field] = true
+	}
+	for _, field := range []string{"A", "B", "C", "E"} {
+		if !got[field] {
+			t.Errorf("missing issue for %s, issues = %v", field, issues)
+		}
+	}
+	// 数值与数值字符串比较、数值结果赋给 string 字段都是合法的
+	for _, field := range []string{"D", "F"} {
+		if got[field] {
+			t.Errorf("unexpected issue for %s, issues = %v", field, issues)
+		}
+	}
+}
+
+// TestValidate_FuncsAndTables 测试未知函数、未注册的表和语法错误
+func TestValidate_FuncsAndTables(t *testing.T) {
+	type item struct {
+		ID int `excel:"id"`
+		A  int `excel:"a,when:unknown(id)"`
+		B  int `excel:"b,when:exists(Skill[id])"`
+		C  int `excel:"c,when:id >"`
+		D  int `excel:"d,when:1 > 2"`
+	}
+
+	issues := validateIssues[item](t, []string{"id", "a", "b", "c", "d"})
+	want := map[string]string{
+		"A": "未知函数",
+		"B": "未注册",
+		"C": "语法错误",
+		"D": "恒为假",
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("issues = %v, want %d", issues, len(want))
+	}
+	for _, issue := range issues {
+		if !strings.Contains(issue.Msg, want[issue.Field]) {
+			t.Errorf("issue = %v, want %q", issue, want[issue.Field])
+		}
+	}
+}
+
+// TestValidate_Loader 测试加载时执行静态检查
+func TestValidate_Loader(t *testing.T) {
+	type item struct {
+		ID     int `excel:"id"`
+		Attack int `excel:"attack,when:typo=1"`
+	}
+
+	loader := NewLoader[item]("", "Sheet1", LoadOptions{
+		Mode:     ModeMemory,
+		MockData: [][]string{{"id", "attack"}},
+	})
+	if _, err := loader.Load(); !errors.Is(err, ErrInvalidCondition) {
+		t.Errorf("Load() error = %v, want ErrInvalidCondition", err)
+	}
+}
+
+// TestValidate_LooseComparison 测试 string 字段与数值比较与运行时一致，不报告问题
+func TestValidate_LooseComparison(t *testing.T) {
+	type item struct {
+		Type   string `excel:"type"`
+		Attack int    `excel:"attack,when:type == 1"`
+		Speed  int    `excel:"speed,when:type=1"`
+	}
+
+	if issues := validateIssues[item](t, []string{"type", "attack", "speed"}); len(issues) != 0 {
+		t.Errorf("issues = %v, want none", issues)
+	}
+}
+
+// TestValidate_LoaderWarnings 测试加载时警告不中止加载，SkipExprCheck 关闭检查
+func TestValidate_LoaderWarnings(t *testing.T) {
+	type item struct {
+		ID     int    `excel:"id"`
+		Name   string `excel:"name"`
+		Attack int    `excel:"attack,when:name > 3"`
+	}
+	data := [][]string{{"id", "name", "attack"}, {"1", "5", "10"}}
+
+	var warnings []ExprIssue
+	loader := NewLoader[item]("", "Sheet1", LoadOptions{
+		Mode:          ModeMemory,
+		MockData:      data,
+		OnExprWarning: func(issue ExprIssue) { warnings = append(warnings, issue) },
+	})
+	rows, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if rows[0].Attack != 10 {
+		t.Errorf("Attack = %d, want 10", rows[0].Attack)
+	}
+	if len(warnings) != 1 || !warnings[0].Warning || warnings[0].Field != "Attack" {
+		t.Errorf("warnings = %v, want 1 warning for Attack", warnings)
+	}
+
+	// 显式 Validate 仍返回警告
+	if err := loader.Validate(data[0]); !errors.Is(err, ErrInvalidCondition) {
+		t.Errorf("Validate() error = %v, want ErrInvalidCondition", err)
+	}
+
+	type broken struct {
+		ID     int `excel:"id"`
+		Attack int `excel:"attack,when:typo=1"`
+	}
+	skip := NewLoader[broken]("", "Sheet1", LoadOptions{
+		Mode:          ModeMemory,
+		MockData:      [][]string{{"id", "attack"}, {"1", "10"}},
+		SkipExprCheck: true,
+	})
+	var verr *ExprValidationError
+	if err := skip.Iterate(func(broken) error { return nil }); errors.As(err, &verr) {
+		t.Errorf("Iterate() error = %v, want static check skipped", err)
+	}
+}
+
+// TestMapRow_ConditionalDependencyOrder 测试条件字段依赖其他条件字段时按依赖顺序解析
+func TestMapRow_ConditionalDependencyOrder(t *testing.T) {
+	type item struct {
+		Crit   int `excel:"crit,when:attack>10"`
+		Type   int `excel:"type"`
+		Attack int `excel:"attack,when:type=1"`
+	}
+
+	mapper := NewStructMapper[item]()
+	for i := 0; i < 20; i++ {
+		got, err := mapper.MapRow([]string{"type", "attack", "crit"}, []string{"1", "20", "5"})
+		if err != nil {
+			t.Fatalf("MapRow() error = %v", err)
+		}
+		if got.Crit != 5 {
+			t.Fatalf("Crit = %d, want 5", got.Crit)
+		}
+	}
+}
+
+// TestValidate_TypeRow 测试操作数类型按类型行推断
+func TestValidate_TypeRow(t *testing.T) {
+	type item struct {
+		Level  string `excel:"level"`
+		Attack int    `excel:"attack,when:startsWith(level, \"1\")"`
+	}
+	headers := []string{"level", "attack"}
+
+	mapper := NewStructMapper[item]()
+	if err := mapper.ValidateWithTypes(headers, nil); err != nil {
+		t.Errorf("ValidateWithTypes(nil) error = %v, want nil", err)
+	}
+	// 类型行声明 level 为 int 时 startsWith 的参数类型不匹配
+	err := mapper.ValidateWithTypes(headers, []string{"int", "int"})
+	var verr *ExprValidationError
+	if !errors.As(err, &verr) || len(verr.Issues) != 1 || verr.Issues[0].Field != "Attack" {
+		t.Errorf("ValidateWithTypes() error = %v, want 1 issue for Attack", err)
+	}
+	// 无法识别的类型按字段类型推断
+	if err := mapper.ValidateWithTypes(headers, []string{"vector3", "int"}); err != nil {
+		t.Errorf("ValidateWithTypes(unknown type) error = %v, want nil", err)
+	}
+
+	// Load 和 Iterate 都读取类型行
+	for _, name := range []string{"Load", "Iterate"} {
+		var warnings []ExprIssue
+		loader := NewLoader[item]("", "Sheet1", LoadOptions{
+			Mode:          ModeMemory,
+			TypeRow:       1,
+			MockData:      [][]string{headers, {"int", "int"}, {"10", "5"}},
+			OnExprWarning: func(issue ExprIssue) { warnings = append(warnings, issue) },
+		})
+		if name == "Load" {
+			_, err = loader.Load()
+		} else {
+			err = loader.Iterate(func(item) error { return nil })
+		}
+		if err != nil {
+			t.Fatalf("%s() error = %v", name, err)
+		}
+		if len(warnings) != 1 || warnings[0].Field != "Attack" {
+			t.Errorf("%s() warnings = %v, want 1 warning for Attack", name, warnings)
+		}
+	}
+}
+
+// TestValidate_SilentByDefault 测试未设置 OnExprWarning 时警告不输出到日志
+func TestValidate_SilentByDefault(t *testing.T) {
+	type item struct {
+		Name   string `excel:"name"`
+		Attack int    `excel:"attack,when:name > 3"`
+	}
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	loader := NewLoader[item]("", "Sheet1", LoadOptions{
+		Mode:     ModeMemory,
+		MockData: [][]string{{"name", "attack"}, {"5", "10"}},
+	})
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("log output = %q, want none", buf.String())
+	}
+}
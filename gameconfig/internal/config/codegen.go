@@ -0,0 +1,347 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+)
+
+// DefaultConfigImport 生成代码引用的配置包路径
+const DefaultConfigImport = "github.com/wangtengda0310/gobee/gameconfig/pkg/config"
+
+// ColumnSchema 列定义（由表头行、类型行和表头批注组成）
+type ColumnSchema struct {
+	Name    string // 列名（表头）
+	Type    string // 类型名（类型行中的值，没有类型行时根据数据推断）
+	Comment string // 表头单元格批注
+}
+
+// SheetSchema Sheet 结构定义
+type SheetSchema struct {
+	Sheet   string
+	Columns []ColumnSchema
+}
+
+// ReadSheetSchema 从行数据中读取 Sheet 结构
+// 表头行、类型行的位置由 options 决定，没有类型行时根据数据行推断类型
+func ReadSheetSchema(sheetName string, rows [][]string, options LoadOptions) (*SheetSchema, error) {
+	layout := resolveRowLayout(rows, options)
+	if len(rows) <= layout.headerRow {
+		return nil, fmt.Errorf("%w: Sheet '%s' 缺少表头行", ErrInvalidFormat, sheetName)
+	}
+
+	headers := rows[layout.headerRow]
+	var types []string
+	if layout.typeRow >= 0 {
+		if len(rows) <= layout.typeRow {
+			return nil, fmt.Errorf("%w: Sheet '%s' 缺少类型行", ErrInvalidFormat, sheetName)
+		}
+		types = rows[layout.typeRow]
+	}
+
+	var dataRows [][]string
+	if layout.dataStart < len(rows) {
+		dataRows = rows[layout.dataStart:]
+	}
+
+	schema := &SheetSchema{Sheet: sheetName}
+	for i, header := range headers {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+
+		column := ColumnSchema{Name: header}
+		if i < len(types) && strings.TrimSpace(types[i]) != "" {
+			column.Type = strings.TrimSpace(types[i])
+		} else {
+			column.Type = inferColumnType(dataRows, i)
+		}
+		schema.Columns = append(schema.Columns, column)
+	}
+
+	if len(schema.Columns) == 0 {
+		return nil, fmt.Errorf("%w: Sheet '%s' 表头为空", ErrInvalidFormat, sheetName)
+	}
+
+	return schema, nil
+}
+
+// ReadExcelSchema 读取 Excel Sheet 的结构，并附带表头批注
+func ReadExcelSchema(reader *ExcelReader, sheetName string, options LoadOptions) (*SheetSchema, error) {
+	rows, err := reader.ReadSheet(sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := ReadSheetSchema(sheetName, rows, options)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := resolveRowLayout(rows, options)
+	comments := headerComments(reader, sheetName, rows[layout.headerRow], layout.headerRow)
+	for i := range schema.Columns {
+		schema.Columns[i].Comment = comments[schema.Columns[i].Name]
+	}
+
+	return schema, nil
+}
+
+// inferColumnType 根据数据行推断列类型
+// 所有非空值类型一致时使用该类型，int 与 float 混合时使用 float，其余情况使用 string
+func inferColumnType(rows [][]string, col int) string {
+	result := ""
+	for _, row := range rows {
+		if col >= len(row) {
+			continue
+		}
+
+		typ := inferType(row[col])
+		if typ == "null" {
+			continue
+		}
+
+		switch {
+		case result == "" || result == typ:
+			result = typ
+		case (result == "int" && typ == "float") || (result == "float" && typ == "int"):
+			result = "float"
+		default:
+			return "string"
+		}
+	}
+
+	if result == "" {
+		return "string"
+	}
+	return result
+}
+
+// goTypeName 将类型行中的类型名转换为 Go 类型
+func goTypeName(typeName string) (string, error) {
+	switch strings.ToLower(typeName) {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64", "string", "bool":
+		return strings.ToLower(typeName), nil
+	case "long":
+		return "int64", nil
+	case "float", "double", "number":
+		return "float64", nil
+	case "str", "text":
+		return "string", nil
+	case "boolean":
+		return "bool", nil
+	default:
+		return "", fmt.Errorf("%w: 不支持的列类型 %q", ErrTypeMismatch, typeName)
+	}
+}
+
+// commonInitialisms 生成标识符时需要全大写的缩写
+var commonInitialisms = map[string]bool{
+	"id": true, "uid": true, "url": true, "json": true, "http": true,
+	"hp": true, "mp": true, "exp": true, "npc": true, "ui": true,
+}
+
+// GoIdentifier 将列名或 Sheet 名转换为导出的 Go 标识符
+// 例如: "skill_id" -> "SkillID", "max-hp" -> "MaxHP"
+// 首字符无法导出时（如中文、数字开头）添加 "X" 前缀
+func GoIdentifier(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, part := range parts {
+		if commonInitialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	ident := b.String()
+	if ident == "" {
+		return "X"
+	}
+	if first := []rune(ident)[0]; !unicode.IsUpper(first) {
+		ident = "X" + ident
+	}
+	return ident
+}
+
+// GenerateOptions 代码生成选项
+type GenerateOptions struct {
+	// Package 生成代码的包名
+	Package string
+	// ConfigImport 配置包导入路径（默认 DefaultConfigImport）
+	ConfigImport string
+	// Source 数据来源说明，写入生成文件头部
+	Source string
+}
+
+// TableSpec 单张表的生成参数
+type TableSpec struct {
+	Schema *SheetSchema
+	// TypeName 行结构体名（为空时由 Sheet 名生成）
+	TypeName string
+}
+
+// GenerateGo 根据 Sheet 结构生成 Go 代码
+// 每张表生成一个带 excel tag 的行结构体，以及以第一列为主键的类型化访问器
+func GenerateGo(tables []TableSpec, options GenerateOptions) ([]byte, error) {
+	if options.Package == "" {
+		return nil, fmt.Errorf("未指定生成代码的包名")
+	}
+	if options.ConfigImport == "" {
+		options.ConfigImport = DefaultConfigImport
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gameconfig-gen. DO NOT EDIT.\n")
+	if options.Source != "" {
+		fmt.Fprintf(&buf, "// Source: %s\n", options.Source)
+	}
+	fmt.Fprintf(&buf, "\npackage %s\n\n", options.Package)
+	fmt.Fprintf(&buf, "import (\n\t\"fmt\"\n\n\tconfig %q\n)\n", options.ConfigImport)
+
+	typeNames := make(map[string]string)
+	for _, table := range tables {
+		if table.Schema == nil {
+			return nil, fmt.Errorf("表结构为空")
+		}
+
+		typeName := table.TypeName
+		if typeName == "" {
+			typeName = GoIdentifier(table.Schema.Sheet)
+		}
+		if other, ok := typeNames[typeName]; ok {
+			return nil, fmt.Errorf("Sheet '%s' 与 '%s' 生成的类型名 %s 重复", table.Schema.Sheet, other, typeName)
+		}
+		typeNames[typeName] = table.Schema.Sheet
+
+		if err := writeTable(&buf, typeName, table.Schema); err != nil {
+			return nil, fmt.Errorf("生成 Sheet '%s' 失败: %w", table.Schema.Sheet, err)
+		}
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化生成代码失败: %w", err)
+	}
+	return src, nil
+}
+
+// generatedField 生成的结构体字段
+type generatedField struct {
+	Name    string
+	Type    string
+	Column  string
+	Comment string
+}
+
+// writeTable 生成单张表的结构体和访问器
+func writeTable(buf *bytes.Buffer, typeName string, schema *SheetSchema) error {
+	fields := make([]generatedField, 0, len(schema.Columns))
+	used := make(map[string]int)
+	for _, column := range schema.Columns {
+		goType, err := goTypeName(column.Type)
+		if err != nil {
+			return fmt.Errorf("列 '%s': %w", column.Name, err)
+		}
+
+		name := GoIdentifier(column.Name)
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%s%d", name, used[name])
+		}
+
+		fields = append(fields, generatedField{
+			Name:    name,
+			Type:    goType,
+			Column:  column.Name,
+			Comment: column.Comment,
+		})
+	}
+
+	// 行结构体
+	fmt.Fprintf(buf, "\n// %s 对应 Sheet %q 的一行\n", typeName, schema.Sheet)
+	fmt.Fprintf(buf, "type %s struct {\n", typeName)
+	for _, field := range fields {
+		if field.Comment != "" {
+			writeComment(buf, field.Name, field.Comment)
+		}
+		fmt.Fprintf(buf, "\t%s %s `excel:%q`\n", field.Name, field.Type, field.Column)
+	}
+	buf.WriteString("}\n")
+
+	// 访问器，第一列作为主键
+	key := fields[0]
+	tableName := typeName + "Table"
+	fmt.Fprintf(buf, `
+// %[1]s Sheet %[3]q 的只读访问器，以 %[4]s 为主键
+type %[1]s struct {
+	rows  []%[2]s
+	index map[%[5]s]int
+}
+
+// Load%[1]s 加载 Sheet %[3]q
+func Load%[1]s(basePath string, options config.LoadOptions) (*%[1]s, error) {
+	rows, err := config.NewLoader[%[2]s](basePath, %[3]q, options).Load()
+	if err != nil {
+		return nil, err
+	}
+	return New%[1]s(rows)
+}
+
+// New%[1]s 由已加载的数据创建访问器，主键重复时返回错误
+func New%[1]s(rows []%[2]s) (*%[1]s, error) {
+	index := make(map[%[5]s]int, len(rows))
+	for i, row := range rows {
+		if prev, ok := index[row.%[6]s]; ok {
+			return nil, fmt.Errorf("Sheet %%q 主键 %[4]s=%%v 重复 (第 %%d 行与第 %%d 行)", %[3]q, row.%[6]s, prev+1, i+1)
+		}
+		index[row.%[6]s] = i
+	}
+	return &%[1]s{rows: rows, index: index}, nil
+}
+
+// Get 按主键查找
+func (t *%[1]s) Get(key %[5]s) (%[2]s, bool) {
+	i, ok := t.index[key]
+	if !ok {
+		var zero %[2]s
+		return zero, false
+	}
+	return t.rows[i], true
+}
+
+// All 返回全部行
+func (t *%[1]s) All() []%[2]s {
+	return t.rows
+}
+
+// Len 返回行数
+func (t *%[1]s) Len() int {
+	return len(t.rows)
+}
+`, tableName, typeName, schema.Sheet, key.Column, key.Type, key.Name)
+
+	return nil
+}
+
+// writeComment 写入字段文档注释，多行批注逐行输出
+func writeComment(buf *bytes.Buffer, name, comment string) {
+	lines := strings.Split(strings.ReplaceAll(comment, "\r\n", "\n"), "\n")
+	fmt.Fprintf(buf, "\t// %s %s\n", name, strings.TrimSpace(lines[0]))
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(buf, "\t// %s\n", line)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"errors"
+	"go/parser"
+	gotoken "go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// TestReadSheetSchema_TypeRow 测试从类型行读取列类型
+func TestReadSheetSchema_TypeRow(t *testing.T) {
+	rows := [][]string{
+		{"__version__", "2"},
+		{"id", "name", "attack"},
+		{"int", "string", "int32"},
+		{"1001", "铁剑", "10"},
+	}
+
+	schema, err := ReadSheetSchema("武器", rows, LoadOptions{TypeRow: 1})
+	if err != nil {
+		t.Fatalf("ReadSheetSchema() error = %v", err)
+	}
+
+	want := []ColumnSchema{
+		{Name: "id", Type: "int"},
+		{Name: "name", Type: "string"},
+		{Name: "attack", Type: "int32"},
+	}
+	if len(schema.Columns) != len(want) {
+		t.Fatalf("len(Columns) = %d, want %d", len(schema.Columns), len(want))
+	}
+	for i, col := range schema.Columns {
+		if col != want[i] {
+			t.Errorf("Columns[%d] = %+v, want %+v", i, col, want[i])
+		}
+	}
+}
+
+// TestReadSheetSchema_InferTypes 测试没有类型行时根据数据推断类型
+func TestReadSheetSchema_InferTypes(t *testing.T) {
+	rows := [][]string{
+		{"id", "rate", "enabled", "name", "mixed"},
+		{"1", "0.5", "true", "a", "1"},
+		{"2", "1", "false", "b", "x"},
+	}
+
+	schema, err := ReadSheetSchema("Sheet1", rows, LoadOptions{})
+	if err != nil {
+		t.Fatalf("ReadSheetSchema() error = %v", err)
+	}
+
+	want := []string{"int", "float", "bool", "string", "string"}
+	for i, col := range schema.Columns {
+		if col.Type != want[i] {
+			t.Errorf("column %s type = %s, want %s", col.Name, col.Type, want[i])
+		}
+	}
+}
+
+// TestLoader_TypeRowSkipped 测试类型行不会被当作数据行
+func TestLoader_TypeRowSkipped(t *testing.T) {
+	type Item struct {
+		ID   int    `excel:"id"`
+		Name string `excel:"name"`
+	}
+
+	loader := NewLoader[Item]("", "Sheet1", LoadOptions{
+		Mode:    ModeMemory,
+		TypeRow: 1,
+		MockData: [][]string{
+			{"id", "name"},
+			{"int", "string"},
+			{"1", "a"},
+		},
+	})
+
+	items, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(items) != 1 || items[0].ID != 1 {
+		t.Errorf("items = %+v, want one item with ID 1", items)
+	}
+}
+
+// TestResolveRowLayout 固定行布局：未设置 TypeRow 时与引入类型行之前的布局一致
+func TestResolveRowLayout(t *testing.T) {
+	plain := [][]string{{"id"}, {"1"}}
+	version := [][]string{{"__version__", "3"}, {"id"}, {"1"}}
+	changes := [][]string{{"__version__", "3"}, {"__changes__", "x"}, {"id"}, {"1"}}
+
+	tests := []struct {
+		name    string
+		rows    [][]string
+		options LoadOptions
+		want    rowLayout
+	}{
+		// 原有布局
+		{"默认", plain, LoadOptions{}, rowLayout{headerRow: 0, typeRow: -1, dataStart: 1}},
+		{"指定表头行", plain, LoadOptions{HeaderRow: 2}, rowLayout{headerRow: 2, typeRow: -1, dataStart: 3}},
+		{"指定数据开始行", plain, LoadOptions{DataStart: 3}, rowLayout{headerRow: 0, typeRow: -1, dataStart: 3}},
+		{"版本行", version, LoadOptions{}, rowLayout{headerRow: 1, typeRow: -1, dataStart: 2}},
+		{"版本行和变更说明行", changes, LoadOptions{}, rowLayout{headerRow: 2, typeRow: -1, dataStart: 3}},
+		{"TypeRow 等于 HeaderRow 视为未使用", plain, LoadOptions{TypeRow: 0}, rowLayout{headerRow: 0, typeRow: -1, dataStart: 1}},
+
+		// 类型行和元数据行偏移（见 CHANGELOG）
+		{"类型行", plain, LoadOptions{TypeRow: 1}, rowLayout{headerRow: 0, typeRow: 1, dataStart: 2}},
+		{"类型行和版本行", version, LoadOptions{TypeRow: 1}, rowLayout{headerRow: 1, typeRow: 2, dataStart: 3}},
+		{"版本行之后的表头行", version, LoadOptions{HeaderRow: 1, DataStart: 3}, rowLayout{headerRow: 2, typeRow: -1, dataStart: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveRowLayout(tt.rows, tt.options); got != tt.want {
+				t.Errorf("resolveRowLayout() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadExcelSchema_Comments 测试读取表头批注
+func TestReadExcelSchema_Comments(t *testing.T) {
+	f := excelize.NewFile()
+	f.SetSheetRow("Sheet1", "A1", &[]string{"id", "attack"})
+	f.SetSheetRow("Sheet1", "A2", &[]string{"int", "int"})
+	f.SetSheetRow("Sheet1", "A3", &[]string{"1", "10"})
+	if err := f.AddComment("Sheet1", excelize.Comment{
+		Cell:   "B1",
+		Author: "策划",
+		Text:   "基础攻击力",
+	}); err != nil {
+		t.Fatalf("AddComment() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "comment.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	f.Close()
+
+	reader, err := NewExcelReader(path)
+	if err != nil {
+		t.Fatalf("NewExcelReader() error = %v", err)
+	}
+	defer reader.Close()
+
+	schema, err := ReadExcelSchema(reader, "Sheet1", LoadOptions{TypeRow: 1})
+	if err != nil {
+		t.Fatalf("ReadExcelSchema() error = %v", err)
+	}
+
+	if got := schema.Columns[1].Comment; !strings.Contains(got, "基础攻击力") {
+		t.Errorf("attack comment = %q, want contains %q", got, "基础攻击力")
+	}
+	if got := schema.Columns[0].Comment; got != "" {
+		t.Errorf("id comment = %q, want empty", got)
+	}
+}
+
+// TestGoIdentifier 测试标识符转换
+func TestGoIdentifier(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"id", "ID"},
+		{"skill_id", "SkillID"},
+		{"max-hp", "MaxHP"},
+		{"attack", "Attack"},
+		{"attackPower", "AttackPower"},
+		{"2nd_slot", "X2ndSlot"},
+		{"武器", "X武器"},
+		{"", "X"},
+	}
+
+	for _, tt := range tests {
+		if got := GoIdentifier(tt.input); got != tt.want {
+			t.Errorf("GoIdentifier(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestGenerateGo 测试生成的代码可以解析并包含预期内容
+func TestGenerateGo(t *testing.T) {
+	schema := &SheetSchema{
+		Sheet: "武器",
+		Columns: []ColumnSchema{
+			{Name: "id", Type: "int"},
+			{Name: "name", Type: "string", Comment: "武器名称\n显示在背包中"},
+			{Name: "attack", Type: "float"},
+		},
+	}
+
+	src, err := GenerateGo([]TableSpec{{Schema: schema, TypeName: "Weapon"}}, GenerateOptions{
+		Package: "gamedata",
+		Source:  "装备表.xlsx",
+	})
+	if err != nil {
+		t.Fatalf("GenerateGo() error = %v", err)
+	}
+
+	if _, err := parser.ParseFile(gotoken.NewFileSet(), "gen.go", src, parser.ParseComments); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+
+	code := string(src)
+	for _, want := range []string{
+		"// Code generated by gameconfig-gen. DO NOT EDIT.",
+		"// Source: 装备表.xlsx",
+		"package gamedata",
+		"ID int `excel:\"id\"`",
+		"// Name 武器名称",
+		"// 显示在背包中",
+		"Attack float64 `excel:\"attack\"`",
+		"type WeaponTable struct",
+		"func LoadWeaponTable(basePath string, options config.LoadOptions) (*WeaponTable, error)",
+		"func (t *WeaponTable) Get(key int) (Weapon, bool)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\n%s", want, code)
+		}
+	}
+}
+
+// TestGenerateGo_Deterministic 测试多次生成结果一致（用于 -check 模式）
+func TestGenerateGo_Deterministic(t *testing.T) {
+	rows := [][]string{
+		{"id", "name", "name"},
+		{"1", "a", "b"},
+	}
+	schema, err := ReadSheetSchema("Sheet1", rows, LoadOptions{})
+	if err != nil {
+		t.Fatalf("ReadSheetSchema() error = %v", err)
+	}
+
+	first, err := GenerateGo([]TableSpec{{Schema: schema}}, GenerateOptions{Package: "p"})
+	if err != nil {
+		t.Fatalf("GenerateGo() error = %v", err)
+	}
+	second, _ := GenerateGo([]TableSpec{{Schema: schema}}, GenerateOptions{Package: "p"})
+	if string(first) != string(second) {
+		t.Error("GenerateGo() output is not deterministic")
+	}
+
+	// 重名列生成不同的字段名
+	if !strings.Contains(string(first), "Name2 string") {
+		t.Errorf("duplicate column should be renamed\n%s", first)
+	}
+}
+
+// TestGenerateGo_UnknownType 测试未知类型返回错误
+func TestGenerateGo_UnknownType(t *testing.T) {
+	schema := &SheetSchema{
+		Sheet:   "Sheet1",
+		Columns: []ColumnSchema{{Name: "id", Type: "vector3"}},
+	}
+
+	_, err := GenerateGo([]TableSpec{{Schema: schema}}, GenerateOptions{Package: "p"})
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("error = %v, want ErrTypeMismatch", err)
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/xuri/excelize/v2"
 )
 
@@ -64,10 +66,24 @@ func (r *CommentReader) GetAllComments() map[string]string {
 }
 
 // getCellRef 从 Comment 中提取单元格引用
-// excelize v2 的 Comment 结构可能没有直接的 Ref 字段
-// 这个函数作为占位符，实际使用时可能需要调整
 func getCellRef(comment excelize.Comment) string {
-	// TODO: 根据实际的 excelize v2 API 调整
-	// 暂时返回空字符串
-	return ""
+	return comment.Cell
+}
+
+// headerComments 读取表头单元格的批注
+// 返回 map[列名]批注内容
+func headerComments(reader *ExcelReader, sheetName string, headers []string, headerRow int) map[string]string {
+	cellComments := NewCommentReader(reader.file, sheetName).GetAllComments()
+
+	result := make(map[string]string)
+	for i, header := range headers {
+		if header == "" {
+			continue
+		}
+		// excelize 坐标从 1 开始
+		if text, ok := cellComments[CoordToCell(i+1, headerRow+1)]; ok {
+			result[header] = strings.TrimSpace(text)
+		}
+	}
+	return result
 }
@@ -21,6 +21,7 @@ type EvalContext struct {
 	Values        map[string]interface{} // 当前行的字段值
 	ResolvedFields map[string]bool        // 已解析的字段
 	Errors        []error               // 错误收集
+	Funcs         *FuncRegistry          // 函数和表（为空时使用 DefaultFuncs）
 }
 
 // NewEvalContext 创建评估上下文
@@ -53,6 +54,14 @@ func (c *EvalContext) IsResolved(name string) bool {
 	return c.ResolvedFields[name]
 }
 
+// funcs 返回表达式使用的注册表
+func (c *EvalContext) funcs() *FuncRegistry {
+	if c.Funcs == nil {
+		return DefaultFuncs
+	}
+	return c.Funcs
+}
+
 // AddError 添加错误
 func (c *EvalContext) AddError(err error) {
 	c.Errors = append(c.Errors, err)
@@ -64,15 +73,23 @@ type FieldRef struct {
 }
 
 func (f *FieldRef) Evaluate(ctx *EvalContext) (bool, error) {
-	val, ok := ctx.GetValue(f.FieldName)
-	if !ok {
-		return false, fmt.Errorf("字段 '%s' 不存在", f.FieldName)
+	val, err := f.EvalValue(ctx)
+	if err != nil {
+		return false, err
 	}
 
 	// 将值转换为布尔值
 	return toBool(val), nil
 }
 
+func (f *FieldRef) EvalValue(ctx *EvalContext) (interface{}, error) {
+	val, ok := ctx.GetValue(f.FieldName)
+	if !ok {
+		return nil, fmt.Errorf("字段 '%s' 不存在", f.FieldName)
+	}
+	return val, nil
+}
+
 func (f *FieldRef) String() string {
 	return f.FieldName
 }
@@ -90,6 +107,10 @@ func (l *Literal) Evaluate(ctx *EvalContext) (bool, error) {
 	return toBool(l.Value), nil
 }
 
+func (l *Literal) EvalValue(ctx *EvalContext) (interface{}, error) {
+	return l.Value, nil
+}
+
 func (l *Literal) String() string {
 	return fmt.Sprintf("%v", l.Value)
 }
@@ -106,38 +127,9 @@ type BinaryOp struct {
 }
 
 func (b *BinaryOp) Evaluate(ctx *EvalContext) (bool, error) {
-	// in 操作符特殊处理
-	if b.Operator == "in" {
-		return checkIn(ctx, b.Left, b.Right)
-	}
-
-	leftVal, leftErr := b.Left.Evaluate(ctx)
-	if leftErr != nil {
-		return false, leftErr
-	}
-
-	// 或操作符的短路优化：如果左侧为 true，直接返回 true
-	if b.Operator == "|" || b.Operator == "||" || b.Operator == "or" {
-		if leftVal {
-			// 短路：左侧为 true，整体为 true，不需要评估右侧
-			return true, nil
-		}
-	}
-
-	// 与操作符的短路优化：如果左侧为 false，直接返回 false
-	if b.Operator == "&" || b.Operator == "&&" || b.Operator == "and" {
-		if !leftVal {
-			// 短路：左侧为 false，整体为 false，不需要评估右侧
-			return false, nil
-		}
-	}
-
-	rightVal, rightErr := b.Right.Evaluate(ctx)
-	if rightErr != nil {
-		return false, rightErr
-	}
-
 	switch b.Operator {
+	case "in":
+		return checkIn(ctx, b.Left, b.Right)
 	case "==", "=":
 		return compareEqual(ctx, b.Left, b.Right)
 	case "!=":
@@ -151,15 +143,33 @@ func (b *BinaryOp) Evaluate(ctx *EvalContext) (bool, error) {
 		return compareNumeric(ctx, b.Left, b.Right, func(l, r float64) bool { return l < r })
 	case "<=":
 		return compareNumeric(ctx, b.Left, b.Right, func(l, r float64) bool { return l <= r })
+	}
+
+	leftVal, leftErr := b.Left.Evaluate(ctx)
+	if leftErr != nil {
+		return false, leftErr
+	}
+
+	switch b.Operator {
 	case "&", "&&", "and":
-		return leftVal && rightVal, nil
+		// 短路：左侧为 false，整体为 false，不需要评估右侧
+		if !leftVal {
+			return false, nil
+		}
 	case "|", "||", "or":
-		return leftVal || rightVal, nil
-	case "in":
-		return checkIn(ctx, b.Left, b.Right)
+		// 短路：左侧为 true，整体为 true，不需要评估右侧
+		if leftVal {
+			return true, nil
+		}
 	default:
 		return false, fmt.Errorf("不支持的操作符: %s", b.Operator)
 	}
+
+	return b.Right.Evaluate(ctx)
+}
+
+func (b *BinaryOp) EvalValue(ctx *EvalContext) (interface{}, error) {
+	return b.Evaluate(ctx)
 }
 
 func (b *BinaryOp) String() string {
@@ -179,6 +189,14 @@ type UnaryOp struct {
 }
 
 func (u *UnaryOp) Evaluate(ctx *EvalContext) (bool, error) {
+	if u.Operator == "-" {
+		val, err := u.EvalValue(ctx)
+		if err != nil {
+			return false, err
+		}
+		return toBool(val), nil
+	}
+
 	val, err := u.Operand.Evaluate(ctx)
 	if err != nil {
 		return false, err
@@ -192,6 +210,22 @@ func (u *UnaryOp) Evaluate(ctx *EvalContext) (bool, error) {
 	}
 }
 
+func (u *UnaryOp) EvalValue(ctx *EvalContext) (interface{}, error) {
+	if u.Operator != "-" {
+		return u.Evaluate(ctx)
+	}
+
+	val, err := evalValue(ctx, u.Operand)
+	if err != nil {
+		return nil, err
+	}
+	num, err := toFloat64(val)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 操作符 - 的操作数 %v 不是数值", ErrTypeMismatch, val)
+	}
+	return -num, nil
+}
+
 func (u *UnaryOp) String() string {
 	return fmt.Sprintf("%s%s", u.Operator, u.Operand.String())
 }
@@ -246,19 +280,14 @@ type BetweenOp struct {
 }
 
 func (b *BetweenOp) Evaluate(ctx *EvalContext) (bool, error) {
-	fieldRef, ok := b.Field.(*FieldRef)
-	if !ok {
-		return false, fmt.Errorf("between 操作符左侧必须是字段引用")
-	}
-
-	val, ok := ctx.GetValue(fieldRef.FieldName)
-	if !ok {
-		return false, fmt.Errorf("字段 '%s' 不存在", fieldRef.FieldName)
+	val, err := evalValue(ctx, b.Field)
+	if err != nil {
+		return false, err
 	}
 
 	valFloat, err := toFloat64(val)
 	if err != nil {
-		return false, fmt.Errorf("字段 '%s' 的值 %v 不是数值类型", fieldRef.FieldName, val)
+		return false, fmt.Errorf("'%s' 的值 %v 不是数值类型", b.Field.String(), val)
 	}
 
 	minFloat, err := toFloat64(b.Range.Min)
@@ -274,6 +303,10 @@ func (b *BetweenOp) Evaluate(ctx *EvalContext) (bool, error) {
 	return valFloat >= minFloat && valFloat <= maxFloat, nil
 }
 
+func (b *BetweenOp) EvalValue(ctx *EvalContext) (interface{}, error) {
+	return b.Evaluate(ctx)
+}
+
 func (b *BetweenOp) String() string {
 	return fmt.Sprintf("%s between %s", b.Field.String(), b.Range.String())
 }
@@ -403,7 +436,7 @@ func (p *ConditionParser) parseComparison() (Condition, error) {
 			return &BetweenOp{Field: left, Range: rang}, nil
 		}
 
-		right, err := p.parseOperand()
+		right, err := p.parseAdditive()
 		if err != nil {
 			return nil, err
 		}
@@ -423,9 +456,89 @@ func (p *ConditionParser) parseUnary() (Condition, error) {
 		}
 		return &UnaryOp{Operand: operand, Operator: op.Literal}, nil
 	}
+	return p.parseAdditive()
+}
+
+// parseAdditive 解析加减表达式
+func (p *ConditionParser) parseAdditive() (Condition, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().Type == TokenOperator && (p.peek().Literal == "+" || p.peek().Literal == "-") {
+		op := p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &ArithOp{Left: left, Right: right, Operator: op.Literal}
+	}
+
+	return left, nil
+}
+
+// parseMultiplicative 解析乘除取模表达式
+func (p *ConditionParser) parseMultiplicative() (Condition, error) {
+	left, err := p.parseNegation()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().Type == TokenOperator && (p.peek().Literal == "*" || p.peek().Literal == "/" || p.peek().Literal == "%") {
+		op := p.next()
+		right, err := p.parseNegation()
+		if err != nil {
+			return nil, err
+		}
+		left = &ArithOp{Left: left, Right: right, Operator: op.Literal}
+	}
+
+	return left, nil
+}
+
+// parseNegation 解析取负
+func (p *ConditionParser) parseNegation() (Condition, error) {
+	if p.peek().Type == TokenOperator && p.peek().Literal == "-" {
+		op := p.next()
+		operand, err := p.parseNegation()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryOp{Operand: operand, Operator: op.Literal}, nil
+	}
 	return p.parseOperand()
 }
 
+// parseCall 解析函数调用参数，函数名已消耗
+func (p *ConditionParser) parseCall(name string) (Condition, error) {
+	p.next() // 消耗左括号
+
+	call := &FuncCall{Name: name}
+	if p.peek().Type == TokenRParen {
+		p.next()
+		return call, nil
+	}
+
+	for {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return nil, fmt.Errorf("解析函数 %s 的参数失败: %w", name, err)
+		}
+		call.Args = append(call.Args, arg)
+
+		switch p.peek().Type {
+		case TokenComma:
+			p.next()
+		case TokenRParen:
+			p.next()
+			return call, nil
+		default:
+			return nil, fmt.Errorf("函数 %s 缺少右括号", name)
+		}
+	}
+}
+
 // parseOperand 解析操作数
 func (p *ConditionParser) parseOperand() (Condition, error) {
 	tok := p.peek()
@@ -436,6 +549,23 @@ func (p *ConditionParser) parseOperand() (Condition, error) {
 
 	case TokenField:
 		p.next()
+		switch p.peek().Type {
+		case TokenLParen:
+			// 函数调用 name(args)
+			return p.parseCall(tok.Literal)
+		case TokenLBracket:
+			// 跨表查找 Table[key]
+			p.next()
+			key, err := p.parseExpression()
+			if err != nil {
+				return nil, fmt.Errorf("解析 %s[] 的主键失败: %w", tok.Literal, err)
+			}
+			if p.peek().Type != TokenRBracket {
+				return nil, fmt.Errorf("%s[] 缺少右方括号", tok.Literal)
+			}
+			p.next()
+			return &IndexExpr{Table: tok.Literal, Key: key}, nil
+		}
 		return &FieldRef{FieldName: tok.Literal}, nil
 
 	case TokenNumber:
@@ -603,6 +733,15 @@ func tokenize(expr string) []token {
 				i++
 			}
 
+		case ch == '+' || ch == '*' || ch == '/' || ch == '%':
+			tokens = append(tokens, token{Type: TokenOperator, Literal: string(ch)})
+			i++
+
+		case ch == '-' && (endsOperand(tokens) || i+1 >= len(expr) || !isDigit(expr[i+1])):
+			// 操作数之后为减号，否则为取负
+			tokens = append(tokens, token{Type: TokenOperator, Literal: "-"})
+			i++
+
 		case isDigit(ch) || ch == '-':
 			start := i
 			if ch == '-' {
@@ -637,8 +776,11 @@ func tokenize(expr string) []token {
 			}
 			if i < len(expr) {
 				i++
+				tokens = append(tokens, token{Type: TokenString, Literal: expr[start+1 : i-1]})
+			} else {
+				// 未闭合的字符串，取到末尾
+				tokens = append(tokens, token{Type: TokenString, Literal: expr[start+1:]})
 			}
-			tokens = append(tokens, token{Type: TokenString, Literal: expr[start+1 : i-1]})
 
 		default:
 			// 跳过未知字符
@@ -651,6 +793,19 @@ func tokenize(expr string) []token {
 
 // 辅助函数
 
+// endsOperand 判断标记流是否以操作数结束（用于区分减号和负数）
+func endsOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	switch tokens[len(tokens)-1].Type {
+	case TokenField, TokenNumber, TokenString, TokenKeyword, TokenRParen, TokenRBracket:
+		return true
+	default:
+		return false
+	}
+}
+
 func isSpace(ch byte) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
@@ -741,48 +896,26 @@ func toFloat64(val interface{}) (float64, error) {
 }
 
 func compareEqual(ctx *EvalContext, left, right Condition) (bool, error) {
-	leftVal := getLiteralValue(left)
-	rightVal := getLiteralValue(right)
-
-	// 处理字段引用
-	if fieldRef, ok := left.(*FieldRef); ok {
-		val, exists := ctx.GetValue(fieldRef.FieldName)
-		if !exists {
-			return false, fmt.Errorf("字段 '%s' 不存在", fieldRef.FieldName)
-		}
-		leftVal = val
+	leftVal, err := evalValue(ctx, left)
+	if err != nil {
+		return false, err
 	}
-
-	if fieldRef, ok := right.(*FieldRef); ok {
-		val, exists := ctx.GetValue(fieldRef.FieldName)
-		if !exists {
-			return false, fmt.Errorf("字段 '%s' 不存在", fieldRef.FieldName)
-		}
-		rightVal = val
+	rightVal, err := evalValue(ctx, right)
+	if err != nil {
+		return false, err
 	}
 
 	return compareValues(leftVal, rightVal) == 0, nil
 }
 
 func compareNumeric(ctx *EvalContext, left, right Condition, compare func(float64, float64) bool) (bool, error) {
-	leftVal := getLiteralValue(left)
-	rightVal := getLiteralValue(right)
-
-	// 处理字段引用
-	if fieldRef, ok := left.(*FieldRef); ok {
-		val, exists := ctx.GetValue(fieldRef.FieldName)
-		if !exists {
-			return false, fmt.Errorf("字段 '%s' 不存在", fieldRef.FieldName)
-		}
-		leftVal = val
+	leftVal, err := evalValue(ctx, left)
+	if err != nil {
+		return false, err
 	}
-
-	if fieldRef, ok := right.(*FieldRef); ok {
-		val, exists := ctx.GetValue(fieldRef.FieldName)
-		if !exists {
-			return false, fmt.Errorf("字段 '%s' 不存在", fieldRef.FieldName)
-		}
-		rightVal = val
+	rightVal, err := evalValue(ctx, right)
+	if err != nil {
+		return false, err
 	}
 
 	leftFloat, err := toFloat64(leftVal)
@@ -805,15 +938,9 @@ func checkIn(ctx *EvalContext, left, right Condition) (bool, error) {
 	}
 
 	// 获取左侧的值
-	var leftVal interface{}
-	if fieldRef, ok := left.(*FieldRef); ok {
-		val, exists := ctx.GetValue(fieldRef.FieldName)
-		if !exists {
-			return false, fmt.Errorf("字段 '%s' 不存在", fieldRef.FieldName)
-		}
-		leftVal = val
-	} else {
-		leftVal = getLiteralValue(left)
+	leftVal, err := evalValue(ctx, left)
+	if err != nil {
+		return false, err
 	}
 
 	// 检查是否在列表中
@@ -1002,6 +1129,37 @@ func compareValues(a, b interface{}) int {
 			}
 			return 0
 		}
+	case bool:
+		if vb, ok := b.(bool); ok {
+			if va == vb {
+				return 0
+			} else if vb {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// 其他数值类型（如 int32、uint、float32）统一按 float64 比较
+	if isNumber(a) && isNumber(b) {
+		fa, _ := toFloat64(a)
+		fb, _ := toFloat64(b)
+		if fa < fb {
+			return -1
+		} else if fa > fb {
+			return 1
+		}
+		return 0
 	}
 	return 0
 }
+
+// isNumber 判断是否为数值类型（不包括数值字符串）
+func isNumber(val interface{}) bool {
+	switch val.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	default:
+		return false
+	}
+}
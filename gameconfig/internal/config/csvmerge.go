@@ -0,0 +1,360 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// MergeOptions CSV 合并回 Excel 的选项
+type MergeOptions struct {
+	// Sheet 要合并的 Sheet 名
+	Sheet string
+	// Key 主键列名（为空时使用第一列）
+	Key string
+	// Load 表头行、类型行等布局选项（不含版本行和变更说明行，与导出的 CSV 一致）
+	Load LoadOptions
+	// Base 上次从 Excel 导出的 CSV，作为三方合并的共同版本
+	// 为空时以 CSV 为准覆盖 Excel，不检测冲突
+	Base [][]string
+	// PreferCSV 冲突时使用 CSV 的值（默认保留 Excel 的值）
+	PreferCSV bool
+}
+
+// MergeConflict 合并冲突：Excel 和 CSV 都修改了同一处
+type MergeConflict struct {
+	Key    string `json:"key"`
+	Column string `json:"column,omitempty"` // 为空表示整行冲突
+	Row    int    `json:"row,omitempty"`    // Excel 行号（从 1 开始），Excel 中没有该行时为 0
+	Base   string `json:"base"`
+	Excel  string `json:"excel"`
+	CSV    string `json:"csv"`
+	Reason string `json:"reason"`
+}
+
+func (c MergeConflict) String() string {
+	where := "主键 " + c.Key
+	if c.Column != "" {
+		where += " 列 " + c.Column
+	}
+	if c.Row > 0 {
+		where += fmt.Sprintf("（第 %d 行）", c.Row)
+	}
+	if c.Column == "" {
+		return fmt.Sprintf("%s: %s", where, c.Reason)
+	}
+	return fmt.Sprintf("%s: %s，base=%q excel=%q csv=%q", where, c.Reason, c.Base, c.Excel, c.CSV)
+}
+
+// MergeResult 单张表的合并结果
+type MergeResult struct {
+	Sheet     string          `json:"sheet"`
+	Updated   []CellDiff      `json:"updated,omitempty"` // Column 为 "主键.列名"
+	Added     []string        `json:"added,omitempty"`   // 新增行的主键
+	Deleted   []string        `json:"deleted,omitempty"` // 删除行的主键
+	Conflicts []MergeConflict `json:"conflicts,omitempty"`
+}
+
+// Changed 是否修改了 Excel
+func (r *MergeResult) Changed() bool {
+	return len(r.Updated) > 0 || len(r.Added) > 0 || len(r.Deleted) > 0
+}
+
+// MergeCSV 将 CSV 数据合并回 Excel 工作簿（xlsx2csv 的反向操作）
+//
+// 行按主键匹配，单元格按列名匹配，只修改有变化的单元格：
+// 批注、样式、列宽、版本行和变更说明行保持不变，新增行沿用最后一个数据行的样式
+//
+// 提供 Base 时按三方合并处理：只有 CSV 修改的内容写入 Excel，只有 Excel 修改的内容保留，
+// 两边都修改且结果不同时记录冲突；包含公式的单元格不会被覆盖，改动记录为冲突
+//
+// 调用方负责保存文件
+func MergeCSV(file *excelize.File, csvRows [][]string, options MergeOptions) (*MergeResult, error) {
+	sheet := options.Sheet
+	if idx, err := file.GetSheetIndex(sheet); err != nil || idx < 0 {
+		return nil, fmt.Errorf("%w: Sheet '%s' 不存在", ErrSheetNotFound, sheet)
+	}
+	excelRows, err := file.GetRows(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("读取 Sheet 失败: %w", err)
+	}
+
+	diffOptions := DiffOptions{Sheet: sheet, Key: options.Key, Load: options.Load}
+	excel, err := newDiffSide(excelRows, diffOptions)
+	if err != nil {
+		return nil, fmt.Errorf("Excel: %w", err)
+	}
+	csvSide, err := newDiffSide(csvRows, diffOptions)
+	if err != nil {
+		return nil, fmt.Errorf("CSV: %w", err)
+	}
+	if excel.schema == nil || csvSide.schema == nil {
+		return nil, fmt.Errorf("%w: Sheet '%s' 缺少表头行", ErrInvalidFormat, sheet)
+	}
+
+	base := excel
+	if options.Base != nil {
+		if base, err = newDiffSide(options.Base, diffOptions); err != nil {
+			return nil, fmt.Errorf("Base: %w", err)
+		}
+	}
+
+	if err := checkMergeSchema(excel, csvSide, options.Load); err != nil {
+		return nil, err
+	}
+
+	key := options.Key
+	if key == "" {
+		key = excel.schema.Columns[0].Name
+	}
+	sides := map[string]*diffSide{"Excel": excel, "CSV": csvSide}
+	if base != excel {
+		sides["Base"] = base
+	}
+	for name, side := range sides {
+		if err := side.index(key); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	m := &merger{
+		file:    file,
+		sheet:   sheet,
+		options: options,
+		excel:   excel,
+		csv:     csvSide,
+		base:    base,
+		result:  &MergeResult{Sheet: sheet},
+	}
+	if err := m.mergeRows(); err != nil {
+		return nil, err
+	}
+	if err := m.addRows(len(excelRows)); err != nil {
+		return nil, err
+	}
+	if err := m.deleteRows(); err != nil {
+		return nil, err
+	}
+	return m.result, nil
+}
+
+// checkMergeSchema 检查 CSV 与 Excel 的列一致
+func checkMergeSchema(excel, csvSide *diffSide, options LoadOptions) error {
+	schema := diffSchema(excel.schema, csvSide.schema)
+	var problems []string
+	if len(schema.AddedColumns) > 0 {
+		problems = append(problems, "CSV 多出列 "+strings.Join(schema.AddedColumns, ", "))
+	}
+	if len(schema.RemovedColumns) > 0 {
+		problems = append(problems, "CSV 缺少列 "+strings.Join(schema.RemovedColumns, ", "))
+	}
+	// 没有类型行时类型由数据推断，不作比较
+	if options.TypeRow > options.HeaderRow {
+		for _, change := range schema.TypeChanges {
+			problems = append(problems, fmt.Sprintf("列 %s 类型为 %s，Excel 中为 %s", change.Column, change.NewType, change.OldType))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: 表头不一致: %s", ErrInvalidFormat, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// merger 单张表的合并过程
+type merger struct {
+	file    *excelize.File
+	sheet   string
+	options MergeOptions
+	excel   *diffSide
+	csv     *diffSide
+	base    *diffSide
+	result  *MergeResult
+	deletes []int // 待删除的 Excel 行号
+}
+
+// excelRow 返回数据行在 Excel 中的行号
+func (m *merger) excelRow(idx int) int {
+	return m.excel.start + idx + 1
+}
+
+// conflict 记录冲突，返回是否使用 CSV 的值
+func (m *merger) conflict(c MergeConflict) bool {
+	m.result.Conflicts = append(m.result.Conflicts, c)
+	return m.options.PreferCSV
+}
+
+// mergeRows 合并 Excel 中已有的行
+func (m *merger) mergeRows() error {
+	for _, k := range m.excel.order {
+		ei := m.excel.keys[k]
+		ci, inCSV := m.csv.keys[k]
+		bi, inBase := m.base.keys[k]
+		row := m.excelRow(ei)
+
+		if !inCSV {
+			// Excel 新增的行
+			if !inBase {
+				continue
+			}
+			if m.sameRow(m.excel, ei, m.base, bi) ||
+				m.conflict(MergeConflict{Key: k, Row: row, Reason: "CSV 删除了该行，Excel 修改了该行"}) {
+				m.deletes = append(m.deletes, row)
+				m.result.Deleted = append(m.result.Deleted, k)
+			}
+			continue
+		}
+
+		for _, col := range m.excel.schema.Columns {
+			current := m.excel.cell(ei, col.Name)
+			value := m.csv.cell(ci, col.Name)
+			if current == value {
+				continue
+			}
+
+			original := ""
+			if inBase {
+				original = m.base.cell(bi, col.Name)
+				if value == original {
+					// 只有 Excel 修改
+					continue
+				}
+			}
+
+			cell, _ := excelize.CoordinatesToCellName(m.excel.columns[col.Name]+1, row)
+			c := MergeConflict{Key: k, Column: col.Name, Row: row, Base: original, Excel: current, CSV: value}
+			if formula, _ := m.file.GetCellFormula(m.sheet, cell); formula != "" {
+				c.Reason = "单元格包含公式 =" + formula
+				m.result.Conflicts = append(m.result.Conflicts, c)
+				continue
+			}
+			// 两边都新增了同一主键时没有共同版本，任何不同都是冲突
+			if !inBase || current != original {
+				c.Reason = "Excel 和 CSV 都修改了该单元格"
+				if !m.conflict(c) {
+					continue
+				}
+			}
+
+			if err := m.setCell(cell, value, current); err != nil {
+				return err
+			}
+			m.result.Updated = append(m.result.Updated, CellDiff{Column: k + "." + col.Name, Old: current, New: value})
+		}
+	}
+	return nil
+}
+
+// addRows 追加 CSV 新增的行，lastRow 为 Excel 最后一个非空行的行号
+func (m *merger) addRows(lastRow int) error {
+	// 新增行沿用最后一个数据行的样式
+	template := 0
+	if len(m.excel.rows) > 0 {
+		template = m.excelRow(len(m.excel.rows) - 1)
+	}
+
+	row := lastRow
+	for _, k := range m.csv.order {
+		if _, ok := m.excel.keys[k]; ok {
+			continue
+		}
+		ci := m.csv.keys[k]
+		if bi, inBase := m.base.keys[k]; inBase {
+			// Excel 删除了该行
+			if m.sameRow(m.csv, ci, m.base, bi) ||
+				!m.conflict(MergeConflict{Key: k, Reason: "Excel 删除了该行，CSV 修改了该行"}) {
+				continue
+			}
+		}
+
+		row++
+		for _, col := range m.excel.schema.Columns {
+			x := m.excel.columns[col.Name] + 1
+			cell, _ := excelize.CoordinatesToCellName(x, row)
+			like, likeValue := "", ""
+			if template > 0 {
+				like, _ = excelize.CoordinatesToCellName(x, template)
+				likeValue = m.excel.cell(len(m.excel.rows)-1, col.Name)
+				style, err := m.file.GetCellStyle(m.sheet, like)
+				if err != nil {
+					return err
+				}
+				if err := m.file.SetCellStyle(m.sheet, cell, cell, style); err != nil {
+					return err
+				}
+			}
+			if value := m.csv.cell(ci, col.Name); value != "" {
+				if err := m.setCellLike(cell, value, like, likeValue); err != nil {
+					return err
+				}
+			}
+		}
+		m.result.Added = append(m.result.Added, k)
+	}
+	return nil
+}
+
+// deleteRows 从下往上删除行，避免行号变化
+func (m *merger) deleteRows() error {
+	sort.Sort(sort.Reverse(sort.IntSlice(m.deletes)))
+	for _, row := range m.deletes {
+		if err := m.file.RemoveRow(m.sheet, row); err != nil {
+			return fmt.Errorf("删除第 %d 行失败: %w", row, err)
+		}
+	}
+	return nil
+}
+
+// sameRow 两个版本的行在所有列上是否相同
+func (m *merger) sameRow(a *diffSide, ai int, b *diffSide, bi int) bool {
+	for _, col := range m.excel.schema.Columns {
+		if a.cell(ai, col.Name) != b.cell(bi, col.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// setCell 写入单元格，保持原单元格的样式和值类型
+func (m *merger) setCell(cell, value, current string) error {
+	return m.setCellLike(cell, value, cell, current)
+}
+
+// setCellLike 写入单元格，值类型参照 like 单元格（其显示值为 likeValue）
+// 参照单元格是数值时按数值写入（保留数字格式，如 "1.50" 写回 1.5），
+// 否则只有写回后显示不变的数值才按数值写入（"001" 仍为文本）
+func (m *merger) setCellLike(cell, value, like, likeValue string) error {
+	if value == "" {
+		return m.file.SetCellValue(m.sheet, cell, nil)
+	}
+
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		if formatValue(n) == value || like != "" && m.isNumberCell(like, likeValue) {
+			return m.file.SetCellValue(m.sheet, cell, n)
+		}
+	}
+	return m.file.SetCellValue(m.sheet, cell, value)
+}
+
+// isNumberCell 判断单元格是否为数值
+func (m *merger) isNumberCell(cell, value string) bool {
+	if value == "" {
+		return false
+	}
+	typ, err := m.file.GetCellType(m.sheet, cell)
+	if err != nil {
+		return false
+	}
+	switch typ {
+	case excelize.CellTypeUnset, excelize.CellTypeNumber:
+		return true
+	case excelize.CellTypeFormula:
+		// 公式单元格看缓存的计算结果
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// newMergeWorkbook 创建带版本行、批注、样式和列宽的工作簿
+//
+//	__version__ | 3
+//	id    | name | atk
+//	int   | string | int
+//	1     | 铁剑 | 10
+//	2     | 木盾 | 0
+//	3     | 长弓 | =A6*5
+func newMergeWorkbook(t *testing.T) *excelize.File {
+	t.Helper()
+	f := excelize.NewFile()
+	rows := [][]interface{}{
+		{"__version__", 3},
+		{"id", "name", "atk"},
+		{"int", "string", "int"},
+		{1, "铁剑", 10},
+		{2, "木盾", 0},
+		{3, "长弓", 15},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatalf("SetSheetRow() error = %v", err)
+		}
+	}
+	// 保留缓存值 15
+	if err := f.SetCellFormula("Sheet1", "C6", "A6*5"); err != nil {
+		t.Fatalf("SetCellFormula() error = %v", err)
+	}
+	if err := f.AddComment("Sheet1", excelize.Comment{Cell: "C2", Author: "策划", Text: "攻击力"}); err != nil {
+		t.Fatalf("AddComment() error = %v", err)
+	}
+	style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	f.SetCellStyle("Sheet1", "A4", "C6", style)
+	f.SetColWidth("Sheet1", "B", "B", 30)
+	return f
+}
+
+// mergeCSVRows 与 newMergeWorkbook 对应的导出结果
+func mergeCSVRows() [][]string {
+	return [][]string{
+		{"id", "name", "atk"},
+		{"int", "string", "int"},
+		{"1", "铁剑", "10"},
+		{"2", "木盾", "0"},
+		{"3", "长弓", "15"},
+	}
+}
+
+var mergeLoad = LoadOptions{TypeRow: 1}
+
+// TestMergeCSV 测试以 CSV 为准合并，保留批注、样式、列宽和版本行
+func TestMergeCSV(t *testing.T) {
+	f := newMergeWorkbook(t)
+	defer f.Close()
+
+	csvRows := mergeCSVRows()
+	csvRows[2][2] = "12"                                   // 修改
+	csvRows = append(csvRows[:3], csvRows[4:]...)          // 删除 2
+	csvRows = append(csvRows, []string{"4", "007", "030"}) // 新增
+
+	result, err := MergeCSV(f, csvRows, MergeOptions{Sheet: "Sheet1", Load: mergeLoad})
+	if err != nil {
+		t.Fatalf("MergeCSV() error = %v", err)
+	}
+	if len(result.Updated) != 1 || result.Updated[0] != (CellDiff{Column: "1.atk", Old: "10", New: "12"}) {
+		t.Errorf("Updated = %+v", result.Updated)
+	}
+	if !reflect.DeepEqual(result.Added, []string{"4"}) || !reflect.DeepEqual(result.Deleted, []string{"2"}) {
+		t.Errorf("Added = %v, Deleted = %v", result.Added, result.Deleted)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("Conflicts = %v", result.Conflicts)
+	}
+
+	rows, _ := f.GetRows("Sheet1")
+	want := [][]string{
+		{"__version__", "3"},
+		{"id", "name", "atk"},
+		{"int", "string", "int"},
+		{"1", "铁剑", "12"},
+		{"3", "长弓", "15"},
+		{"4", "007", "30"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+
+	// 值类型参照原单元格和上一行：name 列的 "007" 仍为文本，atk 列的 "030" 按数值写入
+	for _, cell := range []string{"C4", "C6"} {
+		if typ, _ := f.GetCellType("Sheet1", cell); typ != excelize.CellTypeUnset && typ != excelize.CellTypeNumber {
+			t.Errorf("%s type = %v, want number", cell, typ)
+		}
+	}
+	// 公式随删除行调整
+	if formula, _ := f.GetCellFormula("Sheet1", "C5"); formula != "A5*5" {
+		t.Errorf("C5 formula = %q, want A5*5", formula)
+	}
+
+	comments, _ := f.GetComments("Sheet1")
+	if len(comments) != 1 || comments[0].Cell != "C2" {
+		t.Errorf("comments = %+v", comments)
+	}
+	if width, _ := f.GetColWidth("Sheet1", "B"); width != 30 {
+		t.Errorf("column width = %v, want 30", width)
+	}
+	oldStyle, _ := f.GetCellStyle("Sheet1", "B4")
+	newStyle, _ := f.GetCellStyle("Sheet1", "B6")
+	if oldStyle == 0 || newStyle != oldStyle {
+		t.Errorf("new row style = %d, want %d", newStyle, oldStyle)
+	}
+}
+
+// TestMergeCSV_ThreeWay 测试三方合并和冲突
+func TestMergeCSV_ThreeWay(t *testing.T) {
+	run := func(preferCSV bool) (*excelize.File, *MergeResult) {
+		f := newMergeWorkbook(t)
+		// Excel 修改了 1.name 和 2.atk
+		f.SetCellValue("Sheet1", "B4", "精铁剑")
+		f.SetCellValue("Sheet1", "C5", 5)
+
+		csvRows := mergeCSVRows()
+		csvRows[2][2] = "11"  // 只有 CSV 修改 1.atk
+		csvRows[3][2] = "8"   // 两边都修改 2.atk
+		csvRows[4][2] = "20"  // 公式单元格
+		csvRows[2][1] = "精铁剑" // 两边修改相同
+
+		result, err := MergeCSV(f, csvRows, MergeOptions{
+			Sheet:     "Sheet1",
+			Load:      mergeLoad,
+			Base:      mergeCSVRows(),
+			PreferCSV: preferCSV,
+		})
+		if err != nil {
+			t.Fatalf("MergeCSV() error = %v", err)
+		}
+		return f, result
+	}
+
+	f, result := run(false)
+	defer f.Close()
+	if len(result.Updated) != 1 || result.Updated[0].Column != "1.atk" {
+		t.Errorf("Updated = %+v", result.Updated)
+	}
+	if len(result.Conflicts) != 2 {
+		t.Fatalf("Conflicts = %v, want 2", result.Conflicts)
+	}
+	c := result.Conflicts[0]
+	if c.Key != "2" || c.Column != "atk" || c.Row != 5 || c.Base != "0" || c.Excel != "5" || c.CSV != "8" {
+		t.Errorf("Conflicts[0] = %+v", c)
+	}
+	if v, _ := f.GetCellValue("Sheet1", "C5"); v != "5" {
+		t.Errorf("C5 = %s, want Excel value 5", v)
+	}
+
+	// PreferCSV 时冲突使用 CSV 的值，公式单元格仍不覆盖
+	f2, result := run(true)
+	defer f2.Close()
+	if v, _ := f2.GetCellValue("Sheet1", "C5"); v != "8" {
+		t.Errorf("C5 = %s, want CSV value 8", v)
+	}
+	if formula, _ := f2.GetCellFormula("Sheet1", "C6"); formula != "A6*5" {
+		t.Errorf("C6 formula = %q, should be kept", formula)
+	}
+	if len(result.Conflicts) != 2 {
+		t.Errorf("Conflicts = %v, want 2", result.Conflicts)
+	}
+}
+
+// TestMergeCSV_Rows 测试整行的新增、删除冲突
+func TestMergeCSV_Rows(t *testing.T) {
+	f := newMergeWorkbook(t)
+	defer f.Close()
+	// Excel 删除了 3、修改了 2
+	f.RemoveRow("Sheet1", 6)
+	f.SetCellValue("Sheet1", "B5", "铁盾")
+
+	csvRows := mergeCSVRows()
+	csvRows[4][1] = "短弓"                          // CSV 修改了 Excel 删除的行
+	csvRows = append(csvRows[:3], csvRows[4:]...) // CSV 删除了 Excel 修改的行
+
+	result, err := MergeCSV(f, csvRows, MergeOptions{Sheet: "Sheet1", Load: mergeLoad, Base: mergeCSVRows()})
+	if err != nil {
+		t.Fatalf("MergeCSV() error = %v", err)
+	}
+	if result.Changed() || len(result.Conflicts) != 2 {
+		t.Errorf("result = %+v, want 2 conflicts and no change", result)
+	}
+}
+
+// TestMergeCSV_Errors 测试表头不一致和 Sheet 不存在
+func TestMergeCSV_Errors(t *testing.T) {
+	f := newMergeWorkbook(t)
+	defer f.Close()
+
+	csvRows := mergeCSVRows()
+	csvRows[0][2] = "attack"
+	if _, err := MergeCSV(f, csvRows, MergeOptions{Sheet: "Sheet1", Load: mergeLoad}); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("MergeCSV() error = %v, want ErrInvalidFormat", err)
+	}
+
+	csvRows = mergeCSVRows()
+	csvRows[1][2] = "float"
+	if _, err := MergeCSV(f, csvRows, MergeOptions{Sheet: "Sheet1", Load: mergeLoad}); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("MergeCSV() error = %v, want ErrInvalidFormat", err)
+	}
+
+	if _, err := MergeCSV(f, mergeCSVRows(), MergeOptions{Sheet: "Missing"}); !errors.Is(err, ErrSheetNotFound) {
+		t.Errorf("MergeCSV() error = %v, want ErrSheetNotFound", err)
+	}
+}
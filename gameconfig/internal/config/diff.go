@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+)
+
+// DiffOptions 差异对比选项
+type DiffOptions struct {
+	// Sheet Sheet 名（用于报告）
+	Sheet string
+	// Key 主键列名（为空时使用第一列）
+	Key string
+	// Load 表头行、类型行等布局选项（新旧两个版本相同）
+	Load LoadOptions
+}
+
+// TableDiff 单张表的差异
+type TableDiff struct {
+	Sheet   string     `json:"sheet"`
+	Key     string     `json:"key"`
+	Columns []string   `json:"columns"` // 新版本的列，之后是已删除的列
+	Schema  SchemaDiff `json:"schema"`
+	Added   []RowDiff  `json:"added,omitempty"`
+	Removed []RowDiff  `json:"removed,omitempty"`
+	Changed []RowDiff  `json:"changed,omitempty"`
+}
+
+// SchemaDiff 表结构差异
+type SchemaDiff struct {
+	AddedColumns   []string     `json:"addedColumns,omitempty"`
+	RemovedColumns []string     `json:"removedColumns,omitempty"`
+	TypeChanges    []TypeChange `json:"typeChanges,omitempty"`
+}
+
+// TypeChange 列类型变化
+type TypeChange struct {
+	Column  string `json:"column"`
+	OldType string `json:"oldType"`
+	NewType string `json:"newType"`
+}
+
+// RowDiff 行差异
+// 新增和删除的行记录完整的 Values，修改的行只记录变化的 Cells
+type RowDiff struct {
+	Key    string            `json:"key"`
+	Row    int               `json:"row"` // 数据行号（从 1 开始，删除的行为旧版本行号）
+	Values map[string]string `json:"values,omitempty"`
+	Cells  []CellDiff        `json:"cells,omitempty"`
+}
+
+// CellDiff 单元格差异
+type CellDiff struct {
+	Column string `json:"column"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+// Empty 是否没有任何差异
+func (d *TableDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 &&
+		len(d.Schema.AddedColumns) == 0 && len(d.Schema.RemovedColumns) == 0 && len(d.Schema.TypeChanges) == 0
+}
+
+// diffSide 一个版本的表数据
+type diffSide struct {
+	schema  *SheetSchema
+	columns map[string]int // 列名 -> 列索引
+	rows    [][]string     // 数据行
+	start   int            // 第一个数据行在原始行中的索引
+	keys    map[string]int // 主键 -> 数据行索引
+	order   []string       // 主键出现顺序
+}
+
+// DiffTables 对比同一张表的两个版本
+// 行按主键匹配，单元格按列名匹配，列顺序调整不会产生差异
+// 只对比两个版本都存在的列，新增和删除的列记录在 Schema 中
+func DiffTables(oldRows, newRows [][]string, options DiffOptions) (*TableDiff, error) {
+	oldSide, err := newDiffSide(oldRows, options)
+	if err != nil {
+		return nil, fmt.Errorf("旧版本: %w", err)
+	}
+	newSide, err := newDiffSide(newRows, options)
+	if err != nil {
+		return nil, fmt.Errorf("新版本: %w", err)
+	}
+
+	key := options.Key
+	if key == "" {
+		switch {
+		case newSide.schema != nil:
+			key = newSide.schema.Columns[0].Name
+		case oldSide.schema != nil:
+			key = oldSide.schema.Columns[0].Name
+		}
+	}
+	if err := oldSide.index(key); err != nil {
+		return nil, fmt.Errorf("旧版本: %w", err)
+	}
+	if err := newSide.index(key); err != nil {
+		return nil, fmt.Errorf("新版本: %w", err)
+	}
+
+	diff := &TableDiff{
+		Sheet:  options.Sheet,
+		Key:    key,
+		Schema: diffSchema(oldSide.schema, newSide.schema),
+	}
+
+	// 两个版本都存在的列，按新版本顺序
+	var common []string
+	if newSide.schema != nil {
+		for _, col := range newSide.schema.Columns {
+			diff.Columns = append(diff.Columns, col.Name)
+			if _, ok := oldSide.columns[col.Name]; ok {
+				common = append(common, col.Name)
+			}
+		}
+	}
+	diff.Columns = append(diff.Columns, diff.Schema.RemovedColumns...)
+
+	for _, k := range newSide.order {
+		newIdx := newSide.keys[k]
+		oldIdx, ok := oldSide.keys[k]
+		if !ok {
+			diff.Added = append(diff.Added, RowDiff{
+				Key:    k,
+				Row:    newIdx + 1,
+				Values: newSide.values(newIdx),
+			})
+			continue
+		}
+
+		var cells []CellDiff
+		for _, col := range common {
+			oldValue := oldSide.cell(oldIdx, col)
+			newValue := newSide.cell(newIdx, col)
+			if oldValue != newValue {
+				cells = append(cells, CellDiff{Column: col, Old: oldValue, New: newValue})
+			}
+		}
+		if len(cells) > 0 {
+			diff.Changed = append(diff.Changed, RowDiff{Key: k, Row: newIdx + 1, Cells: cells})
+		}
+	}
+
+	for _, k := range oldSide.order {
+		if _, ok := newSide.keys[k]; ok {
+			continue
+		}
+		oldIdx := oldSide.keys[k]
+		diff.Removed = append(diff.Removed, RowDiff{
+			Key:    k,
+			Row:    oldIdx + 1,
+			Values: oldSide.values(oldIdx),
+		})
+	}
+
+	return diff, nil
+}
+
+// newDiffSide 解析一个版本，空数据表示该版本不存在这张表
+func newDiffSide(rows [][]string, options DiffOptions) (*diffSide, error) {
+	side := &diffSide{
+		columns: make(map[string]int),
+		keys:    make(map[string]int),
+	}
+	if len(rows) == 0 {
+		return side, nil
+	}
+
+	schema, err := ReadSheetSchema(options.Sheet, rows, options.Load)
+	if err != nil {
+		return nil, err
+	}
+	side.schema = schema
+
+	layout := resolveRowLayout(rows, options.Load)
+	for i, header := range rows[layout.headerRow] {
+		if header != "" {
+			if _, ok := side.columns[header]; !ok {
+				side.columns[header] = i
+			}
+		}
+	}
+	side.start = layout.dataStart
+	if layout.dataStart < len(rows) {
+		side.rows = rows[layout.dataStart:]
+	}
+	return side, nil
+}
+
+// index 按主键建立索引
+func (s *diffSide) index(key string) error {
+	if s.schema == nil {
+		return nil
+	}
+	col, ok := s.columns[key]
+	if !ok {
+		return fmt.Errorf("%w: 主键列 '%s' 不存在", ErrInvalidFormat, key)
+	}
+
+	for i, row := range s.rows {
+		if col >= len(row) || row[col] == "" {
+			continue
+		}
+		k := row[col]
+		if prev, ok := s.keys[k]; ok {
+			return NewConfigError("", i+1, col+1, key,
+				fmt.Sprintf("主键 '%s' 重复（第 %d 行）", k, prev+1), ErrInvalidFormat)
+		}
+		s.keys[k] = i
+		s.order = append(s.order, k)
+	}
+	return nil
+}
+
+// cell 获取单元格值
+func (s *diffSide) cell(row int, column string) string {
+	col, ok := s.columns[column]
+	if !ok || col >= len(s.rows[row]) {
+		return ""
+	}
+	return s.rows[row][col]
+}
+
+// values 获取整行的值（列名 -> 值）
+func (s *diffSide) values(row int) map[string]string {
+	values := make(map[string]string, len(s.schema.Columns))
+	for _, col := range s.schema.Columns {
+		values[col.Name] = s.cell(row, col.Name)
+	}
+	return values
+}
+
+// diffSchema 对比表结构
+func diffSchema(oldSchema, newSchema *SheetSchema) SchemaDiff {
+	var diff SchemaDiff
+	oldTypes := make(map[string]string)
+	newTypes := make(map[string]string)
+	if oldSchema != nil {
+		for _, col := range oldSchema.Columns {
+			oldTypes[col.Name] = col.Type
+		}
+	}
+	if newSchema != nil {
+		for _, col := range newSchema.Columns {
+			newTypes[col.Name] = col.Type
+			oldType, ok := oldTypes[col.Name]
+			switch {
+			case !ok:
+				diff.AddedColumns = append(diff.AddedColumns, col.Name)
+			case oldType != col.Type:
+				diff.TypeChanges = append(diff.TypeChanges, TypeChange{
+					Column:  col.Name,
+					OldType: oldType,
+					NewType: col.Type,
+				})
+			}
+		}
+	}
+	if oldSchema != nil {
+		for _, col := range oldSchema.Columns {
+			if _, ok := newTypes[col.Name]; !ok {
+				diff.RemovedColumns = append(diff.RemovedColumns, col.Name)
+			}
+		}
+	}
+	return diff
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDiffTables_Rows 测试新增、删除和修改的行
+func TestDiffTables_Rows(t *testing.T) {
+	oldRows := [][]string{
+		{"id", "name", "attack"},
+		{"1", "铁剑", "10"},
+		{"2", "钢剑", "20"},
+		{"3", "木剑", "5"},
+	}
+	newRows := [][]string{
+		{"id", "name", "attack"},
+		{"1", "铁剑", "12"},
+		{"2", "钢剑", "20"},
+		{"4", "金剑", "50"},
+	}
+
+	diff, err := DiffTables(oldRows, newRows, DiffOptions{Sheet: "武器"})
+	if err != nil {
+		t.Fatalf("DiffTables() error = %v", err)
+	}
+
+	if diff.Key != "id" {
+		t.Errorf("Key = %q, want id", diff.Key)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Key != "4" || diff.Added[0].Values["name"] != "金剑" {
+		t.Errorf("Added = %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Key != "3" || diff.Removed[0].Row != 3 {
+		t.Errorf("Removed = %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("Changed = %+v", diff.Changed)
+	}
+	cells := diff.Changed[0].Cells
+	if diff.Changed[0].Key != "1" || len(cells) != 1 || cells[0] != (CellDiff{Column: "attack", Old: "10", New: "12"}) {
+		t.Errorf("Changed[0] = %+v", diff.Changed[0])
+	}
+}
+
+// TestDiffTables_ColumnReorder 测试调整列顺序不产生差异
+func TestDiffTables_ColumnReorder(t *testing.T) {
+	oldRows := [][]string{
+		{"id", "name", "attack"},
+		{"1", "铁剑", "10"},
+	}
+	newRows := [][]string{
+		{"attack", "id", "name"},
+		{"10", "1", "铁剑"},
+	}
+
+	diff, err := DiffTables(oldRows, newRows, DiffOptions{Key: "id"})
+	if err != nil {
+		t.Fatalf("DiffTables() error = %v", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("diff should be empty, got %+v", diff)
+	}
+}
+
+// TestDiffTables_Schema 测试列的新增、删除和类型变化
+func TestDiffTables_Schema(t *testing.T) {
+	oldRows := [][]string{
+		{"id", "rate", "old"},
+		{"1", "10", "x"},
+	}
+	newRows := [][]string{
+		{"id", "rate", "new"},
+		{"1", "0.5", "y"},
+	}
+
+	diff, err := DiffTables(oldRows, newRows, DiffOptions{})
+	if err != nil {
+		t.Fatalf("DiffTables() error = %v", err)
+	}
+
+	schema := diff.Schema
+	if len(schema.AddedColumns) != 1 || schema.AddedColumns[0] != "new" {
+		t.Errorf("AddedColumns = %v", schema.AddedColumns)
+	}
+	if len(schema.RemovedColumns) != 1 || schema.RemovedColumns[0] != "old" {
+		t.Errorf("RemovedColumns = %v", schema.RemovedColumns)
+	}
+	if len(schema.TypeChanges) != 1 || schema.TypeChanges[0] != (TypeChange{Column: "rate", OldType: "int", NewType: "float"}) {
+		t.Errorf("TypeChanges = %+v", schema.TypeChanges)
+	}
+	if strings.Join(diff.Columns, ",") != "id,rate,new,old" {
+		t.Errorf("Columns = %v", diff.Columns)
+	}
+	// 只有两个版本都存在的列参与单元格对比
+	if len(diff.Changed) != 1 || len(diff.Changed[0].Cells) != 1 || diff.Changed[0].Cells[0].Column != "rate" {
+		t.Errorf("Changed = %+v", diff.Changed)
+	}
+}
+
+// TestDiffTables_MissingSheet 测试某个版本不存在这张表
+func TestDiffTables_MissingSheet(t *testing.T) {
+	rows := [][]string{
+		{"id", "name"},
+		{"1", "铁剑"},
+	}
+
+	diff, err := DiffTables(nil, rows, DiffOptions{})
+	if err != nil {
+		t.Fatalf("DiffTables() error = %v", err)
+	}
+	if len(diff.Added) != 1 || len(diff.Schema.AddedColumns) != 2 {
+		t.Errorf("new sheet diff = %+v", diff)
+	}
+
+	diff, err = DiffTables(rows, nil, DiffOptions{})
+	if err != nil {
+		t.Fatalf("DiffTables() error = %v", err)
+	}
+	if len(diff.Removed) != 1 || len(diff.Schema.RemovedColumns) != 2 {
+		t.Errorf("removed sheet diff = %+v", diff)
+	}
+}
+
+// TestDiffTables_Errors 测试主键重复和主键列不存在
+func TestDiffTables_Errors(t *testing.T) {
+	rows := [][]string{
+		{"id", "name"},
+		{"1", "铁剑"},
+	}
+
+	_, err := DiffTables(rows, [][]string{{"id", "name"}, {"1", "a"}, {"1", "b"}}, DiffOptions{})
+	if err == nil || !strings.Contains(err.Error(), "重复") {
+		t.Errorf("error = %v, want duplicate key", err)
+	}
+
+	_, err = DiffTables(rows, rows, DiffOptions{Key: "missing"})
+	if err == nil {
+		t.Error("DiffTables() should fail when key column does not exist")
+	}
+}
@@ -0,0 +1,502 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Reloadable 可参与分组热重载的配置表
+type Reloadable interface {
+	// Name 表名（用于错误信息）
+	Name() string
+	// SourcePaths 返回需要监听的文件
+	SourcePaths() []string
+	// Prepare 加载候选数据，不影响当前生效的数据
+	Prepare() error
+	// Commit 使候选数据生效
+	Commit()
+	// Discard 丢弃候选数据，继续使用当前数据
+	Discard()
+}
+
+// Table 支持分组热重载的配置表
+// 重载时先加载为候选数据，整组校验通过后才替换当前数据
+type Table[T any] struct {
+	name   string
+	loader *Loader[T]
+
+	mu      sync.RWMutex
+	current *tableState[T]
+	staged  *tableState[T] // 为空表示没有候选数据
+}
+
+// tableState 一个版本的表数据
+type tableState[T any] struct {
+	data      []T
+	headers   []string                     // 表头（二进制快照没有原始行，为空）
+	rows      [][]string                   // 文件中的数据行，不含在线覆盖
+	overrides map[string]map[string]string // 主键 -> 列 -> 覆盖值
+	version   int
+	loadedAt  time.Time
+}
+
+// NewTable 创建配置表
+func NewTable[T any](loader *Loader[T]) *Table[T] {
+	return &Table[T]{
+		name:   loader.sheetName,
+		loader: loader,
+	}
+}
+
+// Name 表名（Sheet 名）
+func (t *Table[T]) Name() string {
+	return t.name
+}
+
+// SourcePaths 返回需要监听的文件
+func (t *Table[T]) SourcePaths() []string {
+	return t.loader.SourcePaths()
+}
+
+// Load 首次加载并立即生效
+func (t *Table[T]) Load() error {
+	if err := t.Prepare(); err != nil {
+		return err
+	}
+	t.Commit()
+	return nil
+}
+
+// Prepare 加载候选数据
+// 重新加载的数据以文件为准，不包含之前的在线覆盖
+func (t *Table[T]) Prepare() error {
+	headers, rows, data, err := t.loader.loadTable(t.loader.resolveMode())
+	if err != nil {
+		return err
+	}
+	version, err := t.loader.GetVersion()
+	if err != nil {
+		return fmt.Errorf("读取版本号失败: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.staged = &tableState[T]{
+		data:     data,
+		headers:  headers,
+		rows:     rows,
+		version:  version,
+		loadedAt: time.Now(),
+	}
+	return nil
+}
+
+// Commit 使候选数据生效
+func (t *Table[T]) Commit() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.staged != nil {
+		t.current = t.staged
+	}
+	t.staged = nil
+}
+
+// Discard 丢弃候选数据
+func (t *Table[T]) Discard() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.staged = nil
+}
+
+// Data 返回当前生效的数据
+func (t *Table[T]) Data() []T {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.current == nil {
+		return nil
+	}
+	return t.current.data
+}
+
+// Staged 返回候选数据，没有候选数据时返回当前数据
+// 用于在组校验函数中检查即将生效的数据
+func (t *Table[T]) Staged() []T {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.staged != nil {
+		return t.staged.data
+	}
+	if t.current == nil {
+		return nil
+	}
+	return t.current.data
+}
+
+// ReloadGroup 重载单元：组内的表一起加载、一起校验、一起生效
+type ReloadGroup struct {
+	name      string
+	tables    []Reloadable
+	validate  func() error
+	dependsOn []*ReloadGroup
+}
+
+// NewReloadGroup 创建重载单元
+func NewReloadGroup(name string, tables ...Reloadable) *ReloadGroup {
+	return &ReloadGroup{
+		name:   name,
+		tables: tables,
+	}
+}
+
+// Name 返回重载单元名称
+func (g *ReloadGroup) Name() string {
+	return g.name
+}
+
+// SetValidator 设置组校验函数，在组内所有表加载完成后、生效之前调用
+// 校验函数中使用 Table.Staged 获取即将生效的数据
+func (g *ReloadGroup) SetValidator(fn func() error) *ReloadGroup {
+	g.validate = fn
+	return g
+}
+
+// DependsOn 声明依赖的重载单元
+// 被依赖的单元重载时，本单元会一起重新加载和校验
+func (g *ReloadGroup) DependsOn(groups ...*ReloadGroup) *ReloadGroup {
+	g.dependsOn = append(g.dependsOn, groups...)
+	return g
+}
+
+// DirWatcher 目录级文件监听器
+// 监听所有重载单元涉及的目录，文件变化时重载对应的单元及依赖它的单元
+type DirWatcher struct {
+	groups   []*ReloadGroup
+	debounce time.Duration
+	onReload func(groups []string)
+	onError  func(group string, err error)
+
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+	mu         sync.Mutex
+	reloadMu   sync.Mutex // 保证同一时间只有一次重载
+}
+
+// NewDirWatcher 创建目录级文件监听器
+func NewDirWatcher(groups ...*ReloadGroup) *DirWatcher {
+	return &DirWatcher{
+		groups:   groups,
+		debounce: 200 * time.Millisecond, // 默认防抖 200ms
+	}
+}
+
+// OnReload 设置重载成功回调，参数为本次生效的重载单元名称
+func (w *DirWatcher) OnReload(fn func(groups []string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onReload = fn
+}
+
+// OnError 设置重载失败回调
+// 失败时所有候选数据都会被丢弃，各表继续使用上一个正确版本
+func (w *DirWatcher) OnError(fn func(group string, err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = fn
+}
+
+// SetDebounce 设置防抖时间
+func (w *DirWatcher) SetDebounce(duration time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.debounce = duration
+}
+
+// Reload 重载指定单元及依赖它们的单元
+// 所有相关单元加载并校验通过后才一起生效，任何一步失败都不会替换当前数据
+func (w *DirWatcher) Reload(groups ...*ReloadGroup) error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	ordered, err := w.affectedGroups(groups)
+	if err != nil {
+		w.reportError("", err)
+		return err
+	}
+
+	// 加载候选数据
+	for _, group := range ordered {
+		for _, table := range group.tables {
+			if err := table.Prepare(); err != nil {
+				discardAll(ordered)
+				err = fmt.Errorf("重载单元 '%s' 加载表 '%s' 失败: %w", group.name, table.Name(), err)
+				w.reportError(group.name, err)
+				return err
+			}
+		}
+	}
+
+	// 按依赖顺序校验
+	for _, group := range ordered {
+		if group.validate == nil {
+			continue
+		}
+		if err := group.validate(); err != nil {
+			discardAll(ordered)
+			err = fmt.Errorf("重载单元 '%s' 校验失败: %w", group.name, err)
+			w.reportError(group.name, err)
+			return err
+		}
+	}
+
+	// 全部通过后一起生效
+	names := make([]string, 0, len(ordered))
+	for _, group := range ordered {
+		for _, table := range group.tables {
+			table.Commit()
+		}
+		names = append(names, group.name)
+	}
+
+	w.mu.Lock()
+	callback := w.onReload
+	w.mu.Unlock()
+	if callback != nil {
+		callback(names)
+	}
+	return nil
+}
+
+// affectedGroups 计算受影响的单元（包括传递依赖它们的单元），按依赖顺序排列
+func (w *DirWatcher) affectedGroups(changed []*ReloadGroup) ([]*ReloadGroup, error) {
+	// 反向依赖：被依赖单元 -> 依赖它的单元
+	dependents := make(map[*ReloadGroup][]*ReloadGroup)
+	for _, group := range w.groups {
+		for _, dep := range group.dependsOn {
+			dependents[dep] = append(dependents[dep], group)
+		}
+	}
+
+	affected := make(map[*ReloadGroup]bool)
+	queue := append([]*ReloadGroup(nil), changed...)
+	for len(queue) > 0 {
+		group := queue[0]
+		queue = queue[1:]
+		if affected[group] {
+			continue
+		}
+		affected[group] = true
+		queue = append(queue, dependents[group]...)
+	}
+
+	// 拓扑排序：依赖在前
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*ReloadGroup]int)
+	var ordered []*ReloadGroup
+	var visit func(group *ReloadGroup, path []string) error
+	visit = func(group *ReloadGroup, path []string) error {
+		switch state[group] {
+		case visiting:
+			return fmt.Errorf("重载单元存在循环依赖: %v", append(path, group.name))
+		case visited:
+			return nil
+		}
+		state[group] = visiting
+		for _, dep := range group.dependsOn {
+			if err := visit(dep, append(path, group.name)); err != nil {
+				return err
+			}
+		}
+		state[group] = visited
+		if affected[group] {
+			ordered = append(ordered, group)
+		}
+		return nil
+	}
+
+	for _, group := range w.groups {
+		if err := visit(group, nil); err != nil {
+			return nil, err
+		}
+	}
+	// 未注册到监听器的单元
+	for _, group := range changed {
+		if err := visit(group, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// discardAll 丢弃所有候选数据
+func discardAll(groups []*ReloadGroup) {
+	for _, group := range groups {
+		for _, table := range group.tables {
+			table.Discard()
+		}
+	}
+}
+
+// reportError 调用错误回调
+func (w *DirWatcher) reportError(group string, err error) {
+	w.mu.Lock()
+	callback := w.onError
+	w.mu.Unlock()
+	if callback != nil {
+		callback(group, err)
+	}
+}
+
+// watchIndex 文件路径 -> 重载单元
+func (w *DirWatcher) watchIndex() (map[string][]*ReloadGroup, error) {
+	index := make(map[string][]*ReloadGroup)
+	for _, group := range w.groups {
+		for _, table := range group.tables {
+			for _, path := range table.SourcePaths() {
+				abs, err := filepath.Abs(path)
+				if err != nil {
+					return nil, fmt.Errorf("解析路径 %s 失败: %w", path, err)
+				}
+				index[abs] = append(index[abs], group)
+			}
+		}
+	}
+	return index, nil
+}
+
+// Watch 开始监听文件变化
+func (w *DirWatcher) Watch(ctx context.Context) error {
+	if _, err := w.affectedGroups(nil); err != nil {
+		return err
+	}
+
+	index, err := w.watchIndex()
+	if err != nil {
+		return err
+	}
+	if len(index) == 0 {
+		return fmt.Errorf("没有可监听的文件")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监听器失败: %w", err)
+	}
+	defer watcher.Close()
+
+	// 监听所有涉及的目录
+	dirs := make(map[string]bool)
+	for path := range index {
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("添加监听目录 %s 失败: %w", dir, err)
+		}
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	w.mu.Lock()
+	w.cancelFunc = cancel
+	debounce := w.debounce
+	w.mu.Unlock()
+
+	// 防抖期间累积变化的单元
+	// 定时器只通知监听循环，Reload 在循环所在的 goroutine 中执行，
+	// 保证 Watch/Stop 返回后不会再有重载
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	dirty := make(map[*ReloadGroup]bool)
+
+	flush := func() {
+		groups := make([]*ReloadGroup, 0, len(dirty))
+		for group := range dirty {
+			groups = append(groups, group)
+		}
+		dirty = make(map[*ReloadGroup]bool)
+
+		sort.Slice(groups, func(i, j int) bool { return groups[i].name < groups[j].name })
+		if len(groups) > 0 && watchCtx.Err() == nil {
+			_ = w.Reload(groups...)
+		}
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-watchCtx.Done():
+				return
+
+			case <-timerC:
+				timerC = nil
+				flush()
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				w.reportError("", fmt.Errorf("文件监听错误: %w", err))
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !isReloadEvent(event) {
+					continue
+				}
+
+				abs, err := filepath.Abs(event.Name)
+				if err != nil {
+					continue
+				}
+				groups, ok := index[abs]
+				if !ok {
+					// 临时文件、备份文件等无关文件
+					continue
+				}
+
+				for _, group := range groups {
+					dirty[group] = true
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.NewTimer(debounce)
+				timerC = timer.C
+			}
+		}
+	}()
+
+	<-watchCtx.Done()
+	w.wg.Wait()
+	return nil
+}
+
+// Stop 停止监听
+func (w *DirWatcher) Stop() {
+	w.mu.Lock()
+	if w.cancelFunc != nil {
+		w.cancelFunc()
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+}
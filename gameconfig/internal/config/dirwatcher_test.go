@@ -0,0 +1,278 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type watchItem struct {
+	ID    int `excel:"id"`
+	Value int `excel:"value"`
+}
+
+type watchRef struct {
+	ID     int `excel:"id"`
+	ItemID int `excel:"item_id"`
+}
+
+// writeCSV 写入 CSV 测试文件
+func writeCSV(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// refValidator 校验引用表中的 item_id 都存在
+func refValidator(items *Table[watchItem], refs *Table[watchRef]) func() error {
+	return func() error {
+		ids := make(map[int]bool)
+		for _, item := range items.Staged() {
+			ids[item.ID] = true
+		}
+		for _, ref := range refs.Staged() {
+			if !ids[ref.ItemID] {
+				return errors.New("引用了不存在的 item")
+			}
+		}
+		return nil
+	}
+}
+
+// TestDirWatcher_ReloadGroup 测试组内的表一起生效
+func TestDirWatcher_ReloadGroup(t *testing.T) {
+	dir := t.TempDir()
+	writeCSV(t, filepath.Join(dir, "item.csv"), "id,value\n1,10\n")
+	writeCSV(t, filepath.Join(dir, "ref.csv"), "id,item_id\n1,1\n")
+
+	items := NewTable(NewLoader[watchItem](dir, "item", LoadOptions{Mode: ModeCSV}))
+	refs := NewTable(NewLoader[watchRef](dir, "ref", LoadOptions{Mode: ModeCSV}))
+	group := NewReloadGroup("物品", items, refs).SetValidator(refValidator(items, refs))
+
+	w := NewDirWatcher(group)
+	var reloaded []string
+	w.OnReload(func(groups []string) { reloaded = groups })
+
+	if err := w.Reload(group); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(items.Data()) != 1 || len(refs.Data()) != 1 {
+		t.Fatalf("data not committed: items=%v refs=%v", items.Data(), refs.Data())
+	}
+	if len(reloaded) != 1 || reloaded[0] != "物品" {
+		t.Errorf("OnReload groups = %v, want [物品]", reloaded)
+	}
+
+	// 同时修改两张表
+	writeCSV(t, filepath.Join(dir, "item.csv"), "id,value\n1,10\n2,20\n")
+	writeCSV(t, filepath.Join(dir, "ref.csv"), "id,item_id\n1,1\n2,2\n")
+	if err := w.Reload(group); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(items.Data()) != 2 || len(refs.Data()) != 2 {
+		t.Errorf("data not updated: items=%v refs=%v", items.Data(), refs.Data())
+	}
+}
+
+// TestDirWatcher_ValidationRollback 测试校验失败时保留上一个正确版本
+func TestDirWatcher_ValidationRollback(t *testing.T) {
+	dir := t.TempDir()
+	writeCSV(t, filepath.Join(dir, "item.csv"), "id,value\n1,10\n")
+	writeCSV(t, filepath.Join(dir, "ref.csv"), "id,item_id\n1,1\n")
+
+	items := NewTable(NewLoader[watchItem](dir, "item", LoadOptions{Mode: ModeCSV}))
+	refs := NewTable(NewLoader[watchRef](dir, "ref", LoadOptions{Mode: ModeCSV}))
+	group := NewReloadGroup("物品", items, refs).SetValidator(refValidator(items, refs))
+
+	w := NewDirWatcher(group)
+	var errGroup string
+	var gotErr error
+	w.OnError(func(group string, err error) {
+		errGroup = group
+		gotErr = err
+	})
+
+	if err := w.Reload(group); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	// item 表删掉了被引用的行
+	writeCSV(t, filepath.Join(dir, "item.csv"), "id,value\n3,30\n")
+	if err := w.Reload(group); err == nil {
+		t.Fatal("Reload() should fail validation")
+	}
+
+	if errGroup != "物品" || gotErr == nil {
+		t.Errorf("OnError = (%q, %v), want group 物品", errGroup, gotErr)
+	}
+	if data := items.Data(); len(data) != 1 || data[0].ID != 1 {
+		t.Errorf("items should keep previous version, got %v", data)
+	}
+	if data := items.Staged(); len(data) != 1 || data[0].ID != 1 {
+		t.Errorf("staged data should be discarded, got %v", data)
+	}
+}
+
+// TestDirWatcher_LoadFailureRollback 测试某张表加载失败时整组不生效
+func TestDirWatcher_LoadFailureRollback(t *testing.T) {
+	dir := t.TempDir()
+	writeCSV(t, filepath.Join(dir, "item.csv"), "id,value\n1,10\n")
+	writeCSV(t, filepath.Join(dir, "ref.csv"), "id,item_id\n1,1\n")
+
+	items := NewTable(NewLoader[watchItem](dir, "item", LoadOptions{Mode: ModeCSV}))
+	refs := NewTable(NewLoader[watchRef](dir, "ref", LoadOptions{Mode: ModeCSV}))
+	group := NewReloadGroup("物品", items, refs)
+
+	w := NewDirWatcher(group)
+	if err := w.Reload(group); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	writeCSV(t, filepath.Join(dir, "item.csv"), "id,value\n1,20\n")
+	writeCSV(t, filepath.Join(dir, "ref.csv"), "id,item_id\n1,abc\n")
+	if err := w.Reload(group); err == nil {
+		t.Fatal("Reload() should fail")
+	}
+
+	if data := items.Data(); data[0].Value != 10 {
+		t.Errorf("item should not be committed when another table fails, got %v", data)
+	}
+}
+
+// TestDirWatcher_Dependencies 测试依赖单元一起重载和校验
+func TestDirWatcher_Dependencies(t *testing.T) {
+	dir := t.TempDir()
+	writeCSV(t, filepath.Join(dir, "item.csv"), "id,value\n1,10\n")
+	writeCSV(t, filepath.Join(dir, "ref.csv"), "id,item_id\n1,1\n")
+
+	items := NewTable(NewLoader[watchItem](dir, "item", LoadOptions{Mode: ModeCSV}))
+	refs := NewTable(NewLoader[watchRef](dir, "ref", LoadOptions{Mode: ModeCSV}))
+	itemGroup := NewReloadGroup("item", items)
+	refGroup := NewReloadGroup("ref", refs).
+		SetValidator(refValidator(items, refs)).
+		DependsOn(itemGroup)
+
+	w := NewDirWatcher(refGroup, itemGroup)
+	var reloaded []string
+	w.OnReload(func(groups []string) { reloaded = groups })
+
+	if err := w.Reload(itemGroup); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(reloaded) != 2 || reloaded[0] != "item" || reloaded[1] != "ref" {
+		t.Errorf("reloaded = %v, want [item ref]", reloaded)
+	}
+
+	// 只修改被依赖的 item 表，依赖它的 ref 单元的校验也会执行
+	writeCSV(t, filepath.Join(dir, "item.csv"), "id,value\n2,20\n")
+	if err := w.Reload(itemGroup); err == nil {
+		t.Fatal("Reload() should fail dependent validation")
+	}
+	if data := items.Data(); data[0].ID != 1 {
+		t.Errorf("item should keep previous version, got %v", data)
+	}
+}
+
+// TestDirWatcher_Cycle 测试循环依赖
+func TestDirWatcher_Cycle(t *testing.T) {
+	a := NewReloadGroup("a")
+	b := NewReloadGroup("b").DependsOn(a)
+	a.DependsOn(b)
+
+	w := NewDirWatcher(a, b)
+	if err := w.Reload(a); err == nil {
+		t.Error("Reload() should report cyclic dependency")
+	}
+	if err := w.Watch(context.Background()); err == nil {
+		t.Error("Watch() should report cyclic dependency")
+	}
+}
+
+// TestDirWatcher_AtomicSave 测试通过重命名覆盖的原子保存能触发重载
+func TestDirWatcher_AtomicSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "item.csv")
+	writeCSV(t, path, "id,value\n1,10\n")
+
+	items := NewTable(NewLoader[watchItem](dir, "item", LoadOptions{Mode: ModeCSV}))
+	if err := items.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	w := NewDirWatcher(NewReloadGroup("item", items))
+	w.SetDebounce(20 * time.Millisecond)
+
+	var once sync.Once
+	done := make(chan struct{})
+	w.OnReload(func([]string) { once.Do(func() { close(done) }) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Watch(ctx)
+	defer w.Stop()
+
+	// 等待监听启动
+	time.Sleep(100 * time.Millisecond)
+
+	// 编辑器式保存：写临时文件后重命名覆盖
+	tmp := filepath.Join(dir, ".item.csv.tmp")
+	writeCSV(t, tmp, "id,value\n1,10\n2,20\n")
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("atomic save did not trigger reload")
+	}
+
+	if len(items.Data()) != 2 {
+		t.Errorf("items = %v, want 2 rows", items.Data())
+	}
+}
+
+// TestWatcher_MultiSheet 测试修改拼接的任意一张 Sheet 都会触发重载
+func TestWatcher_MultiSheet(t *testing.T) {
+	dir := t.TempDir()
+	writeCSV(t, filepath.Join(dir, "item_a.csv"), "id,value\n1,10\n")
+	writeCSV(t, filepath.Join(dir, "item_b.csv"), "id,value\n2,20\n")
+
+	loader := NewLoader[watchItem](dir, "item", LoadOptions{Mode: ModeCSV, Sheets: []string{"item_*"}})
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	w := NewWatcher(loader)
+	w.SetDebounce(20 * time.Millisecond)
+	reloaded := make(chan []watchItem, 1)
+	w.OnChange(func(items []watchItem) {
+		select {
+		case reloaded <- items:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Watch(ctx)
+	defer w.Stop()
+
+	// 等待监听启动
+	time.Sleep(100 * time.Millisecond)
+	writeCSV(t, filepath.Join(dir, "item_b.csv"), "id,value\n2,20\n3,30\n")
+
+	select {
+	case items := <-reloaded:
+		if len(items) != 3 {
+			t.Errorf("items = %v, want 3 rows", items)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("editing the second sheet did not trigger reload")
+	}
+}
@@ -0,0 +1,299 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math/bits"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnumValue 字典中的一个枚举值
+type EnumValue struct {
+	Name    string // 常量名（如 Fire），为空时由 Label 生成
+	Label   string // 策划填写的文字（如 火）
+	Value   int64
+	Comment string
+}
+
+// EnumDict 一个枚举字典
+type EnumDict struct {
+	Name   string
+	Values []EnumValue
+
+	byText  map[string]int64 // Label、Name -> 值
+	byValue map[int64]string // 值 -> Label
+}
+
+// Parse 将单元格文字转换为枚举值
+// 支持 Label、Name 和字典中存在的整数值；位标志可以用 | 组合（如 火|水），组合的每一项都必须是单个二进制位
+func (d *EnumDict) Parse(text string) (int64, error) {
+	parts := strings.Split(text, "|")
+	var result int64
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		value, ok := d.byText[part]
+		if !ok {
+			if n, err := strconv.ParseInt(part, 10, 64); err == nil {
+				_, ok = d.byValue[n]
+				value = n
+			}
+		}
+		if !ok {
+			return 0, fmt.Errorf("%w: 字典 '%s' 中不存在 '%s'", ErrUnknownEnum, d.Name, part)
+		}
+		if len(parts) > 1 && value != 0 && bits.OnesCount64(uint64(value)) != 1 {
+			return 0, fmt.Errorf("%w: 字典 '%s' 的 '%s'（%d）不是单个二进制位，不能用 | 组合", ErrUnknownEnum, d.Name, part, value)
+		}
+		result |= value
+	}
+	return result, nil
+}
+
+// Label 返回枚举值的文字，位标志组合返回 火|水 形式
+func (d *EnumDict) Label(value int64) (string, bool) {
+	if label, ok := d.byValue[value]; ok {
+		return label, true
+	}
+	var labels []string
+	rest := value
+	for _, v := range d.Values {
+		if v.Value != 0 && bits.OnesCount64(uint64(v.Value)) == 1 && rest&v.Value != 0 {
+			labels = append(labels, v.Label)
+			rest &^= v.Value
+		}
+	}
+	if rest != 0 || len(labels) == 0 {
+		return "", false
+	}
+	return strings.Join(labels, "|"), true
+}
+
+// EnumRegistry 枚举字典集合，通过 LoadOptions.Enums 提供给 enum:字典名 字段
+type EnumRegistry struct {
+	dicts map[string]*EnumDict
+	order []string
+}
+
+// NewEnumRegistry 创建空的枚举字典集合
+func NewEnumRegistry() *EnumRegistry {
+	return &EnumRegistry{dicts: make(map[string]*EnumDict)}
+}
+
+// Add 向字典添加枚举值，字典不存在时创建
+// 同一字典中 Label、Name 不能重复（不同项的 Name 与 Label 也不能相同），值不能重复
+func (r *EnumRegistry) Add(dict string, values ...EnumValue) error {
+	d, ok := r.dicts[dict]
+	if !ok {
+		d = &EnumDict{Name: dict, byText: make(map[string]int64), byValue: make(map[int64]string)}
+		r.dicts[dict] = d
+		r.order = append(r.order, dict)
+	}
+
+	for _, v := range values {
+		if v.Label == "" {
+			return fmt.Errorf("%w: 字典 '%s' 的值 %d 缺少文字", ErrInvalidFormat, dict, v.Value)
+		}
+		if label, ok := d.byValue[v.Value]; ok {
+			return fmt.Errorf("%w: 字典 '%s' 中 '%s' 与 '%s' 的值都是 %d", ErrInvalidFormat, dict, v.Label, label, v.Value)
+		}
+		for _, text := range []string{v.Label, v.Name} {
+			if text == "" {
+				continue
+			}
+			if value, ok := d.byText[text]; ok && (text != v.Name || value != v.Value) {
+				return fmt.Errorf("%w: 字典 '%s' 中 '%s' 重复", ErrInvalidFormat, dict, text)
+			}
+			if strings.Contains(text, "|") {
+				return fmt.Errorf("%w: 字典 '%s' 的 '%s' 不能包含 |", ErrInvalidFormat, dict, text)
+			}
+			d.byText[text] = v.Value
+		}
+		d.byValue[v.Value] = v.Label
+		d.Values = append(d.Values, v)
+	}
+	return nil
+}
+
+// Dict 按名称获取字典
+func (r *EnumRegistry) Dict(name string) (*EnumDict, bool) {
+	if r == nil {
+		return nil, false
+	}
+	d, ok := r.dicts[name]
+	return d, ok
+}
+
+// Names 返回所有字典名（按首次出现的顺序）
+func (r *EnumRegistry) Names() []string {
+	return append([]string(nil), r.order...)
+}
+
+// enumColumns 字典表的列名
+var enumColumns = []string{"enum", "name", "label", "value", "comment"}
+
+// NewEnums 从字典表的行数据创建枚举字典集合
+// 字典表的列（按表头名称识别，顺序任意，name 和 comment 可省略）:
+//
+//	enum    | name  | label | value | comment
+//	Element | Fire  | 火    | 1     | 火属性
+//	Element | Water | 水    | 2     |
+//	Rarity  | Rare  | rare  | 3     |
+func NewEnums(rows [][]string, options LoadOptions) (*EnumRegistry, error) {
+	layout := resolveRowLayout(rows, options)
+	if len(rows) <= layout.headerRow {
+		return nil, fmt.Errorf("%w: 字典表缺少表头行", ErrInvalidFormat)
+	}
+
+	headers := rows[layout.headerRow]
+	cols := make(map[string]int)
+	for i, header := range headers {
+		cols[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	for _, required := range []string{"enum", "label", "value"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("%w: 字典表缺少 %s 列（需要 %s）", ErrInvalidFormat, required, strings.Join(enumColumns, "、"))
+		}
+	}
+	cell := func(row []string, column string) string {
+		if col, ok := cols[column]; ok && col < len(row) {
+			return strings.TrimSpace(row[col])
+		}
+		return ""
+	}
+
+	registry := NewEnumRegistry()
+	for i := layout.dataStart; i < len(rows); i++ {
+		row := rows[i]
+		dict := cell(row, "enum")
+		if dict == "" {
+			continue
+		}
+		dataRow := i - layout.dataStart + 1
+
+		text := cell(row, "value")
+		value, err := strconv.ParseInt(text, 0, 64)
+		if err != nil {
+			return nil, NewConfigError("", dataRow, cols["value"]+1, "value",
+				fmt.Sprintf("枚举值 '%s' 不是整数", text), ErrTypeMismatch)
+		}
+		err = registry.Add(dict, EnumValue{
+			Name:    cell(row, "name"),
+			Label:   cell(row, "label"),
+			Value:   value,
+			Comment: cell(row, "comment"),
+		})
+		if err != nil {
+			return nil, NewConfigError("", dataRow, cols["label"]+1, "label", err.Error(), ErrInvalidFormat)
+		}
+	}
+	return registry, nil
+}
+
+// LoadEnums 加载字典表，数据源规则与 Loader 相同
+func LoadEnums(basePath string, sheetName string, options LoadOptions) (*EnumRegistry, error) {
+	rows, err := ReadRows(basePath, sheetName, options)
+	if err != nil {
+		return nil, err
+	}
+	return NewEnums(rows, options)
+}
+
+// isIntegerKind 是否为整数类型（枚举字段必须是整数类型）
+func isIntegerKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// convertField 按字段类型转换单元格值，enum 字段先按字典把文字转换为整数
+// 返回转换后的值、放入条件上下文的值，失败时返回 ConfigError 使用的说明
+func (m *StructMapper[T]) convertField(field *FieldInfo, valueStr string) (reflect.Value, interface{}, string, error) {
+	dictName, isEnum := field.Options["enum"]
+	if !isEnum {
+		value, err := convertValue(valueStr, field.Type)
+		if err != nil {
+			return reflect.Value{}, nil, fmt.Sprintf("无法将字符串 %q 转换为 %v 类型", valueStr, field.Type), err
+		}
+		return value, value.Interface(), "", nil
+	}
+
+	dict, ok := m.enums.Dict(dictName)
+	if !ok {
+		return reflect.Value{}, nil, fmt.Sprintf("枚举字典 '%s' 不存在", dictName), ErrUnknownEnum
+	}
+	n, err := dict.Parse(valueStr)
+	if err != nil {
+		return reflect.Value{}, nil, err.Error(), err
+	}
+	value, err := convertValue(strconv.FormatInt(n, 10), field.Type)
+	if err != nil {
+		return reflect.Value{}, nil, fmt.Sprintf("枚举值 %s=%d 无法转换为 %v 类型", valueStr, n, field.Type), err
+	}
+	// 条件上下文中使用普通整数，便于 when:element=1 之类的比较
+	return value, n, "", nil
+}
+
+// GenerateEnums 为字典生成 Go 类型和常量
+// 每个字典生成一个 int 类型，常量名为 类型名+Name（Name 为空时使用 Label）
+func GenerateEnums(registry *EnumRegistry, options GenerateOptions) ([]byte, error) {
+	if options.Package == "" {
+		return nil, fmt.Errorf("未指定生成代码的包名")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gameconfig-enum. DO NOT EDIT.\n")
+	if options.Source != "" {
+		fmt.Fprintf(&buf, "// Source: %s\n", options.Source)
+	}
+	fmt.Fprintf(&buf, "\npackage %s\n", options.Package)
+
+	typeNames := make(map[string]string)
+	for _, name := range registry.Names() {
+		dict, _ := registry.Dict(name)
+		typeName := GoIdentifier(name)
+		if other, ok := typeNames[typeName]; ok {
+			return nil, fmt.Errorf("字典 '%s' 与 '%s' 生成的类型名 %s 重复", name, other, typeName)
+		}
+		typeNames[typeName] = name
+
+		values := append([]EnumValue(nil), dict.Values...)
+		sort.SliceStable(values, func(i, j int) bool { return values[i].Value < values[j].Value })
+
+		fmt.Fprintf(&buf, "\n// %s 枚举字典 %s\ntype %s int\n\nconst (\n", typeName, name, typeName)
+		used := make(map[string]string)
+		for _, v := range values {
+			ident := v.Name
+			if ident == "" {
+				ident = v.Label
+			}
+			constName := typeName + GoIdentifier(ident)
+			if other, ok := used[constName]; ok {
+				return nil, fmt.Errorf("字典 '%s' 中 '%s' 与 '%s' 生成的常量名 %s 重复", name, v.Label, other, constName)
+			}
+			used[constName] = v.Label
+
+			comment := v.Label
+			if v.Comment != "" {
+				comment += " " + v.Comment
+			}
+			fmt.Fprintf(&buf, "\t%s %s = %d // %s\n", constName, typeName, v.Value, strings.ReplaceAll(comment, "\n", " "))
+		}
+		buf.WriteString(")\n")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化生成代码失败: %w", err)
+	}
+	return src, nil
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type Element int
+
+type enumItem struct {
+	ID      int     `excel:"id"`
+	Element Element `excel:"element,enum:Element"`
+	Rarity  *int8   `excel:"rarity,enum:Rarity"`
+	Bonus   int     `excel:"bonus,when:element=1"`
+}
+
+// enumRows 字典表
+var enumRows = [][]string{
+	{"enum", "name", "label", "value", "comment"},
+	{"Element", "None", "无", "0", ""},
+	{"Element", "Fire", "火", "1", "火属性"},
+	{"Element", "Water", "水", "2", ""},
+	{"Element", "Wind", "风", "0x4", ""},
+	{"Rarity", "", "普通", "1", ""},
+	{"Rarity", "", "稀有", "3", ""},
+}
+
+func newTestEnums(t *testing.T) *EnumRegistry {
+	t.Helper()
+	enums, err := NewEnums(enumRows, LoadOptions{})
+	if err != nil {
+		t.Fatalf("NewEnums() error = %v", err)
+	}
+	return enums
+}
+
+// TestEnumDict_Parse 测试文字、常量名、数值和位标志组合
+func TestEnumDict_Parse(t *testing.T) {
+	dict, ok := newTestEnums(t).Dict("Element")
+	if !ok {
+		t.Fatal("Dict(Element) not found")
+	}
+
+	tests := []struct {
+		text    string
+		want    int64
+		wantErr bool
+	}{
+		{"火", 1, false},
+		{"Water", 2, false},
+		{"4", 4, false},
+		{"火|水", 3, false},
+		{" 火 | 风 ", 5, false},
+		{"雷", 0, true},
+		{"8", 0, true},
+		{"火|雷", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := dict.Parse(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, ErrUnknownEnum) {
+				t.Errorf("Parse(%q) error = %v, want ErrUnknownEnum", tt.text, err)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	if label, ok := dict.Label(3); !ok || label != "火|水" {
+		t.Errorf("Label(3) = %q, %v", label, ok)
+	}
+
+	// 稀有（3）不是单个二进制位，不能组合
+	rarity, _ := newTestEnums(t).Dict("Rarity")
+	if _, err := rarity.Parse("普通|稀有"); !errors.Is(err, ErrUnknownEnum) {
+		t.Errorf("Parse(普通|稀有) error = %v, want ErrUnknownEnum", err)
+	}
+}
+
+// TestNewEnums_Errors 测试字典表格式错误
+func TestNewEnums_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]string
+	}{
+		{"缺少 value 列", [][]string{{"enum", "label"}, {"Element", "火"}}},
+		{"值不是整数", [][]string{{"enum", "label", "value"}, {"Element", "火", "abc"}}},
+		{"文字重复", [][]string{{"enum", "label", "value"}, {"Element", "火", "1"}, {"Element", "火", "2"}}},
+		{"值重复", [][]string{{"enum", "label", "value"}, {"Element", "火", "1"}, {"Element", "水", "1"}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewEnums(tt.rows, LoadOptions{}); err == nil {
+			t.Errorf("%s: NewEnums() should fail", tt.name)
+		}
+	}
+}
+
+// TestLoader_Enum 测试 enum 字段映射和条件上下文
+func TestLoader_Enum(t *testing.T) {
+	loader := NewLoader[enumItem]("", "item", LoadOptions{
+		Mode:  ModeMemory,
+		Enums: newTestEnums(t),
+		MockData: [][]string{
+			{"id", "element", "rarity", "bonus"},
+			{"1", "火", "稀有", "10"},
+			{"2", "火|水", "", "20"},
+		},
+	})
+	items, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if items[0].Element != 1 || items[0].Rarity == nil || *items[0].Rarity != 3 || items[0].Bonus != 10 {
+		t.Errorf("items[0] = %+v", items[0])
+	}
+	if items[1].Element != 3 || items[1].Rarity != nil || items[1].Bonus != 0 {
+		t.Errorf("items[1] = %+v", items[1])
+	}
+
+	loader = NewLoader[enumItem]("", "item", LoadOptions{
+		Mode:     ModeMemory,
+		Enums:    newTestEnums(t),
+		MockData: [][]string{{"id", "element"}, {"1", "雷"}},
+	})
+	_, err = loader.Load()
+	var cfgErr *ConfigError
+	if !errors.Is(err, ErrUnknownEnum) || !errors.As(err, &cfgErr) || cfgErr.Row != 1 {
+		t.Errorf("Load() error = %v, want ErrUnknownEnum at row 1", err)
+	}
+}
+
+// TestValidate_Enum 测试缺少字典和非整数字段的静态检查
+func TestValidate_Enum(t *testing.T) {
+	type badItem struct {
+		Element Element `excel:"element,enum:Element"`
+		Name    string  `excel:"name,enum:Rarity"`
+	}
+
+	err := NewLoader[badItem]("", "item", LoadOptions{Mode: ModeMemory}).Validate([]string{"element", "name"})
+	var exprErr *ExprValidationError
+	if !errors.As(err, &exprErr) || len(exprErr.Issues) != 3 {
+		t.Fatalf("Validate() error = %v, want 3 issues", err)
+	}
+
+	err = NewLoader[badItem]("", "item", LoadOptions{Mode: ModeMemory, Enums: newTestEnums(t)}).Validate([]string{"element", "name"})
+	if !errors.As(err, &exprErr) || len(exprErr.Issues) != 1 || !strings.Contains(exprErr.Issues[0].Msg, "整数") {
+		t.Errorf("Validate() error = %v, want non-integer issue", err)
+	}
+}
+
+// TestGenerateEnums 测试生成常量
+func TestGenerateEnums(t *testing.T) {
+	code, err := GenerateEnums(newTestEnums(t), GenerateOptions{Package: "gamedata", Source: "枚举.xlsx [字典]"})
+	if err != nil {
+		t.Fatalf("GenerateEnums() error = %v", err)
+	}
+	src := string(code)
+	for _, want := range []string{
+		"// Code generated by gameconfig-enum. DO NOT EDIT.",
+		"package gamedata",
+		"type Element int",
+		"ElementFire  Element = 1 // 火 火属性",
+		"ElementWind  Element = 4 // 风",
+		"type Rarity int",
+		"Rarity = 3 // 稀有",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code missing %q:\n%s", want, src)
+		}
+	}
+}
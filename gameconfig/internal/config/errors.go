@@ -26,6 +26,24 @@ var (
 
 	// ErrMigrationFailed 迁移失败
 	ErrMigrationFailed = fmt.Errorf("迁移失败")
+
+	// ErrSchemaMismatch 二进制快照与结构体定义不一致
+	ErrSchemaMismatch = fmt.Errorf("Schema 不一致")
+
+	// ErrInvalidCondition 条件表达式或计算表达式无效
+	ErrInvalidCondition = fmt.Errorf("表达式无效")
+
+	// ErrDuplicateKey 主键重复
+	ErrDuplicateKey = fmt.Errorf("主键重复")
+
+	// ErrRowNotFound 按主键找不到行
+	ErrRowNotFound = fmt.Errorf("行不存在")
+
+	// ErrUnknownEnum 枚举字典中不存在的值
+	ErrUnknownEnum = fmt.Errorf("未知的枚举值")
+
+	// ErrStaleFormula 公式缓存值与计算结果不一致或无法计算
+	ErrStaleFormula = fmt.Errorf("公式缓存值已过期")
 )
 
 // ConfigError 配置错误（包含位置信息）
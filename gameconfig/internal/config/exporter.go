@@ -15,6 +15,8 @@ type ExcelExporter struct {
 	excelPath string
 	outputDir string
 	sheets    []string // 要导出的 Sheet 列表，为空则导出全部
+	formula   FormulaPolicy
+	onIssue   func(FormulaIssue)
 }
 
 // NewExcelExporter 创建 Excel 导出器
@@ -31,6 +33,12 @@ func (e *ExcelExporter) SetSheets(sheets []string) {
 	e.sheets = sheets
 }
 
+// SetFormulaPolicy 设置公式单元格的取值策略，onIssue 为空时公式问题输出到日志
+func (e *ExcelExporter) SetFormulaPolicy(policy FormulaPolicy, onIssue func(FormulaIssue)) {
+	e.formula = policy
+	e.onIssue = onIssue
+}
+
 // Export 执行导出
 // 将 Excel 的每个 Sheet 导出为独立的 CSV 文件
 // 文件组织方式: {outputDir}/{Excel名}/{Sheet名}.csv
@@ -79,7 +87,7 @@ func (e *ExcelExporter) createOutputDir() error {
 // exportSheet 导出单个 Sheet
 func (e *ExcelExporter) exportSheet(file *excelize.File, sheetName string) error {
 	// 读取 Sheet 数据
-	rows, err := file.GetRows(sheetName)
+	rows, err := e.readSheet(file, sheetName)
 	if err != nil {
 		return fmt.Errorf("读取 Sheet 数据失败: %w", err)
 	}
@@ -117,6 +125,21 @@ func (e *ExcelExporter) exportSheet(file *excelize.File, sheetName string) error
 	return nil
 }
 
+// readSheet 按公式策略读取 Sheet 数据
+func (e *ExcelExporter) readSheet(file *excelize.File, sheetName string) ([][]string, error) {
+	if e.formula == FormulaCached {
+		return file.GetRows(sheetName)
+	}
+	reader := &ExcelReader{filePath: e.excelPath, file: file}
+	return readSource(SourceFunc(func() (RowIterator, error) {
+		it, err := reader.IterSheet(sheetName)
+		if err != nil {
+			return nil, err
+		}
+		return reader.formulaIterator(it, sheetName, e.formula, e.onIssue), nil
+	}))
+}
+
 // filterMetadataRows 过滤掉元数据行（版本行、说明行等）
 func (e *ExcelExporter) filterMetadataRows(rows [][]string) [][]string {
 	if len(rows) == 0 {
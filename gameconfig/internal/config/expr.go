@@ -0,0 +1,492 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Valuer 可以计算出值的表达式节点
+// 比较、in、between 和函数参数通过 EvalValue 取得操作数的值
+type Valuer interface {
+	EvalValue(ctx *EvalContext) (interface{}, error)
+}
+
+// ValueKind 表达式值的类型
+type ValueKind int
+
+const (
+	// KindAny 任意类型
+	KindAny ValueKind = iota
+	// KindNumber 数值（计算时统一为 float64）
+	KindNumber
+	// KindString 字符串
+	KindString
+	// KindBool 布尔值
+	KindBool
+)
+
+// String 返回类型名称
+func (k ValueKind) String() string {
+	switch k {
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindBool:
+		return "bool"
+	default:
+		return "any"
+	}
+}
+
+// evalValue 计算节点的值，列表和范围字面量返回其元素
+func evalValue(ctx *EvalContext, cond Condition) (interface{}, error) {
+	if v, ok := cond.(Valuer); ok {
+		return v.EvalValue(ctx)
+	}
+	return getLiteralValue(cond), nil
+}
+
+// ArithOp 算术操作节点（+ - * / %）
+// 两个字符串相加时拼接，其余情况按数值计算
+type ArithOp struct {
+	Left     Condition
+	Right    Condition
+	Operator string
+}
+
+func (a *ArithOp) EvalValue(ctx *EvalContext) (interface{}, error) {
+	left, err := evalValue(ctx, a.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := evalValue(ctx, a.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Operator == "+" {
+		ls, lok := left.(string)
+		rs, rok := right.(string)
+		if lok && rok {
+			return ls + rs, nil
+		}
+	}
+
+	l, err := toFloat64(left)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 操作符 %s 左侧值 %v 不是数值", ErrTypeMismatch, a.Operator, left)
+	}
+	r, err := toFloat64(right)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 操作符 %s 右侧值 %v 不是数值", ErrTypeMismatch, a.Operator, right)
+	}
+
+	switch a.Operator {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("除数为 0: %s", a.String())
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("除数为 0: %s", a.String())
+		}
+		return math.Mod(l, r), nil
+	default:
+		return nil, fmt.Errorf("不支持的算术操作符: %s", a.Operator)
+	}
+}
+
+func (a *ArithOp) Evaluate(ctx *EvalContext) (bool, error) {
+	val, err := a.EvalValue(ctx)
+	if err != nil {
+		return false, err
+	}
+	return toBool(val), nil
+}
+
+func (a *ArithOp) String() string {
+	return fmt.Sprintf("(%s %s %s)", a.Left.String(), a.Operator, a.Right.String())
+}
+
+func (a *ArithOp) DependentFields() []string {
+	return append(a.Left.DependentFields(), a.Right.DependentFields()...)
+}
+
+// FuncCall 函数调用节点，如 startsWith(tag, "boss")
+type FuncCall struct {
+	Name string
+	Args []Condition
+}
+
+func (f *FuncCall) EvalValue(ctx *EvalContext) (interface{}, error) {
+	fn, ok := ctx.funcs().Func(f.Name)
+	if !ok {
+		return nil, fmt.Errorf("未知函数: %s", f.Name)
+	}
+
+	args := make([]interface{}, len(f.Args))
+	for i, arg := range f.Args {
+		val, err := evalValue(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = val
+	}
+	return fn.call(f.Name, args)
+}
+
+func (f *FuncCall) Evaluate(ctx *EvalContext) (bool, error) {
+	val, err := f.EvalValue(ctx)
+	if err != nil {
+		return false, err
+	}
+	return toBool(val), nil
+}
+
+func (f *FuncCall) String() string {
+	args := make([]string, len(f.Args))
+	for i, arg := range f.Args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("%s(%s)", f.Name, strings.Join(args, ", "))
+}
+
+func (f *FuncCall) DependentFields() []string {
+	fields := []string{}
+	for _, arg := range f.Args {
+		fields = append(fields, arg.DependentFields()...)
+	}
+	return fields
+}
+
+// IndexExpr 跨表查找节点，如 Skill[skill_id]
+// 值为找到的行，找不到时为 nil
+type IndexExpr struct {
+	Table string
+	Key   Condition
+}
+
+func (x *IndexExpr) EvalValue(ctx *EvalContext) (interface{}, error) {
+	table, ok := ctx.funcs().Table(x.Table)
+	if !ok {
+		return nil, fmt.Errorf("表 '%s' 未注册", x.Table)
+	}
+
+	key, err := evalValue(ctx, x.Key)
+	if err != nil {
+		return nil, err
+	}
+	row, found := table.Lookup(key)
+	if !found {
+		return nil, nil
+	}
+	return row, nil
+}
+
+func (x *IndexExpr) Evaluate(ctx *EvalContext) (bool, error) {
+	val, err := x.EvalValue(ctx)
+	if err != nil {
+		return false, err
+	}
+	return val != nil, nil
+}
+
+func (x *IndexExpr) String() string {
+	return fmt.Sprintf("%s[%s]", x.Table, x.Key.String())
+}
+
+func (x *IndexExpr) DependentFields() []string {
+	return x.Key.DependentFields()
+}
+
+// Func 表达式函数
+type Func struct {
+	// Params 参数类型，调用时按类型检查和转换（数值统一转换为 float64）
+	Params []ValueKind
+	// Variadic 最后一个参数可以重复
+	Variadic bool
+	// Result 返回值类型
+	Result ValueKind
+	// Call 函数实现
+	Call func(args []interface{}) (interface{}, error)
+}
+
+// check 检查函数定义是否有效
+func (f *Func) check(name string) error {
+	if f == nil || f.Call == nil {
+		return fmt.Errorf("函数 %s 缺少实现", name)
+	}
+	if f.Variadic && len(f.Params) == 0 {
+		return fmt.Errorf("函数 %s 声明为 Variadic 但没有参数", name)
+	}
+	return nil
+}
+
+// call 检查参数后调用函数
+func (f *Func) call(name string, args []interface{}) (interface{}, error) {
+	if err := f.check(name); err != nil {
+		return nil, err
+	}
+	if f.Variadic {
+		if len(args) < len(f.Params) {
+			return nil, fmt.Errorf("函数 %s 至少需要 %d 个参数，实际为 %d 个", name, len(f.Params), len(args))
+		}
+	} else if len(args) != len(f.Params) {
+		return nil, fmt.Errorf("函数 %s 需要 %d 个参数，实际为 %d 个", name, len(f.Params), len(args))
+	}
+
+	for i, arg := range args {
+		kind := f.Params[len(f.Params)-1]
+		if i < len(f.Params) {
+			kind = f.Params[i]
+		}
+		val, err := coerceValue(arg, kind)
+		if err != nil {
+			return nil, fmt.Errorf("函数 %s 第 %d 个参数: %w", name, i+1, err)
+		}
+		args[i] = val
+	}
+	return f.Call(args)
+}
+
+// coerceValue 按类型检查并转换值
+func coerceValue(val interface{}, kind ValueKind) (interface{}, error) {
+	switch kind {
+	case KindNumber:
+		f, err := toFloat64(val)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 需要 number，实际为 %v", ErrTypeMismatch, val)
+		}
+		return f, nil
+	case KindString:
+		if s, ok := val.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("%w: 需要 string，实际为 %v", ErrTypeMismatch, val)
+	case KindBool:
+		if b, ok := val.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("%w: 需要 bool，实际为 %v", ErrTypeMismatch, val)
+	default:
+		return val, nil
+	}
+}
+
+// TableLookup 按主键查找其他表的行（用于 Table[key] 表达式）
+type TableLookup interface {
+	Lookup(key interface{}) (interface{}, bool)
+}
+
+// LookupFunc 函数形式的 TableLookup
+type LookupFunc func(key interface{}) (interface{}, bool)
+
+// Lookup 实现 TableLookup
+func (f LookupFunc) Lookup(key interface{}) (interface{}, bool) {
+	return f(key)
+}
+
+// IndexRows 按 excel tag 为 key 的字段为已加载的数据建立索引
+// 主键按字符串形式匹配，数值 1 与字符串 "1" 视为同一主键
+func IndexRows[T any](rows []T, key string) (TableLookup, error) {
+	mapper := NewStructMapper[T]()
+	field, ok := mapper.fields[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: 主键字段 '%s' 不存在", ErrInvalidFormat, key)
+	}
+
+	index := make(map[string]interface{}, len(rows))
+	for _, row := range rows {
+		v := reflect.ValueOf(row)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+		index[formatValue(v.Field(field.Index).Interface())] = row
+	}
+
+	return LookupFunc(func(k interface{}) (interface{}, bool) {
+		row, ok := index[formatValue(k)]
+		return row, ok
+	}), nil
+}
+
+// formatValue 将表达式的值格式化为字符串，整数值的浮点数不带小数部分
+func formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FuncRegistry 表达式函数和表的注册表
+// 查找时先查自身，再查父注册表
+type FuncRegistry struct {
+	parent *FuncRegistry
+	mu     sync.RWMutex
+	funcs  map[string]*Func
+	tables map[string]TableLookup
+}
+
+// DefaultFuncs 默认注册表，包含内置函数
+// 注册到这里的函数对所有 Loader 可见
+var DefaultFuncs = newBuiltinRegistry()
+
+// NewFuncRegistry 创建注册表，未找到的函数从 DefaultFuncs 查找
+func NewFuncRegistry() *FuncRegistry {
+	return &FuncRegistry{
+		parent: DefaultFuncs,
+		funcs:  make(map[string]*Func),
+		tables: make(map[string]TableLookup),
+	}
+}
+
+// Register 注册函数，同名函数覆盖父注册表中的定义
+// 函数定义无效 (缺少 Call，或 Variadic 但没有参数) 时 panic
+func (r *FuncRegistry) Register(name string, fn *Func) *FuncRegistry {
+	if err := fn.check(name); err != nil {
+		panic("config: " + err.Error())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.funcs[name] = fn
+	return r
+}
+
+// RegisterTable 注册可在表达式中通过 name[key] 查找的表
+func (r *FuncRegistry) RegisterTable(name string, table TableLookup) *FuncRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tables[name] = table
+	return r
+}
+
+// Func 查找函数
+func (r *FuncRegistry) Func(name string) (*Func, bool) {
+	for reg := r; reg != nil; reg = reg.parent {
+		reg.mu.RLock()
+		fn, ok := reg.funcs[name]
+		reg.mu.RUnlock()
+		if ok {
+			return fn, true
+		}
+	}
+	return nil, false
+}
+
+// Table 查找表
+func (r *FuncRegistry) Table(name string) (TableLookup, bool) {
+	for reg := r; reg != nil; reg = reg.parent {
+		reg.mu.RLock()
+		table, ok := reg.tables[name]
+		reg.mu.RUnlock()
+		if ok {
+			return table, true
+		}
+	}
+	return nil, false
+}
+
+// newBuiltinRegistry 创建包含内置函数的注册表
+func newBuiltinRegistry() *FuncRegistry {
+	r := &FuncRegistry{
+		funcs:  make(map[string]*Func),
+		tables: make(map[string]TableLookup),
+	}
+
+	// 字符串函数
+	stringPredicate := func(fn func(s, sub string) bool) *Func {
+		return &Func{
+			Params: []ValueKind{KindString, KindString},
+			Result: KindBool,
+			Call: func(args []interface{}) (interface{}, error) {
+				return fn(args[0].(string), args[1].(string)), nil
+			},
+		}
+	}
+	stringMap := func(fn func(s string) string) *Func {
+		return &Func{
+			Params: []ValueKind{KindString},
+			Result: KindString,
+			Call: func(args []interface{}) (interface{}, error) {
+				return fn(args[0].(string)), nil
+			},
+		}
+	}
+	r.Register("startsWith", stringPredicate(strings.HasPrefix))
+	r.Register("endsWith", stringPredicate(strings.HasSuffix))
+	r.Register("contains", stringPredicate(strings.Contains))
+	r.Register("lower", stringMap(strings.ToLower))
+	r.Register("upper", stringMap(strings.ToUpper))
+	r.Register("trim", stringMap(strings.TrimSpace))
+	r.Register("len", &Func{
+		Params: []ValueKind{KindString},
+		Result: KindNumber,
+		Call: func(args []interface{}) (interface{}, error) {
+			return float64(utf8.RuneCountInString(args[0].(string))), nil
+		},
+	})
+
+	// 数值函数
+	numberMap := func(fn func(float64) float64) *Func {
+		return &Func{
+			Params: []ValueKind{KindNumber},
+			Result: KindNumber,
+			Call: func(args []interface{}) (interface{}, error) {
+				return fn(args[0].(float64)), nil
+			},
+		}
+	}
+	numberReduce := func(fn func(a, b float64) float64) *Func {
+		return &Func{
+			Params:   []ValueKind{KindNumber},
+			Variadic: true,
+			Result:   KindNumber,
+			Call: func(args []interface{}) (interface{}, error) {
+				result := args[0].(float64)
+				for _, arg := range args[1:] {
+					result = fn(result, arg.(float64))
+				}
+				return result, nil
+			},
+		}
+	}
+	r.Register("abs", numberMap(math.Abs))
+	r.Register("floor", numberMap(math.Floor))
+	r.Register("ceil", numberMap(math.Ceil))
+	r.Register("round", numberMap(math.Round))
+	r.Register("min", numberReduce(math.Min))
+	r.Register("max", numberReduce(math.Max))
+
+	// exists(Table[key]) 判断跨表引用是否存在
+	r.Register("exists", &Func{
+		Params: []ValueKind{KindAny},
+		Result: KindBool,
+		Call: func(args []interface{}) (interface{}, error) {
+			return args[0] != nil, nil
+		},
+	})
+
+	return r
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestExprArithmetic 测试算术表达式
+func TestExprArithmetic(t *testing.T) {
+	tests := []struct {
+		expr   string
+		values map[string]interface{}
+		want   bool
+	}{
+		{"level*2 > cap", map[string]interface{}{"level": 6, "cap": 10}, true},
+		{"level*2 > cap", map[string]interface{}{"level": 5, "cap": 10}, false},
+		{"level-1 = 4", map[string]interface{}{"level": 5}, true},
+		{"level - 1 = 4", map[string]interface{}{"level": 5}, true},
+		{"1 + 2 * 3 = 7", nil, true},
+		{"(1 + 2) * 3 = 9", nil, true},
+		{"10 % 3 = 1", nil, true},
+		{"-level < 0", map[string]interface{}{"level": 5}, true},
+		{"level > -5", map[string]interface{}{"level": -1}, true},
+		{"atk / 2 between 5,10", map[string]interface{}{"atk": 12}, true},
+		{"level*2 in [4,6]", map[string]interface{}{"level": 3}, true},
+		{"prefix + name = \"boss_a\"", map[string]interface{}{"prefix": "boss_", "name": "a"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cond, err := ParseCondition(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCondition() error = %v", err)
+			}
+			ctx := NewEvalContext()
+			for k, v := range tt.values {
+				ctx.SetValue(k, v)
+			}
+			got, err := cond.Evaluate(ctx)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v (%s)", got, tt.want, cond.String())
+			}
+		})
+	}
+}
+
+// TestExprFunctions 测试内置函数
+func TestExprFunctions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`startsWith(tag, "boss")`, true},
+		{`endsWith(tag, "dragon")`, true},
+		{`contains(tag, "_")`, true},
+		{`!startsWith(tag, "npc")`, true},
+		{`startsWith(tag, "boss") = true`, true},
+		{`len(tag) = 11`, true},
+		{`upper(lower(tag)) = "BOSS_DRAGON"`, true},
+		{`max(level, 10, 3) = 10`, true},
+		{`min(level, 10) = 5`, true},
+		{`abs(-level) = 5`, true},
+		{`round(level / 2) = 3`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cond, err := ParseCondition(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCondition() error = %v", err)
+			}
+			ctx := NewEvalContext()
+			ctx.SetValue("tag", "boss_dragon")
+			ctx.SetValue("level", 5)
+			got, err := cond.Evaluate(ctx)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExprErrors 测试类型错误和未知函数
+func TestExprErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		typeErr bool
+	}{
+		{"字符串参与乘法", `tag * 2 > 1`, true},
+		{"参数类型不匹配", `startsWith(level, "1")`, true},
+		{"参数个数不匹配", `startsWith(tag)`, false},
+		{"未知函数", `unknown(tag)`, false},
+		{"除数为 0", `level / 0 > 1`, false},
+		{"表未注册", `exists(Skill[level])`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := ParseCondition(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCondition() error = %v", err)
+			}
+			ctx := NewEvalContext()
+			ctx.SetValue("tag", "boss")
+			ctx.SetValue("level", 5)
+			_, err = cond.Evaluate(ctx)
+			if err == nil {
+				t.Fatal("Evaluate() should fail")
+			}
+			if tt.typeErr && !errors.Is(err, ErrTypeMismatch) {
+				t.Errorf("error = %v, want ErrTypeMismatch", err)
+			}
+		})
+	}
+
+	for _, expr := range []string{`startsWith(tag, "a"`, `Skill[id`, `level * `} {
+		if _, err := ParseCondition(expr); err == nil {
+			t.Errorf("ParseCondition(%q) should fail", expr)
+		}
+	}
+}
+
+// TestExprDependentFields 测试函数和跨表查找的依赖字段
+func TestExprDependentFields(t *testing.T) {
+	cond, err := ParseCondition(`startsWith(tag, "boss") & exists(Skill[skill_id]) & level*2 > cap`)
+	if err != nil {
+		t.Fatalf("ParseCondition() error = %v", err)
+	}
+	got := strings.Join(cond.DependentFields(), ",")
+	if got != "tag,skill_id,level,cap" {
+		t.Errorf("DependentFields() = %s", got)
+	}
+}
+
+// TestExprRegistry 测试自定义函数和跨表查找
+func TestExprRegistry(t *testing.T) {
+	type skill struct {
+		ID   int    `excel:"id"`
+		Name string `excel:"name"`
+	}
+	type monster struct {
+		ID         int    `excel:"id"`
+		Tag        string `excel:"tag"`
+		SkillID    int    `excel:"skill_id"`
+		SkillLevel int    `excel:"skill_level,when:exists(Skill[skill_id]) & isBoss(tag)"`
+	}
+
+	skills, err := IndexRows([]skill{{ID: 101, Name: "火球"}}, "id")
+	if err != nil {
+		t.Fatalf("IndexRows() error = %v", err)
+	}
+	funcs := NewFuncRegistry().
+		RegisterTable("Skill", skills).
+		Register("isBoss", &Func{
+			Params: []ValueKind{KindString},
+			Result: KindBool,
+			Call: func(args []interface{}) (interface{}, error) {
+				return strings.HasPrefix(args[0].(string), "boss"), nil
+			},
+		})
+
+	loader := NewLoader[monster]("", "Sheet1", LoadOptions{
+		Mode:  ModeMemory,
+		Funcs: funcs,
+		MockData: [][]string{
+			{"id", "tag", "skill_id", "skill_level"},
+			{"1", "boss_a", "101", "3"},
+			{"2", "boss_b", "999", "3"},
+			{"3", "npc", "101", "3"},
+		},
+	})
+
+	monsters, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []int{3, 0, 0}
+	for i, m := range monsters {
+		if m.SkillLevel != want[i] {
+			t.Errorf("monsters[%d].SkillLevel = %d, want %d", i, m.SkillLevel, want[i])
+		}
+	}
+
+	// 自定义函数不影响默认注册表
+	if _, ok := DefaultFuncs.Func("isBoss"); ok {
+		t.Error("isBoss should not be registered in DefaultFuncs")
+	}
+
+	if _, err := IndexRows([]skill{}, "missing"); err == nil {
+		t.Error("IndexRows() should fail for unknown key field")
+	}
+}
+
+// TestFuncRegistry_InvalidFunc 测试注册无效函数时 panic，调用已损坏的函数返回错误
+func TestFuncRegistry_InvalidFunc(t *testing.T) {
+	bad := &Func{
+		Variadic: true,
+		Result:   KindNumber,
+		Call: func(args []interface{}) (interface{}, error) {
+			return float64(len(args)), nil
+		},
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Register() should panic for variadic func without params")
+			}
+		}()
+		NewFuncRegistry().Register("count", bad)
+	}()
+
+	if _, err := bad.call("count", []interface{}{1, 2}); err == nil {
+		t.Error("call() should fail for variadic func without params")
+	}
+}
+
+// TestComputedField 测试 computed 字段
+func TestComputedField(t *testing.T) {
+	type item struct {
+		Base   int     `excel:"base"`
+		Bonus  int     `excel:"bonus,default:0"`
+		Total  int     `excel:"total,computed:base+bonus"`
+		Power  float64 `excel:"power,computed:total*1.5"`
+		IsHigh bool    `excel:"is_high,computed:total >= 100"`
+		Label  string  `excel:"label,computed:\"lv\" + name,when:base > 50"`
+		Name   string  `excel:"name"`
+	}
+
+	loader := NewLoader[item]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		MockData: [][]string{
+			{"base", "bonus", "name", "total"},
+			{"80", "30", "a", "999"},
+			{"10", "", "b", ""},
+		},
+	})
+
+	items, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if items[0].Total != 110 || items[0].Power != 165 || !items[0].IsHigh || items[0].Label != "lva" {
+		t.Errorf("items[0] = %+v", items[0])
+	}
+	if items[1].Total != 10 || items[1].IsHigh || items[1].Label != "" {
+		t.Errorf("items[1] = %+v", items[1])
+	}
+}
+
+// TestComputedFieldErrors 测试 computed 字段错误
+func TestComputedFieldErrors(t *testing.T) {
+	t.Run("结果不是整数", func(t *testing.T) {
+		type item struct {
+			Base int `excel:"base"`
+			Half int `excel:"half,computed:base/2"`
+		}
+		mapper := NewStructMapper[item]()
+		if _, err := mapper.MapRow([]string{"base"}, []string{"3"}); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("error = %v, want ErrTypeMismatch", err)
+		}
+	})
+
+	t.Run("循环依赖", func(t *testing.T) {
+		type item struct {
+			A int `excel:"a,computed:b+1"`
+			B int `excel:"b,computed:a+1"`
+		}
+		mapper := NewStructMapper[item]()
+		_, err := mapper.MapRow([]string{}, []string{})
+		if err == nil || !strings.Contains(err.Error(), "循环依赖") {
+			t.Errorf("error = %v, want cyclic dependency", err)
+		}
+	})
+}
+
+// TestParseFieldTag_ExprOptions 测试表达式选项中的逗号
+func TestParseFieldTag_ExprOptions(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want map[string]string
+	}{
+		{`a,when:x=1,default:5`, map[string]string{"when": "x=1", "default": "5"}},
+		{`a,when:x=1,default:5,required`, map[string]string{"when": "x=1", "default": "5", "required": ""}},
+		{`a,required,when:x in [1,2]`, map[string]string{"required": "", "when": "x in [1,2]"}},
+		{`a,when:lv between 1,10,required`, map[string]string{"when": "lv between 1,10", "required": ""}},
+		{`a,when:lv between 1,max_level,required`, map[string]string{"when": "lv between 1,max_level", "required": ""}},
+		{`a,when:lv between min_level,required_level`, map[string]string{"when": "lv between min_level,required_level"}},
+		{`a,when:startsWith(t,"b,c"),computed:max(x,y)`, map[string]string{"when": `startsWith(t,"b,c")`, "computed": "max(x,y)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			_, options := parseFieldTag(tt.tag)
+			if len(options) != len(tt.want) {
+				t.Errorf("options = %v, want %v", options, tt.want)
+			}
+			for k, v := range tt.want {
+				if options[k] != v {
+					t.Errorf("options[%q] = %q, want %q", k, options[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// FormulaPolicy 公式单元格的取值策略
+type FormulaPolicy string
+
+const (
+	// FormulaCached 使用文件中保存的缓存值（默认）
+	FormulaCached FormulaPolicy = ""
+	// FormulaEvaluate 用 excelize 计算引擎重新计算，使用计算结果，缓存值过期时报告问题
+	FormulaEvaluate FormulaPolicy = "evaluate"
+	// FormulaStrict 重新计算，缓存值过期或无法计算时返回 ErrStaleFormula
+	FormulaStrict FormulaPolicy = "strict"
+)
+
+// FormulaIssueKind 公式问题类型
+type FormulaIssueKind string
+
+const (
+	// FormulaStale 缓存值与计算结果不一致（保存文件的工具没有重新计算）
+	FormulaStale FormulaIssueKind = "stale"
+	// FormulaVolatile 使用了易变函数，每次计算结果可能不同
+	FormulaVolatile FormulaIssueKind = "volatile"
+	// FormulaCalcFailed 计算引擎无法计算（不支持的函数等）
+	FormulaCalcFailed FormulaIssueKind = "calc_failed"
+)
+
+// FormulaIssue 公式单元格的问题
+type FormulaIssue struct {
+	Kind    FormulaIssueKind
+	Sheet   string
+	Cell    string // 例如 C6
+	Formula string
+	Cached  string // 文件中保存的值
+	Value   string // 计算结果
+	Msg     string
+}
+
+func (i FormulaIssue) String() string {
+	return fmt.Sprintf("Sheet '%s' %s =%s: %s", i.Sheet, i.Cell, i.Formula, i.Msg)
+}
+
+// volatileFuncs 每次计算结果可能不同的函数
+var volatileFuncs = regexp.MustCompile(`(?i)\b(RAND|RANDBETWEEN|RANDARRAY|NOW|TODAY|OFFSET|INDIRECT|INFO|CELL)\s*\(`)
+
+// formulaStrings 公式中的字符串字面量（检查易变函数前去掉）
+var formulaStrings = regexp.MustCompile(`"(?:[^"]|"")*"`)
+
+// VolatileFuncs 返回公式中使用的易变函数（大写、去重）
+func VolatileFuncs(formula string) []string {
+	var funcs []string
+	seen := make(map[string]bool)
+	for _, m := range volatileFuncs.FindAllStringSubmatch(formulaStrings.ReplaceAllString(formula, `""`), -1) {
+		name := strings.ToUpper(m[1])
+		if !seen[name] {
+			seen[name] = true
+			funcs = append(funcs, name)
+		}
+	}
+	return funcs
+}
+
+// EvalCell 计算公式单元格
+// 返回公式（不是公式单元格时为空）、计算结果和缓存值，值的格式与 GetCellValue(raw=false) 一致
+func (r *ExcelReader) EvalCell(sheetName, cell string) (formula, value, cached string, err error) {
+	formula, err = r.file.GetCellFormula(sheetName, cell)
+	if err != nil {
+		return "", "", "", fmt.Errorf("读取公式失败: %w", err)
+	}
+	cached, err = r.GetCellValue(sheetName, cell, false)
+	if err != nil || formula == "" {
+		return formula, cached, cached, err
+	}
+	value, err = r.file.CalcCellValue(sheetName, cell)
+	if err != nil {
+		return formula, "", cached, fmt.Errorf("计算公式失败: %w", err)
+	}
+	return formula, value, cached, nil
+}
+
+// CheckFormulas 检查 Sheet 中所有公式单元格：缓存值是否过期、是否使用易变函数、能否计算
+func (r *ExcelReader) CheckFormulas(sheetName string) ([]FormulaIssue, error) {
+	it, err := r.IterSheet(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	checker := r.formulaIterator(it, sheetName, FormulaEvaluate, nil)
+	defer checker.Close()
+
+	var issues []FormulaIssue
+	checker.onIssue = func(issue FormulaIssue) { issues = append(issues, issue) }
+	for checker.Next() {
+	}
+	return issues, checker.Err()
+}
+
+// formulaRowIterator 按策略替换公式单元格的值
+// 需要读取公式，整张 Sheet 会被读入内存
+type formulaRowIterator struct {
+	RowIterator
+	reader  *ExcelReader
+	sheet   string
+	policy  FormulaPolicy
+	width   int // Sheet 的列数（没有缓存值的公式单元格不在行中）
+	rowNum  int
+	row     []string
+	onIssue func(FormulaIssue)
+	err     error
+}
+
+// formulaIterator 包装 Sheet 迭代器，onIssue 为空时用 log.Printf 输出
+func (r *ExcelReader) formulaIterator(it RowIterator, sheetName string, policy FormulaPolicy, onIssue func(FormulaIssue)) *formulaRowIterator {
+	if onIssue == nil {
+		onIssue = func(issue FormulaIssue) {
+			log.Printf("配置公式警告: %s", issue)
+		}
+	}
+	width := 0
+	if dim, err := r.file.GetSheetDimension(sheetName); err == nil {
+		if _, end, ok := strings.Cut(dim, ":"); ok {
+			width, _, _ = excelize.CellNameToCoordinates(end)
+		}
+	}
+	return &formulaRowIterator{
+		RowIterator: it,
+		reader:      r,
+		sheet:       sheetName,
+		policy:      policy,
+		width:       width,
+		onIssue:     onIssue,
+	}
+}
+
+func (it *formulaRowIterator) Next() bool {
+	if it.err != nil || !it.RowIterator.Next() {
+		return false
+	}
+	it.rowNum++
+	row := it.RowIterator.Row()
+	width := max(it.width, len(row))
+
+	var out []string
+	for col := 1; col <= width; col++ {
+		cell, _ := excelize.CoordinatesToCellName(col, it.rowNum)
+		value, ok, err := it.eval(cell)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = append(make([]string, 0, width), row...)
+		}
+		for len(out) < col {
+			out = append(out, "")
+		}
+		out[col-1] = value
+	}
+
+	if out == nil {
+		it.row = row
+		return true
+	}
+	// 与 GetRows 一致，去掉末尾的空单元格
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	it.row = out
+	return true
+}
+
+// eval 计算单元格，不是公式单元格时 ok 为 false
+func (it *formulaRowIterator) eval(cell string) (string, bool, error) {
+	formula, value, cached, err := it.reader.EvalCell(it.sheet, cell)
+	if formula == "" {
+		return "", false, nil
+	}
+	issue := FormulaIssue{Sheet: it.sheet, Cell: cell, Formula: formula, Cached: cached, Value: value}
+
+	// 易变函数的计算结果每次不同，使用缓存值，不检查是否过期
+	if funcs := VolatileFuncs(formula); len(funcs) > 0 {
+		issue.Kind = FormulaVolatile
+		issue.Msg = fmt.Sprintf("使用了易变函数 %s，每次计算结果可能不同，使用缓存值 '%s'", strings.Join(funcs, "、"), cached)
+		it.onIssue(issue)
+		return cached, true, nil
+	}
+
+	if err != nil {
+		if it.policy == FormulaStrict {
+			return "", false, fmt.Errorf("%w: Sheet '%s' %s =%s: %v", ErrStaleFormula, it.sheet, cell, formula, err)
+		}
+		issue.Kind = FormulaCalcFailed
+		issue.Msg = fmt.Sprintf("无法计算，使用缓存值 '%s': %v", cached, err)
+		it.onIssue(issue)
+		return cached, true, nil
+	}
+
+	if !sameFormulaValue(value, cached) {
+		if it.policy == FormulaStrict {
+			return "", false, fmt.Errorf("%w: Sheet '%s' %s =%s 缓存值 '%s'，计算结果 '%s'",
+				ErrStaleFormula, it.sheet, cell, formula, cached, value)
+		}
+		issue.Kind = FormulaStale
+		issue.Msg = fmt.Sprintf("缓存值 '%s' 已过期，使用计算结果 '%s'", cached, value)
+		it.onIssue(issue)
+	}
+	return value, true, nil
+}
+
+func (it *formulaRowIterator) Row() []string {
+	return it.row
+}
+
+func (it *formulaRowIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.RowIterator.Err()
+}
+
+// sameFormulaValue 比较计算结果和缓存值，数值按相对误差比较
+func sameFormulaValue(value, cached string) bool {
+	if value == cached {
+		return true
+	}
+	a, err1 := strconv.ParseFloat(value, 64)
+	b, err2 := strconv.ParseFloat(cached, 64)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type formulaItem struct {
+	ID    int `excel:"id"`
+	Atk   int `excel:"atk"`
+	Power int `excel:"power"`
+	Luck  int `excel:"luck"`
+}
+
+// newFormulaWorkbook 创建缓存值过期的工作簿
+//
+//	id | atk | power     | luck
+//	1  | 10  | =B2*2 (20) |
+//	2  | 30  | =B3*2 (40，过期) | =RANDBETWEEN(1,6) (3)
+func newFormulaWorkbook(t *testing.T) string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	rows := [][]interface{}{
+		{"id", "atk", "power", "luck"},
+		{1, 10, 20},
+		{2, 30, 40, 3},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatalf("SetSheetRow() error = %v", err)
+		}
+	}
+	// 设置公式时保留上面写入的缓存值
+	f.SetCellFormula("Sheet1", "C2", "B2*2")
+	f.SetCellFormula("Sheet1", "C3", "B3*2")
+	f.SetCellFormula("Sheet1", "D3", "RANDBETWEEN(1,6)")
+
+	path := filepath.Join(t.TempDir(), "formula.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	return path
+}
+
+// TestFormulaPolicy 测试各公式策略的取值和问题报告
+func TestFormulaPolicy(t *testing.T) {
+	path := newFormulaWorkbook(t)
+
+	got, err := NewLoader[formulaItem](path, "Sheet1", LoadOptions{}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got[1].Power != 40 {
+		t.Errorf("cached Power = %d, want 40", got[1].Power)
+	}
+
+	var issues []FormulaIssue
+	loader := NewLoader[formulaItem](path, "Sheet1", LoadOptions{
+		Formula:        FormulaEvaluate,
+		OnFormulaIssue: func(issue FormulaIssue) { issues = append(issues, issue) },
+	})
+	got, err = loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []formulaItem{{1, 10, 20, 0}, {2, 30, 60, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("issues = %v, want 2", issues)
+	}
+	if i := issues[0]; i.Kind != FormulaStale || i.Cell != "C3" || i.Cached != "40" || i.Value != "60" {
+		t.Errorf("issues[0] = %+v", i)
+	}
+	if i := issues[1]; i.Kind != FormulaVolatile || i.Cell != "D3" {
+		t.Errorf("issues[1] = %+v", i)
+	}
+
+	_, err = NewLoader[formulaItem](path, "Sheet1", LoadOptions{
+		Formula:        FormulaStrict,
+		OnFormulaIssue: func(FormulaIssue) {},
+	}).Load()
+	if !errors.Is(err, ErrStaleFormula) {
+		t.Errorf("Load() error = %v, want ErrStaleFormula", err)
+	}
+}
+
+// TestCheckFormulas 测试检查整张 Sheet 的公式
+func TestCheckFormulas(t *testing.T) {
+	reader, err := NewExcelReader(newFormulaWorkbook(t))
+	if err != nil {
+		t.Fatalf("NewExcelReader() error = %v", err)
+	}
+	defer reader.Close()
+
+	issues, err := reader.CheckFormulas("Sheet1")
+	if err != nil {
+		t.Fatalf("CheckFormulas() error = %v", err)
+	}
+	if len(issues) != 2 || issues[0].Kind != FormulaStale || issues[1].Kind != FormulaVolatile {
+		t.Errorf("CheckFormulas() = %v", issues)
+	}
+
+	if _, err := reader.CheckFormulas("Missing"); !errors.Is(err, ErrSheetNotFound) {
+		t.Errorf("CheckFormulas() error = %v, want ErrSheetNotFound", err)
+	}
+}
+
+// TestVolatileFuncs 测试易变函数识别
+func TestVolatileFuncs(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    []string
+	}{
+		{"A1*2", nil},
+		{"rand()+NOW()+Rand()", []string{"RAND", "NOW"}},
+		{`IF(A1="NOW()",1,TODAY ())`, []string{"TODAY"}},
+		{"OPERAND(1)", nil},
+	}
+	for _, tt := range tests {
+		if got := VolatileFuncs(tt.formula); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("VolatileFuncs(%q) = %v, want %v", tt.formula, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// 多语言字段模式
+const (
+	// i18nModeSuffix 按语言后缀收集列，如 name_zh、name_en
+	i18nModeSuffix = "suffix"
+	// i18nModeKey 单元格为文本 ID，从文本表中查找翻译
+	i18nModeKey = "key"
+)
+
+// LocalizedString 多语言文本
+// 通过 tag 选项 i18n 映射:
+//
+//	Name LocalizedString `excel:"name,i18n"`     // 收集 name_zh、name_en、name_ja 等列
+//	Desc LocalizedString `excel:"desc,i18n:key"` // 单元格为文本 ID，从 I18nOptions.StringTable 查找
+type LocalizedString struct {
+	ID    string            // 文本 ID（i18n:key 模式）
+	Text  string            // 按 Locale 解析后的文本
+	Texts map[string]string // 语言 -> 文本（只包含非空翻译）
+}
+
+// Get 获取指定语言的文本
+func (s LocalizedString) Get(lang string) (string, bool) {
+	text, ok := s.Texts[lang]
+	return text, ok
+}
+
+// String 返回按 Locale 解析后的文本
+func (s LocalizedString) String() string {
+	return s.Text
+}
+
+// localizedStringType LocalizedString 的反射类型
+var localizedStringType = reflect.TypeOf(LocalizedString{})
+
+// I18nOptions 多语言选项
+type I18nOptions struct {
+	// Locale 解析 LocalizedString.Text 使用的语言（如 "zh"），为空时不解析
+	Locale string
+	// FallbackLocale Locale 没有翻译时使用的语言
+	FallbackLocale string
+	// Languages 期望的语言列表，用于识别后缀列和统计翻译覆盖率
+	// 为空时只识别常用语言代码（如 zh、en、zh_tw），见 knownLocales
+	Languages []string
+	// StringTable 文本表（用于 i18n:key 字段）
+	StringTable *StringTable
+}
+
+// knownLocales 未设置 Languages 时识别的语言后缀（小写，区域用 "_" 分隔）
+// 不含 "id"（印度尼西亚语）等与常见列名后缀冲突的代码，需要时通过 Languages 指定
+var knownLocales = map[string]bool{
+	"zh": true, "zh_cn": true, "zh_tw": true, "zh_hk": true, "zh_hans": true, "zh_hant": true,
+	"en": true, "en_us": true, "en_gb": true,
+	"ja": true, "ko": true,
+	"fr": true, "fr_fr": true, "fr_ca": true,
+	"de": true, "it": true, "ru": true, "pl": true, "tr": true, "nl": true,
+	"es": true, "es_es": true, "es_mx": true,
+	"pt": true, "pt_br": true, "pt_pt": true,
+	"ar": true, "th": true, "vi": true, "ms": true, "hi": true, "uk": true,
+}
+
+// languageSuffix 判断列名是否为 field 的语言后缀列，返回语言代码
+// 设置了 Languages 时只识别其中的语言，否则只识别 knownLocales 中的语言
+func (o *I18nOptions) languageSuffix(field, header string) (string, bool) {
+	prefix := field + "_"
+	if !strings.HasPrefix(header, prefix) {
+		return "", false
+	}
+	lang := header[len(prefix):]
+
+	if len(o.Languages) > 0 {
+		for _, l := range o.Languages {
+			if l == lang {
+				return lang, true
+			}
+		}
+		return "", false
+	}
+	return lang, knownLocales[strings.ReplaceAll(strings.ToLower(lang), "-", "_")]
+}
+
+// resolve 按 Locale、FallbackLocale 顺序解析文本
+// 都没有翻译时，i18n:key 模式返回文本 ID 以便发现遗漏
+func (o *I18nOptions) resolve(s LocalizedString) string {
+	if text, ok := s.Texts[o.Locale]; ok && o.Locale != "" {
+		return text
+	}
+	if text, ok := s.Texts[o.FallbackLocale]; ok && o.FallbackLocale != "" {
+		return text
+	}
+	return s.ID
+}
+
+// StringTable 文本表
+// 第一列为文本 ID，其余每列为一种语言，表头为语言代码:
+//
+//	id        | zh   | en
+//	item_1001 | 铁剑 | Iron Sword
+type StringTable struct {
+	Languages []string
+	texts     map[string]map[string]string
+}
+
+// NewStringTable 从行数据创建文本表
+func NewStringTable(rows [][]string, options LoadOptions) (*StringTable, error) {
+	layout := resolveRowLayout(rows, options)
+	if len(rows) <= layout.headerRow {
+		return nil, fmt.Errorf("%w: 文本表缺少表头行", ErrInvalidFormat)
+	}
+
+	headers := rows[layout.headerRow]
+	if len(headers) < 2 {
+		return nil, fmt.Errorf("%w: 文本表至少需要 ID 列和一种语言", ErrInvalidFormat)
+	}
+
+	table := &StringTable{
+		Languages: make([]string, 0, len(headers)-1),
+		texts:     make(map[string]map[string]string),
+	}
+	for _, lang := range headers[1:] {
+		table.Languages = append(table.Languages, strings.TrimSpace(lang))
+	}
+
+	for i := layout.dataStart; i < len(rows); i++ {
+		row := rows[i]
+		if len(row) == 0 || row[0] == "" {
+			continue
+		}
+		id := row[0]
+		if _, ok := table.texts[id]; ok {
+			return nil, NewConfigError("", i-layout.dataStart+1, 1, headers[0],
+				fmt.Sprintf("文本 ID '%s' 重复", id), ErrInvalidFormat)
+		}
+
+		texts := make(map[string]string)
+		for j, lang := range table.Languages {
+			if j+1 < len(row) && row[j+1] != "" {
+				texts[lang] = row[j+1]
+			}
+		}
+		table.texts[id] = texts
+	}
+
+	return table, nil
+}
+
+// LoadStringTable 加载文本表，数据源规则与 Loader 相同
+func LoadStringTable(basePath string, sheetName string, options LoadOptions) (*StringTable, error) {
+	rows, err := ReadRows(basePath, sheetName, options)
+	if err != nil {
+		return nil, err
+	}
+	return NewStringTable(rows, options)
+}
+
+// Lookup 查找文本 ID 的全部翻译
+func (t *StringTable) Lookup(id string) (map[string]string, bool) {
+	texts, ok := t.texts[id]
+	return texts, ok
+}
+
+// Len 返回文本数量
+func (t *StringTable) Len() int {
+	return len(t.texts)
+}
+
+// mapI18nField 映射多语言字段
+func (m *StructMapper[T]) mapI18nField(result reflect.Value, field *FieldInfo, headers []string, colIndex map[string]int, row []string, ctx *EvalContext) error {
+	if field.Type != localizedStringType {
+		return NewConfigError("", 0, 0, field.Name,
+			fmt.Sprintf("i18n 字段 '%s' 必须是 LocalizedString 类型，当前为 %v", field.Name, field.Type), ErrTypeMismatch)
+	}
+
+	value := LocalizedString{Texts: make(map[string]string)}
+	col := -1
+
+	switch field.I18n {
+	case i18nModeKey:
+		idx, ok := colIndex[field.Name]
+		if !ok {
+			// 列不存在，跳过
+			return nil
+		}
+		col = idx
+		if idx < len(row) {
+			value.ID = row[idx]
+		}
+		if value.ID == "" {
+			value.ID = field.Options["default"]
+		}
+		if value.ID != "" {
+			if m.i18n.StringTable == nil {
+				return NewConfigError("", 0, idx, field.Name,
+					fmt.Sprintf("字段 '%s' 使用 i18n:key，但未设置 StringTable", field.Name), ErrInvalidFormat)
+			}
+			texts, ok := m.i18n.StringTable.Lookup(value.ID)
+			if !ok {
+				return NewConfigError("", 0, idx, field.Name,
+					fmt.Sprintf("文本表中不存在文本 ID '%s'", value.ID), ErrInvalidFormat)
+			}
+			for lang, text := range texts {
+				value.Texts[lang] = text
+			}
+		}
+
+	default:
+		for i, header := range headers {
+			lang, ok := m.i18n.languageSuffix(field.Name, header)
+			if !ok {
+				continue
+			}
+			if col < 0 {
+				col = i
+			}
+			if i < len(row) && row[i] != "" {
+				value.Texts[lang] = row[i]
+			}
+		}
+		if col < 0 {
+			// 没有任何语言列，跳过
+			return nil
+		}
+	}
+
+	value.Text = m.i18n.resolve(value)
+
+	// 检查必填字段：至少有一种语言的文本
+	if _, required := field.Options["required"]; required && len(value.Texts) == 0 {
+		return NewConfigError("", 0, col, field.Name,
+			fmt.Sprintf("缺少必填字段 '%s'", field.Name), ErrRequiredField)
+	}
+
+	result.Field(field.Index).Set(reflect.ValueOf(value))
+	ctx.MarkResolved(field.Name)
+	ctx.SetValue(field.Name, value.Text)
+	return nil
+}
+
+// I18nMissing 缺失的翻译
+type I18nMissing struct {
+	Row   int    // 数据行号（从 1 开始）
+	Field string // 字段名（excel tag）
+	ID    string // 文本 ID（i18n:key 模式）
+}
+
+// I18nCoverage 翻译覆盖率报告
+type I18nCoverage struct {
+	Languages []string
+	// Total 文本总数（至少有一种语言或文本 ID 的字段）
+	Total int
+	// Missing 语言 -> 缺失的翻译
+	Missing map[string][]I18nMissing
+}
+
+// Ratio 返回指定语言的翻译覆盖率（0~1）
+func (c *I18nCoverage) Ratio(lang string) float64 {
+	if c.Total == 0 {
+		return 1
+	}
+	return float64(c.Total-len(c.Missing[lang])) / float64(c.Total)
+}
+
+// String 返回可读的覆盖率摘要
+func (c *I18nCoverage) String() string {
+	var b strings.Builder
+	for _, lang := range c.Languages {
+		fmt.Fprintf(&b, "%s: %d/%d (%.1f%%)\n", lang, c.Total-len(c.Missing[lang]), c.Total, c.Ratio(lang)*100)
+	}
+	return b.String()
+}
+
+// CheckI18nCoverage 统计已加载数据中多语言字段的翻译覆盖率
+// languages 为空时使用数据中出现过的所有语言
+func CheckI18nCoverage[T any](rows []T, languages []string) *I18nCoverage {
+	mapper := NewStructMapper[T]()
+
+	var fields []*FieldInfo
+	for _, field := range mapper.fields {
+		if field.I18n != "" && field.Type == localizedStringType {
+			fields = append(fields, field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Index < fields[j].Index })
+
+	// 收集文本
+	type entry struct {
+		row   int
+		field string
+		value LocalizedString
+	}
+	var entries []entry
+	seen := make(map[string]bool)
+	for i := range rows {
+		v := reflect.ValueOf(&rows[i]).Elem()
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+		for _, field := range fields {
+			value := v.Field(field.Index).Interface().(LocalizedString)
+			if value.ID == "" && len(value.Texts) == 0 {
+				continue
+			}
+			entries = append(entries, entry{row: i + 1, field: field.Name, value: value})
+			for lang := range value.Texts {
+				seen[lang] = true
+			}
+		}
+	}
+
+	if len(languages) == 0 {
+		for lang := range seen {
+			languages = append(languages, lang)
+		}
+		sort.Strings(languages)
+	}
+
+	coverage := &I18nCoverage{
+		Languages: languages,
+		Total:     len(entries),
+		Missing:   make(map[string][]I18nMissing),
+	}
+	for _, e := range entries {
+		for _, lang := range languages {
+			if _, ok := e.value.Texts[lang]; !ok {
+				coverage.Missing[lang] = append(coverage.Missing[lang], I18nMissing{
+					Row:   e.row,
+					Field: e.field,
+					ID:    e.value.ID,
+				})
+			}
+		}
+	}
+
+	return coverage
+}
+
+// I18nCoverage 统计翻译覆盖率，期望语言取自 LoadOptions.I18n
+func (l *Loader[T]) I18nCoverage(rows []T) *I18nCoverage {
+	languages := l.options.I18n.Languages
+	if len(languages) == 0 && l.options.I18n.StringTable != nil {
+		languages = l.options.I18n.StringTable.Languages
+	}
+	return CheckI18nCoverage(rows, languages)
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type i18nItem struct {
+	ID   int             `excel:"id"`
+	Name LocalizedString `excel:"name,i18n,required"`
+	Desc LocalizedString `excel:"desc,i18n:key"`
+}
+
+// TestI18n_SuffixColumns 测试按语言后缀收集列
+func TestI18n_SuffixColumns(t *testing.T) {
+	loader := NewLoader[i18nItem]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		I18n: I18nOptions{Locale: "en", FallbackLocale: "zh"},
+		MockData: [][]string{
+			{"id", "name_zh", "name_en", "name_ja", "name_extra"},
+			{"1", "铁剑", "Iron Sword", "鉄の剣", "x"},
+			{"2", "钢剑", "", "", "y"},
+		},
+	})
+
+	items, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if items[0].Name.Text != "Iron Sword" {
+		t.Errorf("Name.Text = %q, want %q", items[0].Name.Text, "Iron Sword")
+	}
+	if text, _ := items[0].Name.Get("ja"); text != "鉄の剣" {
+		t.Errorf("Name.Get(ja) = %q, want %q", text, "鉄の剣")
+	}
+	if _, ok := items[0].Name.Get("extra"); ok {
+		t.Error("name_extra should not be treated as a language column")
+	}
+
+	// 缺少 en 翻译时回退到 zh
+	if items[1].Name.Text != "钢剑" {
+		t.Errorf("fallback Name.Text = %q, want %q", items[1].Name.Text, "钢剑")
+	}
+}
+
+// TestI18n_Languages 测试指定语言列表时只收集这些语言
+func TestI18n_Languages(t *testing.T) {
+	type item struct {
+		Name LocalizedString `excel:"name,i18n"`
+	}
+
+	loader := NewLoader[item]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		I18n: I18nOptions{Locale: "zh", Languages: []string{"zh", "en"}},
+		MockData: [][]string{
+			{"name_zh", "name_en", "name_ja"},
+			{"铁剑", "Iron Sword", "鉄の剣"},
+		},
+	})
+
+	items, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(items[0].Name.Texts) != 2 {
+		t.Errorf("Texts = %v, want only zh and en", items[0].Name.Texts)
+	}
+}
+
+// TestI18n_SuffixNotLanguage 测试未指定语言列表时，id、min 等后缀列不被当作翻译
+func TestI18n_SuffixNotLanguage(t *testing.T) {
+	type item struct {
+		ItemID int             `excel:"item_id"`
+		Item   LocalizedString `excel:"item,i18n"`
+		Min    int             `excel:"item_min"`
+	}
+
+	loader := NewLoader[item]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		MockData: [][]string{
+			{"item_id", "item_zh", "item_en", "item_zh_TW", "item_min", "item_max"},
+			{"1001", "剑", "sword", "劍", "1", "9"},
+		},
+	})
+
+	items, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := map[string]string{"zh": "剑", "en": "sword", "zh_TW": "劍"}
+	if len(items[0].Item.Texts) != len(want) {
+		t.Fatalf("Texts = %v, want %v", items[0].Item.Texts, want)
+	}
+	for lang, text := range want {
+		if got, _ := items[0].Item.Get(lang); got != text {
+			t.Errorf("Get(%s) = %q, want %q", lang, got, text)
+		}
+	}
+	if items[0].ItemID != 1001 || items[0].Min != 1 {
+		t.Errorf("item = %+v", items[0])
+	}
+}
+
+// TestI18n_Required 测试必填的多语言字段
+func TestI18n_Required(t *testing.T) {
+	loader := NewLoader[i18nItem]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		MockData: [][]string{
+			{"id", "name_zh", "name_en"},
+			{"1", "", ""},
+		},
+	})
+
+	_, err := loader.Load()
+	if !errors.Is(err, ErrRequiredField) {
+		t.Errorf("error = %v, want ErrRequiredField", err)
+	}
+}
+
+// TestI18n_StringTable 测试从文本表查找翻译
+func TestI18n_StringTable(t *testing.T) {
+	table, err := NewStringTable([][]string{
+		{"id", "zh", "en"},
+		{"desc_1", "锋利的剑", "A sharp sword"},
+		{"desc_2", "沉重的剑", ""},
+	}, LoadOptions{})
+	if err != nil {
+		t.Fatalf("NewStringTable() error = %v", err)
+	}
+	if table.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", table.Len())
+	}
+
+	loader := NewLoader[i18nItem]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		I18n: I18nOptions{Locale: "en", StringTable: table},
+		MockData: [][]string{
+			{"id", "name_zh", "desc"},
+			{"1", "铁剑", "desc_1"},
+			{"2", "钢剑", "desc_2"},
+		},
+	})
+
+	items, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if items[0].Desc.ID != "desc_1" || items[0].Desc.Text != "A sharp sword" {
+		t.Errorf("Desc = %+v", items[0].Desc)
+	}
+	// 没有翻译也没有回退语言时返回文本 ID
+	if items[1].Desc.Text != "desc_2" {
+		t.Errorf("Desc.Text = %q, want text ID", items[1].Desc.Text)
+	}
+}
+
+// TestI18n_StringTableErrors 测试文本表相关错误
+func TestI18n_StringTableErrors(t *testing.T) {
+	t.Run("未设置文本表", func(t *testing.T) {
+		loader := NewLoader[i18nItem]("", "Sheet1", LoadOptions{
+			Mode:     ModeMemory,
+			MockData: [][]string{{"id", "name_zh", "desc"}, {"1", "铁剑", "desc_1"}},
+		})
+		if _, err := loader.Load(); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("error = %v, want ErrInvalidFormat", err)
+		}
+	})
+
+	t.Run("文本 ID 不存在", func(t *testing.T) {
+		table, _ := NewStringTable([][]string{{"id", "zh"}, {"desc_1", "a"}}, LoadOptions{})
+		loader := NewLoader[i18nItem]("", "Sheet1", LoadOptions{
+			Mode:     ModeMemory,
+			I18n:     I18nOptions{StringTable: table},
+			MockData: [][]string{{"id", "name_zh", "desc"}, {"1", "铁剑", "desc_9"}},
+		})
+		_, err := loader.Load()
+		if err == nil || !strings.Contains(err.Error(), "desc_9") {
+			t.Errorf("error = %v, want missing text ID desc_9", err)
+		}
+	})
+
+	t.Run("文本 ID 重复", func(t *testing.T) {
+		_, err := NewStringTable([][]string{{"id", "zh"}, {"a", "1"}, {"a", "2"}}, LoadOptions{})
+		if err == nil {
+			t.Error("NewStringTable() should reject duplicate IDs")
+		}
+	})
+}
+
+// TestI18n_TypeMismatch 测试 i18n 选项用于非 LocalizedString 字段
+func TestI18n_TypeMismatch(t *testing.T) {
+	type item struct {
+		Name string `excel:"name,i18n"`
+	}
+
+	mapper := NewStructMapper[item]()
+	_, err := mapper.MapRow([]string{"name_zh"}, []string{"铁剑"})
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("error = %v, want ErrTypeMismatch", err)
+	}
+}
+
+// TestI18n_Condition 测试多语言字段参与条件判断
+func TestI18n_Condition(t *testing.T) {
+	type item struct {
+		Type int             `excel:"type"`
+		Tip  LocalizedString `excel:"tip,i18n,when:type=1"`
+	}
+
+	loader := NewLoader[item]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		I18n: I18nOptions{Locale: "zh"},
+		MockData: [][]string{
+			{"type", "tip_zh"},
+			{"1", "提示"},
+			{"2", "不加载"},
+		},
+	})
+
+	items, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if items[0].Tip.Text != "提示" {
+		t.Errorf("items[0].Tip = %+v, want loaded", items[0].Tip)
+	}
+	if items[1].Tip.Text != "" || len(items[1].Tip.Texts) != 0 {
+		t.Errorf("items[1].Tip = %+v, want empty", items[1].Tip)
+	}
+}
+
+// TestI18n_Coverage 测试翻译覆盖率统计
+func TestI18n_Coverage(t *testing.T) {
+	loader := NewLoader[i18nItem]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		I18n: I18nOptions{Locale: "zh", Languages: []string{"zh", "en", "ja"}},
+		MockData: [][]string{
+			{"id", "name_zh", "name_en", "name_ja"},
+			{"1", "铁剑", "Iron Sword", ""},
+			{"2", "钢剑", "", ""},
+		},
+	})
+
+	items, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	coverage := loader.I18nCoverage(items)
+	if coverage.Total != 2 {
+		t.Errorf("Total = %d, want 2", coverage.Total)
+	}
+	if got := coverage.Ratio("zh"); got != 1 {
+		t.Errorf("Ratio(zh) = %v, want 1", got)
+	}
+	if got := coverage.Ratio("en"); got != 0.5 {
+		t.Errorf("Ratio(en) = %v, want 0.5", got)
+	}
+	if missing := coverage.Missing["ja"]; len(missing) != 2 || missing[1].Row != 2 || missing[1].Field != "name" {
+		t.Errorf("Missing[ja] = %+v", missing)
+	}
+	if !strings.Contains(coverage.String(), "en: 1/2 (50.0%)") {
+		t.Errorf("String() = %q", coverage.String())
+	}
+}
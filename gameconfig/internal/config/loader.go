@@ -3,7 +3,6 @@ package config
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 )
@@ -20,6 +19,16 @@ const (
 	ModeCSV Mode = "csv"
 	// ModeMemory 从内存数据加载（用于 Mock 测试）
 	ModeMemory Mode = "memory"
+	// ModeBinary 从二进制快照加载（生产环境快速启动）
+	ModeBinary Mode = "binary"
+	// ModeTSV 读取制表符分隔的文本（Google Sheets 导出格式）
+	ModeTSV Mode = "tsv"
+	// ModeJSON 读取 JSON 记录数组
+	ModeJSON Mode = "json"
+	// ModeYAML 读取 YAML 记录数组
+	ModeYAML Mode = "yaml"
+	// ModeTOML 读取 TOML 表数组
+	ModeTOML Mode = "toml"
 )
 
 // LoadOptions 加载选项
@@ -36,6 +45,29 @@ type LoadOptions struct {
 	TagName string
 	// MockData Mock 数据源（用于 Memory 模式）
 	MockData [][]string
+	// I18n 多语言选项（用于 LocalizedString 字段）
+	I18n I18nOptions
+	// Funcs when / computed 表达式使用的函数和表（为空时使用 DefaultFuncs）
+	Funcs *FuncRegistry
+	// Source 自定义数据源（设置后忽略 Mode 对应的内置数据源）
+	Source Source
+	// Sheets 按顺序拼接的多张 Sheet，支持 * ? [] 通配符（设置后 sheetName 只作为表名）
+	// 文本格式时为数据目录下的文件名（不含扩展名）
+	Sheets []string
+	// Extends 基础 Sheet，Sheets（为空时为 sheetName）中的行按主键覆盖或追加到基础 Sheet
+	Extends string
+	// Key 主键列名（多 Sheet 合并、按主键查询和在线覆盖使用，默认第一列）
+	Key string
+	// Formula 公式单元格的取值策略（仅 Excel 模式，默认使用缓存值）
+	Formula FormulaPolicy
+	// OnFormulaIssue 公式问题（缓存过期、易变函数、无法计算）回调，为空时输出到日志
+	OnFormulaIssue func(FormulaIssue)
+	// Enums 枚举字典（用于 enum:字典名 字段）
+	Enums *EnumRegistry
+	// SkipExprCheck 加载前不静态检查 when / computed 表达式
+	SkipExprCheck bool
+	// OnExprWarning 表达式检查警告（类型不匹配、列缺失等）回调，为空时忽略
+	OnExprWarning func(ExprIssue)
 }
 
 // Loader 配置加载器（泛型）
@@ -60,32 +92,64 @@ func NewLoader[T any](basePath string, sheetName string, options LoadOptions) *L
 		options.Mode = ModeAuto
 	}
 
+	mapper := NewStructMapper[T]()
+	mapper.SetI18n(options.I18n)
+	mapper.SetFuncs(options.Funcs)
+	mapper.SetEnums(options.Enums)
+
 	return &Loader[T]{
 		basePath:  basePath,
 		sheetName: sheetName,
 		options:   options,
-		mapper:    NewStructMapper[T](),
+		mapper:    mapper,
 	}
 }
 
 // Load 加载配置到结构体切片
 func (l *Loader[T]) Load() ([]T, error) {
-	// 确定加载模式
-	mode := l.options.Mode
-	if mode == ModeAuto {
-		mode = l.detectMode()
+	return l.loadMode(l.resolveMode())
+}
+
+// resolveMode 确定加载模式，ModeAuto 时自动检测
+func (l *Loader[T]) resolveMode() Mode {
+	if l.options.Mode == ModeAuto {
+		return l.detectMode()
 	}
+	return l.options.Mode
+}
 
-	switch mode {
-	case ModeExcel:
-		return l.loadFromExcel()
-	case ModeCSV:
-		return l.loadFromCSV()
-	case ModeMemory:
-		return l.loadFromMemory()
-	default:
-		return nil, fmt.Errorf("不支持的加载模式: %s", mode)
+// loadMode 按指定模式加载
+func (l *Loader[T]) loadMode(mode Mode) ([]T, error) {
+	_, _, data, err := l.loadTable(mode)
+	return data, err
+}
+
+// loadTable 按指定模式加载，同时返回表头和原始数据行
+// 二进制快照没有原始行，表头和数据行为空
+func (l *Loader[T]) loadTable(mode Mode) ([]string, [][]string, []T, error) {
+	if mode == ModeBinary {
+		data, err := l.loadFromBinary()
+		return nil, nil, data, err
+	}
+
+	src, err := l.source(mode)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rows, err := readSource(src)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return l.parseRows(src, rows)
+}
+
+// readRows 按指定模式读取原始行数据（未映射）
+func (l *Loader[T]) readRows(mode Mode) ([][]string, error) {
+	src, err := l.source(mode)
+	if err != nil {
+		return nil, err
 	}
+	return readSource(src)
 }
 
 // Reload 重新加载
@@ -93,129 +157,147 @@ func (l *Loader[T]) Reload() ([]T, error) {
 	return l.Load()
 }
 
-// loadFromMemory 从内存数据加载
+// ReadRows 按 Loader 的数据源规则读取原始行数据（不映射到结构体）
+// 用于文本表、差异对比等不需要结构体定义的场景
+func ReadRows(basePath string, sheetName string, options LoadOptions) ([][]string, error) {
+	loader := NewLoader[struct{}](basePath, sheetName, options)
+	mode := loader.options.Mode
+	if mode == ModeAuto {
+		mode = loader.detectMode()
+	}
+	return loader.readRows(mode)
+}
+
+// readMemoryRows 读取内存数据
 // 并发安全：使用读锁保护数据访问
-func (l *Loader[T]) loadFromMemory() ([]T, error) {
+func (l *Loader[T]) readMemoryRows() [][]string {
 	// 如果 MockData 不为空，使用 MockData
 	if len(l.options.MockData) > 0 {
-		return l.parseRows(l.options.MockData)
+		return l.options.MockData
 	}
 
 	// 使用读锁保护内部数据访问
 	l.dataMu.RLock()
 	defer l.dataMu.RUnlock()
-	return l.parseRows(l.data)
+	return l.data
 }
 
 // detectMode 自动检测加载模式
+// basePath 是文件时按扩展名确定模式（.xlsx / .gcs / .csv / .tsv / .json / .yaml / .yml / .toml）
+// basePath 是目录时按顺序查找 {sheetName}.csv、.tsv、.json、.yaml、.toml
 func (l *Loader[T]) detectMode() Mode {
-	// 检查 basePath 是否是 Excel 文件
-	if strings.HasSuffix(strings.ToLower(l.basePath), ".xlsx") {
-		return ModeExcel
+	if mode := modeByExt(l.basePath); mode != "" {
+		return mode
 	}
 
-	// 检查是否存在对应的 CSV 文件
-	csvPath := l.getCSVPath()
-	if _, err := os.Stat(csvPath); err == nil {
-		return ModeCSV
+	// 检查是否存在对应的文本文件
+	for _, mode := range []Mode{ModeCSV, ModeTSV, ModeJSON, ModeYAML, ModeTOML} {
+		if _, err := os.Stat(l.sourcePath(mode)); err == nil {
+			return mode
+		}
 	}
 
-	// 默认使用 CSV 模式（如果不是 .xlsx 文件）
+	// 默认使用 CSV 模式
 	return ModeCSV
 }
 
-// getCSVPath 获取 CSV 文件路径
-// 格式: {basePath}/{sheetName}.csv
-// 如果 basePath 是 .xlsx 文件，则使用同名目录
-// 如果 basePath 是 .csv 文件，则直接使用它
-func (l *Loader[T]) getCSVPath() string {
-	// 如果 basePath 是 Excel 文件
-	if strings.HasSuffix(strings.ToLower(l.basePath), ".xlsx") {
-		baseDir := filepath.Dir(l.basePath)
-		excelName := strings.TrimSuffix(filepath.Base(l.basePath), ".xlsx")
-		return filepath.Join(baseDir, excelName, l.sheetName+".csv")
+// SourcePath 返回当前模式下实际读取的文件路径
+// Memory 模式没有文件，返回空字符串
+func (l *Loader[T]) SourcePath() string {
+	mode := l.options.Mode
+	if mode == ModeAuto {
+		mode = l.detectMode()
 	}
 
-	// 如果 basePath 已经是 CSV 文件，直接返回
-	if strings.HasSuffix(strings.ToLower(l.basePath), ".csv") {
+	switch mode {
+	case ModeExcel:
 		return l.basePath
+	case ModeCSV, ModeTSV, ModeJSON, ModeYAML, ModeTOML:
+		return l.sourcePath(mode)
+	case ModeBinary:
+		return l.getSnapshotPath()
+	default:
+		return ""
 	}
-
-	// 否则假设 basePath 是目录
-	return filepath.Join(l.basePath, l.sheetName+".csv")
 }
 
-// loadFromExcel 从 Excel 加载
-func (l *Loader[T]) loadFromExcel() ([]T, error) {
-	reader, err := NewExcelReader(l.basePath)
-	if err != nil {
-		return nil, err
+// parseRows 解析行数据，返回表头、数据行和映射结果
+func (l *Loader[T]) parseRows(src Source, rows [][]string) ([]string, [][]string, []T, error) {
+	if len(rows) == 0 {
+		return nil, nil, nil, nil
 	}
-	defer reader.Close()
 
-	// 读取 Sheet 数据
-	rows, err := reader.ReadSheet(l.sheetName)
-	if err != nil {
-		return nil, err
+	layout := l.layout(src, rows)
+
+	// 验证行数
+	if len(rows) <= layout.headerRow {
+		return nil, nil, nil, fmt.Errorf("数据行数不足")
 	}
 
-	return l.parseRows(rows)
-}
+	// 获取表头
+	headers := rows[layout.headerRow]
 
-// loadFromCSV 从 CSV 加载
-func (l *Loader[T]) loadFromCSV() ([]T, error) {
-	csvPath := l.getCSVPath()
+	// 获取数据行
+	var dataRows [][]string
+	if layout.dataStart < len(rows) {
+		dataRows = rows[layout.dataStart:]
+	}
 
-	reader := NewCSVReader(csvPath)
-	defer reader.Close()
+	// 静态检查表达式，每张表检查一次
+	var types []string
+	if layout.typeRow >= 0 && layout.typeRow < len(rows) {
+		types = rows[layout.typeRow]
+	}
+	if err := l.checkExprs(headers, types); err != nil {
+		return nil, nil, nil, err
+	}
 
-	// 读取 CSV 数据
-	rows, err := reader.Read()
+	// 使用映射器映射数据
+	results, err := l.mapper.MapRows(headers, dataRows)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, locateError(src, err)
 	}
-
-	return l.parseRows(rows)
+	return headers, dataRows, results, nil
 }
 
-// parseRows 解析行数据
-func (l *Loader[T]) parseRows(rows [][]string) ([]T, error) {
-	if len(rows) == 0 {
-		return nil, nil
-	}
-
-	// 确定表头行和数据开始行
-	headerRow := l.options.HeaderRow
-	dataStart := l.options.DataStart
-	if dataStart == 0 {
-		dataStart = headerRow + 1
-	}
+// rowLayout 行布局（已计入版本行、变更说明行的偏移）
+type rowLayout struct {
+	headerRow int
+	typeRow   int // -1 表示没有类型行
+	dataStart int
+}
 
-	// 检查是否有版本行
+// resolveRowLayout 根据加载选项和元数据行确定表头行、类型行和数据开始行
+// TypeRow 不大于 HeaderRow 时视为未使用类型行
+func resolveRowLayout(rows [][]string, options LoadOptions) rowLayout {
+	// 检查是否有版本行和变更说明行
+	offset := 0
 	if len(rows) > 0 && len(rows[0]) > 0 && rows[0][0] == "__version__" {
-		// 跳过版本行
-		headerRow = 1
-		dataStart = 2
-		// 检查是否有变更说明行
+		offset = 1
 		if len(rows) > 1 && len(rows[1]) > 0 && rows[1][0] == "__changes__" {
-			headerRow = 2
-			dataStart = 3
+			offset = 2
 		}
 	}
 
-	// 验证行数
-	if len(rows) <= headerRow {
-		return nil, fmt.Errorf("数据行数不足")
+	layout := rowLayout{
+		headerRow: offset + options.HeaderRow,
+		typeRow:   -1,
+	}
+	if options.TypeRow > options.HeaderRow {
+		layout.typeRow = offset + options.TypeRow
 	}
 
-	// 获取表头
-	headers := rows[headerRow]
-
-	// 获取数据行
-	dataRows := rows[dataStart:]
+	if options.DataStart > 0 {
+		layout.dataStart = offset + options.DataStart
+	} else {
+		layout.dataStart = layout.headerRow + 1
+		// 类型行不是数据行
+		if layout.typeRow >= layout.dataStart {
+			layout.dataStart = layout.typeRow + 1
+		}
+	}
 
-	// 使用映射器映射数据
-	return l.mapper.MapRows(headers, dataRows)
+	return layout
 }
 
 // GetVersion 获取配置版本号
@@ -234,6 +316,14 @@ func (l *Loader[T]) GetVersion() (int, error) {
 		return reader.GetVersion(l.sheetName)
 	}
 
+	if mode == ModeBinary {
+		header, err := l.readSnapshotHeader()
+		if err != nil {
+			return 0, err
+		}
+		return header.DataVersion, nil
+	}
+
 	// CSV 模式下不支持版本号
 	return 0, nil
 }
@@ -273,7 +363,10 @@ func LoadWithComments[T any](basePath string, sheetName string, options LoadOpti
 	defer reader.Close()
 
 	// 读取批注
-	comments := readComments(reader, sheetName)
+	comments, err := readComments(reader, sheetName, options)
+	if err != nil {
+		return nil, err
+	}
 
 	// 加载数据
 	loader := NewLoader[T](basePath, sheetName, options)
@@ -288,11 +381,20 @@ func LoadWithComments[T any](basePath string, sheetName string, options LoadOpti
 	}, nil
 }
 
-// readComments 读取 Excel 批注
-func readComments(reader *ExcelReader, sheetName string) map[string]string {
-	// TODO: 实现批注读取
-	// 目前返回空 map，后续在 comment.go 中实现
-	return make(map[string]string)
+// readComments 读取 Excel 表头批注
+// 返回 map[字段名]批注内容
+func readComments(reader *ExcelReader, sheetName string, options LoadOptions) (map[string]string, error) {
+	rows, err := reader.ReadSheet(sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := resolveRowLayout(rows, options)
+	if len(rows) <= layout.headerRow {
+		return make(map[string]string), nil
+	}
+
+	return headerComments(reader, sheetName, rows[layout.headerRow], layout.headerRow), nil
 }
 
 // SetMockData 设置 Mock 数据（用于测试）
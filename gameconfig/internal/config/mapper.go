@@ -4,18 +4,24 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // FieldInfo 字段信息
 type FieldInfo struct {
 	Name         string       // 字段名
+	StructField  string       // 结构体字段名
 	Index        int          // 结构体字段索引
 	Type         reflect.Type // 字段类型
 	Options      map[string]string // tag 选项
 	Condition    Condition    // 条件表达式
 	ConditionStr string       // 条件表达式字符串
+	I18n         string       // 多语言模式（为空表示非多语言字段）
+	Computed     Condition    // 计算表达式
+	ComputedStr  string       // 计算表达式字符串（为空表示非计算字段）
 }
 
 // StructMapper 结构体映射器
@@ -23,6 +29,13 @@ type StructMapper[T any] struct {
 	typ       reflect.Type
 	fields    map[string]*FieldInfo // excel tag -> FieldInfo
 	fieldName map[string]*FieldInfo // 结构体字段名 -> FieldInfo
+	i18n      I18nOptions           // 多语言选项
+	funcs     *FuncRegistry         // 表达式函数和表
+	enums     *EnumRegistry         // 枚举字典
+
+	prepareOnce sync.Once
+	ordered     []*FieldInfo // 按依赖排序的条件字段和计算字段
+	prepareErr  error
 }
 
 // NewStructMapper 创建结构体映射器
@@ -72,10 +85,11 @@ func (m *StructMapper[T]) parseFields() {
 		}
 
 		fieldInfo := &FieldInfo{
-			Name:    name,
-			Index:   i,
-			Type:    field.Type,
-			Options: options,
+			Name:        name,
+			StructField: field.Name,
+			Index:       i,
+			Type:        field.Type,
+			Options:     options,
 		}
 
 		// 解析条件表达式
@@ -84,11 +98,41 @@ func (m *StructMapper[T]) parseFields() {
 			delete(options, "when")
 		}
 
+		// 解析计算表达式
+		if expr, ok := options["computed"]; ok {
+			fieldInfo.ComputedStr = expr
+			delete(options, "computed")
+		}
+
+		// 解析多语言选项
+		if mode, ok := options["i18n"]; ok {
+			if mode == i18nModeKey {
+				fieldInfo.I18n = i18nModeKey
+			} else {
+				fieldInfo.I18n = i18nModeSuffix
+			}
+		}
+
 		m.fields[name] = fieldInfo
 		m.fieldName[field.Name] = fieldInfo
 	}
 }
 
+// SetI18n 设置多语言选项
+func (m *StructMapper[T]) SetI18n(options I18nOptions) {
+	m.i18n = options
+}
+
+// SetFuncs 设置表达式使用的函数和表（为空时使用 DefaultFuncs）
+func (m *StructMapper[T]) SetFuncs(funcs *FuncRegistry) {
+	m.funcs = funcs
+}
+
+// SetEnums 设置 enum:字典名 字段使用的枚举字典
+func (m *StructMapper[T]) SetEnums(enums *EnumRegistry) {
+	m.enums = enums
+}
+
 // MapRow 将行数据映射到结构体
 func (m *StructMapper[T]) MapRow(headers []string, row []string) (T, error) {
 	var zero T
@@ -102,32 +146,27 @@ func (m *StructMapper[T]) MapRow(headers []string, row []string) (T, error) {
 
 	// 创建评估上下文
 	ctx := NewEvalContext()
+	ctx.Funcs = m.funcs
 
-	// 首先解析条件表达式
-	for _, fieldInfo := range m.fields {
-		if fieldInfo.ConditionStr != "" && fieldInfo.Condition == nil {
-			cond, err := ParseCondition(fieldInfo.ConditionStr)
-			if err != nil {
-				return zero, fmt.Errorf("解析字段 '%s' 的条件表达式失败: %w", fieldInfo.Name, err)
-			}
-			fieldInfo.Condition = cond
-		}
+	// 首先解析条件表达式和计算表达式
+	dependentFields, err := m.prepare()
+	if err != nil {
+		return zero, err
 	}
 
-	// 分离无条件字段和有条件字段
-	var unconditionalFields, conditionalFields []string
-	for excelName := range m.fields {
-		fieldInfo := m.fields[excelName]
-		if fieldInfo.Condition == nil {
-			unconditionalFields = append(unconditionalFields, excelName)
-		} else {
-			conditionalFields = append(conditionalFields, excelName)
+	// 第一遍历：解析所有无条件字段
+	for excelName, fieldInfo := range m.fields {
+		if fieldInfo.Condition != nil || fieldInfo.Computed != nil {
+			continue
 		}
-	}
 
-	// 第一遍历：解析所有无条件字段
-	for _, excelName := range unconditionalFields {
-		fieldInfo := m.fields[excelName]
+		// 多语言字段
+		if fieldInfo.I18n != "" {
+			if err := m.mapI18nField(result, fieldInfo, headers, colIndex, row, ctx); err != nil {
+				return zero, err
+			}
+			continue
+		}
 
 		// 获取列索引
 		idx, ok := colIndex[excelName]
@@ -159,10 +198,9 @@ func (m *StructMapper[T]) MapRow(headers []string, row []string) (T, error) {
 		}
 
 		// 类型转换
-		value, err := convertValue(valueStr, fieldInfo.Type)
+		value, ctxValue, msg, err := m.convertField(fieldInfo, valueStr)
 		if err != nil {
-			return zero, NewConfigError("", 0, idx, excelName,
-				fmt.Sprintf("无法将字符串 %q 转换为 %v 类型", valueStr, fieldInfo.Type), err)
+			return zero, NewConfigError("", 0, idx, excelName, msg, err)
 		}
 
 		// 设置字段值
@@ -170,12 +208,35 @@ func (m *StructMapper[T]) MapRow(headers []string, row []string) (T, error) {
 
 		// 标记字段已解析并填充到上下文
 		ctx.MarkResolved(excelName)
-		ctx.SetValue(excelName, value.Interface())
+		ctx.SetValue(excelName, ctxValue)
 	}
 
-	// 第二遍历：解析有条件字段
-	for _, excelName := range conditionalFields {
-		fieldInfo := m.fields[excelName]
+	// 第二遍历：按依赖顺序解析条件字段和计算字段
+	for _, fieldInfo := range dependentFields {
+		excelName := fieldInfo.Name
+
+		// 计算字段
+		if fieldInfo.Computed != nil {
+			if err := m.mapComputedField(result, fieldInfo, ctx); err != nil {
+				return zero, err
+			}
+			continue
+		}
+
+		// 多语言字段
+		if fieldInfo.I18n != "" {
+			shouldLoad, err := fieldInfo.Condition.Evaluate(ctx)
+			if err != nil {
+				return zero, NewConfigError("", 0, 0, excelName,
+					fmt.Sprintf("评估条件 '%s' 失败 (上下文字段: %v): %v", fieldInfo.ConditionStr, ctx.Values, err), err)
+			}
+			if shouldLoad {
+				if err := m.mapI18nField(result, fieldInfo, headers, colIndex, row, ctx); err != nil {
+					return zero, err
+				}
+			}
+			continue
+		}
 
 		// 获取列索引
 		idx, ok := colIndex[excelName]
@@ -218,22 +279,168 @@ func (m *StructMapper[T]) MapRow(headers []string, row []string) (T, error) {
 		}
 
 		// 类型转换
-		value, err := convertValue(valueStr, fieldInfo.Type)
+		value, ctxValue, msg, err := m.convertField(fieldInfo, valueStr)
 		if err != nil {
-			return zero, NewConfigError("", 0, idx, excelName,
-				fmt.Sprintf("无法将字符串 %q 转换为 %v 类型", valueStr, fieldInfo.Type), err)
+			return zero, NewConfigError("", 0, idx, excelName, msg, err)
 		}
 
 		// 设置字段值
 		result.Field(fieldInfo.Index).Set(value)
 
-		// 标记字段已解析
+		// 标记字段已解析并填充到上下文
 		ctx.MarkResolved(excelName)
+		ctx.SetValue(excelName, ctxValue)
 	}
 
 	return result.Interface().(T), nil
 }
 
+// prepare 解析条件表达式和计算表达式，并按依赖排序（只执行一次）
+func (m *StructMapper[T]) prepare() ([]*FieldInfo, error) {
+	m.prepareOnce.Do(func() {
+		m.ordered, m.prepareErr = m.buildOrder()
+	})
+	return m.ordered, m.prepareErr
+}
+
+// buildOrder 解析表达式，返回按依赖排序的条件字段和计算字段
+// 依赖其他条件字段或计算字段的排在被依赖字段之后
+func (m *StructMapper[T]) buildOrder() ([]*FieldInfo, error) {
+	var fields []*FieldInfo
+	for _, fieldInfo := range m.fields {
+		if fieldInfo.ConditionStr != "" {
+			cond, err := ParseCondition(fieldInfo.ConditionStr)
+			if err != nil {
+				return nil, fmt.Errorf("解析字段 '%s' 的条件表达式失败: %w", fieldInfo.Name, err)
+			}
+			fieldInfo.Condition = cond
+		}
+		if fieldInfo.ComputedStr != "" {
+			expr, err := ParseCondition(fieldInfo.ComputedStr)
+			if err != nil {
+				return nil, fmt.Errorf("解析字段 '%s' 的计算表达式失败: %w", fieldInfo.Name, err)
+			}
+			fieldInfo.Computed = expr
+		}
+		if fieldInfo.Condition != nil || fieldInfo.Computed != nil {
+			fields = append(fields, fieldInfo)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Index < fields[j].Index })
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[*FieldInfo]int)
+	order := make([]*FieldInfo, 0, len(fields))
+
+	var visit func(field *FieldInfo, path []*FieldInfo) error
+	visit = func(field *FieldInfo, path []*FieldInfo) error {
+		switch state[field] {
+		case visited:
+			return nil
+		case visiting:
+			return &cycleError{path: append(path, field)}
+		}
+		state[field] = visiting
+
+		for _, dep := range m.expressionDeps(field) {
+			if depField, ok := m.fields[dep]; ok && (depField.Condition != nil || depField.Computed != nil) {
+				if err := visit(depField, append(path, field)); err != nil {
+					return err
+				}
+			}
+		}
+
+		state[field] = visited
+		order = append(order, field)
+		return nil
+	}
+
+	for _, field := range fields {
+		if err := visit(field, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// expressionDeps 返回字段的条件表达式和计算表达式依赖的字段
+func (m *StructMapper[T]) expressionDeps(field *FieldInfo) []string {
+	var deps []string
+	if field.Condition != nil {
+		deps = append(deps, field.Condition.DependentFields()...)
+	}
+	if field.Computed != nil {
+		deps = append(deps, field.Computed.DependentFields()...)
+	}
+	return deps
+}
+
+// cycleError 字段之间的循环依赖
+type cycleError struct {
+	path []*FieldInfo
+}
+
+func (e *cycleError) Error() string {
+	names := make([]string, len(e.path))
+	for i, field := range e.path {
+		names[i] = field.StructField
+	}
+	// 只保留环上的字段
+	last := e.path[len(e.path)-1]
+	for i, field := range e.path {
+		if field == last {
+			names = names[i:]
+			break
+		}
+	}
+	return fmt.Sprintf("字段循环依赖: %s", strings.Join(names, " -> "))
+}
+
+func (e *cycleError) Unwrap() error {
+	return ErrInvalidCondition
+}
+
+// mapComputedField 计算字段值，结果按字段类型转换（如 int 字段要求结果为整数）
+func (m *StructMapper[T]) mapComputedField(result reflect.Value, field *FieldInfo, ctx *EvalContext) error {
+	if field.Condition != nil {
+		shouldLoad, err := field.Condition.Evaluate(ctx)
+		if err != nil {
+			return NewConfigError("", 0, 0, field.Name,
+				fmt.Sprintf("评估条件 '%s' 失败 (上下文字段: %v): %v", field.ConditionStr, ctx.Values, err), err)
+		}
+		if !shouldLoad {
+			return nil
+		}
+	}
+
+	val, err := evalValue(ctx, field.Computed)
+	if err != nil {
+		return NewConfigError("", 0, 0, field.Name,
+			fmt.Sprintf("计算 '%s' 失败 (上下文字段: %v): %v", field.ComputedStr, ctx.Values, err), err)
+	}
+	if val == nil {
+		return nil
+	}
+
+	valueStr := formatValue(val)
+	value, err := convertValue(valueStr, field.Type)
+	if err != nil {
+		return NewConfigError("", 0, 0, field.Name,
+			fmt.Sprintf("计算结果 %q 无法转换为 %v 类型", valueStr, field.Type), ErrTypeMismatch)
+	}
+
+	result.Field(field.Index).Set(value)
+	ctx.MarkResolved(field.Name)
+	ctx.SetValue(field.Name, value.Interface())
+	return nil
+}
+
 // MapRows 将多行数据映射到结构体切片
 func (m *StructMapper[T]) MapRows(headers []string, rows [][]string) ([]T, error) {
 	results := make([]T, 0, len(rows))
@@ -264,8 +471,10 @@ func (m *StructMapper[T]) GetFieldInfo(excelName string) (*FieldInfo, bool) {
 // 格式: excel:"name,opt1,opt2:value" 或 excel:"name,when:condition,opt2:value"
 func parseFieldTag(tag string) (string, map[string]string) {
 	// 去掉 `excel:"` 前缀和 `"` 后缀
-	tag = strings.TrimPrefix(tag, `excel:"`)
-	tag = strings.TrimSuffix(tag, `"`)
+	if strings.HasPrefix(tag, `excel:"`) {
+		tag = strings.TrimPrefix(tag, `excel:"`)
+		tag = strings.TrimSuffix(tag, `"`)
+	}
 
 	if tag == "-" {
 		return "-", map[string]string{"-": ""}
@@ -282,85 +491,83 @@ func parseFieldTag(tag string) (string, map[string]string) {
 
 	// 解析选项
 	options := make(map[string]string)
+	for _, option := range splitTagOptions(remaining) {
+		kv := strings.SplitN(option, ":", 2)
+		if len(kv) == 1 {
+			options[kv[0]] = ""
+		} else {
+			options[kv[0]] = kv[1]
+		}
+	}
 
-	// 逐个解析选项
-	for len(remaining) > 0 {
-		// 检查是否是 when: 标签
-		if strings.HasPrefix(remaining, "when:") {
-			// 找到 when: 标签，提取完整的条件表达式
-			// 条件表达式可能包含逗号（如 between 操作符），需要智能解析
-			whenExpr := strings.TrimPrefix(remaining, "when:")
-
-			// 分析 when 表达式的结构
-			depth := 0   // 括号深度
-			inString := false
-			endPos := -1
-
-			for i := 0; i < len(whenExpr); i++ {
-				ch := whenExpr[i]
-
-				if ch == '"' {
-					inString = !inString
-				}
+	return name, options
+}
 
-				if !inString {
-					switch ch {
-					case '[':
-						depth++
-					case ']':
-						depth--
-					case ',':
-						// 找到逗号，检查是否在括号内
-						if depth == 0 {
-							// 这是条件表达式的结束位置
-							endPos = i
-							// 检查后续是否有其他选项（如 default）
-							rest := whenExpr[i+1:]
-							if restIdx := strings.Index(rest, ","); restIdx >= 0 {
-								// 有其他选项，只取条件表达式
-								whenExpr = whenExpr[:i]
-								remaining = rest[restIdx+1:]
-							}
-							break
-						}
-					}
-				}
+// exprOptions 值为表达式的选项，表达式中可以包含逗号
+var exprOptions = []string{"when:", "computed:"}
+
+// splitTagOptions 按逗号拆分 tag 选项
+// 括号、方括号和字符串内的逗号不拆分；表达式选项（when、computed）中的逗号
+// 只有后面紧跟另一个已知选项（如 default:0、required）时才作为分隔符，
+// 因此 when:level between 1,10 和 when:level between 1,max_level 中的逗号属于表达式
+func splitTagOptions(s string) []string {
+	var options []string
+	start := 0
+	depth := 0
+	inString := false
+
+	isExpr := func(option string) bool {
+		for _, prefix := range exprOptions {
+			if strings.HasPrefix(option, prefix) {
+				return true
 			}
+		}
+		return false
+	}
 
-			if endPos < 0 {
-				// 没有找到结束逗号，整个 remaining 都是条件表达式
-				options["when"] = whenExpr
-				remaining = ""
-			} else {
-				options["when"] = whenExpr
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch == '"' {
+			inString = !inString
+		}
+		if inString {
+			continue
+		}
+
+		switch ch {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth > 0 {
+				continue
 			}
-		} else {
-			// 找到下一个逗号
-			nextComma := strings.Index(remaining, ",")
-			if nextComma < 0 {
-				// 没有更多逗号，整个剩余部分是一个选项
-				kv := strings.SplitN(remaining, ":", 2)
-				if len(kv) == 1 {
-					options[kv[0]] = ""
-				} else {
-					options[kv[0]] = kv[1]
-				}
-				remaining = ""
-			} else {
-				// 提取一个选项
-				option := remaining[:nextComma]
-				kv := strings.SplitN(option, ":", 2)
-				if len(kv) == 1 {
-					options[kv[0]] = ""
-				} else {
-					options[kv[0]] = kv[1]
-				}
-				remaining = remaining[nextComma+1:]
+			if isExpr(s[start:i]) && !startsWithTagOption(s[i+1:]) {
+				continue
 			}
+			options = append(options, s[start:i])
+			start = i + 1
 		}
 	}
+	if start < len(s) {
+		options = append(options, s[start:])
+	}
+	return options
+}
 
-	return name, options
+// tagOptionNames 已知的 tag 选项
+var tagOptionNames = []string{"required", "default", "when", "computed", "i18n", "enum"}
+
+// startsWithTagOption 判断文本是否以已知选项开头（name、name:value）
+// 表达式中的标识符（如 between 1,max_level 中的 max_level）不视为选项
+func startsWithTagOption(s string) bool {
+	for _, name := range tagOptionNames {
+		if rest, ok := strings.CutPrefix(s, name); ok && (rest == "" || rest[0] == ':' || rest[0] == ',') {
+			return true
+		}
+	}
+	return false
 }
 
 // convertValue 将字符串值转换为目标类型
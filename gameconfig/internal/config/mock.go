@@ -0,0 +1,373 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mockAttempts 单行生成失败（如计算字段出错）时的最大重试次数
+const mockAttempts = 100
+
+// defaultMockLanguages i18n 字段默认生成的语言列
+var defaultMockLanguages = []string{"zh", "en"}
+
+// mockWords 生成字符串字段使用的词
+var mockWords = []string{"铁剑", "长弓", "法杖", "盾牌", "药水", "宝石", "火焰", "冰霜", "雷电", "暗影", "圣光", "疾风"}
+
+// GenerateMock 根据 struct tag 生成测试数据（表头行 + n 行数据），可直接用于 SetMockData
+// 相同的 seed 生成相同的数据；生成的每一行都经过 StructMapper.MapRow 校验
+func GenerateMock[T any](n int, seed int64) ([][]string, error) {
+	return GenerateMockWith[T](n, seed, LoadOptions{})
+}
+
+// GenerateMockWith 根据 struct tag 生成测试数据，使用 options 中的枚举字典和多语言设置:
+//   - 遵守 required、default、字段类型，指针字段可能为空
+//   - 条件字段依赖的字段优先取条件中出现的值，使条件两种结果都能覆盖到；条件不满足时单元格为空
+//   - 第一个字段为整数或字符串时作为主键，按行号生成不重复的值
+//   - enum 字段从 options.Enums 中取值（位标志字典会生成 火|水 组合）
+//   - i18n 字段按 options.I18n.Languages 生成语言列，i18n:key 字段从 options.I18n.StringTable 中取文本 ID
+//   - computed 字段不生成列
+func GenerateMockWith[T any](n int, seed int64, options LoadOptions) ([][]string, error) {
+	mapper := NewStructMapper[T]()
+	mapper.SetI18n(options.I18n)
+	mapper.SetFuncs(options.Funcs)
+	mapper.SetEnums(options.Enums)
+
+	g, err := newMockGenerator(mapper, options, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, n+1)
+	rows = append(rows, g.headers)
+	for i := 0; i < n; i++ {
+		row, err := g.row(i)
+		if err != nil {
+			return nil, fmt.Errorf("生成第 %d 行测试数据失败: %w", i+1, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// mockGenerator 测试数据生成器
+type mockGenerator[T any] struct {
+	mapper    *StructMapper[T]
+	rand      *rand.Rand
+	languages []string
+	textIDs   []string
+
+	headers []string
+	colIdx  map[string]int           // excel tag -> 列索引（i18n 后缀字段为第一个语言列）
+	fields  []*FieldInfo             // 按生成顺序：无条件字段在前，条件字段按依赖顺序
+	key     *FieldInfo               // 主键字段
+	drivers map[string]bool          // 被表达式引用的字段（不能为空）
+	hints   map[string][]interface{} // 字段 -> 表达式中与之比较的值
+}
+
+func newMockGenerator[T any](mapper *StructMapper[T], options LoadOptions, seed int64) (*mockGenerator[T], error) {
+	dependent, err := mapper.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &mockGenerator[T]{
+		mapper:    mapper,
+		rand:      rand.New(rand.NewSource(seed)),
+		languages: options.I18n.Languages,
+		colIdx:    make(map[string]int),
+		drivers:   make(map[string]bool),
+		hints:     make(map[string][]interface{}),
+	}
+	if len(g.languages) == 0 {
+		g.languages = defaultMockLanguages
+	}
+	if table := options.I18n.StringTable; table != nil {
+		for id := range table.texts {
+			g.textIDs = append(g.textIDs, id)
+		}
+		sort.Strings(g.textIDs)
+	}
+
+	// 表头按结构体字段顺序
+	var columns []*FieldInfo
+	for _, field := range mapper.fields {
+		if field.Computed == nil {
+			columns = append(columns, field)
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Index < columns[j].Index })
+	for _, field := range columns {
+		g.colIdx[field.Name] = len(g.headers)
+		if field.I18n == i18nModeSuffix {
+			for _, lang := range g.languages {
+				g.headers = append(g.headers, field.Name+"_"+lang)
+			}
+			continue
+		}
+		g.headers = append(g.headers, field.Name)
+	}
+	if len(columns) > 0 && columns[0].I18n == "" && columns[0].Condition == nil {
+		if _, isEnum := columns[0].Options["enum"]; !isEnum {
+			switch kind := derefType(columns[0].Type).Kind(); {
+			case kind == reflect.String, isIntegerKind(columns[0].Type):
+				g.key = columns[0]
+			}
+		}
+	}
+
+	for _, field := range columns {
+		if field.Condition == nil {
+			g.fields = append(g.fields, field)
+		}
+	}
+	for _, field := range dependent {
+		g.collectHints(field.Condition)
+		g.collectHints(field.Computed)
+		if field.Computed == nil {
+			g.fields = append(g.fields, field)
+		}
+	}
+	return g, nil
+}
+
+// derefType 去掉指针
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// collectHints 收集表达式引用的字段和与之比较的字面量
+func (g *mockGenerator[T]) collectHints(cond Condition) {
+	if cond == nil {
+		return
+	}
+	for _, name := range cond.DependentFields() {
+		g.drivers[name] = true
+	}
+
+	switch c := cond.(type) {
+	case *BinaryOp:
+		field, other := c.Left, c.Right
+		if _, ok := field.(*FieldRef); !ok {
+			field, other = other, field
+		}
+		if ref, ok := field.(*FieldRef); ok {
+			switch lit := other.(type) {
+			case *Literal:
+				g.hints[ref.FieldName] = append(g.hints[ref.FieldName], lit.Value)
+				// > < 比较在边界两侧各取一个值
+				if num, ok := lit.Value.(float64); ok && c.Operator != "=" && c.Operator != "==" && c.Operator != "!=" {
+					g.hints[ref.FieldName] = append(g.hints[ref.FieldName], num-1, num+1)
+				}
+			case *ListLiteral:
+				g.hints[ref.FieldName] = append(g.hints[ref.FieldName], lit.Values...)
+			}
+		}
+		g.collectHints(c.Left)
+		g.collectHints(c.Right)
+	case *UnaryOp:
+		g.collectHints(c.Operand)
+	case *BetweenOp:
+		if ref, ok := c.Field.(*FieldRef); ok {
+			g.hints[ref.FieldName] = append(g.hints[ref.FieldName], c.Range.Min, c.Range.Max)
+		}
+		g.collectHints(c.Field)
+	}
+}
+
+// row 生成一行数据，MapRow 校验失败时重新生成
+func (g *mockGenerator[T]) row(index int) ([]string, error) {
+	var lastErr error
+	for attempt := 0; attempt < mockAttempts; attempt++ {
+		row, err := g.tryRow(index)
+		if err != nil {
+			return nil, err
+		}
+		if _, lastErr = g.mapper.MapRow(g.headers, row); lastErr == nil {
+			return row, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// tryRow 按字段顺序生成单元格，条件字段根据已生成的值评估条件
+func (g *mockGenerator[T]) tryRow(index int) ([]string, error) {
+	row := make([]string, len(g.headers))
+	ctx := NewEvalContext()
+	ctx.Funcs = g.mapper.funcs
+
+	for _, field := range g.fields {
+		col := g.colIdx[field.Name]
+
+		if field.Condition != nil {
+			shouldLoad, err := field.Condition.Evaluate(ctx)
+			if err != nil || !shouldLoad {
+				continue
+			}
+		}
+
+		if field.I18n != "" {
+			if err := g.i18nCells(field, row[col:]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		text, err := g.cell(field, index)
+		if err != nil {
+			return nil, err
+		}
+		row[col] = text
+
+		if text == "" {
+			text = field.Options["default"]
+		}
+		if text != "" {
+			if _, ctxValue, _, err := g.mapper.convertField(field, text); err == nil {
+				ctx.MarkResolved(field.Name)
+				ctx.SetValue(field.Name, ctxValue)
+			}
+		}
+	}
+	return row, nil
+}
+
+// cell 生成单元格文字，可能为空（由默认值或零值代替）
+func (g *mockGenerator[T]) cell(field *FieldInfo, index int) (string, error) {
+	if field == g.key {
+		if derefType(field.Type).Kind() == reflect.String {
+			return fmt.Sprintf("%s_%d", field.Name, index+1), nil
+		}
+		return strconv.Itoa(index + 1), nil
+	}
+
+	_, required := field.Options["required"]
+	_, hasDefault := field.Options["default"]
+	if !required && (hasDefault || !g.drivers[field.Name]) {
+		empty := 10
+		if hasDefault || field.Type.Kind() == reflect.Ptr {
+			empty = 4
+		}
+		if g.rand.Intn(empty) == 0 {
+			return "", nil
+		}
+	}
+
+	if dictName, ok := field.Options["enum"]; ok {
+		return g.enumCell(field, dictName)
+	}
+
+	if hints := g.hints[field.Name]; len(hints) > 0 && g.rand.Intn(2) == 0 {
+		if text, ok := formatMockValue(hints[g.rand.Intn(len(hints))], field.Type); ok {
+			return text, nil
+		}
+	}
+	return g.randomValue(field)
+}
+
+// randomValue 按字段类型生成随机值
+func (g *mockGenerator[T]) randomValue(field *FieldInfo) (string, error) {
+	t := derefType(field.Type)
+	switch t.Kind() {
+	case reflect.String:
+		return mockWords[g.rand.Intn(len(mockWords))] + strconv.Itoa(g.rand.Intn(100)), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		limit := int64(1000)
+		if max := int64(1)<<(t.Bits()-1) - 1; max < limit {
+			limit = max
+		}
+		return strconv.FormatInt(g.rand.Int63n(2*limit+1)-limit, 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		limit := uint64(1000)
+		if max := uint64(1)<<t.Bits() - 1; max < limit {
+			limit = max
+		}
+		return strconv.FormatUint(uint64(g.rand.Int63n(int64(limit)+1)), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(math.Round(g.rand.Float64()*100000)/100, 'f', -1, 64), nil
+	case reflect.Bool:
+		return strconv.FormatBool(g.rand.Intn(2) == 0), nil
+	default:
+		return "", NewConfigError("", 0, 0, field.Name,
+			fmt.Sprintf("无法为 %v 类型的字段 '%s' 生成测试数据", field.Type, field.StructField), ErrTypeMismatch)
+	}
+}
+
+// enumCell 从字典中取值，位标志字典有一半概率组合两个值
+func (g *mockGenerator[T]) enumCell(field *FieldInfo, dictName string) (string, error) {
+	dict, ok := g.mapper.enums.Dict(dictName)
+	if !ok || len(dict.Values) == 0 {
+		return "", NewConfigError("", 0, 0, field.Name,
+			fmt.Sprintf("枚举字典 '%s' 不存在（LoadOptions.Enums）", dictName), ErrUnknownEnum)
+	}
+
+	// 条件中出现的枚举值
+	if hints := g.hints[field.Name]; len(hints) > 0 && g.rand.Intn(2) == 0 {
+		if text, ok := formatMockValue(hints[g.rand.Intn(len(hints))], reflect.TypeOf(int64(0))); ok {
+			n, _ := strconv.ParseInt(text, 10, 64)
+			if label, ok := dict.Label(n); ok {
+				return label, nil
+			}
+		}
+	}
+
+	first := dict.Values[g.rand.Intn(len(dict.Values))]
+	second := dict.Values[g.rand.Intn(len(dict.Values))]
+	if g.rand.Intn(2) == 0 && first.Value != second.Value && isFlag(first.Value) && isFlag(second.Value) {
+		return first.Label + "|" + second.Label, nil
+	}
+	return first.Label, nil
+}
+
+// isFlag 是否为单个二进制位
+func isFlag(value int64) bool {
+	return value > 0 && value&(value-1) == 0
+}
+
+// i18nCells 生成多语言字段的单元格（后缀模式为每种语言一列）
+func (g *mockGenerator[T]) i18nCells(field *FieldInfo, cells []string) error {
+	_, required := field.Options["required"]
+
+	if field.I18n == i18nModeKey {
+		if len(g.textIDs) == 0 {
+			if required {
+				return NewConfigError("", 0, 0, field.Name,
+					fmt.Sprintf("字段 '%s' 使用 i18n:key，需要在 LoadOptions.I18n.StringTable 中提供文本", field.Name), ErrInvalidFormat)
+			}
+			return nil
+		}
+		cells[0] = g.textIDs[g.rand.Intn(len(g.textIDs))]
+		return nil
+	}
+
+	word := mockWords[g.rand.Intn(len(mockWords))]
+	for i, lang := range g.languages {
+		// 非必填时偶尔缺少翻译
+		if i > 0 && g.rand.Intn(10) == 0 {
+			continue
+		}
+		cells[i] = fmt.Sprintf("%s_%s", word, lang)
+	}
+	return nil
+}
+
+// formatMockValue 将表达式中的字面量格式化为字段类型的单元格文字
+func formatMockValue(value interface{}, t reflect.Type) (string, bool) {
+	t = derefType(t)
+	text := fmt.Sprintf("%v", value)
+	if num, ok := value.(float64); ok && num == math.Trunc(num) {
+		text = strconv.FormatInt(int64(num), 10)
+	}
+	if _, err := convertValue(text, t); err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(text), true
+}
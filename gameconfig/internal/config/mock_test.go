@@ -1,6 +1,10 @@
 package config
 
 import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -553,3 +557,156 @@ func TestMemoryModeReload(t *testing.T) {
 		t.Errorf("Reload() 后 ID 不一致: %d vs %d", items1[0].ID, items2[0].ID)
 	}
 }
+
+type mockSkill struct {
+	ID       int      `excel:"id"`
+	Name     string   `excel:"name,required"`
+	Level    int      `excel:"level,default:1"`
+	Type     int      `excel:"type,required"`
+	Damage   int      `excel:"damage,required,when:type=1"`
+	Heal     *float64 `excel:"heal,when:type in [2,3]"`
+	Passive  bool     `excel:"passive"`
+	Cooldown uint8    `excel:"cooldown,when:level>5"`
+	Score    int      `excel:"score,computed:level*10"`
+}
+
+// TestGenerateMock 测试生成的数据可以加载，并覆盖条件字段的两种结果
+func TestGenerateMock(t *testing.T) {
+	rows, err := GenerateMock[mockSkill](200, 42)
+	if err != nil {
+		t.Fatalf("GenerateMock() error = %v", err)
+	}
+	wantHeaders := []string{"id", "name", "level", "type", "damage", "heal", "passive", "cooldown"}
+	if !reflect.DeepEqual(rows[0], wantHeaders) {
+		t.Errorf("headers = %v, want %v", rows[0], wantHeaders)
+	}
+
+	again, _ := GenerateMock[mockSkill](200, 42)
+	if !reflect.DeepEqual(rows, again) {
+		t.Error("same seed should generate same rows")
+	}
+	if other, _ := GenerateMock[mockSkill](200, 7); reflect.DeepEqual(rows, other) {
+		t.Error("different seeds should generate different rows")
+	}
+
+	loader := NewLoader[mockSkill]("", "skill", LoadOptions{Mode: ModeMemory})
+	loader.SetMockData(rows)
+	skills, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(skills) != 200 {
+		t.Fatalf("Load() = %d rows, want 200", len(skills))
+	}
+
+	var damage, noDamage, heal, cooldown int
+	for i, skill := range skills {
+		if skill.ID != i+1 || skill.Name == "" || skill.Score != skill.Level*10 {
+			t.Fatalf("skills[%d] = %+v", i, skill)
+		}
+		if skill.Type == 1 {
+			damage++
+		} else {
+			noDamage++
+			if skill.Damage != 0 {
+				t.Errorf("skills[%d].Damage = %d, want 0 when type != 1", i, skill.Damage)
+			}
+		}
+		if skill.Heal != nil {
+			heal++
+		}
+		if skill.Cooldown != 0 {
+			cooldown++
+		}
+	}
+	if damage == 0 || noDamage == 0 || heal == 0 || cooldown == 0 {
+		t.Errorf("conditions not covered: damage=%d noDamage=%d heal=%d cooldown=%d", damage, noDamage, heal, cooldown)
+	}
+}
+
+// TestGenerateMockWith 测试枚举和多语言字段
+func TestGenerateMockWith(t *testing.T) {
+	type item struct {
+		Key     string          `excel:"key"`
+		Element Element         `excel:"element,enum:Element,required"`
+		Name    LocalizedString `excel:"name,i18n,required"`
+	}
+
+	options := LoadOptions{Mode: ModeMemory, Enums: newTestEnums(t), I18n: I18nOptions{Locale: "en", Languages: []string{"zh", "en", "ja"}}}
+	rows, err := GenerateMockWith[item](50, 1, options)
+	if err != nil {
+		t.Fatalf("GenerateMockWith() error = %v", err)
+	}
+	if got := strings.Join(rows[0], ","); got != "key,element,name_zh,name_en,name_ja" {
+		t.Errorf("headers = %s", got)
+	}
+	options.MockData = rows
+	items, err := NewLoader[item]("", "item", options).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if items[0].Key != "key_1" || items[0].Name.Text == "" {
+		t.Errorf("items[0] = %+v", items[0])
+	}
+	flags := 0
+	for i, row := range rows[1:] {
+		if strings.Contains(row[1], "|") {
+			flags++
+			if bits := items[i].Element; bits&(bits-1) == 0 {
+				t.Errorf("items[%d].Element = %d, want flag combination for %q", i, bits, row[1])
+			}
+		}
+	}
+	if flags == 0 {
+		t.Error("no flag combinations generated")
+	}
+
+	if _, err := GenerateMock[item](1, 1); !errors.Is(err, ErrUnknownEnum) {
+		t.Errorf("GenerateMock() without enums error = %v, want ErrUnknownEnum", err)
+	}
+
+	type unsupported struct {
+		Tags []string `excel:"tags,required"`
+	}
+	if _, err := GenerateMock[unsupported](1, 1); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("GenerateMock() error = %v, want ErrTypeMismatch", err)
+	}
+}
+
+// FuzzMapRow 基于生成数据的性质测试：生成的行总能映射，任意改写单元格时 MapRow 不崩溃且返回 ConfigError
+func FuzzMapRow(f *testing.F) {
+	f.Add(int64(1), 0, "")
+	f.Add(int64(2), 3, "1")
+	f.Add(int64(3), 4, "abc")
+	f.Add(int64(4), 2, "-99999999999999999999")
+	f.Add(int64(5), 7, "256")
+	f.Add(int64(6), math.MinInt, "x")
+
+	mapper := NewStructMapper[mockSkill]()
+	f.Fuzz(func(t *testing.T, seed int64, col int, text string) {
+		rows, err := GenerateMock[mockSkill](3, seed)
+		if err != nil {
+			t.Fatalf("GenerateMock(seed=%d) error = %v", seed, err)
+		}
+		headers := rows[0]
+		for _, row := range rows[1:] {
+			if _, err := mapper.MapRow(headers, row); err != nil {
+				t.Fatalf("MapRow(%v) error = %v", row, err)
+			}
+		}
+
+		row := append([]string(nil), rows[1]...)
+		// 先取模再取绝对值，避免 math.MinInt 取反后仍为负数
+		i := col % len(row)
+		if i < 0 {
+			i = -i
+		}
+		row[i] = text
+		if _, err := mapper.MapRow(headers, row); err != nil {
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) {
+				t.Errorf("MapRow(%v) error = %v, want *ConfigError", row, err)
+			}
+		}
+	})
+}
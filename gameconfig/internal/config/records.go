@@ -0,0 +1,402 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// recordFileSource JSON / YAML / TOML 文件数据源
+//
+// 文件内容为记录数组，每条记录的键对应表头（与 excel tag 相同）：
+//
+//	[{"id": 1, "name": "铁剑"}, {"id": 2, "name": "木盾"}]
+//
+// 顶层为对象时按 Sheet 名称取对应的数组，对象只有一个数组时直接使用它：
+//
+//	{"武器": [...], "防具": [...]}
+//
+// 数组元素也可以是数组，此时第一个元素为表头
+type recordFileSource struct {
+	path   string
+	sheet  string
+	format string
+	decode func(data []byte) (interface{}, error)
+}
+
+// NewJSONSource 创建 JSON 数据源
+func NewJSONSource(path string, sheetName string) Source {
+	return &recordFileSource{path: path, sheet: sheetName, format: "JSON", decode: decodeJSON}
+}
+
+// NewYAMLSource 创建 YAML 数据源
+func NewYAMLSource(path string, sheetName string) Source {
+	return &recordFileSource{path: path, sheet: sheetName, format: "YAML", decode: decodeYAML}
+}
+
+// NewTOMLSource 创建 TOML 数据源
+// TOML 顶层必须是表，记录使用表数组：[["武器"]]
+func NewTOMLSource(path string, sheetName string) Source {
+	return &recordFileSource{path: path, sheet: sheetName, format: "TOML", decode: decodeTOML}
+}
+
+func (s *recordFileSource) records() {}
+
+func (s *recordFileSource) Open() (RowIterator, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, s.path)
+	}
+
+	doc, err := s.decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", s.format, err)
+	}
+
+	list, err := selectRecords(doc, s.sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := recordRows(list)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", s.format, err)
+	}
+	return NewSliceRowIterator(rows), nil
+}
+
+// object 保留键顺序的对象
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]interface{})}
+}
+
+func (o *object) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// MarshalJSON 按原始键顺序输出
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// selectRecords 从文件内容中取出记录数组
+func selectRecords(doc interface{}, sheet string) ([]interface{}, error) {
+	switch v := doc.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		return v, nil
+	case *object:
+		if list, ok := v.values[sheet]; ok {
+			return asList(list, sheet)
+		}
+		var arrays []string
+		for _, key := range v.keys {
+			if _, ok := v.values[key].([]interface{}); ok {
+				arrays = append(arrays, key)
+			}
+		}
+		if len(arrays) == 1 {
+			return asList(v.values[arrays[0]], arrays[0])
+		}
+		return nil, fmt.Errorf("%w: Sheet '%s' 不存在", ErrSheetNotFound, sheet)
+	default:
+		return nil, fmt.Errorf("顶层必须是数组或对象")
+	}
+}
+
+func asList(value interface{}, name string) ([]interface{}, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'%s' 不是数组", name)
+	}
+	return list, nil
+}
+
+// recordRows 将记录转换为行数据，第一行为表头
+// 表头为所有记录键的并集，按首次出现的顺序排列；记录中缺少的键为空单元格
+func recordRows(list []interface{}) ([][]string, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	// 数组的数组：第一个元素为表头
+	if _, ok := list[0].([]interface{}); ok {
+		rows := make([][]string, len(list))
+		for i, item := range list {
+			cells, ok := item.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("第 %d 条记录不是数组", i+1)
+			}
+			rows[i] = make([]string, len(cells))
+			for j, cell := range cells {
+				rows[i][j] = cellString(cell)
+			}
+		}
+		return rows, nil
+	}
+
+	var headers []string
+	columns := make(map[string]int)
+	for i, item := range list {
+		record, ok := item.(*object)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 条记录不是对象", i+1)
+		}
+		for _, key := range record.keys {
+			if _, ok := columns[key]; !ok {
+				columns[key] = len(headers)
+				headers = append(headers, key)
+			}
+		}
+	}
+
+	rows := make([][]string, 0, len(list)+1)
+	rows = append(rows, headers)
+	for _, item := range list {
+		record := item.(*object)
+		row := make([]string, len(headers))
+		for key, value := range record.values {
+			row[columns[key]] = cellString(value)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// cellString 将记录中的值转换为单元格文本
+// 数组和对象转换为 JSON 文本
+func cellString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return formatValue(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []interface{}, *object:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// decodeJSON 解析 JSON，对象保留键顺序
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	value, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("JSON 值之后有多余的内容")
+	}
+	return value, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := newObject()
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(keyTok.(string), value)
+		}
+		_, err = dec.Token()
+		return obj, err
+	case '[':
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	default:
+		return nil, fmt.Errorf("意外的分隔符 %v", delim)
+	}
+}
+
+// decodeYAML 解析 YAML，对象保留键顺序
+func decodeYAML(data []byte) (interface{}, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return convertYAMLNode(&node)
+}
+
+func convertYAMLNode(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return convertYAMLNode(node.Content[0])
+	case yaml.MappingNode:
+		obj := newObject()
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := convertYAMLNode(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			obj.set(node.Content[i].Value, value)
+		}
+		return obj, nil
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := convertYAMLNode(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case yaml.AliasNode:
+		return convertYAMLNode(node.Alias)
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", node.Line, err)
+		}
+		return value, nil
+	default:
+		return nil, nil
+	}
+}
+
+// decodeTOML 解析 TOML，表按键在文件中出现的顺序排列
+func decodeTOML(data []byte) (interface{}, error) {
+	var doc map[string]interface{}
+	md, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make(map[string]int)
+	for i, key := range md.Keys() {
+		path := strings.Join(key, "\x00")
+		if _, ok := order[path]; !ok {
+			order[path] = i
+		}
+	}
+	return convertTOMLValue(doc, nil, order), nil
+}
+
+func convertTOMLValue(value interface{}, path []string, order map[string]int) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		position := func(key string) int {
+			if i, ok := order[strings.Join(append(path[:len(path):len(path)], key), "\x00")]; ok {
+				return i
+			}
+			return len(order)
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			pi, pj := position(keys[i]), position(keys[j])
+			if pi != pj {
+				return pi < pj
+			}
+			return keys[i] < keys[j]
+		})
+
+		obj := newObject()
+		for _, key := range keys {
+			obj.set(key, convertTOMLValue(v[key], append(path[:len(path):len(path)], key), order))
+		}
+		return obj
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = convertTOMLValue(item, path, order)
+		}
+		return list
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = convertTOMLValue(item, path, order)
+		}
+		return list
+	default:
+		return v
+	}
+}
@@ -0,0 +1,375 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sheetTable 一张 Sheet 的表头和数据行
+type sheetTable struct {
+	sheet   string
+	headers []string
+	columns map[string]int
+	rows    [][]string
+}
+
+// cell 获取单元格值
+func (t *sheetTable) cell(row []string, column string) string {
+	col, ok := t.columns[column]
+	if !ok || col >= len(row) {
+		return ""
+	}
+	return row[col]
+}
+
+// rowOrigin 合并后的数据行来自哪张 Sheet 的第几个数据行
+type rowOrigin struct {
+	sheet string
+	row   int // 从 1 开始
+}
+
+// sheetsSource 多张 Sheet 合并后的数据源
+// 第一行为表头，之后为数据行，记录每个数据行的来源用于错误定位
+type sheetsSource struct {
+	rows    [][]string
+	origins []rowOrigin
+}
+
+func (s *sheetsSource) Open() (RowIterator, error) {
+	return NewSliceRowIterator(s.rows), nil
+}
+
+func (s *sheetsSource) records() {}
+
+// locate 将合并后的数据行号（从 1 开始）转换为原 Sheet 的位置
+func (s *sheetsSource) locate(err error) error {
+	configErr, ok := err.(*ConfigError)
+	if !ok || configErr.Row <= 0 || configErr.Row > len(s.origins) {
+		return err
+	}
+	origin := s.origins[configErr.Row-1]
+	configErr.File = origin.sheet
+	configErr.Row = origin.row
+	return configErr
+}
+
+// locateError 多 Sheet 数据源的错误定位到原 Sheet
+func locateError(src Source, err error) error {
+	if s, ok := src.(*sheetsSource); ok {
+		return s.locate(err)
+	}
+	return err
+}
+
+// multiSheet 是否需要合并多张 Sheet
+func (l *Loader[T]) multiSheet() bool {
+	return len(l.options.Sheets) > 0 || l.options.Extends != ""
+}
+
+// sheetLoader 返回读取单张 Sheet 的加载器（共享映射器）
+func (l *Loader[T]) sheetLoader(sheet string) *Loader[T] {
+	options := l.options
+	options.Sheets = nil
+	options.Extends = ""
+	basePath := l.basePath
+	if sourceExts[modeByExt(basePath)] != nil {
+		// 文本文件只用于定位数据目录
+		basePath = l.sourceDir()
+	}
+	return &Loader[T]{
+		basePath:  basePath,
+		sheetName: sheet,
+		options:   options,
+		mapper:    l.mapper,
+	}
+}
+
+// resolveSheets 按 Sheets 中的模式（支持 * ? [] 通配符）展开 Sheet 列表
+// 模式按顺序展开，同一模式匹配的 Sheet 按工作簿中的顺序（文本文件按文件名）排列，重复的 Sheet 只取一次
+// Sheets 为空时返回 sheetName
+func (l *Loader[T]) resolveSheets(mode Mode) ([]string, error) {
+	if len(l.options.Sheets) == 0 {
+		return []string{l.sheetName}, nil
+	}
+
+	names, err := l.sheetNames(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	var sheets []string
+	seen := make(map[string]bool)
+	for _, pattern := range l.options.Sheets {
+		matched := false
+		for _, name := range names {
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, fmt.Errorf("%w: Sheet 模式 '%s' 无效: %v", ErrInvalidFormat, pattern, err)
+			}
+			if !ok {
+				continue
+			}
+			matched = true
+			if !seen[name] && name != l.options.Extends {
+				seen[name] = true
+				sheets = append(sheets, name)
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("%w: 没有与 '%s' 匹配的 Sheet", ErrSheetNotFound, pattern)
+		}
+	}
+	return sheets, nil
+}
+
+// sheetNames 列出数据源中所有的 Sheet
+// Excel 为工作簿中的 Sheet，文本格式为目录下同一扩展名的文件
+func (l *Loader[T]) sheetNames(mode Mode) ([]string, error) {
+	if mode == ModeExcel {
+		reader, err := NewExcelReader(l.basePath)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return reader.GetSheetNames(), nil
+	}
+
+	exts := sourceExts[mode]
+	if len(exts) == 0 {
+		return nil, fmt.Errorf("%s 模式不支持多 Sheet 加载", mode)
+	}
+
+	dir := l.sourceDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, dir)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(entry.Name())
+		for _, e := range exts {
+			if strings.EqualFold(ext, e) {
+				names = append(names, strings.TrimSuffix(entry.Name(), ext))
+				break
+			}
+		}
+	}
+	return names, nil
+}
+
+// readSheet 读取单张 Sheet 的表头和数据行
+func (l *Loader[T]) readSheet(mode Mode, sheet string) (*sheetTable, error) {
+	loader := l.sheetLoader(sheet)
+	src, err := loader.source(mode)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := readSource(src)
+	if err != nil {
+		return nil, fmt.Errorf("读取 Sheet '%s' 失败: %w", sheet, err)
+	}
+
+	table := &sheetTable{sheet: sheet, columns: make(map[string]int)}
+	if len(rows) == 0 {
+		return table, nil
+	}
+	layout := loader.layout(src, rows)
+	if len(rows) <= layout.headerRow {
+		return nil, fmt.Errorf("Sheet '%s' 数据行数不足", sheet)
+	}
+	table.headers = rows[layout.headerRow]
+	for i, header := range table.headers {
+		if _, ok := table.columns[header]; header != "" && !ok {
+			table.columns[header] = i
+		}
+	}
+	if layout.dataStart < len(rows) {
+		table.rows = rows[layout.dataStart:]
+	}
+	return table, nil
+}
+
+// sheetsSource 读取并合并多张 Sheet
+//
+// Sheets 中的各 Sheet 按顺序拼接，表头（列的集合）必须相同；
+// 设置 Extends 时先读取基础 Sheet，再用拼接结果按主键覆盖：
+// 同主键的行用子 Sheet 中非空的单元格覆盖，空单元格和子 Sheet 中没有的列沿用基础 Sheet，
+// 新主键的行追加在末尾
+// 同一侧（基础 Sheet 或拼接结果）中主键重复时返回 ErrDuplicateKey
+func (l *Loader[T]) sheetsSource(mode Mode) (Source, error) {
+	sheets, err := l.resolveSheets(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*sheetTable
+	for _, sheet := range sheets {
+		table, err := l.readSheet(mode, sheet)
+		if err != nil {
+			return nil, err
+		}
+		if len(table.headers) == 0 {
+			continue
+		}
+		if len(tables) > 0 {
+			if err := sameColumns(tables[0], table); err != nil {
+				return nil, err
+			}
+		}
+		tables = append(tables, table)
+	}
+
+	var base *sheetTable
+	if l.options.Extends != "" {
+		if base, err = l.readSheet(mode, l.options.Extends); err != nil {
+			return nil, err
+		}
+	}
+
+	// 确定表头和主键
+	var first *sheetTable
+	switch {
+	case base != nil && len(base.headers) > 0:
+		first = base
+	case len(tables) > 0:
+		first = tables[0]
+	default:
+		return &sheetsSource{}, nil
+	}
+	key := l.options.Key
+	if key == "" {
+		key = first.headers[0]
+	}
+	if _, ok := first.columns[key]; !ok {
+		return nil, fmt.Errorf("%w: 主键列 '%s' 不存在", ErrInvalidFormat, key)
+	}
+
+	merged := &sheetsSource{rows: [][]string{first.headers}}
+	keys := make(map[string]int) // 主键 -> 合并后的数据行索引
+	add := func(table *sheetTable, idx int) {
+		row := make([]string, len(first.headers))
+		for i, header := range first.headers {
+			row[i] = table.cell(table.rows[idx], header)
+		}
+		if k := row[first.columns[key]]; k != "" {
+			keys[k] = len(merged.origins)
+		}
+		merged.rows = append(merged.rows, row)
+		merged.origins = append(merged.origins, rowOrigin{sheet: table.sheet, row: idx + 1})
+	}
+
+	if base != nil {
+		if err := checkDuplicateKeys([]*sheetTable{base}, key); err != nil {
+			return nil, err
+		}
+		for i := range base.rows {
+			add(base, i)
+		}
+	}
+	if err := checkDuplicateKeys(tables, key); err != nil {
+		return nil, err
+	}
+
+	for _, table := range tables {
+		if base != nil {
+			for header := range table.columns {
+				if _, ok := base.columns[header]; !ok {
+					return nil, fmt.Errorf("%w: Sheet '%s' 的列 '%s' 在基础 Sheet '%s' 中不存在",
+						ErrInvalidFormat, table.sheet, header, base.sheet)
+				}
+			}
+		}
+
+		for i, row := range table.rows {
+			idx, ok := keys[table.cell(row, key)]
+			if base == nil || !ok {
+				add(table, i)
+				continue
+			}
+			// 覆盖基础 Sheet 的行，空单元格继承基础值
+			for header := range table.columns {
+				if cell := table.cell(row, header); cell != "" {
+					merged.rows[idx+1][first.columns[header]] = cell
+				}
+			}
+			merged.origins[idx] = rowOrigin{sheet: table.sheet, row: i + 1}
+		}
+	}
+	return merged, nil
+}
+
+// sameColumns 检查两张 Sheet 的列相同（顺序可以不同）
+func sameColumns(a, b *sheetTable) error {
+	var problems []string
+	for header := range b.columns {
+		if _, ok := a.columns[header]; !ok {
+			problems = append(problems, "多出列 "+header)
+		}
+	}
+	for header := range a.columns {
+		if _, ok := b.columns[header]; !ok {
+			problems = append(problems, "缺少列 "+header)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: Sheet '%s' 的表头与 '%s' 不一致: %s",
+			ErrInvalidFormat, b.sheet, a.sheet, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// checkDuplicateKeys 检查多张 Sheet 之间（及各自内部）的重复主键
+func checkDuplicateKeys(tables []*sheetTable, key string) error {
+	seen := make(map[string]rowOrigin)
+	for _, table := range tables {
+		col := table.columns[key]
+		for i, row := range table.rows {
+			k := table.cell(row, key)
+			if k == "" {
+				continue
+			}
+			if prev, ok := seen[k]; ok {
+				return NewConfigError(table.sheet, i+1, col+1, key,
+					fmt.Sprintf("主键 '%s' 重复（Sheet '%s' 第 %d 行）", k, prev.sheet, prev.row), ErrDuplicateKey)
+			}
+			seen[k] = rowOrigin{sheet: table.sheet, row: i + 1}
+		}
+	}
+	return nil
+}
+
+// SourcePaths 返回当前模式下实际读取的所有文件
+// 多 Sheet 的文本格式返回每张 Sheet 的文件，其余情况与 SourcePath 相同
+func (l *Loader[T]) SourcePaths() []string {
+	mode := l.options.Mode
+	if mode == ModeAuto {
+		mode = l.detectMode()
+	}
+
+	if l.multiSheet() && sourceExts[mode] != nil {
+		sheets, err := l.resolveSheets(mode)
+		if err == nil {
+			if l.options.Extends != "" {
+				sheets = append([]string{l.options.Extends}, sheets...)
+			}
+			paths := make([]string, len(sheets))
+			for i, sheet := range sheets {
+				paths[i] = l.sheetLoader(sheet).sourcePath(mode)
+			}
+			return paths
+		}
+	}
+
+	if path := l.SourcePath(); path != "" {
+		return []string{path}
+	}
+	return nil
+}
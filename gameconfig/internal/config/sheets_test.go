@@ -0,0 +1,183 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type sheetItem struct {
+	ID   int    `excel:"id"`
+	Name string `excel:"name,required"`
+	Atk  int    `excel:"atk,default:1"`
+}
+
+// newSheetsWorkbook 创建多 Sheet 工作簿，返回文件路径
+func newSheetsWorkbook(t *testing.T, sheets map[string][][]interface{}) string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	names := make([]string, 0, len(sheets))
+	for name := range sheets {
+		names = append(names, name)
+	}
+	// 工作簿中的 Sheet 按名称排序
+	sort.Strings(names)
+	for _, name := range names {
+		rows := sheets[name]
+		if _, err := f.NewSheet(name); err != nil {
+			t.Fatalf("NewSheet() error = %v", err)
+		}
+		for i, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err := f.SetSheetRow(name, cell, &row); err != nil {
+				t.Fatalf("SetSheetRow() error = %v", err)
+			}
+		}
+	}
+	path := filepath.Join(t.TempDir(), "items.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	return path
+}
+
+// TestSheets_Concat 测试按通配符拼接多张 Sheet
+func TestSheets_Concat(t *testing.T) {
+	path := newSheetsWorkbook(t, map[string][][]interface{}{
+		"武器_1": {{"id", "name", "atk"}, {1, "铁剑", 10}},
+		"武器_2": {{"name", "id", "atk"}, {"长弓", 2, 8}}, // 列顺序不同
+		"防具":   {{"id", "name", "atk"}, {3, "木盾", 0}},
+	})
+
+	loader := NewLoader[sheetItem](path, "装备", LoadOptions{Sheets: []string{"武器_*", "防具", "武器_1"}})
+	got, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []sheetItem{{1, "铁剑", 10}, {2, "长弓", 8}, {3, "木盾", 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+	if streamed := collect(t, loader); !reflect.DeepEqual(streamed, want) {
+		t.Errorf("Iterate() = %+v, want %+v", streamed, want)
+	}
+
+	_, err = NewLoader[sheetItem](path, "装备", LoadOptions{Sheets: []string{"护甲*"}}).Load()
+	if !errors.Is(err, ErrSheetNotFound) {
+		t.Errorf("Load() error = %v, want ErrSheetNotFound", err)
+	}
+}
+
+// TestSheets_TextDir 测试文本格式按目录下的文件拼接
+func TestSheets_TextDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "monster_a.csv", "id,name,atk\n1,史莱姆,3\n")
+	writeFile(t, dir, "monster_b.csv", "id,name,atk\n2,巨龙,\n")
+	writeFile(t, dir, "drop.csv", "id,item\n1,金币\n")
+
+	loader := NewLoader[sheetItem](dir, "monster", LoadOptions{Mode: ModeCSV, Sheets: []string{"monster_*"}})
+	got, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []sheetItem{{1, "史莱姆", 3}, {2, "巨龙", 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	paths := loader.SourcePaths()
+	wantPaths := []string{filepath.Join(dir, "monster_a.csv"), filepath.Join(dir, "monster_b.csv")}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("SourcePaths() = %v, want %v", paths, wantPaths)
+	}
+}
+
+// TestSheets_Extends 测试子 Sheet 按主键覆盖基础 Sheet
+func TestSheets_Extends(t *testing.T) {
+	path := newSheetsWorkbook(t, map[string][][]interface{}{
+		"base":  {{"id", "name", "atk"}, {1, "铁剑", 10}, {2, "长弓", 8}},
+		"event": {{"id", "atk"}, {2, 80}, {3, ""}},
+	})
+
+	got, err := NewLoader[sheetItem](path, "event", LoadOptions{Extends: "base"}).Load()
+	if err == nil {
+		t.Fatalf("Load() = %+v, want required error for new row without name", got)
+	}
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.File != "event" || configErr.Row != 2 {
+		t.Errorf("Load() error = %v, want located at event row 2", err)
+	}
+
+	path = newSheetsWorkbook(t, map[string][][]interface{}{
+		"base":  {{"id", "name", "atk"}, {1, "铁剑", 10}, {2, "长弓", 8}},
+		"event": {{"id", "name", "atk"}, {2, "神弓", 80}, {3, "活动剑", ""}},
+	})
+	got, err = NewLoader[sheetItem](path, "event", LoadOptions{Extends: "base"}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []sheetItem{{1, "铁剑", 10}, {2, "神弓", 80}, {3, "活动剑", 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	// 子 Sheet 只覆盖自己的列
+	path = newSheetsWorkbook(t, map[string][][]interface{}{
+		"base":  {{"id", "name", "atk"}, {1, "铁剑", 10}},
+		"patch": {{"id", "atk"}, {1, 12}},
+		"bad":   {{"id", "def"}, {1, 12}},
+	})
+	got, err = NewLoader[sheetItem](path, "patch", LoadOptions{Extends: "base"}).Load()
+	if err != nil || !reflect.DeepEqual(got, []sheetItem{{1, "铁剑", 12}}) {
+		t.Errorf("Load() = %+v, %v", got, err)
+	}
+	_, err = NewLoader[sheetItem](path, "bad", LoadOptions{Extends: "base"}).Load()
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Load() error = %v, want ErrInvalidFormat", err)
+	}
+
+	// 子 Sheet 的空单元格继承基础 Sheet 的值
+	path = newSheetsWorkbook(t, map[string][][]interface{}{
+		"base":  {{"id", "name", "atk"}, {1, "铁剑", 10}, {2, "长弓", 8}},
+		"patch": {{"id", "name", "atk"}, {1, "", 12}, {2, "神弓", ""}},
+	})
+	got, err = NewLoader[sheetItem](path, "patch", LoadOptions{Extends: "base"}).Load()
+	want = []sheetItem{{1, "铁剑", 12}, {2, "神弓", 8}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+// TestSheets_Errors 测试重复主键和表头不一致
+func TestSheets_Errors(t *testing.T) {
+	path := newSheetsWorkbook(t, map[string][][]interface{}{
+		"a": {{"id", "name"}, {1, "x"}, {2, "y"}},
+		"b": {{"id", "name"}, {3, "z"}, {2, "w"}},
+		"c": {{"id", "title"}, {4, "v"}},
+	})
+
+	_, err := NewLoader[sheetItem](path, "all", LoadOptions{Sheets: []string{"a", "b"}}).Load()
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("Load() error = %v, want ErrDuplicateKey", err)
+	}
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.File != "b" || configErr.Row != 2 || !strings.Contains(configErr.Msg, "'a' 第 2 行") {
+		t.Errorf("Load() error = %v", err)
+	}
+
+	_, err = NewLoader[sheetItem](path, "all", LoadOptions{Sheets: []string{"a", "c"}}).Load()
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Load() error = %v, want ErrInvalidFormat", err)
+	}
+
+	_, err = NewLoader[sheetItem](path, "all", LoadOptions{Sheets: []string{"a"}, Key: "missing"}).Load()
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Load() error = %v, want ErrInvalidFormat", err)
+	}
+}
@@ -0,0 +1,629 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// 二进制快照格式（列式存储，所有整数使用 varint 编码）:
+//
+//	magic       "GCFS"
+//	version     uint16（小端）
+//	schemaHash  [32]byte
+//	dataVersion varint（Sheet 的 __version__）
+//	rowCount    uvarint
+//	colCount    uvarint
+//	每列:
+//	    name  uvarint 长度 + 字节
+//	    kind  byte
+//	    values rowCount 个值
+//
+// 快照中保存的是经过默认值、条件字段和必填校验后的最终值，
+// 加载时按字段偏移直接写入内存，不再逐个单元格解析字符串和反射赋值。
+
+// SnapshotExt 二进制快照文件扩展名
+const SnapshotExt = ".gcs"
+
+// snapshotMagic 快照文件头
+var snapshotMagic = [4]byte{'G', 'C', 'F', 'S'}
+
+// snapshotFormatVersion 快照格式版本，格式不兼容变更时递增
+const snapshotFormatVersion uint16 = 1
+
+// 列值类型
+const (
+	snapshotKindInt byte = iota + 1
+	snapshotKindUint
+	snapshotKindFloat
+	snapshotKindBool
+	snapshotKindString
+	snapshotKindLocalized        // LocalizedString: ID、Text 和按语言排序的 Texts
+	snapshotKindPtr       = 0x80 // 与以上类型组合，表示指针字段
+)
+
+// SnapshotHeader 快照文件头信息
+type SnapshotHeader struct {
+	FormatVersion uint16
+	SchemaHash    [32]byte
+	DataVersion   int
+	RowCount      int
+}
+
+// snapshotColumn 单列编解码器，按字段偏移直接读写结构体内存
+type snapshotColumn struct {
+	name   string
+	kind   byte
+	offset uintptr
+	encode func(w *snapshotWriter, p unsafe.Pointer)
+	decode func(r *snapshotReader, p unsafe.Pointer) error
+}
+
+// snapshotCodec 结构体快照编解码器
+type snapshotCodec[T any] struct {
+	columns []snapshotColumn
+	hash    [32]byte
+}
+
+// newSnapshotCodec 根据结构体映射器创建快照编解码器
+func newSnapshotCodec[T any](mapper *StructMapper[T]) (*snapshotCodec[T], error) {
+	var zero T
+	if reflect.TypeOf(zero).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("二进制快照仅支持结构体类型，当前为 %v", reflect.TypeOf(zero))
+	}
+
+	// 按结构体字段顺序排列，保证编码结果稳定
+	fields := make([]*FieldInfo, 0, len(mapper.fields))
+	for _, field := range mapper.fields {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Index < fields[j].Index })
+
+	codec := &snapshotCodec[T]{}
+	h := sha256.New()
+	fmt.Fprintf(h, "gameconfig-snapshot/%d\n", snapshotFormatVersion)
+
+	for _, field := range fields {
+		column, err := newSnapshotColumn(field.Name, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		column.offset = mapper.typ.Field(field.Index).Offset
+		codec.columns = append(codec.columns, column)
+		fmt.Fprintf(h, "%s:%d:%s\n", field.Name, column.kind, field.Type)
+	}
+
+	copy(codec.hash[:], h.Sum(nil))
+	return codec, nil
+}
+
+// newSnapshotColumn 根据字段类型创建列编解码器
+func newSnapshotColumn(name string, typ reflect.Type) (snapshotColumn, error) {
+	if typ.Kind() == reflect.Ptr {
+		elem, err := newSnapshotColumn(name, typ.Elem())
+		if err != nil {
+			return snapshotColumn{}, err
+		}
+		if elem.kind&snapshotKindPtr != 0 {
+			return snapshotColumn{}, fmt.Errorf("字段 '%s' 不支持多级指针类型 %v", name, typ)
+		}
+		alloc := snapshotAllocator(typ.Elem())
+		return snapshotColumn{
+			name: name,
+			kind: elem.kind | snapshotKindPtr,
+			encode: func(w *snapshotWriter, p unsafe.Pointer) {
+				ptr := *(*unsafe.Pointer)(p)
+				if ptr == nil {
+					w.writeByte(0)
+					return
+				}
+				w.writeByte(1)
+				elem.encode(w, ptr)
+			},
+			decode: func(r *snapshotReader, p unsafe.Pointer) error {
+				present, err := r.readByte()
+				if err != nil || present == 0 {
+					return err
+				}
+				ptr := alloc()
+				if err := elem.decode(r, ptr); err != nil {
+					return err
+				}
+				*(*unsafe.Pointer)(p) = ptr
+				return nil
+			},
+		}, nil
+	}
+
+	if typ == localizedStringType {
+		return localizedColumn(name), nil
+	}
+
+	column := snapshotColumn{name: name}
+	switch typ.Kind() {
+	case reflect.Int:
+		column.kind, column.encode, column.decode = intColumn[int]()
+	case reflect.Int8:
+		column.kind, column.encode, column.decode = intColumn[int8]()
+	case reflect.Int16:
+		column.kind, column.encode, column.decode = intColumn[int16]()
+	case reflect.Int32:
+		column.kind, column.encode, column.decode = intColumn[int32]()
+	case reflect.Int64:
+		column.kind, column.encode, column.decode = intColumn[int64]()
+	case reflect.Uint:
+		column.kind, column.encode, column.decode = uintColumn[uint]()
+	case reflect.Uint8:
+		column.kind, column.encode, column.decode = uintColumn[uint8]()
+	case reflect.Uint16:
+		column.kind, column.encode, column.decode = uintColumn[uint16]()
+	case reflect.Uint32:
+		column.kind, column.encode, column.decode = uintColumn[uint32]()
+	case reflect.Uint64:
+		column.kind, column.encode, column.decode = uintColumn[uint64]()
+	case reflect.Float32:
+		column.kind, column.encode, column.decode = floatColumn[float32]()
+	case reflect.Float64:
+		column.kind, column.encode, column.decode = floatColumn[float64]()
+	case reflect.Bool:
+		column.kind = snapshotKindBool
+		column.encode = func(w *snapshotWriter, p unsafe.Pointer) {
+			if *(*bool)(p) {
+				w.writeByte(1)
+			} else {
+				w.writeByte(0)
+			}
+		}
+		column.decode = func(r *snapshotReader, p unsafe.Pointer) error {
+			b, err := r.readByte()
+			*(*bool)(p) = b != 0
+			return err
+		}
+	case reflect.String:
+		column.kind = snapshotKindString
+		column.encode = func(w *snapshotWriter, p unsafe.Pointer) {
+			w.writeString(*(*string)(p))
+		}
+		column.decode = func(r *snapshotReader, p unsafe.Pointer) error {
+			s, err := r.readString()
+			*(*string)(p) = s
+			return err
+		}
+	default:
+		return snapshotColumn{}, fmt.Errorf("字段 '%s' 的类型 %v 不支持二进制快照", name, typ)
+	}
+	return column, nil
+}
+
+// localizedColumn 多语言文本列
+// Text 保存导出时按 Locale 解析的结果，加载时不会按新的 Locale 重新解析
+func localizedColumn(name string) snapshotColumn {
+	return snapshotColumn{
+		name: name,
+		kind: snapshotKindLocalized,
+		encode: func(w *snapshotWriter, p unsafe.Pointer) {
+			s := (*LocalizedString)(p)
+			w.writeString(s.ID)
+			w.writeString(s.Text)
+			langs := make([]string, 0, len(s.Texts))
+			for lang := range s.Texts {
+				langs = append(langs, lang)
+			}
+			sort.Strings(langs)
+			// 0 表示 Texts 为 nil（没有对应的列），否则为翻译数量 + 1
+			if s.Texts == nil {
+				w.writeUvarint(0)
+				return
+			}
+			w.writeUvarint(uint64(len(langs)) + 1)
+			for _, lang := range langs {
+				w.writeString(lang)
+				w.writeString(s.Texts[lang])
+			}
+		},
+		decode: func(r *snapshotReader, p unsafe.Pointer) error {
+			s := (*LocalizedString)(p)
+			var err error
+			if s.ID, err = r.readString(); err != nil {
+				return err
+			}
+			if s.Text, err = r.readString(); err != nil {
+				return err
+			}
+			n, err := r.readUvarint()
+			if err != nil || n == 0 {
+				return err
+			}
+			n--
+			// 每条翻译至少占 2 字节（两个长度）
+			if n > uint64(r.r.Len())/2 {
+				return fmt.Errorf("翻译数量 %d 超出剩余数据 %d 字节", n, r.r.Len())
+			}
+			s.Texts = make(map[string]string, n)
+			for i := uint64(0); i < n; i++ {
+				lang, err := r.readString()
+				if err != nil {
+					return err
+				}
+				if s.Texts[lang], err = r.readString(); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// snapshotAllocator 返回分配指针字段指向值的函数
+// 按底层类型分配，避免加载时逐个单元格调用 reflect.New
+func snapshotAllocator(typ reflect.Type) func() unsafe.Pointer {
+	switch typ.Kind() {
+	case reflect.String:
+		return func() unsafe.Pointer { return unsafe.Pointer(new(string)) }
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return func() unsafe.Pointer { return unsafe.Pointer(new(uint8)) }
+	case reflect.Int16, reflect.Uint16:
+		return func() unsafe.Pointer { return unsafe.Pointer(new(uint16)) }
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return func() unsafe.Pointer { return unsafe.Pointer(new(uint32)) }
+	default:
+		return func() unsafe.Pointer { return unsafe.Pointer(new(uint64)) }
+	}
+}
+
+// intColumn 有符号整数列
+func intColumn[E int | int8 | int16 | int32 | int64]() (byte, func(*snapshotWriter, unsafe.Pointer), func(*snapshotReader, unsafe.Pointer) error) {
+	return snapshotKindInt,
+		func(w *snapshotWriter, p unsafe.Pointer) {
+			w.writeVarint(int64(*(*E)(p)))
+		},
+		func(r *snapshotReader, p unsafe.Pointer) error {
+			v, err := r.readVarint()
+			*(*E)(p) = E(v)
+			return err
+		}
+}
+
+// uintColumn 无符号整数列
+func uintColumn[E uint | uint8 | uint16 | uint32 | uint64]() (byte, func(*snapshotWriter, unsafe.Pointer), func(*snapshotReader, unsafe.Pointer) error) {
+	return snapshotKindUint,
+		func(w *snapshotWriter, p unsafe.Pointer) {
+			w.writeUvarint(uint64(*(*E)(p)))
+		},
+		func(r *snapshotReader, p unsafe.Pointer) error {
+			v, err := r.readUvarint()
+			*(*E)(p) = E(v)
+			return err
+		}
+}
+
+// floatColumn 浮点数列
+func floatColumn[E float32 | float64]() (byte, func(*snapshotWriter, unsafe.Pointer), func(*snapshotReader, unsafe.Pointer) error) {
+	return snapshotKindFloat,
+		func(w *snapshotWriter, p unsafe.Pointer) {
+			w.writeUint64(math.Float64bits(float64(*(*E)(p))))
+		},
+		func(r *snapshotReader, p unsafe.Pointer) error {
+			v, err := r.readUint64()
+			*(*E)(p) = E(math.Float64frombits(v))
+			return err
+		}
+}
+
+// WriteSnapshot 将已加载的数据写入二进制快照
+func WriteSnapshot[T any](w io.Writer, rows []T, dataVersion int) error {
+	codec, err := newSnapshotCodec(NewStructMapper[T]())
+	if err != nil {
+		return err
+	}
+	return codec.write(w, rows, dataVersion)
+}
+
+// ReadSnapshot 读取二进制快照
+// 快照的 Schema 哈希与 T 不一致时返回 ErrSchemaMismatch
+func ReadSnapshot[T any](r io.Reader) ([]T, *SnapshotHeader, error) {
+	codec, err := newSnapshotCodec(NewStructMapper[T]())
+	if err != nil {
+		return nil, nil, err
+	}
+	return codec.read(r)
+}
+
+// write 写入快照
+func (c *snapshotCodec[T]) write(w io.Writer, rows []T, dataVersion int) error {
+	sw := &snapshotWriter{}
+	sw.buf.Write(snapshotMagic[:])
+	sw.buf.Write(binary.LittleEndian.AppendUint16(nil, snapshotFormatVersion))
+	sw.buf.Write(c.hash[:])
+	sw.writeVarint(int64(dataVersion))
+	sw.writeUvarint(uint64(len(rows)))
+	sw.writeUvarint(uint64(len(c.columns)))
+
+	var zero T
+	stride := unsafe.Sizeof(zero)
+	var base unsafe.Pointer
+	if len(rows) > 0 {
+		base = unsafe.Pointer(&rows[0])
+	}
+
+	for _, column := range c.columns {
+		sw.writeString(column.name)
+		sw.writeByte(column.kind)
+		for i := range rows {
+			column.encode(sw, unsafe.Add(base, uintptr(i)*stride+column.offset))
+		}
+	}
+
+	if _, err := w.Write(sw.buf.Bytes()); err != nil {
+		return fmt.Errorf("写入二进制快照失败: %w", err)
+	}
+	return nil
+}
+
+// read 读取快照
+func (c *snapshotCodec[T]) read(r io.Reader) ([]T, *SnapshotHeader, error) {
+	// 整体读入内存，解码时可以按剩余长度校验行数和字符串长度，
+	// 避免损坏的文件导致超大内存分配
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取二进制快照失败: %w", err)
+	}
+	sr := &snapshotReader{r: bytes.NewReader(data)}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(sr.r, magic[:]); err != nil || magic != snapshotMagic {
+		return nil, nil, fmt.Errorf("%w: 不是有效的二进制快照", ErrInvalidFormat)
+	}
+
+	header := &SnapshotHeader{}
+	var version [2]byte
+	if _, err := io.ReadFull(sr.r, version[:]); err != nil {
+		return nil, nil, sr.wrap(err)
+	}
+	header.FormatVersion = binary.LittleEndian.Uint16(version[:])
+	if header.FormatVersion != snapshotFormatVersion {
+		return nil, nil, fmt.Errorf("%w: 快照格式版本 %d，当前支持 %d", ErrSchemaMismatch, header.FormatVersion, snapshotFormatVersion)
+	}
+
+	if _, err := io.ReadFull(sr.r, header.SchemaHash[:]); err != nil {
+		return nil, nil, sr.wrap(err)
+	}
+	if header.SchemaHash != c.hash {
+		return nil, nil, fmt.Errorf("%w: 快照与结构体 %T 的字段定义不一致，请重新导出", ErrSchemaMismatch, *new(T))
+	}
+
+	dataVersion, err := sr.readVarint()
+	if err != nil {
+		return nil, nil, sr.wrap(err)
+	}
+	header.DataVersion = int(dataVersion)
+
+	rowCount, err := sr.readUvarint()
+	if err != nil {
+		return nil, nil, sr.wrap(err)
+	}
+	colCount, err := sr.readUvarint()
+	if err != nil {
+		return nil, nil, sr.wrap(err)
+	}
+	if colCount != uint64(len(c.columns)) {
+		return nil, nil, fmt.Errorf("%w: 快照列数 %d，结构体字段数 %d", ErrSchemaMismatch, colCount, len(c.columns))
+	}
+	// 每个值至少占 1 字节，行数不可能超过剩余字节数
+	if len(c.columns) > 0 && rowCount > uint64(sr.r.Len()) || rowCount > math.MaxInt32 {
+		return nil, nil, fmt.Errorf("%w: 二进制快照数据损坏: 行数 %d 超出文件长度", ErrInvalidFormat, rowCount)
+	}
+	header.RowCount = int(rowCount)
+
+	rows := make([]T, header.RowCount)
+	var zero T
+	stride := unsafe.Sizeof(zero)
+	var base unsafe.Pointer
+	if len(rows) > 0 {
+		base = unsafe.Pointer(&rows[0])
+	}
+
+	for _, column := range c.columns {
+		name, err := sr.readString()
+		if err != nil {
+			return nil, nil, sr.wrap(err)
+		}
+		kind, err := sr.readByte()
+		if err != nil {
+			return nil, nil, sr.wrap(err)
+		}
+		if name != column.name || kind != column.kind {
+			return nil, nil, fmt.Errorf("%w: 列 '%s' 与字段 '%s' 不匹配", ErrSchemaMismatch, name, column.name)
+		}
+
+		for i := range rows {
+			if err := column.decode(sr, unsafe.Add(base, uintptr(i)*stride+column.offset)); err != nil {
+				return nil, nil, sr.wrap(fmt.Errorf("列 '%s' 行%d: %w", column.name, i+1, err))
+			}
+		}
+	}
+
+	return rows, header, nil
+}
+
+// snapshotWriter 快照写入缓冲
+type snapshotWriter struct {
+	buf     bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (w *snapshotWriter) writeByte(b byte) {
+	w.buf.WriteByte(b)
+}
+
+func (w *snapshotWriter) writeVarint(v int64) {
+	n := binary.PutVarint(w.scratch[:], v)
+	w.buf.Write(w.scratch[:n])
+}
+
+func (w *snapshotWriter) writeUvarint(v uint64) {
+	n := binary.PutUvarint(w.scratch[:], v)
+	w.buf.Write(w.scratch[:n])
+}
+
+func (w *snapshotWriter) writeUint64(v uint64) {
+	binary.LittleEndian.PutUint64(w.scratch[:8], v)
+	w.buf.Write(w.scratch[:8])
+}
+
+func (w *snapshotWriter) writeString(s string) {
+	w.writeUvarint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// snapshotReader 快照读取器
+type snapshotReader struct {
+	r       *bytes.Reader
+	scratch [8]byte
+}
+
+func (r *snapshotReader) readByte() (byte, error) {
+	return r.r.ReadByte()
+}
+
+func (r *snapshotReader) readVarint() (int64, error) {
+	return binary.ReadVarint(r.r)
+}
+
+func (r *snapshotReader) readUvarint() (uint64, error) {
+	return binary.ReadUvarint(r.r)
+}
+
+func (r *snapshotReader) readUint64() (uint64, error) {
+	if _, err := io.ReadFull(r.r, r.scratch[:8]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(r.scratch[:8]), nil
+}
+
+func (r *snapshotReader) readString() (string, error) {
+	n, err := r.readUvarint()
+	if err != nil {
+		return "", err
+	}
+	if n > uint64(r.r.Len()) {
+		return "", fmt.Errorf("字符串长度 %d 超出剩余数据 %d 字节", n, r.r.Len())
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// wrap 将读取错误包装为格式错误
+func (r *snapshotReader) wrap(err error) error {
+	if errors.Is(err, ErrSchemaMismatch) || errors.Is(err, ErrInvalidFormat) {
+		return err
+	}
+	return fmt.Errorf("%w: 二进制快照数据损坏: %w", ErrInvalidFormat, err)
+}
+
+// getSnapshotPath 获取二进制快照路径
+// 格式: {basePath}/{sheetName}.gcs
+// 如果 basePath 是 .xlsx 文件，则使用同名目录（与 CSV 导出目录一致）
+// 如果 basePath 是 .csv / .json 等文本文件，则使用同名 .gcs 文件
+// 如果 basePath 是 .gcs 文件，则直接使用它
+func (l *Loader[T]) getSnapshotPath() string {
+	lower := strings.ToLower(l.basePath)
+	switch {
+	case strings.HasSuffix(lower, SnapshotExt):
+		return l.basePath
+	case strings.HasSuffix(lower, ".xlsx"):
+		baseDir := filepath.Dir(l.basePath)
+		excelName := strings.TrimSuffix(filepath.Base(l.basePath), filepath.Ext(l.basePath))
+		return filepath.Join(baseDir, excelName, l.sheetName+SnapshotExt)
+	case sourceExts[modeByExt(lower)] != nil:
+		return strings.TrimSuffix(l.basePath, filepath.Ext(l.basePath)) + SnapshotExt
+	default:
+		return filepath.Join(l.basePath, l.sheetName+SnapshotExt)
+	}
+}
+
+// readSnapshotHeader 读取快照文件头（用于获取版本号）
+func (l *Loader[T]) readSnapshotHeader() (*SnapshotHeader, error) {
+	path := l.getSnapshotPath()
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, path)
+	}
+	defer file.Close()
+
+	_, header, err := ReadSnapshot[T](file)
+	if err != nil {
+		return nil, fmt.Errorf("读取二进制快照 %s 失败: %w", path, err)
+	}
+	return header, nil
+}
+
+// loadFromBinary 从二进制快照加载
+func (l *Loader[T]) loadFromBinary() ([]T, error) {
+	path := l.getSnapshotPath()
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, path)
+	}
+	defer file.Close()
+
+	rows, _, err := ReadSnapshot[T](file)
+	if err != nil {
+		return nil, fmt.Errorf("加载二进制快照 %s 失败: %w", path, err)
+	}
+	return rows, nil
+}
+
+// ExportSnapshot 通过 Excel/CSV 完整加载并校验一次，然后写入二进制快照
+// outPath 为空时写入 ModeBinary 使用的默认快照路径
+func (l *Loader[T]) ExportSnapshot(outPath string) error {
+	mode := l.options.Mode
+	if mode == ModeAuto {
+		mode = l.detectMode()
+	}
+	if mode == ModeBinary {
+		return fmt.Errorf("导出二进制快照需要 Excel、CSV 等原始数据源")
+	}
+
+	rows, err := l.loadMode(mode)
+	if err != nil {
+		return err
+	}
+
+	version := 0
+	if mode == ModeExcel {
+		if version, err = l.GetVersion(); err != nil {
+			return err
+		}
+	}
+
+	if outPath == "" {
+		outPath = l.getSnapshotPath()
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return fmt.Errorf("创建快照目录失败: %w", err)
+	}
+
+	file, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("创建快照文件失败: %w", err)
+	}
+	if err := WriteSnapshot(file, rows, version); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
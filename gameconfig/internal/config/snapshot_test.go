@@ -0,0 +1,300 @@
+package config
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type snapshotElement int32
+
+type snapshotItem struct {
+	ID      int             `excel:"id"`
+	Name    string          `excel:"name,required"`
+	Rate    float32         `excel:"rate,default:0.5"`
+	Weight  float64         `excel:"weight"`
+	Level   uint16          `excel:"level"`
+	Enabled bool            `excel:"enabled"`
+	Element snapshotElement `excel:"element"`
+	Bonus   *int64          `excel:"bonus"`
+	Title   *string         `excel:"title"`
+	Ignored string          `excel:"-"`
+	Extra   string
+}
+
+// TestSnapshot_RoundTrip 测试快照写入后读取结果一致
+func TestSnapshot_RoundTrip(t *testing.T) {
+	bonus := int64(-42)
+	title := "勇者"
+	rows := []snapshotItem{
+		{ID: 1, Name: "铁剑", Rate: 0.25, Weight: 1.5, Level: 3, Enabled: true, Element: 2, Bonus: &bonus, Title: &title},
+		{ID: 2, Name: "", Rate: 0, Weight: -3.75, Level: 65535},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, rows, 7); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	got, header, err := ReadSnapshot[snapshotItem](&buf)
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+
+	if header.DataVersion != 7 || header.RowCount != 2 {
+		t.Errorf("header = %+v, want DataVersion 7 RowCount 2", header)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("ReadSnapshot() = %+v, want %+v", got, rows)
+	}
+	if got[0].Bonus == &bonus {
+		t.Error("pointer fields should be newly allocated")
+	}
+}
+
+// TestSnapshot_Empty 测试空数据快照
+func TestSnapshot_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSnapshot[snapshotItem](&buf, nil, 0); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	got, _, err := ReadSnapshot[snapshotItem](&buf)
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("len = %d, want 0", len(got))
+	}
+}
+
+// TestSnapshot_SchemaMismatch 测试结构体变更后拒绝加载旧快照
+func TestSnapshot_SchemaMismatch(t *testing.T) {
+	type v1 struct {
+		ID     int `excel:"id"`
+		Attack int `excel:"attack"`
+	}
+	type v2 struct {
+		ID     int     `excel:"id"`
+		Attack float64 `excel:"attack"`
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, []v1{{ID: 1, Attack: 10}}, 0); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	_, _, err := ReadSnapshot[v2](&buf)
+	if !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("error = %v, want ErrSchemaMismatch", err)
+	}
+}
+
+// TestSnapshot_Corrupted 测试损坏的快照返回格式错误
+func TestSnapshot_Corrupted(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, []snapshotItem{{ID: 1, Name: "a"}}, 0); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	data := buf.Bytes()
+
+	tests := map[string][]byte{
+		"截断":    data[:len(data)-3],
+		"文件头错误": append([]byte("XXXX"), data[4:]...),
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := ReadSnapshot[snapshotItem](bytes.NewReader(input))
+			if !errors.Is(err, ErrInvalidFormat) {
+				t.Errorf("error = %v, want ErrInvalidFormat", err)
+			}
+		})
+	}
+}
+
+// TestSnapshot_HostileLengths 测试任意截断和超大长度都返回格式错误而不是 panic
+func TestSnapshot_HostileLengths(t *testing.T) {
+	title := "勇者"
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, []snapshotItem{{ID: 1, Name: "铁剑", Title: &title}}, 3); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	data := buf.Bytes()
+
+	for n := 0; n < len(data); n++ {
+		if _, _, err := ReadSnapshot[snapshotItem](bytes.NewReader(data[:n])); !errors.Is(err, ErrInvalidFormat) {
+			t.Fatalf("截断到 %d 字节: error = %v, want ErrInvalidFormat", n, err)
+		}
+	}
+
+	// 文件头 (magic + 格式版本 + schema 哈希) 之后依次是 dataVersion、行数、列数
+	header := data[:4+2+32]
+	build := func(parts ...[]byte) []byte {
+		out := append([]byte(nil), header...)
+		for _, p := range parts {
+			out = append(out, p...)
+		}
+		return out
+	}
+	uvarint := func(v uint64) []byte { return binary.AppendUvarint(nil, v) }
+	cols := uvarint(uint64(reflect.TypeOf(snapshotItem{}).NumField() - 2))
+
+	tests := map[string][]byte{
+		"超大行数":      build(uvarint(0), uvarint(math.MaxUint64), cols),
+		"行数超出文件长度":  build(uvarint(0), uvarint(1<<40), cols),
+		"超大字符串长度":   build(uvarint(0), uvarint(1), cols, uvarint(math.MaxUint64)),
+		"字符串超出文件长度": build(uvarint(0), uvarint(1), cols, uvarint(1<<30), []byte("id")),
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := ReadSnapshot[snapshotItem](bytes.NewReader(input))
+			if !errors.Is(err, ErrInvalidFormat) {
+				t.Errorf("error = %v, want ErrInvalidFormat", err)
+			}
+		})
+	}
+}
+
+// TestSnapshot_LocalizedString 测试多语言字段写入快照后保持一致
+func TestSnapshot_LocalizedString(t *testing.T) {
+	type item struct {
+		ID    int             `excel:"id"`
+		Name  LocalizedString `excel:"name,i18n"`
+		Title LocalizedString `excel:"title,i18n"` // 没有对应的列，Texts 为 nil
+	}
+
+	loader := NewLoader[item]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		I18n: I18nOptions{Locale: "en", FallbackLocale: "zh"},
+		MockData: [][]string{
+			{"id", "name_zh", "name_en"},
+			{"1", "铁剑", "Iron Sword"},
+			{"2", "钢剑", ""},
+		},
+	})
+	rows, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, rows, 1); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	got, _, err := ReadSnapshot[item](&buf)
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("ReadSnapshot() = %+v, want %+v", got, rows)
+	}
+	if got[1].Name.Text != "钢剑" || got[0].Title.Texts != nil {
+		t.Errorf("unexpected localized values: %+v", got)
+	}
+}
+
+// TestSnapshot_UnsupportedType 测试不支持的字段类型
+func TestSnapshot_UnsupportedType(t *testing.T) {
+	type item struct {
+		Tags []string `excel:"tags"`
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, []item{{}}, 0); err == nil {
+		t.Error("WriteSnapshot() should fail for slice field")
+	}
+}
+
+// TestLoader_ExportSnapshotAndModeBinary 测试从 CSV 导出快照并以 ModeBinary 加载
+func TestLoader_ExportSnapshotAndModeBinary(t *testing.T) {
+	dir := t.TempDir()
+	csv := "id,name,rate,weight,level,enabled,element,bonus,title\n" +
+		"1,铁剑,,1.5,3,true,2,-42,勇者\n" +
+		"2,钢剑,0.75,2,4,false,1,,\n"
+	if err := os.WriteFile(filepath.Join(dir, "武器.csv"), []byte(csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	csvLoader := NewLoader[snapshotItem](dir, "武器", LoadOptions{Mode: ModeCSV})
+	want, err := csvLoader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if err := csvLoader.ExportSnapshot(""); err != nil {
+		t.Fatalf("ExportSnapshot() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "武器"+SnapshotExt)); err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+
+	binLoader := NewLoader[snapshotItem](dir, "武器", LoadOptions{Mode: ModeBinary})
+	got, err := binLoader.Load()
+	if err != nil {
+		t.Fatalf("Load() binary error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("binary Load() = %+v, want %+v", got, want)
+	}
+
+	// 默认值在导出时已经应用
+	if got[0].Rate != 0.5 {
+		t.Errorf("Rate = %v, want default 0.5", got[0].Rate)
+	}
+
+	// ModeAuto 识别 .gcs 文件
+	autoLoader := NewLoader[snapshotItem](filepath.Join(dir, "武器"+SnapshotExt), "武器", LoadOptions{})
+	if got, err := autoLoader.Load(); err != nil || len(got) != 2 {
+		t.Errorf("auto Load() = %d rows, err %v", len(got), err)
+	}
+}
+
+// TestLoader_ExportSnapshotValidates 测试导出时会执行完整校验
+func TestLoader_ExportSnapshotValidates(t *testing.T) {
+	loader := NewLoader[snapshotItem]("", "Sheet1", LoadOptions{
+		Mode: ModeMemory,
+		MockData: [][]string{
+			{"id", "name"},
+			{"1", ""},
+		},
+	})
+
+	out := filepath.Join(t.TempDir(), "out"+SnapshotExt)
+	if err := loader.ExportSnapshot(out); !errors.Is(err, ErrRequiredField) {
+		t.Errorf("ExportSnapshot() error = %v, want ErrRequiredField", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("snapshot should not be written when validation fails")
+	}
+}
+
+// TestLoader_SnapshotVersion 测试快照保留 Excel 版本号
+func TestLoader_SnapshotVersion(t *testing.T) {
+	excelFile := createTestExcelFileWithVersion(t, 3)
+	defer os.Remove(excelFile)
+
+	type item struct {
+		ID   int    `excel:"id"`
+		Name string `excel:"name"`
+	}
+
+	out := filepath.Join(t.TempDir(), "Sheet1"+SnapshotExt)
+	excelLoader := NewLoader[item](excelFile, "Sheet1", LoadOptions{Mode: ModeExcel})
+	if err := excelLoader.ExportSnapshot(out); err != nil {
+		t.Fatalf("ExportSnapshot() error = %v", err)
+	}
+
+	binLoader := NewLoader[item](out, "Sheet1", LoadOptions{Mode: ModeBinary})
+	version, err := binLoader.GetVersion()
+	if err != nil {
+		t.Fatalf("GetVersion() error = %v", err)
+	}
+	if version != 3 {
+		t.Errorf("GetVersion() = %d, want 3", version)
+	}
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Source 行数据源
+// 按顺序产出原始行：版本行（可选）、表头行、类型行（可选）和数据行，
+// 行布局由 LoadOptions 的 HeaderRow / TypeRow / DataStart 决定，与 Excel / CSV 一致
+// 设置 LoadOptions.Source 可以接入自定义数据源
+type Source interface {
+	// Open 打开数据源，返回行迭代器
+	Open() (RowIterator, error)
+}
+
+// SourceFunc 函数形式的数据源
+type SourceFunc func() (RowIterator, error)
+
+// Open 实现 Source
+func (f SourceFunc) Open() (RowIterator, error) {
+	return f()
+}
+
+// recordSource 由记录（对象）构成的数据源（JSON / YAML / TOML）
+// 第一行固定为各记录键的并集，之后每条记录一行，不使用 HeaderRow / TypeRow / DataStart
+type recordSource interface {
+	Source
+	records()
+}
+
+// sourceExts 各模式对应的文件扩展名（第一个为默认扩展名）
+var sourceExts = map[Mode][]string{
+	ModeCSV:  {".csv"},
+	ModeTSV:  {".tsv"},
+	ModeJSON: {".json"},
+	ModeYAML: {".yaml", ".yml"},
+	ModeTOML: {".toml"},
+}
+
+// modeByExt 根据文件扩展名确定模式，未知扩展名返回空字符串
+func modeByExt(path string) Mode {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".xlsx":
+		return ModeExcel
+	case SnapshotExt:
+		return ModeBinary
+	}
+	for mode, exts := range sourceExts {
+		for _, e := range exts {
+			if ext == e {
+				return mode
+			}
+		}
+	}
+	return ""
+}
+
+// excelSource Excel Sheet 数据源
+type excelSource struct {
+	path    string
+	sheet   string
+	formula FormulaPolicy
+	onIssue func(FormulaIssue)
+}
+
+// NewExcelSource 创建 Excel 数据源
+func NewExcelSource(path string, sheetName string) Source {
+	return &excelSource{path: path, sheet: sheetName}
+}
+
+func (s *excelSource) Open() (RowIterator, error) {
+	reader, err := NewExcelReader(s.path)
+	if err != nil {
+		return nil, err
+	}
+	it, err := reader.IterSheet(s.sheet)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	it.(*excelRowIterator).closer = reader
+	if s.formula != FormulaCached {
+		return reader.formulaIterator(it, s.sheet, s.formula, s.onIssue), nil
+	}
+	return it, nil
+}
+
+// NewCSVSource 创建 CSV 数据源
+func NewCSVSource(path string) Source {
+	return SourceFunc(func() (RowIterator, error) {
+		return NewCSVReader(path).Iter()
+	})
+}
+
+// NewTSVSource 创建 TSV 数据源
+// 格式与 Google Sheets 导出的 TSV 一致：字段以制表符分隔，不使用引号转义
+func NewTSVSource(path string) Source {
+	return SourceFunc(func() (RowIterator, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrFileNotFound, path)
+		}
+		return &tsvRowIterator{file: file, reader: bufio.NewReader(file), first: true}, nil
+	})
+}
+
+// tsvRowIterator TSV 逐行读取，空行被忽略（与 CSV 一致）
+type tsvRowIterator struct {
+	file   *os.File
+	reader *bufio.Reader
+	first  bool
+	row    []string
+	err    error
+}
+
+func (it *tsvRowIterator) Next() bool {
+	for it.err == nil {
+		line, err := it.reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			it.err = fmt.Errorf("读取 TSV 数据失败: %w", err)
+			return false
+		}
+		if it.first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			it.first = false
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			it.row = strings.Split(line, "\t")
+			return true
+		}
+		if err != nil {
+			return false
+		}
+	}
+	return false
+}
+
+func (it *tsvRowIterator) Row() []string {
+	return it.row
+}
+
+func (it *tsvRowIterator) Err() error {
+	return it.err
+}
+
+func (it *tsvRowIterator) Close() error {
+	return it.file.Close()
+}
+
+// source 返回指定模式的数据源
+func (l *Loader[T]) source(mode Mode) (Source, error) {
+	if l.options.Source != nil {
+		return l.options.Source, nil
+	}
+	if l.multiSheet() {
+		return l.sheetsSource(mode)
+	}
+
+	switch mode {
+	case ModeExcel:
+		return &excelSource{
+			path:    l.basePath,
+			sheet:   l.sheetName,
+			formula: l.options.Formula,
+			onIssue: l.options.OnFormulaIssue,
+		}, nil
+	case ModeCSV:
+		return NewCSVSource(l.sourcePath(mode)), nil
+	case ModeTSV:
+		return NewTSVSource(l.sourcePath(mode)), nil
+	case ModeJSON:
+		return NewJSONSource(l.sourcePath(mode), l.sheetName), nil
+	case ModeYAML:
+		return NewYAMLSource(l.sourcePath(mode), l.sheetName), nil
+	case ModeTOML:
+		return NewTOMLSource(l.sourcePath(mode), l.sheetName), nil
+	case ModeMemory:
+		return SourceFunc(func() (RowIterator, error) {
+			return NewSliceRowIterator(l.readMemoryRows()), nil
+		}), nil
+	default:
+		return nil, fmt.Errorf("不支持的加载模式: %s", mode)
+	}
+}
+
+// sourcePath 获取文本数据源的文件路径
+// 格式: {basePath}/{sheetName}{ext}
+// 如果 basePath 是 .xlsx 文件，则使用同名目录
+// 如果 basePath 已经是该模式的文件，则直接使用它
+func (l *Loader[T]) sourcePath(mode Mode) string {
+	exts := sourceExts[mode]
+	if len(exts) == 0 {
+		return l.basePath
+	}
+
+	// 如果 basePath 已经是该模式的文件，直接返回
+	if modeByExt(l.basePath) == mode {
+		return l.basePath
+	}
+
+	dir := l.sourceDir()
+
+	// 有多个扩展名时（.yaml / .yml）使用已存在的文件
+	for _, ext := range exts {
+		path := filepath.Join(dir, l.sheetName+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, l.sheetName+exts[0])
+}
+
+// layout 确定数据源的行布局
+func (l *Loader[T]) layout(src Source, head [][]string) rowLayout {
+	if _, ok := src.(recordSource); ok {
+		return rowLayout{headerRow: 0, typeRow: -1, dataStart: 1}
+	}
+	return resolveRowLayout(head, l.options)
+}
+
+// readSource 读取数据源的全部行
+func readSource(src Source) ([][]string, error) {
+	it, err := src.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var rows [][]string
+	for it.Next() {
+		rows = append(rows, it.Row())
+	}
+	return rows, it.Err()
+}
+
+// sourceDir 文本数据源所在目录
+// basePath 为 Excel 文件时使用同级的同名目录，为文本文件时使用其所在目录，否则 basePath 即为目录
+func (l *Loader[T]) sourceDir() string {
+	switch mode := modeByExt(l.basePath); {
+	case mode == ModeExcel:
+		return filepath.Join(filepath.Dir(l.basePath), strings.TrimSuffix(filepath.Base(l.basePath), filepath.Ext(l.basePath)))
+	case mode != "":
+		return filepath.Dir(l.basePath)
+	default:
+		return l.basePath
+	}
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type sourceItem struct {
+	ID     int     `excel:"id"`
+	Name   string  `excel:"name,required"`
+	Rate   float64 `excel:"rate,default:1.5"`
+	Boss   bool    `excel:"boss,default:false"`
+	Skill  int     `excel:"skill,when:boss=true"`
+	Weight int     `excel:"weight,computed:id*10"`
+}
+
+var sourceWant = []sourceItem{
+	{ID: 1, Name: "史莱姆", Rate: 1.5, Weight: 10},
+	{ID: 2, Name: "巨龙", Rate: 0.25, Boss: true, Skill: 7, Weight: 20},
+}
+
+// writeFile 写入测试文件
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+// TestSources 测试各文本格式使用同样的 tag、默认值和条件
+func TestSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"json", "monster.json", `[
+			{"id": 1, "name": "史莱姆", "boss": false, "skill": 3},
+			{"id": 2, "name": "巨龙", "rate": 0.25, "boss": true, "skill": 7}
+		]`},
+		{"json 按 Sheet 名称", "monster.json", `{
+			"Monster": [
+				{"id": 1, "name": "史莱姆", "skill": 3},
+				{"id": 2, "name": "巨龙", "rate": 0.25, "boss": true, "skill": 7}
+			],
+			"Other": []
+		}`},
+		{"json 数组的数组", "monster.json", `[
+			["id", "name", "rate", "boss", "skill"],
+			[1, "史莱姆", null, false, 3],
+			[2, "巨龙", 0.25, true, 7]
+		]`},
+		{"yaml", "monster.yaml", `
+- id: 1
+  name: 史莱姆
+  skill: 3
+- id: 2
+  name: 巨龙
+  rate: 0.25
+  boss: true
+  skill: 7
+`},
+		{"yml 按 Sheet 名称", "monster.yml", `
+Monster:
+  - {id: 1, name: 史莱姆, skill: 3}
+  - {id: 2, name: 巨龙, rate: 0.25, boss: yes, skill: 7}
+`},
+		{"toml", "monster.toml", `
+[[Monster]]
+id = 1
+name = "史莱姆"
+skill = 3
+
+[[Monster]]
+id = 2
+name = "巨龙"
+rate = 0.25
+boss = true
+skill = 7
+`},
+		{"tsv", "monster.tsv", "\ufeffid\tname\trate\tboss\tskill\r\n1\t史莱姆\t\tfalse\t3\r\n\r\n2\t巨龙\t0.25\ttrue\t7\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), tt.file, tt.content)
+
+			// ModeAuto 按扩展名选择数据源
+			loader := NewLoader[sourceItem](path, "Monster", LoadOptions{})
+			got, err := loader.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, sourceWant) {
+				t.Errorf("Load() = %+v, want %+v", got, sourceWant)
+			}
+
+			streamed := collect(t, loader)
+			if !reflect.DeepEqual(streamed, sourceWant) {
+				t.Errorf("Iterate() = %+v, want %+v", streamed, sourceWant)
+			}
+		})
+	}
+}
+
+// TestSources_Headers 测试记录数据源的表头顺序和嵌套值
+func TestSources_Headers(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "Sheet1.json", `[
+		{"id": 1, "name": "a"},
+		{"name": "b", "tags": ["x", "y"], "id": 2, "extra": {"b": 1, "a": 2}}
+	]`)
+
+	rows, err := ReadRows(path, "Sheet1", LoadOptions{})
+	if err != nil {
+		t.Fatalf("ReadRows() error = %v", err)
+	}
+	want := [][]string{
+		{"id", "name", "tags", "extra"},
+		{"1", "a", "", ""},
+		{"2", "b", `["x","y"]`, `{"b":1,"a":2}`},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("ReadRows() = %q, want %q", rows, want)
+	}
+
+	// TOML 表头按文件中键的顺序
+	path = writeFile(t, t.TempDir(), "Sheet1.toml", "[[Sheet1]]\nz = 1\na = 2\n")
+	rows, err = ReadRows(path, "Sheet1", LoadOptions{})
+	if err != nil {
+		t.Fatalf("ReadRows() error = %v", err)
+	}
+	if !reflect.DeepEqual(rows[0], []string{"z", "a"}) {
+		t.Errorf("headers = %q, want [z a]", rows[0])
+	}
+}
+
+// TestSources_Errors 测试数据源错误
+func TestSources_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    error
+	}{
+		{"Sheet 不存在", "a.json", `{"A": [], "B": []}`, ErrSheetNotFound},
+		{"语法错误", "b.json", `[{"id": 1}`, nil},
+		{"记录不是对象", "c.yaml", "- 1\n- 2\n", nil},
+		{"多余内容", "d.json", `[] []`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, dir, tt.file, tt.content)
+			_, err := NewLoader[sourceItem](path, "Monster", LoadOptions{}).Load()
+			if err == nil {
+				t.Fatal("Load() should fail")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Load() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	_, err := NewLoader[sourceItem](dir, "Missing", LoadOptions{Mode: ModeJSON}).Load()
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("Load() error = %v, want ErrFileNotFound", err)
+	}
+}
+
+// TestSources_DetectMode 测试目录下按文件查找数据源
+func TestSources_DetectMode(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Monster.yml", "- {id: 1, name: a}\n")
+	writeFile(t, dir, "Drop.tsv", "id\tname\n1\ta\n")
+
+	tests := []struct {
+		sheet string
+		mode  Mode
+		path  string
+	}{
+		{"Monster", ModeYAML, filepath.Join(dir, "Monster.yml")},
+		{"Drop", ModeTSV, filepath.Join(dir, "Drop.tsv")},
+		{"Missing", ModeCSV, filepath.Join(dir, "Missing.csv")},
+	}
+	for _, tt := range tests {
+		loader := NewLoader[sourceItem](dir, tt.sheet, LoadOptions{})
+		if mode := loader.detectMode(); mode != tt.mode {
+			t.Errorf("detectMode(%s) = %s, want %s", tt.sheet, mode, tt.mode)
+		}
+		if path := loader.SourcePath(); path != tt.path {
+			t.Errorf("SourcePath(%s) = %s, want %s", tt.sheet, path, tt.path)
+		}
+	}
+}
+
+// TestSources_Custom 测试自定义数据源
+func TestSources_Custom(t *testing.T) {
+	src := SourceFunc(func() (RowIterator, error) {
+		return NewSliceRowIterator([][]string{
+			{"id", "name", "rate", "boss", "skill"},
+			{"1", "史莱姆", "", "", "3"},
+			{"2", "巨龙", "", "1", "7"},
+		}), nil
+	})
+
+	got, err := NewLoader[sourceItem]("", "Monster", LoadOptions{Source: src}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []sourceItem{sourceWant[0], sourceWant[1]}
+	want[1].Rate = 1.5
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,349 @@
+package config
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// RowIterator 逐行读取数据，不把整张表读入内存
+//
+//	it, err := reader.IterSheet("掉落")
+//	defer it.Close()
+//	for it.Next() {
+//	    row := it.Row()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type RowIterator interface {
+	// Next 前进到下一行，没有更多行或出错时返回 false
+	Next() bool
+	// Row 返回当前行
+	Row() []string
+	// Err 返回读取过程中的错误
+	Err() error
+	// Close 释放资源
+	Close() error
+}
+
+// excelRowIterator 基于 excelize 行流式读取的迭代器
+// 与 GetRows 一致：中间的空行返回空切片，末尾的空行被忽略
+type excelRowIterator struct {
+	rows   *excelize.Rows
+	closer io.Closer // 迭代器持有的文件，为空表示由调用方关闭
+	row    []string
+	empty  int      // 待返回的空行数
+	next   []string // 空行之后的数据行
+	err    error
+}
+
+// IterSheet 流式读取指定 Sheet
+// 与 ReadSheet 返回相同的行，但每次只在内存中保留一行
+func (r *ExcelReader) IterSheet(sheetName string) (RowIterator, error) {
+	if idx, err := r.file.GetSheetIndex(sheetName); err != nil || idx < 0 {
+		return nil, fmt.Errorf("%w: Sheet '%s' 不存在", ErrSheetNotFound, sheetName)
+	}
+
+	rows, err := r.file.Rows(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("读取 Sheet 失败: %w", err)
+	}
+	return &excelRowIterator{rows: rows}, nil
+}
+
+func (it *excelRowIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	// 先返回缓存的空行和数据行
+	if it.empty > 0 {
+		it.empty--
+		it.row = []string{}
+		return true
+	}
+	if it.next != nil {
+		it.row, it.next = it.next, nil
+		return true
+	}
+
+	// 空行只有在后面还有数据时才返回
+	empty := 0
+	for it.rows.Next() {
+		cols, err := it.rows.Columns()
+		if err != nil {
+			it.err = fmt.Errorf("读取 Sheet 失败: %w", err)
+			return false
+		}
+		if len(cols) == 0 {
+			empty++
+			continue
+		}
+		if empty > 0 {
+			it.empty = empty - 1
+			it.next = cols
+			it.row = []string{}
+			return true
+		}
+		it.row = cols
+		return true
+	}
+
+	if err := it.rows.Error(); err != nil {
+		it.err = fmt.Errorf("读取 Sheet 失败: %w", err)
+	}
+	return false
+}
+
+func (it *excelRowIterator) Row() []string {
+	return it.row
+}
+
+func (it *excelRowIterator) Err() error {
+	return it.err
+}
+
+func (it *excelRowIterator) Close() error {
+	err := it.rows.Close()
+	if it.closer != nil {
+		if cerr := it.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// csvRowIterator 基于 encoding/csv 增量读取的迭代器
+type csvRowIterator struct {
+	file       *os.File
+	reader     *csv.Reader
+	skipHeader bool
+	row        []string
+	err        error
+}
+
+// Iter 流式读取 CSV 文件
+func (r *CSVReader) Iter() (RowIterator, error) {
+	file, err := os.Open(r.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, r.filePath)
+	}
+
+	reader := csv.NewReader(file)
+	reader.Comment = r.options.Comment
+	reader.TrimLeadingSpace = r.options.TrimSpace
+
+	return &csvRowIterator{
+		file:       file,
+		reader:     reader,
+		skipHeader: r.options.SkipHeader,
+	}, nil
+}
+
+func (it *csvRowIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for {
+		record, err := it.reader.Read()
+		if errors.Is(err, io.EOF) {
+			return false
+		}
+		if err != nil {
+			it.err = fmt.Errorf("读取 CSV 数据失败: %w", err)
+			return false
+		}
+		if it.skipHeader {
+			it.skipHeader = false
+			continue
+		}
+		it.row = record
+		return true
+	}
+}
+
+func (it *csvRowIterator) Row() []string {
+	return it.row
+}
+
+func (it *csvRowIterator) Err() error {
+	return it.err
+}
+
+func (it *csvRowIterator) Close() error {
+	return it.file.Close()
+}
+
+// sliceRowIterator 内存数据的迭代器
+type sliceRowIterator struct {
+	rows [][]string
+	pos  int
+}
+
+// NewSliceRowIterator 创建遍历内存数据的迭代器
+func NewSliceRowIterator(rows [][]string) RowIterator {
+	return &sliceRowIterator{rows: rows, pos: -1}
+}
+
+func (it *sliceRowIterator) Next() bool {
+	if it.pos+1 >= len(it.rows) {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+func (it *sliceRowIterator) Row() []string {
+	return it.rows[it.pos]
+}
+
+func (it *sliceRowIterator) Err() error {
+	return nil
+}
+
+func (it *sliceRowIterator) Close() error {
+	return nil
+}
+
+// Iterate 逐行加载并回调，适合行数很多的表
+// 数据行读取一行、映射一行，内存占用与行数无关；fn 返回错误时停止并返回该错误
+// 校验规则与 Load 相同；Binary 模式先读取整个快照再逐行回调
+func (l *Loader[T]) Iterate(fn func(T) error) error {
+	mode := l.options.Mode
+	if mode == ModeAuto {
+		mode = l.detectMode()
+	}
+
+	if mode == ModeBinary {
+		rows, err := l.loadFromBinary()
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	src, err := l.source(mode)
+	if err != nil {
+		return err
+	}
+	it, err := src.Open()
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	return l.iterateRows(src, it, fn)
+}
+
+// Seq 以 iter.Seq2 形式逐行加载
+//
+//	for item, err := range loader.Seq() {
+//	    if err != nil { return err }
+//	}
+//
+// 出错时产出一次错误后结束；提前 break 会关闭文件
+func (l *Loader[T]) Seq() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		stopped := errors.New("stop")
+		err := l.Iterate(func(row T) error {
+			if !yield(row, nil) {
+				return stopped
+			}
+			return nil
+		})
+		if err != nil && err != stopped {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// iterateRows 按行布局解析迭代器中的数据
+func (l *Loader[T]) iterateRows(src Source, it RowIterator, fn func(T) error) error {
+	// 行布局只取决于前两行（版本行和变更说明行）
+	var head [][]string
+	for len(head) < 2 && it.Next() {
+		head = append(head, it.Row())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if len(head) == 0 {
+		return nil
+	}
+	layout := l.layout(src, head)
+
+	var headers []string
+	checked := false
+	index := 0
+	dataRow := 0
+	handle := func(row []string) error {
+		defer func() { index++ }()
+
+		// 静态检查表达式，每张表检查一次；有类型行时读到类型行再检查
+		if index == layout.headerRow {
+			headers = row
+			if layout.typeRow <= layout.headerRow {
+				checked = true
+				return l.checkExprs(headers, nil)
+			}
+			return nil
+		}
+		if index == layout.typeRow && headers != nil && !checked {
+			checked = true
+			if err := l.checkExprs(headers, row); err != nil {
+				return err
+			}
+		}
+		if index < layout.dataStart || headers == nil {
+			return nil
+		}
+		if !checked {
+			checked = true
+			if err := l.checkExprs(headers, nil); err != nil {
+				return err
+			}
+		}
+
+		dataRow++
+		result, err := l.mapper.MapRow(headers, row)
+		if err != nil {
+			// 添加行号到错误信息
+			if configErr, ok := err.(*ConfigError); ok {
+				configErr.Row = dataRow
+				return locateError(src, configErr)
+			}
+			return err
+		}
+		return fn(result)
+	}
+
+	for _, row := range head {
+		if err := handle(row); err != nil {
+			return err
+		}
+	}
+	for it.Next() {
+		if err := handle(it.Row()); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	if headers == nil {
+		return fmt.Errorf("数据行数不足")
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type streamItem struct {
+	ID   int    `excel:"id"`
+	Name string `excel:"name"`
+}
+
+// streamRows 测试用的表数据（含版本行、类型行和中间空行）
+var streamRows = [][]string{
+	{"__version__", "3"},
+	{"id", "name"},
+	{"int", "string"},
+	{"1", "a"},
+	{},
+	{"3", "c"},
+}
+
+// collect 通过 Iterate 读取全部数据
+func collect[T any](t *testing.T, loader *Loader[T]) []T {
+	t.Helper()
+	var items []T
+	if err := loader.Iterate(func(item T) error {
+		items = append(items, item)
+		return nil
+	}); err != nil {
+		t.Fatalf("Iterate() error = %v", err)
+	}
+	return items
+}
+
+// TestIterate_MatchesLoad 测试各数据源的流式读取结果与 Load 一致
+func TestIterate_MatchesLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	xlsx := filepath.Join(dir, "items.xlsx")
+	f := excelize.NewFile()
+	for i, row := range streamRows {
+		for j, v := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+1)
+			f.SetCellValue("Sheet1", cell, v)
+		}
+	}
+	// 末尾的空行不应产生数据
+	f.SetCellValue("Sheet1", "A10", "")
+	if err := f.SaveAs(xlsx); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	f.Close()
+
+	csvFile := filepath.Join(dir, "Sheet1.csv")
+	content := "__version__,3\nid,name\nint,string\n1,a\n2,b\n3,c\n"
+	if err := os.WriteFile(csvFile, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		option LoadOptions
+	}{
+		{"excel", xlsx, LoadOptions{Mode: ModeExcel, TypeRow: 1}},
+		{"csv", dir, LoadOptions{Mode: ModeCSV, TypeRow: 1}},
+		{"memory", "", LoadOptions{Mode: ModeMemory, TypeRow: 1, MockData: streamRows}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := NewLoader[streamItem](tt.path, "Sheet1", tt.option)
+			want, err := loader.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if len(want) != 3 {
+				t.Fatalf("Load() returned %d rows, want 3", len(want))
+			}
+
+			got := collect(t, loader)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Iterate() = %+v, want %+v", got, want)
+			}
+
+			var seq []streamItem
+			for item, err := range loader.Seq() {
+				if err != nil {
+					t.Fatalf("Seq() error = %v", err)
+				}
+				seq = append(seq, item)
+			}
+			if !reflect.DeepEqual(seq, want) {
+				t.Errorf("Seq() = %+v, want %+v", seq, want)
+			}
+		})
+	}
+}
+
+// TestIterate_Stop 测试回调返回错误和 break 时提前结束
+func TestIterate_Stop(t *testing.T) {
+	loader := NewLoader[streamItem]("", "Sheet1", LoadOptions{
+		Mode:     ModeMemory,
+		MockData: [][]string{{"id", "name"}, {"1", "a"}, {"2", "b"}, {"3", "c"}},
+	})
+
+	errStop := errors.New("stop")
+	count := 0
+	err := loader.Iterate(func(item streamItem) error {
+		count++
+		if item.ID == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || count != 2 {
+		t.Errorf("Iterate() error = %v, count = %d", err, count)
+	}
+
+	count = 0
+	for item, err := range loader.Seq() {
+		if err != nil {
+			t.Fatalf("Seq() error = %v", err)
+		}
+		count++
+		if item.ID == 1 {
+			break
+		}
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+}
+
+// TestIterate_Errors 测试映射错误的行号和文件错误
+func TestIterate_Errors(t *testing.T) {
+	loader := NewLoader[streamItem]("", "Sheet1", LoadOptions{
+		Mode:     ModeMemory,
+		MockData: [][]string{{"id", "name"}, {"1", "a"}, {"x", "b"}},
+	})
+
+	var got error
+	count := 0
+	for _, err := range loader.Seq() {
+		if err != nil {
+			got = err
+			break
+		}
+		count++
+	}
+	var configErr *ConfigError
+	if !errors.As(got, &configErr) || configErr.Row != 2 || count != 1 {
+		t.Errorf("Seq() error = %v, count = %d, want error on row 2", got, count)
+	}
+
+	missing := NewLoader[streamItem](t.TempDir(), "Sheet1", LoadOptions{Mode: ModeCSV})
+	if err := missing.Iterate(func(streamItem) error { return nil }); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("Iterate() error = %v, want ErrFileNotFound", err)
+	}
+
+	xlsx := filepath.Join(t.TempDir(), "empty.xlsx")
+	f := excelize.NewFile()
+	if err := f.SaveAs(xlsx); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+	f.Close()
+	noSheet := NewLoader[streamItem](xlsx, "Missing", LoadOptions{Mode: ModeExcel})
+	if err := noSheet.Iterate(func(streamItem) error { return nil }); !errors.Is(err, ErrSheetNotFound) {
+		t.Errorf("Iterate() error = %v, want ErrSheetNotFound", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -51,10 +52,28 @@ func (w *Watcher[T]) Watch(ctx context.Context) error {
 	}
 	defer watcher.Close()
 
-	// 监听文件路径
-	filePath := w.loader.basePath
-	if err := watcher.Add(filePath); err != nil {
-		return fmt.Errorf("添加监听失败: %w", err)
+	// 监听文件所在目录：编辑器通常先写临时文件再重命名覆盖，
+	// 直接监听文件会在第一次重命名后丢失监听
+	// 多 Sheet（Sheets / Extends）时监听参与合并的所有文件
+	files := make(map[string]bool)
+	for _, path := range w.loader.SourcePaths() {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("解析路径 %s 失败: %w", path, err)
+		}
+		files[abs] = true
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("当前加载模式没有可监听的文件")
+	}
+	dirs := make(map[string]bool)
+	for path := range files {
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("添加监听目录 %s 失败: %w", dir, err)
+		}
 	}
 
 	// 创建上下文
@@ -84,8 +103,11 @@ func (w *Watcher[T]) Watch(ctx context.Context) error {
 					return
 				}
 
-				// 只处理写入和创建事件
-				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				// 只处理目标文件的写入、创建、重命名和删除事件
+				if !isReloadEvent(event) {
+					continue
+				}
+				if abs, err := filepath.Abs(event.Name); err != nil || !files[abs] {
 					continue
 				}
 
@@ -126,6 +148,12 @@ func (w *Watcher[T]) reload() {
 	callback(data)
 }
 
+// isReloadEvent 判断事件是否可能改变文件内容
+// 原子保存（写临时文件后重命名覆盖）会产生 Create/Rename/Remove 事件
+func isReloadEvent(event fsnotify.Event) bool {
+	return event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0
+}
+
 // Stop 停止监听
 func (w *Watcher[T]) Stop() {
 	w.mu.Lock()
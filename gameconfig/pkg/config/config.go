@@ -17,9 +17,11 @@ package config
 
 import (
 	"context"
+	"iter"
 	"time"
 
 	"github.com/wangtengda0310/gobee/gameconfig/internal/config"
+	"github.com/xuri/excelize/v2"
 )
 
 // Mode 配置加载模式
@@ -30,11 +32,122 @@ const (
 	ModeExcel  = config.ModeExcel
 	ModeCSV    = config.ModeCSV
 	ModeMemory = config.ModeMemory
+	ModeBinary = config.ModeBinary
+	ModeTSV    = config.ModeTSV
+	ModeJSON   = config.ModeJSON
+	ModeYAML   = config.ModeYAML
+	ModeTOML   = config.ModeTOML
 )
 
+// Source 行数据源
+type Source = config.Source
+
+// SourceFunc 函数形式的数据源
+type SourceFunc = config.SourceFunc
+
+// RowIterator 逐行读取原始行数据的迭代器
+type RowIterator = config.RowIterator
+
+// NewExcelSource 创建 Excel 数据源
+func NewExcelSource(path string, sheetName string) Source {
+	return config.NewExcelSource(path, sheetName)
+}
+
+// NewCSVSource 创建 CSV 数据源
+func NewCSVSource(path string) Source {
+	return config.NewCSVSource(path)
+}
+
+// NewTSVSource 创建 TSV 数据源（Google Sheets 导出格式）
+func NewTSVSource(path string) Source {
+	return config.NewTSVSource(path)
+}
+
+// NewJSONSource 创建 JSON 数据源
+func NewJSONSource(path string, sheetName string) Source {
+	return config.NewJSONSource(path, sheetName)
+}
+
+// NewYAMLSource 创建 YAML 数据源
+func NewYAMLSource(path string, sheetName string) Source {
+	return config.NewYAMLSource(path, sheetName)
+}
+
+// NewTOMLSource 创建 TOML 数据源
+func NewTOMLSource(path string, sheetName string) Source {
+	return config.NewTOMLSource(path, sheetName)
+}
+
+// NewSliceRowIterator 创建遍历内存数据的迭代器
+func NewSliceRowIterator(rows [][]string) RowIterator {
+	return config.NewSliceRowIterator(rows)
+}
+
+// SnapshotExt 二进制快照文件扩展名
+const SnapshotExt = config.SnapshotExt
+
+// ErrSchemaMismatch 二进制快照与结构体定义不一致
+var ErrSchemaMismatch = config.ErrSchemaMismatch
+
+// ErrInvalidCondition 条件表达式或计算表达式无效
+var ErrInvalidCondition = config.ErrInvalidCondition
+
+// ErrDuplicateKey 多 Sheet 合并时主键重复
+var ErrDuplicateKey = config.ErrDuplicateKey
+
+// ExprIssue 表达式静态检查发现的问题
+type ExprIssue = config.ExprIssue
+
+// ExprValidationError 表达式静态检查错误，包含全部问题
+type ExprValidationError = config.ExprValidationError
+
 // LoadOptions 加载选项
 type LoadOptions = config.LoadOptions
 
+// I18nOptions 多语言选项
+type I18nOptions = config.I18nOptions
+
+// LocalizedString 多语言文本
+type LocalizedString = config.LocalizedString
+
+// StringTable 文本表
+type StringTable = config.StringTable
+
+// I18nCoverage 翻译覆盖率报告
+type I18nCoverage = config.I18nCoverage
+
+// LoadStringTable 加载文本表
+func LoadStringTable(basePath string, sheetName string, options LoadOptions) (*StringTable, error) {
+	return config.LoadStringTable(basePath, sheetName, options)
+}
+
+// EnumValue 字典中的一个枚举值
+type EnumValue = config.EnumValue
+
+// EnumDict 一个枚举字典
+type EnumDict = config.EnumDict
+
+// EnumRegistry 枚举字典集合
+type EnumRegistry = config.EnumRegistry
+
+// NewEnumRegistry 创建空的枚举字典集合
+func NewEnumRegistry() *EnumRegistry {
+	return config.NewEnumRegistry()
+}
+
+// NewEnums 从字典表的行数据创建枚举字典集合
+func NewEnums(rows [][]string, options LoadOptions) (*EnumRegistry, error) {
+	return config.NewEnums(rows, options)
+}
+
+// LoadEnums 加载字典表
+func LoadEnums(basePath string, sheetName string, options LoadOptions) (*EnumRegistry, error) {
+	return config.LoadEnums(basePath, sheetName, options)
+}
+
+// ErrUnknownEnum 枚举字典中不存在的值
+var ErrUnknownEnum = config.ErrUnknownEnum
+
 // Loader 配置加载器（泛型）
 type Loader[T any] struct {
 	inner *config.Loader[T]
@@ -62,11 +175,57 @@ func (l *Loader[T]) GetVersion() (int, error) {
 	return l.inner.GetVersion()
 }
 
+// ExportSnapshot 完整加载并校验一次，然后写入二进制快照
+// outPath 为空时写入 ModeBinary 使用的默认快照路径
+func (l *Loader[T]) ExportSnapshot(outPath string) error {
+	return l.inner.ExportSnapshot(outPath)
+}
+
+// Iterate 逐行加载并回调，适合行数很多的表
+func (l *Loader[T]) Iterate(fn func(T) error) error {
+	return l.inner.Iterate(fn)
+}
+
+// SourcePaths 返回当前模式下实际读取的所有文件
+func (l *Loader[T]) SourcePaths() []string {
+	return l.inner.SourcePaths()
+}
+
+// Seq 以 iter.Seq2 形式逐行加载
+func (l *Loader[T]) Seq() iter.Seq2[T, error] {
+	return l.inner.Seq()
+}
+
+// Validate 对照表头静态检查 when 和 computed 表达式
+func (l *Loader[T]) Validate(headers []string) error {
+	return l.inner.Validate(headers)
+}
+
+// ValidateWithTypes 对照表头和类型行静态检查表达式
+func (l *Loader[T]) ValidateWithTypes(headers, types []string) error {
+	return l.inner.ValidateWithTypes(headers, types)
+}
+
+// I18nCoverage 统计翻译覆盖率
+func (l *Loader[T]) I18nCoverage(rows []T) *I18nCoverage {
+	return l.inner.I18nCoverage(rows)
+}
+
 // SetMockData 设置 Mock 数据（用于测试）
 func (l *Loader[T]) SetMockData(data [][]string) {
 	l.inner.SetMockData(data)
 }
 
+// GenerateMock 根据 struct tag 生成测试数据（表头行 + n 行数据），相同的 seed 生成相同的数据
+func GenerateMock[T any](n int, seed int64) ([][]string, error) {
+	return config.GenerateMock[T](n, seed)
+}
+
+// GenerateMockWith 根据 struct tag 生成测试数据，使用 options 中的枚举字典和多语言设置
+func GenerateMockWith[T any](n int, seed int64, options LoadOptions) ([][]string, error) {
+	return config.GenerateMockWith[T](n, seed, options)
+}
+
 // ConfigWithComments 带批注的配置数据
 type ConfigWithComments[T any] struct {
 	Data     []T
@@ -126,6 +285,117 @@ func (w *Watcher[T]) Stop() {
 	w.inner.Stop()
 }
 
+// Reloadable 可参与分组热重载的配置表
+type Reloadable = config.Reloadable
+
+// Table 支持分组热重载的配置表（对外）
+type Table[T any] struct {
+	inner *config.Table[T]
+}
+
+// NewTable 创建配置表
+func NewTable[T any](loader *Loader[T]) *Table[T] {
+	return &Table[T]{
+		inner: config.NewTable[T](loader.inner),
+	}
+}
+
+// Name 表名（Sheet 名）
+func (t *Table[T]) Name() string {
+	return t.inner.Name()
+}
+
+// SourcePaths 返回需要监听的文件
+func (t *Table[T]) SourcePaths() []string {
+	return t.inner.SourcePaths()
+}
+
+// Load 首次加载并立即生效
+func (t *Table[T]) Load() error {
+	return t.inner.Load()
+}
+
+// Prepare 加载候选数据
+func (t *Table[T]) Prepare() error {
+	return t.inner.Prepare()
+}
+
+// Commit 使候选数据生效
+func (t *Table[T]) Commit() {
+	t.inner.Commit()
+}
+
+// Discard 丢弃候选数据
+func (t *Table[T]) Discard() {
+	t.inner.Discard()
+}
+
+// Data 返回当前生效的数据
+func (t *Table[T]) Data() []T {
+	return t.inner.Data()
+}
+
+// Staged 返回候选数据（用于组校验函数）
+func (t *Table[T]) Staged() []T {
+	return t.inner.Staged()
+}
+
+// Info 返回当前生效版本的状态
+func (t *Table[T]) Info() TableInfo {
+	return t.inner.Info()
+}
+
+// Row 按主键返回当前生效的行
+func (t *Table[T]) Row(key string) (any, bool) {
+	return t.inner.Row(key)
+}
+
+// Overrides 返回生效中的在线覆盖
+func (t *Table[T]) Overrides() map[string]map[string]string {
+	return t.inner.Overrides()
+}
+
+// StageOverride 覆盖一行的部分列，结果作为候选数据
+func (t *Table[T]) StageOverride(key string, values map[string]string) (before, after any, err error) {
+	return t.inner.StageOverride(key, values)
+}
+
+// TableInfo 配置表状态
+type TableInfo = config.TableInfo
+
+// Patchable 支持按主键查询和在线覆盖的配置表
+type Patchable = config.Patchable
+
+// AuditEntry 在线覆盖的审计记录
+type AuditEntry = config.AuditEntry
+
+// AdminHandler 配置管理 HTTP 接口
+type AdminHandler = config.AdminHandler
+
+// NewAdminHandler 创建配置管理接口
+func NewAdminHandler(watcher *DirWatcher) *AdminHandler {
+	return config.NewAdminHandler(watcher)
+}
+
+// ErrRowNotFound 按主键找不到行
+var ErrRowNotFound = config.ErrRowNotFound
+
+// ReloadGroup 重载单元（对外）
+type ReloadGroup = config.ReloadGroup
+
+// NewReloadGroup 创建重载单元
+func NewReloadGroup(name string, tables ...Reloadable) *ReloadGroup {
+	return config.NewReloadGroup(name, tables...)
+}
+
+// DirWatcher 目录级文件监听器（对外）
+type DirWatcher = config.DirWatcher
+
+// NewDirWatcher 创建目录级文件监听器
+func NewDirWatcher(groups ...*ReloadGroup) *DirWatcher {
+	return config.NewDirWatcher(groups...)
+}
+
 // ExcelExporter Excel 导出器（对外）
 type ExcelExporter = config.ExcelExporter
 
@@ -144,7 +414,116 @@ func SetSheets(e *ExcelExporter, sheets []string) {
 	(*config.ExcelExporter)(e).SetSheets(sheets)
 }
 
+// SetFormulaPolicy 设置导出时公式单元格的取值策略
+func SetFormulaPolicy(e *ExcelExporter, policy FormulaPolicy, onIssue func(FormulaIssue)) {
+	(*config.ExcelExporter)(e).SetFormulaPolicy(policy, onIssue)
+}
+
+// FormulaPolicy 公式单元格的取值策略
+type FormulaPolicy = config.FormulaPolicy
+
+const (
+	FormulaCached   = config.FormulaCached
+	FormulaEvaluate = config.FormulaEvaluate
+	FormulaStrict   = config.FormulaStrict
+)
+
+// FormulaIssueKind 公式问题类型
+type FormulaIssueKind = config.FormulaIssueKind
+
+const (
+	FormulaStale      = config.FormulaStale
+	FormulaVolatile   = config.FormulaVolatile
+	FormulaCalcFailed = config.FormulaCalcFailed
+)
+
+// FormulaIssue 公式单元格的问题
+type FormulaIssue = config.FormulaIssue
+
+// ErrStaleFormula 公式缓存值已过期或无法计算
+var ErrStaleFormula = config.ErrStaleFormula
+
+// VolatileFuncs 返回公式中使用的易变函数
+func VolatileFuncs(formula string) []string {
+	return config.VolatileFuncs(formula)
+}
+
+// CheckFormulas 检查 Excel Sheet 中的所有公式：缓存值是否过期、是否使用易变函数、能否计算
+func CheckFormulas(excelPath, sheetName string) ([]FormulaIssue, error) {
+	reader, err := config.NewExcelReader(excelPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return reader.CheckFormulas(sheetName)
+}
+
+// MergeOptions CSV 合并回 Excel 的选项
+type MergeOptions = config.MergeOptions
+
+// MergeConflict 合并冲突
+type MergeConflict = config.MergeConflict
+
+// MergeResult 单张表的合并结果
+type MergeResult = config.MergeResult
+
+// MergeCSV 将 CSV 数据合并回 Excel 工作簿，调用方负责保存文件
+func MergeCSV(file *excelize.File, csvRows [][]string, options MergeOptions) (*MergeResult, error) {
+	return config.MergeCSV(file, csvRows, options)
+}
+
 // ConvertToType 类型转换（对外）
 func ConvertToType(value string, targetType string) (interface{}, error) {
 	return config.ConvertToType(value, targetType)
 }
+
+// ReadRows 读取 Sheet 的原始行数据，数据源规则与 Loader 相同
+func ReadRows(basePath string, sheetName string, options LoadOptions) ([][]string, error) {
+	return config.ReadRows(basePath, sheetName, options)
+}
+
+// DiffOptions 差异对比选项
+type DiffOptions = config.DiffOptions
+
+// TableDiff 单张表的差异
+type TableDiff = config.TableDiff
+
+// DiffTables 按主键对比同一张表的两个版本
+func DiffTables(oldRows, newRows [][]string, options DiffOptions) (*TableDiff, error) {
+	return config.DiffTables(oldRows, newRows, options)
+}
+
+// FuncRegistry 表达式函数和表的注册表
+type FuncRegistry = config.FuncRegistry
+
+// Func 表达式函数
+type Func = config.Func
+
+// ValueKind 表达式值的类型
+type ValueKind = config.ValueKind
+
+const (
+	KindAny    = config.KindAny
+	KindNumber = config.KindNumber
+	KindString = config.KindString
+	KindBool   = config.KindBool
+)
+
+// TableLookup 按主键查找其他表的行
+type TableLookup = config.TableLookup
+
+// LookupFunc 函数形式的 TableLookup
+type LookupFunc = config.LookupFunc
+
+// DefaultFuncs 默认注册表，包含内置函数
+var DefaultFuncs = config.DefaultFuncs
+
+// NewFuncRegistry 创建注册表，未找到的函数从 DefaultFuncs 查找
+func NewFuncRegistry() *FuncRegistry {
+	return config.NewFuncRegistry()
+}
+
+// IndexRows 按 excel tag 为 key 的字段为已加载的数据建立索引
+func IndexRows[T any](rows []T, key string) (TableLookup, error) {
+	return config.IndexRows(rows, key)
+}